@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/export"
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+	"github.com/bigwhite/my-issue2md/internal/service"
+)
+
+const exportUsage = `issue2md export - Export all matching issues and pull requests of a repository
+
+Usage:
+  issue2md export owner/repo [flags]
+
+Re-running the command in the same directory only exports items updated since
+the last run. The state is kept in .issue2md-state.json next to index.md.
+
+Examples:
+  issue2md export facebook/react --state=all --output=react-issues
+  issue2md export facebook/react --label=bug,regression --since=2024-01-01
+
+Flags:
+  -h, --help              Show help information
+  -o, --output string     Output directory (default: ".")
+  -f, --format string     Output format: markdown, html, json (default: "markdown")
+  -t, --token string      GitHub token (or set GITHUB_TOKEN env var)
+  --template string      Markdown template: a builtin name (default, compact) or a
+                         text/template file (default: "default")
+  --state string         Filter by state: open, closed, all (default: "open")
+  --label string         Only items with all of these labels, comma separated
+  --since string         Only items updated at or after this time (RFC3339 or 2006-01-02)
+  --author string        Only items created by this user
+  --concurrency int      Number of items exported concurrently (default: 4)
+  --force                Ignore the saved state and export every item again
+  --no-comments          Exclude comments from output
+  --no-metadata          Exclude metadata from output
+  --no-timestamps        Exclude timestamps from output`
+
+// exportCommand 是 export 子命令解析后的参数
+type exportCommand struct {
+	owner    string
+	repo     string
+	options  *export.Options
+	showHelp bool
+}
+
+// runExport 执行 export 子命令
+func runExport(ctx context.Context, args []string, cfg *config.Config) error {
+	cmd, err := parseExportArgs(args, cfg)
+	if err != nil {
+		return fmt.Errorf("%w\n\n%s", err, exportUsage)
+	}
+	if cmd.showHelp {
+		fmt.Println(exportUsage)
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	markdownParser, conv, err := service.Initialize(cfg)
+	if err != nil {
+		return err
+	}
+	githubClient := github.NewClient(cfg.GitHubToken)
+	render := func(ctx context.Context, res *parser.ResourceURL) ([]byte, error) {
+		doc, err := service.FetchDocument(ctx, githubClient, markdownParser, res, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return conv.Convert(doc)
+	}
+
+	result, err := export.NewExporter(githubClient, render, cmd.options).Export(ctx, cmd.owner, cmd.repo)
+	if result != nil {
+		if result.NotModified {
+			fmt.Fprintf(os.Stderr, "%s/%s is up to date\n", cmd.owner, cmd.repo)
+		} else {
+			fmt.Fprintf(os.Stderr, "Listed %d items: %d exported, %d unchanged, %d failed\n",
+				result.Listed, result.Exported, result.Skipped, len(result.Failed))
+		}
+		for _, failed := range result.Failed {
+			fmt.Fprintf(os.Stderr, "  %v\n", failed)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d items failed to export, re-run to retry them", len(result.Failed))
+	}
+	return nil
+}
+
+// parseExportArgs 解析 export 子命令的参数
+func parseExportArgs(args []string, cfg *config.Config) (*exportCommand, error) {
+	cmd := &exportCommand{options: export.DefaultOptions()}
+	opts := cmd.options
+	var labels, since string
+	var noComments, noMetadata, noTimestamps bool
+
+	fs := flag.NewFlagSet(name+" export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&cmd.showHelp, "h", false, "")
+	fs.BoolVar(&cmd.showHelp, "help", false, "")
+	fs.StringVar(&opts.Dir, "o", opts.Dir, "")
+	fs.StringVar(&opts.Dir, "output", opts.Dir, "")
+	fs.StringVar(&cfg.Output.Format, "f", cfg.Output.Format, "")
+	fs.StringVar(&cfg.Output.Format, "format", cfg.Output.Format, "")
+	fs.StringVar(&cfg.GitHubToken, "t", cfg.GitHubToken, "")
+	fs.StringVar(&cfg.GitHubToken, "token", cfg.GitHubToken, "")
+	fs.StringVar(&cfg.Parser.Template, "template", cfg.Parser.Template, "")
+	fs.StringVar(&opts.State, "state", opts.State, "")
+	fs.StringVar(&labels, "label", "", "")
+	fs.StringVar(&since, "since", "", "")
+	fs.StringVar(&opts.Author, "author", "", "")
+	fs.IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "")
+	fs.BoolVar(&opts.Force, "force", false, "")
+	fs.BoolVar(&noComments, "no-comments", false, "")
+	fs.BoolVar(&noMetadata, "no-metadata", false, "")
+	fs.BoolVar(&noTimestamps, "no-timestamps", false, "")
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				cmd.showHelp = true
+				return cmd, nil
+			}
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if cmd.showHelp {
+		return cmd, nil
+	}
+
+	cfg.Parser.IncludeComments = cfg.Parser.IncludeComments && !noComments
+	cfg.Parser.IncludeMetadata = cfg.Parser.IncludeMetadata && !noMetadata
+	cfg.Parser.IncludeTimestamps = cfg.Parser.IncludeTimestamps && !noTimestamps
+
+	switch cfg.Output.Format {
+	case "markdown", "html", "json":
+	default:
+		return nil, fmt.Errorf("unsupported format %q", cfg.Output.Format)
+	}
+	opts.Ext = service.FileExt(cfg.Output.Format)
+
+	switch opts.State {
+	case "open", "closed", "all":
+	default:
+		return nil, fmt.Errorf("invalid state %q, expected open, closed or all", opts.State)
+	}
+	if opts.Concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1")
+	}
+	for _, l := range strings.Split(labels, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			opts.Labels = append(opts.Labels, l)
+		}
+	}
+	if since != "" {
+		t, err := export.ParseSince(since)
+		if err != nil {
+			return nil, err
+		}
+		opts.Since = t
+	}
+
+	if len(positional) != 1 {
+		return nil, fmt.Errorf("expected a single owner/repo")
+	}
+	owner, repo, ok := strings.Cut(positional[0], "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("invalid repository %q, expected owner/repo", positional[0])
+	}
+	cmd.owner, cmd.repo = owner, repo
+	return cmd, nil
+}
@@ -2,41 +2,62 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 
+	"github.com/bigwhite/my-issue2md/internal/archive"
 	"github.com/bigwhite/my-issue2md/internal/cli"
 	"github.com/bigwhite/my-issue2md/internal/config"
 	"github.com/bigwhite/my-issue2md/internal/converter"
-	"github.com/bigwhite/my-issue2md/internal/github"
 	"github.com/bigwhite/my-issue2md/internal/parser"
+	"github.com/bigwhite/my-issue2md/internal/service"
 )
 
 const (
 	name    = "issue2md"
 	version = "1.0.0"
-	usage   = `issue2md - Convert GitHub issues to Markdown
+	usage   = `issue2md - Convert GitHub, GitLab and Gitea issues to Markdown
 
 Usage:
   issue2md [owner/repo] [issue-number] [flags]
+  issue2md [issue-url] [flags]
+  issue2md export owner/repo [flags]   Export all matching issues and PRs of a GitHub repository
+                                       (see issue2md export --help)
 
 Examples:
   issue2md facebook/react 12345
   issue2md facebook/react 12345 --output=issue.md
   issue2md facebook/react 12345 --format=html --no-comments
+  issue2md https://github.com/facebook/react/pull/12345 --archive --output=pr.zip
+  issue2md https://gitlab.com/gitlab-org/gitlab/-/merge_requests/1234
+  issue2md https://git.example.com/team/app/issues/7 --host git.example.com=gitea
 
 Flags:
   -h, --help              Show help information
   -v, --version           Show version information
   -o, --output string     Output file path (default: "output.md")
   -f, --format string     Output format: markdown, html, json (default: "markdown")
-  -t, --token string      GitHub token (or set GITHUB_TOKEN env var)
+  -t, --token string      Token for the forge of the URL (or set GITHUB_TOKEN,
+                         GITLAB_TOKEN or GITEA_TOKEN env var)
+  --host host=forge      Treat a self-hosted host as gitlab or gitea; repeatable
+                         (or set ISSUE2MD_HOSTS="host=forge,...")
+  --template string      Markdown template: a builtin name (default, compact) or a
+                         text/template file (default: "default")
   --no-comments          Exclude comments from output
   --no-metadata          Exclude metadata from output
   --no-timestamps        Exclude timestamps from output
+  --archive              Download images and attachments and rewrite links to them;
+                         the output is a directory, or a single .zip/.tar.gz bundle
+                         (default: "<repo>-<number>")
+  --archive-workers int  Number of concurrent downloads in archive mode (default: 4)
   --overwrite            Overwrite existing output file
   --debug                Enable debug logging`
 )
@@ -67,49 +88,213 @@ func main() {
 	}
 }
 
+// cliOptions 是命令行参数中不属于配置的部分
+type cliOptions struct {
+	resource    *parser.ResourceURL
+	showHelp    bool
+	showVersion bool
+}
+
 // runCLI 执行CLI逻辑
 func runCLI(ctx context.Context, app *cli.CLI, cfg *config.Config) error {
-	// TODO: 实现命令行参数解析和业务逻辑
-	fmt.Printf("%s v%s\n", name, version)
-	fmt.Println("Phase 1 Foundation completed successfully!")
-	fmt.Println("Core data structures have been initialized.")
+	args := app.Args()
+	if len(args) > 0 && args[0] == "export" {
+		return runExport(ctx, args[1:], cfg)
+	}
+
+	opts, err := parseArgs(args, cfg)
+	if err != nil {
+		return fmt.Errorf("%w\n\n%s", err, usage)
+	}
+	if opts.showHelp {
+		fmt.Println(usage)
+		return nil
+	}
+	if opts.showVersion {
+		fmt.Printf("%s v%s\n", name, version)
+		return nil
+	}
 
 	// 验证配置
-	if err := cfg.Validate(); err != nil {
+	if err := cfg.ValidateFor(opts.resource.Forge); err != nil {
 		return fmt.Errorf("configuration validation failed: %w", err)
 	}
 
-	return nil
+	markdownParser, conv, err := service.Initialize(cfg)
+	if err != nil {
+		return err
+	}
+
+	doc, err := service.FetchDocument(ctx, service.NewSource(opts.resource, cfg), markdownParser, opts.resource, cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Output.Archive {
+		return writeArchive(ctx, doc, conv, opts.resource, cfg)
+	}
+
+	data, err := conv.Convert(doc)
+	if err != nil {
+		return fmt.Errorf("failed to convert document: %w", err)
+	}
+	output := cfg.Output.Filename
+	if output == "" {
+		output = "output" + service.FileExt(cfg.Output.Format)
+	}
+	return writeFile(output, data, cfg.Output.Overwrite)
 }
 
-// initializeServices 初始化服务
-func initializeServices(cfg *config.Config) (*github.GitHubClient, *parser.MarkdownParser, converter.Converter, error) {
-	// 初始化GitHub客户端
-	githubClient := github.NewClient(cfg.GitHubToken)
+// parseArgs 解析命令行参数，标志可以出现在位置参数前后
+func parseArgs(args []string, cfg *config.Config) (*cliOptions, error) {
+	opts := &cliOptions{}
+	var token string
+	var noComments, noMetadata, noTimestamps bool
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.showHelp, "h", false, "")
+	fs.BoolVar(&opts.showHelp, "help", false, "")
+	fs.BoolVar(&opts.showVersion, "v", false, "")
+	fs.BoolVar(&opts.showVersion, "version", false, "")
+	fs.StringVar(&cfg.Output.Filename, "o", cfg.Output.Filename, "")
+	fs.StringVar(&cfg.Output.Filename, "output", cfg.Output.Filename, "")
+	fs.StringVar(&cfg.Output.Format, "f", cfg.Output.Format, "")
+	fs.StringVar(&cfg.Output.Format, "format", cfg.Output.Format, "")
+	fs.StringVar(&token, "t", "", "")
+	fs.StringVar(&token, "token", "", "")
+	fs.Func("host", "", cfg.AddHost)
+	fs.StringVar(&cfg.Parser.Template, "template", cfg.Parser.Template, "")
+	fs.BoolVar(&noComments, "no-comments", false, "")
+	fs.BoolVar(&noMetadata, "no-metadata", false, "")
+	fs.BoolVar(&noTimestamps, "no-timestamps", false, "")
+	fs.BoolVar(&cfg.Output.Archive, "archive", cfg.Output.Archive, "")
+	fs.IntVar(&cfg.Output.ArchiveWorkers, "archive-workers", cfg.Output.ArchiveWorkers, "")
+	fs.BoolVar(&cfg.Output.Overwrite, "overwrite", cfg.Output.Overwrite, "")
+	debug := fs.Bool("debug", false, "")
 
-	// 初始化解析器
-	parserOptions := &parser.Options{
-		IncludeComments:    cfg.Parser.IncludeComments,
-		IncludeMetadata:    cfg.Parser.IncludeMetadata,
-		IncludeTimestamps:  cfg.Parser.IncludeTimestamps,
-		IncludeUserLinks:   cfg.Parser.IncludeUserLinks,
-		EmojisEnabled:      cfg.Parser.EmojisEnabled,
-		PreserveLineBreaks: cfg.Parser.PreserveLineBreaks,
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				opts.showHelp = true
+				return opts, nil
+			}
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
-	markdownParser := parser.NewParser(parserOptions)
 
-	// 初始化转换器
-	converterOptions := converter.DefaultConverterOptions()
-	var conv converter.Converter
+	cfg.Parser.IncludeComments = cfg.Parser.IncludeComments && !noComments
+	cfg.Parser.IncludeMetadata = cfg.Parser.IncludeMetadata && !noMetadata
+	cfg.Parser.IncludeTimestamps = cfg.Parser.IncludeTimestamps && !noTimestamps
+	if *debug {
+		log.SetFlags(log.LstdFlags | log.Lshortfile)
+	}
+	if opts.showHelp || opts.showVersion {
+		return opts, nil
+	}
 
 	switch cfg.Output.Format {
-	case "html":
-		conv = converter.NewHTMLConverter(converterOptions)
-	case "json":
-		conv = converter.NewJSONConverter(converterOptions)
+	case "markdown", "html", "json":
 	default:
-		conv = converter.NewMarkdownConverter(converterOptions)
+		return nil, fmt.Errorf("unsupported format %q", cfg.Output.Format)
 	}
 
-	return githubClient, markdownParser, conv, nil
-}
\ No newline at end of file
+	resource, err := parseResource(positional, cfg.Hosts)
+	if err != nil {
+		return nil, err
+	}
+	opts.resource = resource
+	if token != "" {
+		cfg.SetToken(resource.Forge, token)
+	}
+	return opts, nil
+}
+
+// parseResource 由位置参数确定要导出的资源：一个Issue/PR URL，或GitHub的 owner/repo 加编号
+// hosts是自建GitLab、Gitea实例的 主机名->平台 映射
+func parseResource(args []string, hosts map[string]string) (*parser.ResourceURL, error) {
+	switch len(args) {
+	case 1:
+		urlParser, err := parser.NewForgeURLParser(hosts)
+		if err != nil {
+			return nil, err
+		}
+		return urlParser.Parse(args[0])
+	case 2:
+		owner, repo, ok := strings.Cut(args[0], "/")
+		if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return nil, fmt.Errorf("invalid repository %q, expected owner/repo", args[0])
+		}
+		number, err := strconv.Atoi(args[1])
+		if err != nil || number <= 0 {
+			return nil, fmt.Errorf("invalid issue number %q", args[1])
+		}
+		return &parser.ResourceURL{
+			Type:    "issue",
+			Owner:   owner,
+			Repo:    repo,
+			Number:  number,
+			URL:     fmt.Sprintf("https://github.com/%s/%s/issues/%d", owner, repo, number),
+			Forge:   parser.ForgeGitHub,
+			BaseURL: "https://github.com",
+		}, nil
+	default:
+		return nil, fmt.Errorf("expected an issue URL or owner/repo and issue number")
+	}
+}
+
+// writeArchive 下载文档引用的资源，把文档、资源和manifest写入目录或打包文件
+func writeArchive(ctx context.Context, doc *parser.MarkdownDocument, conv converter.Converter, res *parser.ResourceURL, cfg *config.Config) error {
+	archiveOptions := archive.DefaultOptions()
+	archiveOptions.Workers = cfg.Output.ArchiveWorkers
+	archiveOptions.Token = cfg.GitHubToken
+
+	bundle, err := archive.NewArchiver(archiveOptions).Collect(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("failed to archive assets: %w", err)
+	}
+	data, err := conv.Convert(bundle.Document)
+	if err != nil {
+		return fmt.Errorf("failed to convert document: %w", err)
+	}
+
+	dest := cfg.Output.Filename
+	if dest == "" {
+		dest = fmt.Sprintf("%s-%d", res.Repo, res.Number)
+	}
+	if err := bundle.Write(dest, "index"+service.FileExt(cfg.Output.Format), data, cfg.Output.Overwrite); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Archived %d assets to %s\n", len(bundle.Assets), dest)
+	for _, failed := range bundle.Failed {
+		fmt.Fprintf(os.Stderr, "  skipped %s: %s\n", failed.URL, failed.Error)
+	}
+	return nil
+}
+
+// writeFile 写入输出文件，不允许覆盖时文件已存在即报错
+func writeFile(path string, data []byte, overwrite bool) error {
+	mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		mode |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, mode, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("output file %s already exists, use --overwrite to replace it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return f.Close()
+}
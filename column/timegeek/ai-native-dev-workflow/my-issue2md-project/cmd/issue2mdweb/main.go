@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/web"
 )
 
 const (
@@ -52,17 +53,18 @@ func main() {
 
 // runWebServer 运行Web服务器
 func runWebServer(ctx context.Context, port string, cfg *config.Config) error {
-	mux := http.NewServeMux()
-
-	// 设置路由
-	setupRoutes(mux, cfg)
+	srv, err := web.NewServer(cfg, webName, webVersion)
+	if err != nil {
+		return fmt.Errorf("failed to create web server: %w", err)
+	}
+	defer srv.Close()
 
-	// 创建HTTP服务器
+	// 创建HTTP服务器，同步转换最长60秒，写超时需要留出余量
 	server := &http.Server{
 		Addr:         ":" + port,
-		Handler:      mux,
+		Handler:      srv.Handler(),
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: 90 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -88,52 +90,3 @@ func runWebServer(ctx context.Context, port string, cfg *config.Config) error {
 	log.Println("Web server stopped gracefully")
 	return nil
 }
-
-// setupRoutes 设置路由
-func setupRoutes(mux *http.ServeMux, cfg *config.Config) {
-	// 健康检查
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"ok","service":"%s","version":"%s"}`, webName, webVersion)
-	})
-
-	// 首页
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		w.Header().Set("Content-Type", "text/html")
-		fmt.Fprintf(w, `
-<!DOCTYPE html>
-<html>
-<head>
-    <title>%s v%s</title>
-    <meta charset="utf-8">
-</head>
-<body>
-    <h1>%s v%s</h1>
-    <p>GitHub Issue to Markdown Converter Web Service</p>
-    <p>Phase 1 Foundation completed successfully!</p>
-    <ul>
-        <li><a href="/health">Health Check</a></li>
-        <li><a href="/api/v1/convert">API Documentation</a></li>
-    </ul>
-</body>
-</html>`, webName, webVersion, webName, webVersion)
-	})
-
-	// API路由
-	mux.HandleFunc("/api/v1/convert", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotImplemented)
-		fmt.Fprintf(w, `{"error":"API endpoint not yet implemented","status":"Phase 1 in progress"}`)
-	})
-}
\ No newline at end of file
@@ -2,6 +2,19 @@ module github.com/bigwhite/my-issue2md
 
 go 1.21
 
-require github.com/google/go-github/v56 v56.0.0
+require (
+	github.com/alecthomas/chroma/v2 v2.14.0
+	github.com/google/go-github/v56 v56.0.0
+	github.com/microcosm-cc/bluemonday v1.0.27
+	github.com/yuin/goldmark v1.7.8
+	github.com/yuin/goldmark-emoji v1.0.3
+	github.com/yuin/goldmark-highlighting/v2 v2.0.0-20230729083705-37449abec8cc
+)
 
-require github.com/google/go-querystring v1.1.0 // indirect
+require (
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/dlclark/regexp2 v1.11.0 // indirect
+	github.com/google/go-querystring v1.1.0 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	golang.org/x/net v0.26.0 // indirect
+)
@@ -0,0 +1,271 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+// assetDir 是资源文件在归档中的目录
+const assetDir = "assets"
+
+// ManifestName 是归档中manifest文件的名称
+const ManifestName = "manifest.json"
+
+// Options 归档选项
+type Options struct {
+	Workers      int          // 并发下载数
+	MaxAssetSize int64        // 单个资源的大小上限(字节)
+	Token        string       // 下载私有仓库附件时使用，只发送给GitHub的域名
+	HTTPClient   *http.Client // 为nil时使用http.DefaultClient
+}
+
+// DefaultOptions 返回默认归档选项
+func DefaultOptions() *Options {
+	return &Options{
+		Workers:      4,
+		MaxAssetSize: 50 << 20,
+	}
+}
+
+// Archiver 下载文档引用的图片和附件，并把链接改写为归档内的相对路径
+type Archiver struct {
+	options *Options
+	client  *http.Client
+}
+
+// NewArchiver 创建归档器
+func NewArchiver(opts *Options) *Archiver {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Archiver{
+		options: opts,
+		client:  client,
+	}
+}
+
+// Asset 是归档中的一个资源文件，内容相同的多个地址共用一个文件
+type Asset struct {
+	Path        string   `json:"path"`
+	SHA256      string   `json:"sha256"`
+	Size        int64    `json:"size"`
+	ContentType string   `json:"content_type"`
+	URLs        []string `json:"urls"`
+
+	data []byte
+}
+
+// FailedAsset 是下载失败的资源，文档中保留其原始链接
+type FailedAsset struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// Bundle 是归档的内容：改写链接后的文档和下载的资源
+type Bundle struct {
+	Document  *parser.MarkdownDocument
+	Assets    []*Asset
+	Failed    []FailedAsset
+	CreatedAt time.Time
+}
+
+// Manifest 描述归档内容，写入归档根目录的manifest.json
+type Manifest struct {
+	Title     string        `json:"title"`
+	Source    string        `json:"source,omitempty"`
+	Document  string        `json:"document"`
+	CreatedAt time.Time     `json:"created_at"`
+	Assets    []*Asset      `json:"assets"`
+	Failed    []FailedAsset `json:"failed,omitempty"`
+}
+
+// Collect 下载文档引用的资源并改写链接
+// 单个资源下载失败不会中断归档，只记录在Failed中；ctx取消时返回错误
+func (a *Archiver) Collect(ctx context.Context, doc *parser.MarkdownDocument) (*Bundle, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("document is nil")
+	}
+
+	links := CollectLinks(doc.Content)
+	results := a.downloadAll(ctx, links)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("archive canceled: %w", err)
+	}
+
+	bundle := &Bundle{CreatedAt: time.Now().UTC()}
+	byHash := make(map[string]*Asset)
+	paths := make(map[string]string)
+	for i, result := range results {
+		link := links[i]
+		if result.err != nil {
+			bundle.Failed = append(bundle.Failed, FailedAsset{URL: link, Error: result.err.Error()})
+			continue
+		}
+
+		sum := sha256.Sum256(result.data)
+		hash := hex.EncodeToString(sum[:])
+		asset, ok := byHash[hash]
+		if !ok {
+			asset = &Asset{
+				Path:        path.Join(assetDir, hash[:16]+assetExt(link, result.contentType)),
+				SHA256:      hash,
+				Size:        int64(len(result.data)),
+				ContentType: result.contentType,
+				data:        result.data,
+			}
+			byHash[hash] = asset
+			bundle.Assets = append(bundle.Assets, asset)
+		}
+		asset.URLs = append(asset.URLs, link)
+		paths[link] = asset.Path
+	}
+
+	bundle.Document = &parser.MarkdownDocument{
+		Title:    doc.Title,
+		Content:  RewriteLinks(doc.Content, paths),
+		Metadata: doc.Metadata,
+	}
+	return bundle, nil
+}
+
+// download 是单个资源的下载结果
+type download struct {
+	data        []byte
+	contentType string
+	err         error
+}
+
+// downloadAll 用固定数量的worker并发下载，结果与links一一对应
+func (a *Archiver) downloadAll(ctx context.Context, links []string) []download {
+	results := make([]download, len(links))
+	workers := min(max(a.options.Workers, 1), len(links))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = a.download(ctx, links[i])
+			}
+		}()
+	}
+
+feed:
+	for i := range links {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// download 下载单个资源
+func (a *Archiver) download(ctx context.Context, link string) download {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return download{err: fmt.Errorf("invalid asset url: %w", err)}
+	}
+	if a.options.Token != "" && isGitHubHost(req.URL.Hostname()) {
+		req.Header.Set("Authorization", "Bearer "+a.options.Token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return download{err: fmt.Errorf("download failed: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return download{err: fmt.Errorf("download failed: %s", resp.Status)}
+	}
+
+	limit := a.options.MaxAssetSize
+	if limit <= 0 {
+		limit = DefaultOptions().MaxAssetSize
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return download{err: fmt.Errorf("download failed: %w", err)}
+	}
+	if int64(len(data)) > limit {
+		return download{err: fmt.Errorf("asset exceeds %d bytes", limit)}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	} else {
+		contentType, _, _ = strings.Cut(http.DetectContentType(data), ";")
+	}
+	return download{data: data, contentType: contentType}
+}
+
+// isGitHubHost 判断是否为GitHub自己的域名，token不能发送给第三方图床
+func isGitHubHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "github.com" || strings.HasSuffix(host, ".github.com") ||
+		strings.HasSuffix(host, ".githubusercontent.com")
+}
+
+// knownExts 是常见附件类型的扩展名，mime包对同一类型可能返回多个扩展名
+var knownExts = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/svg+xml":   ".svg",
+	"video/mp4":       ".mp4",
+	"video/quicktime": ".mov",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
+
+// assetExt 推断资源的扩展名：优先使用URL路径中的扩展名，其次根据Content-Type
+func assetExt(link, contentType string) string {
+	if u, err := url.Parse(link); err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		if len(ext) > 1 && len(ext) <= 6 && isAlnum(ext[1:]) {
+			return ext
+		}
+	}
+	if ext, ok := knownExts[contentType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// isAlnum 判断字符串是否只包含ASCII字母和数字
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
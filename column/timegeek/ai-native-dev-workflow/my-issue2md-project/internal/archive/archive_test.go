@@ -0,0 +1,323 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+// pngData 是一个最小的PNG文件头，足以让Content-Type探测识别为image/png
+var pngData = []byte("\x89PNG\r\n\x1a\n0000IHDR")
+
+// newAssetServer 创建提供测试资源的服务器
+func newAssetServer(t *testing.T, inFlight, maxInFlight *int32) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/copy-of-a", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf; charset=binary")
+		w.Write([]byte("%PDF-1.4"))
+	})
+	mux.HandleFunc("/big.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 2048))
+	})
+	mux.HandleFunc("/slow/", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(inFlight, 1)
+		defer atomic.AddInt32(inFlight, -1)
+		for {
+			m := atomic.LoadInt32(maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("token sent to non-GitHub host %s", r.Host)
+		}
+		fmt.Fprint(w, r.URL.Path)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestArchiver_Collect(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := newAssetServer(t, &inFlight, &maxInFlight)
+
+	doc := &parser.MarkdownDocument{
+		Title: "Bug",
+		Content: fmt.Sprintf("![a](%[1]s/a.png)\n<img src=\"%[1]s/copy-of-a\">\n![r](%[1]s/report)\n"+
+			"![missing](%[1]s/missing.png)\n![big](%[1]s/big.png)\n```\n![code](%[1]s/a.png)\n```\n", server.URL),
+		Metadata: map[string]string{"url": "https://github.com/o/r/issues/1"},
+	}
+	archiver := NewArchiver(&Options{Workers: 2, MaxAssetSize: 1024, Token: "secret"})
+	bundle, err := archiver.Collect(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if len(bundle.Assets) != 2 {
+		t.Fatalf("len(Assets) = %d, want 2", len(bundle.Assets))
+	}
+	png := bundle.Assets[0]
+	if !strings.HasPrefix(png.Path, "assets/") || !strings.HasSuffix(png.Path, ".png") {
+		t.Errorf("png.Path = %q, want assets/*.png", png.Path)
+	}
+	if len(png.URLs) != 2 || png.ContentType != "image/png" {
+		t.Errorf("png asset = %+v, want two URLs sharing one image/png file", png)
+	}
+	pdf := bundle.Assets[1]
+	if !strings.HasSuffix(pdf.Path, ".pdf") || pdf.ContentType != "application/pdf" {
+		t.Errorf("pdf asset = %+v, want .pdf with application/pdf", pdf)
+	}
+
+	if len(bundle.Failed) != 2 {
+		t.Fatalf("Failed = %+v, want 2 entries", bundle.Failed)
+	}
+	if !strings.Contains(bundle.Failed[0].Error, "404") {
+		t.Errorf("Failed[0].Error = %q, want 404", bundle.Failed[0].Error)
+	}
+	if !strings.Contains(bundle.Failed[1].Error, "exceeds 1024 bytes") {
+		t.Errorf("Failed[1].Error = %q, want size error", bundle.Failed[1].Error)
+	}
+
+	want := fmt.Sprintf("![a](%[2]s)\n<img src=\"%[2]s\">\n![r](%[3]s)\n"+
+		"![missing](%[1]s/missing.png)\n![big](%[1]s/big.png)\n```\n![code](%[1]s/a.png)\n```\n", server.URL, png.Path, pdf.Path)
+	if bundle.Document.Content != want {
+		t.Errorf("Content = %q, want %q", bundle.Document.Content, want)
+	}
+	if doc.Content == bundle.Document.Content {
+		t.Error("Collect() should not modify the original document")
+	}
+}
+
+func TestArchiver_CollectConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := newAssetServer(t, &inFlight, &maxInFlight)
+
+	var b strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&b, "![%d](%s/slow/%d.png)\n", i, server.URL, i)
+	}
+	archiver := NewArchiver(&Options{Workers: 3, Token: "secret"})
+	bundle, err := archiver.Collect(context.Background(), &parser.MarkdownDocument{Content: b.String()})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(bundle.Assets) != 10 {
+		t.Errorf("len(Assets) = %d, want 10", len(bundle.Assets))
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got > 3 || got < 2 {
+		t.Errorf("max concurrent downloads = %d, want 2..3", got)
+	}
+}
+
+func TestArchiver_CollectCanceled(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := newAssetServer(t, &inFlight, &maxInFlight)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewArchiver(nil).Collect(ctx, &parser.MarkdownDocument{Content: "![a](" + server.URL + "/slow/a.png)"})
+	if err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("Collect() error = %v, want canceled", err)
+	}
+}
+
+func TestIsGitHubHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"github.com", true},
+		{"user-images.githubusercontent.com", true},
+		{"private-user-images.githubusercontent.com", true},
+		{"api.github.com", true},
+		{"example.com", false},
+		{"github.com.evil.example", false},
+		{"evilgithub.com", false},
+	}
+	for _, tt := range tests {
+		if got := isGitHubHost(tt.host); got != tt.want {
+			t.Errorf("isGitHubHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestAssetExt(t *testing.T) {
+	tests := []struct {
+		link        string
+		contentType string
+		want        string
+	}{
+		{"https://example.com/a.PNG", "image/png", ".png"},
+		{"https://github.com/user-attachments/assets/1234-abcd", "image/jpeg", ".jpg"},
+		{"https://example.com/file", "application/pdf", ".pdf"},
+		{"https://example.com/file", "application/x-unknown", ""},
+	}
+	for _, tt := range tests {
+		if got := assetExt(tt.link, tt.contentType); got != tt.want {
+			t.Errorf("assetExt(%q, %q) = %q, want %q", tt.link, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+// testBundle 返回一个包含两个资源和一个失败记录的归档
+func testBundle() *Bundle {
+	return &Bundle{
+		Document: &parser.MarkdownDocument{
+			Title:    "Bug",
+			Content:  "![a](assets/aaaa.png)",
+			Metadata: map[string]string{"url": "https://github.com/o/r/issues/1"},
+		},
+		Assets: []*Asset{
+			{Path: "assets/aaaa.png", SHA256: "aaaa", Size: 3, ContentType: "image/png", URLs: []string{"https://example.com/a.png"}, data: []byte("png")},
+			{Path: "assets/bbbb.pdf", SHA256: "bbbb", Size: 3, ContentType: "application/pdf", URLs: []string{"https://example.com/b"}, data: []byte("pdf")},
+		},
+		Failed:    []FailedAsset{{URL: "https://example.com/c.png", Error: "download failed: 404 Not Found"}},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestBundle_Write(t *testing.T) {
+	tests := []struct {
+		name string
+		dest string
+		read func(t *testing.T, dest string) map[string]string
+	}{
+		{name: "目录", dest: "out", read: readDir},
+		{name: "zip", dest: "out.zip", read: readZip},
+		{name: "tar.gz", dest: "out.tar.gz", read: readTarGz},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), tt.dest)
+			if err := testBundle().Write(dest, "issue.md", []byte("# Bug"), false); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			files := tt.read(t, dest)
+			var names []string
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			wantNames := []string{"assets/aaaa.png", "assets/bbbb.pdf", "issue.md", "manifest.json"}
+			if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+				t.Fatalf("files = %v, want %v", names, wantNames)
+			}
+			if files["issue.md"] != "# Bug" || files["assets/bbbb.pdf"] != "pdf" {
+				t.Errorf("unexpected file contents: %q", files)
+			}
+
+			var manifest Manifest
+			if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+				t.Fatalf("invalid manifest: %v", err)
+			}
+			if manifest.Document != "issue.md" || manifest.Source != "https://github.com/o/r/issues/1" ||
+				len(manifest.Assets) != 2 || manifest.Assets[1].SHA256 != "bbbb" || len(manifest.Failed) != 1 {
+				t.Errorf("manifest = %+v", manifest)
+			}
+
+			if err := testBundle().Write(dest, "issue.md", []byte("# Bug"), false); err == nil {
+				t.Error("Write() to existing output without overwrite should fail")
+			}
+			if err := testBundle().Write(dest, "issue.md", []byte("# Bug v2"), true); err != nil {
+				t.Errorf("Write() with overwrite error = %v", err)
+			}
+			if got := tt.read(t, dest)["issue.md"]; got != "# Bug v2" {
+				t.Errorf("issue.md after overwrite = %q", got)
+			}
+		})
+	}
+}
+
+func readDir(t *testing.T, dest string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dest, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dest, p)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	return files
+}
+
+func readZip(t *testing.T, dest string) map[string]string {
+	t.Helper()
+	zr, err := zip.OpenReader(dest)
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	defer zr.Close()
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+func readTarGz(t *testing.T, dest string) map[string]string {
+	t.Helper()
+	f, err := os.Open(dest)
+	if err != nil {
+		t.Fatalf("open tar.gz: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("open gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files := make(map[string]string)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[h.Name] = string(data)
+	}
+	return files
+}
@@ -0,0 +1,135 @@
+package archive
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// markdownImage 匹配 ![alt](url "title") 形式的图片，捕获url
+	markdownImage = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)>?`)
+
+	// htmlImage 匹配 <img src="url"> 形式的图片，捕获url
+	htmlImage = regexp.MustCompile(`(?i)<img\b[^>]*?\bsrc\s*=\s*["']([^"']+)["']`)
+
+	// attachmentURL 匹配GitHub上传的附件地址，无论以图片、链接还是裸URL的形式出现
+	attachmentURL = regexp.MustCompile(`https://(?:(?:private-)?user-images\.githubusercontent\.com|github\.com/user-attachments/(?:assets|files)|github\.com/[\w.-]+/[\w.-]+/(?:files|assets))/[^\s)"'<>\]]+`)
+)
+
+// CollectLinks 收集Markdown中需要下载的资源地址：所有http(s)图片和GitHub附件
+// 围栏代码块中的内容不会被收集，返回的地址按首次出现的顺序去重
+func CollectLinks(markdown string) []string {
+	seen := make(map[string]bool)
+	var links []string
+	add := func(link string) {
+		if !strings.HasPrefix(link, "https://") && !strings.HasPrefix(link, "http://") {
+			return
+		}
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+
+	for _, l := range splitLines(markdown) {
+		if l.code {
+			continue
+		}
+		for _, m := range markdownImage.FindAllStringSubmatch(l.text, -1) {
+			add(m[1])
+		}
+		for _, m := range htmlImage.FindAllStringSubmatch(l.text, -1) {
+			add(m[1])
+		}
+		for _, m := range attachmentURL.FindAllString(l.text, -1) {
+			add(m)
+		}
+	}
+	return links
+}
+
+// RewriteLinks 把Markdown中的资源地址替换为paths中对应的相对路径，围栏代码块保持不变
+func RewriteLinks(markdown string, paths map[string]string) string {
+	if len(paths) == 0 {
+		return markdown
+	}
+
+	// 较长的地址优先替换，避免一个地址是另一个地址的前缀时被截断替换
+	urls := make([]string, 0, len(paths))
+	for u := range paths {
+		urls = append(urls, u)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if len(urls[i]) != len(urls[j]) {
+			return len(urls[i]) > len(urls[j])
+		}
+		return urls[i] < urls[j]
+	})
+	pairs := make([]string, 0, 2*len(urls))
+	for _, u := range urls {
+		pairs = append(pairs, u, paths[u])
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	lines := splitLines(markdown)
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		if l.code {
+			out[i] = l.text
+		} else {
+			out[i] = replacer.Replace(l.text)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// line 是Markdown中的一行，code表示该行属于围栏代码块(含围栏本身)
+type line struct {
+	text string
+	code bool
+}
+
+// splitLines 按行切分Markdown并标记围栏代码块
+func splitLines(markdown string) []line {
+	texts := strings.Split(markdown, "\n")
+	lines := make([]line, len(texts))
+	open := ""
+	for i, text := range texts {
+		fence := fenceOf(text)
+		switch {
+		case open == "" && fence != "":
+			open = fence
+			lines[i] = line{text: text, code: true}
+		case open != "":
+			lines[i] = line{text: text, code: true}
+			if fence != "" && fence[0] == open[0] && len(fence) >= len(open) &&
+				strings.TrimSpace(strings.TrimLeft(text, " ")[len(fence):]) == "" {
+				open = ""
+			}
+		default:
+			lines[i] = line{text: text}
+		}
+	}
+	return lines
+}
+
+// fenceOf 返回行首的代码块围栏(至少3个`或~)，不是围栏时返回空串
+func fenceOf(text string) string {
+	trimmed := strings.TrimLeft(text, " ")
+	if len(text)-len(trimmed) > 3 || trimmed == "" {
+		return ""
+	}
+	ch := trimmed[0]
+	if ch != '`' && ch != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == ch {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	return trimmed[:n]
+}
@@ -0,0 +1,96 @@
+package archive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollectLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []string
+	}{
+		{
+			name:     "Markdown图片",
+			markdown: "![screenshot](https://user-images.githubusercontent.com/1/a.png)\n![logo](https://example.com/logo.svg \"Logo\")",
+			want:     []string{"https://user-images.githubusercontent.com/1/a.png", "https://example.com/logo.svg"},
+		},
+		{
+			name:     "HTML图片",
+			markdown: `<img width="300" alt="x" src="https://github.com/user-attachments/assets/1234-abcd">`,
+			want:     []string{"https://github.com/user-attachments/assets/1234-abcd"},
+		},
+		{
+			name:     "附件链接和裸URL",
+			markdown: "[log.txt](https://github.com/owner/repo/files/42/log.txt)\n\nhttps://github.com/user-attachments/assets/5678-efgh\n\n[docs](https://example.com/docs)",
+			want:     []string{"https://github.com/owner/repo/files/42/log.txt", "https://github.com/user-attachments/assets/5678-efgh"},
+		},
+		{
+			name:     "去重并保持顺序",
+			markdown: "![a](https://example.com/b.png) ![b](https://example.com/a.png) ![c](https://example.com/b.png)",
+			want:     []string{"https://example.com/b.png", "https://example.com/a.png"},
+		},
+		{
+			name:     "忽略相对路径和data URI",
+			markdown: "![a](assets/a.png) ![b](data:image/png;base64,AAAA)",
+			want:     nil,
+		},
+		{
+			name:     "忽略围栏代码块",
+			markdown: "```md\n![a](https://example.com/in-code.png)\n```\n~~~~\n```\n![b](https://example.com/still-code.png)\n~~~~\n![c](https://example.com/out.png)",
+			want:     []string{"https://example.com/out.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CollectLinks(tt.markdown)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CollectLinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		paths    map[string]string
+		want     string
+	}{
+		{
+			name:     "替换图片和附件",
+			markdown: "![a](https://example.com/a.png)\n<img src=\"https://example.com/a.png\">\n[f](https://github.com/o/r/files/1/f.zip)\n",
+			paths: map[string]string{
+				"https://example.com/a.png":            "assets/aaaa.png",
+				"https://github.com/o/r/files/1/f.zip": "assets/ffff.zip",
+			},
+			want: "![a](assets/aaaa.png)\n<img src=\"assets/aaaa.png\">\n[f](assets/ffff.zip)\n",
+		},
+		{
+			name:     "较长的地址优先",
+			markdown: "![a](https://example.com/a.png?size=2) ![b](https://example.com/a.png)",
+			paths: map[string]string{
+				"https://example.com/a.png":        "assets/1.png",
+				"https://example.com/a.png?size=2": "assets/2.png",
+			},
+			want: "![a](assets/2.png) ![b](assets/1.png)",
+		},
+		{
+			name:     "代码块保持不变",
+			markdown: "```\n![a](https://example.com/a.png)\n```\n![a](https://example.com/a.png)",
+			paths:    map[string]string{"https://example.com/a.png": "assets/1.png"},
+			want:     "```\n![a](https://example.com/a.png)\n```\n![a](assets/1.png)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RewriteLinks(tt.markdown, tt.paths); got != tt.want {
+				t.Errorf("RewriteLinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Write 把文档、资源和manifest写入dest
+// dest以.zip或.tar.gz(.tgz)结尾时打包为单个文件，否则写入目录；name是文档在归档中的文件名
+func (b *Bundle) Write(dest, name string, content []byte, overwrite bool) error {
+	w, err := newBundleWriter(dest, overwrite, b.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := json.MarshalIndent(Manifest{
+		Title:     b.Document.Title,
+		Source:    b.Document.Metadata["url"],
+		Document:  name,
+		CreatedAt: b.CreatedAt,
+		Assets:    b.Assets,
+		Failed:    b.Failed,
+	}, "", "  ")
+	if err != nil {
+		w.Abort()
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	files := map[string][]byte{name: content, ManifestName: manifest}
+	names := []string{name, ManifestName}
+	for _, asset := range b.Assets {
+		files[asset.Path] = asset.data
+		names = append(names, asset.Path)
+	}
+	for _, n := range names {
+		if err := w.WriteFile(n, files[n]); err != nil {
+			w.Abort()
+			return fmt.Errorf("failed to write %s to %s: %w", n, dest, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// bundleWriter 把文件写入目录或打包文件
+type bundleWriter interface {
+	WriteFile(name string, data []byte) error
+	Close() error
+	// Abort 在出错时清理未完成的输出
+	Abort()
+}
+
+// newBundleWriter 根据dest的后缀选择输出形式
+func newBundleWriter(dest string, overwrite bool, modTime time.Time) (bundleWriter, error) {
+	lower := strings.ToLower(dest)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		f, err := createFile(dest, overwrite)
+		if err != nil {
+			return nil, err
+		}
+		return &zipWriter{file: f, zw: zip.NewWriter(f), modTime: modTime}, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		f, err := createFile(dest, overwrite)
+		if err != nil {
+			return nil, err
+		}
+		gz := gzip.NewWriter(f)
+		return &tarGzWriter{file: f, gz: gz, tw: tar.NewWriter(gz), modTime: modTime}, nil
+	default:
+		if _, err := os.Stat(dest); err == nil && !overwrite {
+			return nil, fmt.Errorf("output %s already exists", dest)
+		}
+		if err := os.MkdirAll(dest, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		return &dirWriter{root: dest}, nil
+	}
+}
+
+// createFile 创建打包文件，不允许覆盖时文件已存在即报错
+func createFile(dest string, overwrite bool) (*os.File, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flag |= os.O_EXCL
+	}
+	f, err := os.OpenFile(dest, flag, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("output %s already exists", dest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	return f, nil
+}
+
+// dirWriter 把文件写入目录
+type dirWriter struct {
+	root string
+}
+
+func (w *dirWriter) WriteFile(name string, data []byte) error {
+	p := filepath.Join(w.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+func (w *dirWriter) Close() error { return nil }
+
+// Abort 保留已写入的文件，目录可能是用户已有的目录，不做删除
+func (w *dirWriter) Abort() {}
+
+// zipWriter 把文件打包为zip
+type zipWriter struct {
+	file    *os.File
+	zw      *zip.Writer
+	modTime time.Time
+}
+
+func (w *zipWriter) WriteFile(name string, data []byte) error {
+	f, err := w.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: w.modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func (w *zipWriter) Close() error {
+	if err := w.zw.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+func (w *zipWriter) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// tarGzWriter 把文件打包为tar.gz
+type tarGzWriter struct {
+	file    *os.File
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	modTime time.Time
+}
+
+func (w *tarGzWriter) WriteFile(name string, data []byte) error {
+	if err := w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  w.modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+func (w *tarGzWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+func (w *tarGzWriter) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
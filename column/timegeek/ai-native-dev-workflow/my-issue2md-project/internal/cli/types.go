@@ -25,6 +25,11 @@ func NewCLI(name string, args []string) *CLI {
 	}
 }
 
+// Args 返回命令行参数(不含程序名)
+func (c *CLI) Args() []string {
+	return c.args
+}
+
 // Output 输出配置
 type Output struct {
 	Writer      interface{}
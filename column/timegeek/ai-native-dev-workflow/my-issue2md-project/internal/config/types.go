@@ -1,15 +1,23 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config 应用程序配置
 type Config struct {
 	GitHubToken string      `json:"github_token"`
+	GitLabToken string      `json:"gitlab_token"`
+	GiteaToken  string      `json:"gitea_token"`
 	Output      OutputConfig `json:"output"`
 	Parser      ParserConfig `json:"parser"`
+	Web         WebConfig    `json:"web"`
+
+	// Hosts 自建实例的 主机名->平台(gitlab, gitea) 映射，例如 {"git.example.com": "gitlab"}
+	Hosts map[string]string `json:"hosts"`
 }
 
 // OutputConfig 输出配置
@@ -18,23 +26,52 @@ type OutputConfig struct {
 	Filename    string `json:"filename"`
 	Destination string `json:"destination"`
 	Overwrite   bool   `json:"overwrite"`
+
+	// Archive 开启后下载文档引用的图片和附件，并把链接改写为归档内的相对路径
+	Archive        bool `json:"archive"`
+	ArchiveWorkers int  `json:"archive_workers"` // 并发下载数
 }
 
 // ParserConfig 解析器配置
 type ParserConfig struct {
 	IncludeComments    bool `json:"include_comments"`
+	IncludeEvents      bool `json:"include_events"`
+	IncludeReactions   bool `json:"include_reactions"`
+	IncludeDiff        bool `json:"include_diff"`
 	IncludeMetadata    bool `json:"include_metadata"`
 	IncludeTimestamps  bool `json:"include_timestamps"`
 	IncludeUserLinks   bool `json:"include_user_links"`
 	EmojisEnabled      bool `json:"emojis_enabled"`
 	PreserveLineBreaks bool `json:"preserve_line_breaks"`
+
+	// Template 是Markdown输出模板：内置模板名(default, compact)或模板文件路径
+	Template string `json:"template"`
+}
+
+// WebConfig Web服务配置
+type WebConfig struct {
+	CacheSize  int    `json:"cache_size"`  // 内存中缓存的转换结果数
+	CacheDir   string `json:"cache_dir"`   // 磁盘缓存目录，为空时只使用内存缓存
+	RateLimit  int    `json:"rate_limit"`  // 每个客户端每分钟允许的API请求数，0表示不限制
+	RateBurst  int    `json:"rate_burst"`  // 每个客户端允许的突发请求数
+	JobWorkers int    `json:"job_workers"` // 同时执行的异步任务数
+	TrustProxy bool   `json:"trust_proxy"` // 部署在反向代理之后时按X-Forwarded-For识别客户端
 }
 
 // Environment 环境变量配置
 type Environment struct {
 	GitHubToken string
+	GitLabToken string
+	GiteaToken  string
+	Hosts       string // ISSUE2MD_HOSTS，格式为 host=forge,host=forge
 	Debug       bool
 	NoColor     bool
+
+	CacheDir   string
+	CacheSize  int
+	RateLimit  int
+	JobWorkers int
+	TrustProxy bool
 }
 
 // DefaultConfig 返回默认配置
@@ -44,15 +81,25 @@ func DefaultConfig() *Config {
 			Format:      "markdown",
 			Destination: "output",
 			Overwrite:   false,
+
+			ArchiveWorkers: 4,
 		},
 		Parser: ParserConfig{
 			IncludeComments:    true,
+			IncludeEvents:      true,
+			IncludeReactions:   false,
 			IncludeMetadata:    true,
 			IncludeTimestamps:  true,
 			IncludeUserLinks:   true,
 			EmojisEnabled:      true,
 			PreserveLineBreaks: true,
 		},
+		Web: WebConfig{
+			CacheSize:  256,
+			RateLimit:  30,
+			RateBurst:  10,
+			JobWorkers: 2,
+		},
 	}
 }
 
@@ -63,6 +110,34 @@ func (c *Config) LoadFromEnv() {
 	if token := env.GitHubToken; token != "" {
 		c.GitHubToken = token
 	}
+	if token := env.GitLabToken; token != "" {
+		c.GitLabToken = token
+	}
+	if token := env.GiteaToken; token != "" {
+		c.GiteaToken = token
+	}
+	for _, entry := range strings.Split(env.Hosts, ",") {
+		if strings.TrimSpace(entry) != "" {
+			// 环境变量中格式错误的项直接忽略，命令行的 --host 会报告错误
+			_ = c.AddHost(entry)
+		}
+	}
+
+	if env.CacheDir != "" {
+		c.Web.CacheDir = env.CacheDir
+	}
+	if env.CacheSize > 0 {
+		c.Web.CacheSize = env.CacheSize
+	}
+	if env.RateLimit >= 0 {
+		c.Web.RateLimit = env.RateLimit
+	}
+	if env.JobWorkers > 0 {
+		c.Web.JobWorkers = env.JobWorkers
+	}
+	if env.TrustProxy {
+		c.Web.TrustProxy = true
+	}
 
 	if debug := env.Debug; debug {
 		c.Parser.EmojisEnabled = false // Example debug setting
@@ -73,8 +148,17 @@ func (c *Config) LoadFromEnv() {
 func GetEnvironment() *Environment {
 	env := &Environment{
 		GitHubToken: os.Getenv("GITHUB_TOKEN"),
+		GitLabToken: os.Getenv("GITLAB_TOKEN"),
+		GiteaToken:  os.Getenv("GITEA_TOKEN"),
+		Hosts:       os.Getenv("ISSUE2MD_HOSTS"),
 		Debug:       getBoolEnv("DEBUG", false),
 		NoColor:     getBoolEnv("NO_COLOR", false),
+
+		CacheDir:   os.Getenv("ISSUE2MD_CACHE_DIR"),
+		CacheSize:  getIntEnv("ISSUE2MD_CACHE_SIZE", 0),
+		RateLimit:  getIntEnv("ISSUE2MD_RATE_LIMIT", -1),
+		JobWorkers: getIntEnv("ISSUE2MD_JOB_WORKERS", 0),
+		TrustProxy: getBoolEnv("ISSUE2MD_TRUST_PROXY", false),
 	}
 	return env
 }
@@ -90,6 +174,69 @@ func getBoolEnv(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getIntEnv 获取整数环境变量，未设置或解析失败时返回默认值
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// AddHost 添加一个 host=forge 形式的自建实例映射
+func (c *Config) AddHost(entry string) error {
+	host, forge, ok := strings.Cut(strings.TrimSpace(entry), "=")
+	host, forge = strings.ToLower(strings.TrimSpace(host)), strings.ToLower(strings.TrimSpace(forge))
+	if !ok || host == "" || forge == "" {
+		return fmt.Errorf("invalid host mapping %q, expected host=forge", entry)
+	}
+	if c.Hosts == nil {
+		c.Hosts = make(map[string]string)
+	}
+	c.Hosts[host] = forge
+	return nil
+}
+
+// SetToken 设置指定平台的访问令牌，用于命令行的 --token
+func (c *Config) SetToken(forge, token string) {
+	switch forge {
+	case "gitlab":
+		c.GitLabToken = token
+	case "gitea":
+		c.GiteaToken = token
+	default:
+		c.GitHubToken = token
+	}
+}
+
+// Token 返回指定平台的访问令牌
+func (c *Config) Token(forge string) string {
+	switch forge {
+	case "gitlab":
+		return c.GitLabToken
+	case "gitea":
+		return c.GiteaToken
+	default:
+		return c.GitHubToken
+	}
+}
+
+// ValidateFor 验证访问指定平台所需的配置
+// GitHub API必须提供令牌；GitLab和Gitea的公开项目可以匿名访问
+func (c *Config) ValidateFor(forge string) error {
+	if forge == "gitlab" || forge == "gitea" {
+		if c.Output.Format == "" {
+			return &ValidationError{
+				Field:   "output.format",
+				Message: "Output format is required",
+			}
+		}
+		return nil
+	}
+	return c.Validate()
+}
+
 // Validate 验证配置
 func (c *Config) Validate() error {
 	if c.GitHubToken == "" {
@@ -185,4 +185,72 @@ func TestValidationError(t *testing.T) {
 	if err.Field != "test_field" {
 		t.Errorf("ValidationError.Field = %v, want %v", err.Field, "test_field")
 	}
-}
\ No newline at end of file
+}
+func TestLoadFromEnvForges(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "gl-token")
+	t.Setenv("GITEA_TOKEN", "gt-token")
+	t.Setenv("ISSUE2MD_HOSTS", "git.example.com=gitlab, Code.Example.org = gitea,broken")
+
+	cfg := DefaultConfig()
+	cfg.LoadFromEnv()
+
+	if cfg.Token("gitlab") != "gl-token" || cfg.Token("gitea") != "gt-token" {
+		t.Errorf("tokens = %q, %q", cfg.Token("gitlab"), cfg.Token("gitea"))
+	}
+	want := map[string]string{"git.example.com": "gitlab", "code.example.org": "gitea"}
+	if len(cfg.Hosts) != len(want) {
+		t.Fatalf("Hosts = %v, want %v", cfg.Hosts, want)
+	}
+	for host, forge := range want {
+		if cfg.Hosts[host] != forge {
+			t.Errorf("Hosts[%s] = %q, want %q", host, cfg.Hosts[host], forge)
+		}
+	}
+}
+
+func TestConfigValidateFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		forge   string
+		token   string
+		wantErr bool
+	}{
+		{name: "github requires token", forge: "github", wantErr: true},
+		{name: "github with token", forge: "github", token: "t", wantErr: false},
+		{name: "gitlab anonymous", forge: "gitlab", wantErr: false},
+		{name: "gitea anonymous", forge: "gitea", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.SetToken(tt.forge, tt.token)
+			if err := cfg.ValidateFor(tt.forge); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFor(%s) error = %v, wantErr %v", tt.forge, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromEnvWeb(t *testing.T) {
+	t.Setenv("ISSUE2MD_CACHE_DIR", "/var/cache/issue2md")
+	t.Setenv("ISSUE2MD_CACHE_SIZE", "10")
+	t.Setenv("ISSUE2MD_RATE_LIMIT", "0")
+	t.Setenv("ISSUE2MD_JOB_WORKERS", "not-a-number")
+	t.Setenv("ISSUE2MD_TRUST_PROXY", "true")
+
+	cfg := DefaultConfig()
+	cfg.LoadFromEnv()
+
+	want := WebConfig{
+		CacheSize:  10,
+		CacheDir:   "/var/cache/issue2md",
+		RateLimit:  0, // 0 表示不限流，不能被当作未设置
+		RateBurst:  10,
+		JobWorkers: 2,
+		TrustProxy: true,
+	}
+	if cfg.Web != want {
+		t.Errorf("Web = %+v, want %+v", cfg.Web, want)
+	}
+}
@@ -0,0 +1,339 @@
+package converter
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+// highlightStyle 是代码高亮使用的chroma样式
+const highlightStyle = "github"
+
+// tocMaxLevel 是目录收录的最深标题级别，一级标题是文档标题，不进入目录
+const tocMaxLevel = 3
+
+// defaultHTMLTemplate 是默认的HTML页面模板
+// 模板数据见 htmlTemplateData
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+{{.CSS}}
+</style>
+</head>
+<body>
+<article class="markdown-body">
+{{- if .TOC}}
+<nav class="toc">
+<ul>
+{{- range .TOC}}
+<li class="toc-level-{{.Level}}"><a href="#{{.ID}}">{{.Text}}</a></li>
+{{- end}}
+</ul>
+</nav>
+{{- end}}
+{{.Body}}
+</article>
+</body>
+</html>
+`
+
+// defaultCSS 是默认页面样式
+const defaultCSS = `body { margin: 0; background: #fff; color: #1f2328; }
+.markdown-body { box-sizing: border-box; max-width: 980px; margin: 0 auto; padding: 32px;
+  font-family: -apple-system, "Segoe UI", "Noto Sans", Helvetica, Arial, sans-serif; font-size: 16px; line-height: 1.5; word-wrap: break-word; }
+.markdown-body h1, .markdown-body h2 { border-bottom: 1px solid #d1d9e0; padding-bottom: .3em; }
+.markdown-body a { color: #0969da; }
+.markdown-body code { padding: .2em .4em; border-radius: 6px; background: #eff1f3; font-size: 85%; }
+.markdown-body pre { padding: 16px; overflow: auto; border-radius: 6px; background: #f6f8fa; font-size: 85%; line-height: 1.45; }
+.markdown-body pre code { padding: 0; background: transparent; font-size: 100%; }
+.markdown-body blockquote { margin: 0; padding: 0 1em; color: #59636e; border-left: .25em solid #d1d9e0; }
+.markdown-body table { border-collapse: collapse; }
+.markdown-body th, .markdown-body td { padding: 6px 13px; border: 1px solid #d1d9e0; }
+.markdown-body img { max-width: 100%; }
+.markdown-body li:has(> input[type=checkbox]) { list-style: none; }
+.toc { margin-bottom: 16px; padding: 8px 16px; border: 1px solid #d1d9e0; border-radius: 6px; }
+.toc ul { margin: 0; padding-left: 0; list-style: none; }
+.toc .toc-level-3 { padding-left: 1.5em; }
+`
+
+// TOCEntry 是目录中的一项
+type TOCEntry struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+// htmlTemplateData 是传给HTML模板的数据
+type htmlTemplateData struct {
+	Title    string
+	Body     template.HTML // 已经过清洗的正文HTML
+	TOC      []TOCEntry    // 未开启目录时为空
+	CSS      template.CSS  // 默认样式、高亮样式与CustomCSS
+	Metadata map[string]string
+}
+
+// Convert 将Markdown文档渲染为完整的HTML页面
+// 正文按GFM渲染，再经过白名单清洗，最后套用html/template模板，标题等字段由模板负责转义
+func (hc *HTMLConverter) Convert(doc *parser.MarkdownDocument) ([]byte, error) {
+	if doc == nil {
+		return nil, &ConversionError{
+			Message: "document is nil",
+			Format:  FormatHTML,
+		}
+	}
+
+	tmplText := defaultHTMLTemplate
+	if hc.options.Template != "" {
+		tmplText = hc.options.Template
+	}
+	tmpl, err := template.New("page").Parse(tmplText)
+	if err != nil {
+		return nil, &ConversionError{
+			Message:   "failed to parse HTML template",
+			Format:    FormatHTML,
+			SourceErr: err,
+		}
+	}
+
+	md := hc.newMarkdown()
+	source := []byte(stripFrontmatter(doc.Content))
+	root := md.Parser().Parse(text.NewReader(source))
+
+	var body bytes.Buffer
+	if err := md.Renderer().Render(&body, source, root); err != nil {
+		return nil, &ConversionError{
+			Message:   "failed to render markdown",
+			Format:    FormatHTML,
+			SourceErr: err,
+		}
+	}
+
+	css, err := hc.css()
+	if err != nil {
+		return nil, err
+	}
+
+	data := htmlTemplateData{
+		Title:    doc.Title,
+		Body:     template.HTML(newSanitizer(hc.options).SanitizeBytes(body.Bytes())),
+		CSS:      css,
+		Metadata: doc.Metadata,
+	}
+	if hc.options.EnableTableOfContents {
+		data.TOC = buildTOC(root, source)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, &ConversionError{
+			Message:   "failed to execute HTML template",
+			Format:    FormatHTML,
+			SourceErr: err,
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// newMarkdown 按转换器选项组装GFM渲染器
+// 原始HTML会原样输出，由之后的清洗步骤处理，这样Issue中常见的<details>等标签得以保留
+func (hc *HTMLConverter) newMarkdown() goldmark.Markdown {
+	var extensions []goldmark.Extender
+	if hc.options.EnableTables {
+		extensions = append(extensions, extension.NewTable(
+			extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute),
+		))
+	}
+	if hc.options.EnableStrikethrough {
+		extensions = append(extensions, extension.Strikethrough)
+	}
+	if hc.options.EnableTaskLists {
+		extensions = append(extensions, extension.TaskList)
+	}
+	if hc.options.EnableLinks {
+		extensions = append(extensions, extension.Linkify)
+	}
+	if hc.options.EnableEmojis {
+		extensions = append(extensions, emoji.Emoji)
+	}
+	if hc.options.EnableSyntaxHighlighting {
+		extensions = append(extensions, highlighting.NewHighlighting(
+			highlighting.WithStyle(highlightStyle),
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		))
+	}
+
+	parserOptions := []goldmarkparser.Option{goldmarkparser.WithAutoHeadingID()}
+	if !hc.options.EnableImages {
+		parserOptions = append(parserOptions, goldmarkparser.WithASTTransformers(
+			util.Prioritized(imageToLinkTransformer{}, 100),
+		))
+	}
+
+	return goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(parserOptions...),
+		goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+	)
+}
+
+// css 拼接默认样式、代码高亮样式和自定义样式
+func (hc *HTMLConverter) css() (template.CSS, error) {
+	var b strings.Builder
+	b.WriteString(defaultCSS)
+	if hc.options.EnableSyntaxHighlighting {
+		formatter := chromahtml.New(chromahtml.WithClasses(true))
+		if err := formatter.WriteCSS(&b, styles.Get(highlightStyle)); err != nil {
+			return "", &ConversionError{
+				Message:   "failed to generate highlight CSS",
+				Format:    FormatHTML,
+				SourceErr: err,
+			}
+		}
+	}
+	if hc.options.CustomCSS != "" {
+		b.WriteString(hc.options.CustomCSS)
+		b.WriteString("\n")
+	}
+	// 样式直接写入<style>，避免CustomCSS中的"</style>"提前结束标签
+	return template.CSS(strings.ReplaceAll(b.String(), "</", `<\/`)), nil
+}
+
+// headingID 限定标题id的字符，允许非ASCII字母以保留中文标题生成的锚点
+var headingID = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// newSanitizer 创建白名单清洗策略
+// 只保留GitHub Markdown会产生的标签和属性，脚本、事件属性、样式和表单一律去掉
+func newSanitizer(opts *ConverterOptions) *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowStandardAttributes()
+	p.AllowStandardURLs()
+	p.RequireNoFollowOnLinks(true)
+
+	p.AllowElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("id").Matching(headingID).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowElements("p", "br", "hr", "div", "span", "details", "summary", "sub", "sup",
+		"b", "i", "strong", "em", "del", "s", "ins", "mark", "kbd", "samp", "var", "abbr", "small", "tt", "u")
+	p.AllowAttrs("open").Matching(regexp.MustCompile(`(?i)^(|open)$`)).OnElements("details")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("cite").OnElements("blockquote", "q")
+	p.AllowElements("blockquote")
+	p.AllowLists()
+	p.AllowTables()
+
+	// 代码块: chroma以class标记高亮，未开启高亮时code带有language-xxx
+	p.AllowElements("pre", "code")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[\w -]+$`)).OnElements("pre", "code", "span")
+
+	// 任务列表: 只允许禁用状态的复选框
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^$`)).OnElements("input")
+
+	if opts.EnableImages {
+		p.AllowImages()
+	}
+	return p
+}
+
+// buildTOC 从AST中收集二级和三级标题生成目录
+func buildTOC(root ast.Node, source []byte) []TOCEntry {
+	var toc []TOCEntry
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if heading.Level < 2 || heading.Level > tocMaxLevel {
+			return ast.WalkSkipChildren, nil
+		}
+		id, ok := heading.AttributeString("id")
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		idBytes, _ := id.([]byte)
+		toc = append(toc, TOCEntry{
+			Level: heading.Level,
+			ID:    string(idBytes),
+			Text:  plainText(heading, source),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return toc
+}
+
+// plainText 返回节点下的纯文本，忽略强调、链接等内联格式
+func plainText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			b.Write(t.Value(source))
+			if t.SoftLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// imageToLinkTransformer 在禁用图片时把Markdown图片改写为指向图片地址的链接
+type imageToLinkTransformer struct{}
+
+func (imageToLinkTransformer) Transform(doc *ast.Document, _ text.Reader, _ goldmarkparser.Context) {
+	var images []*ast.Image
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if img, ok := n.(*ast.Image); ok && entering {
+			images = append(images, img)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, img := range images {
+		link := ast.NewLink()
+		link.Destination = img.Destination
+		link.Title = img.Title
+		for c := img.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			link.AppendChild(link, c)
+			c = next
+		}
+		img.Parent().ReplaceChild(img.Parent(), img, link)
+	}
+}
+
+// stripFrontmatter 去掉文档开头的YAML frontmatter，它只供Markdown输出使用
+func stripFrontmatter(content string) string {
+	if !strings.HasPrefix(content, "---\n") {
+		return content
+	}
+	end := strings.Index(content[4:], "\n---\n")
+	if end < 0 {
+		return content
+	}
+	return strings.TrimLeft(content[4+end+5:], "\n")
+}
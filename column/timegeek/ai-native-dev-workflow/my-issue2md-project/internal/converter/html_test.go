@@ -0,0 +1,205 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+func TestHTMLConverter_Convert(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    func(*ConverterOptions)
+		doc     *parser.MarkdownDocument
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "标题被转义",
+			doc: &parser.MarkdownDocument{
+				Title:   `</title><script>alert(1)</script>`,
+				Content: "body\n",
+			},
+			want:    []string{"<title>&lt;/title&gt;&lt;script&gt;alert(1)&lt;/script&gt;</title>"},
+			notWant: []string{"<script>alert(1)</script>"},
+		},
+		{
+			name: "正文中的脚本和事件属性被清洗",
+			doc: &parser.MarkdownDocument{
+				Title:   "xss",
+				Content: "hello <script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n\n[click](javascript:alert(1))\n\n<details><summary>more</summary>hidden</details>\n",
+			},
+			want:    []string{"<details>", "<summary>more</summary>"},
+			notWant: []string{"<script>", "onerror", "javascript:"},
+		},
+		{
+			name: "frontmatter不进入正文",
+			doc: &parser.MarkdownDocument{
+				Title:   "Bug",
+				Content: "---\ntitle: \"Bug\"\n---\n\n# Bug - Open\n",
+			},
+			want:    []string{`<h1 id="bug---open">Bug - Open</h1>`},
+			notWant: []string{"title: &#34;Bug&#34;", "<hr>"},
+		},
+		{
+			name: "代码块按语言高亮",
+			doc: &parser.MarkdownDocument{
+				Title:   "code",
+				Content: "```go\nfunc main() {}\n```\n",
+			},
+			want: []string{`<pre class="chroma">`, `<span class="kd">func</span>`, ".chroma .kd {"},
+		},
+		{
+			name: "关闭高亮时保留语言class",
+			opts: func(o *ConverterOptions) { o.EnableSyntaxHighlighting = false },
+			doc: &parser.MarkdownDocument{
+				Title:   "code",
+				Content: "```go\nfunc main() {}\n```\n",
+			},
+			want:    []string{`<code class="language-go">func main() {}`},
+			notWant: []string{"chroma"},
+		},
+		{
+			name: "任务列表和表格",
+			doc: &parser.MarkdownDocument{
+				Title:   "gfm",
+				Content: "- [x] done\n- [ ] todo\n\n| a | b |\n|:--|--:|\n| 1 | 2 |\n\n~~old~~\n",
+			},
+			want: []string{
+				`<input checked="" disabled="" type="checkbox"> done`,
+				`<input disabled="" type="checkbox"> todo`,
+				`<th align="left">a</th>`,
+				`<td align="right">2</td>`,
+				"<del>old</del>",
+			},
+		},
+		{
+			name: "目录收录二三级标题",
+			opts: func(o *ConverterOptions) { o.EnableTableOfContents = true },
+			doc: &parser.MarkdownDocument{
+				Title:   "toc",
+				Content: "# Title\n\n## Description\n\ntext\n\n### @alice - 2024\n\n#### deep\n",
+			},
+			want: []string{
+				`<li class="toc-level-2"><a href="#description">Description</a></li>`,
+				`<li class="toc-level-3"><a href="#alice---2024">@alice - 2024</a></li>`,
+				`<h2 id="description">Description</h2>`,
+			},
+			notWant: []string{`href="#title"`, `href="#deep"`},
+		},
+		{
+			name: "未开启目录时不输出nav",
+			doc: &parser.MarkdownDocument{
+				Title:   "toc",
+				Content: "## Description\n",
+			},
+			notWant: []string{`<nav class="toc">`},
+		},
+		{
+			name: "禁用图片时改写为链接",
+			opts: func(o *ConverterOptions) { o.EnableImages = false },
+			doc: &parser.MarkdownDocument{
+				Title:   "img",
+				Content: "![screenshot](https://example.com/a.png)\n\n<img src=\"https://example.com/b.png\">\n",
+			},
+			want:    []string{`<a href="https://example.com/a.png" rel="nofollow">screenshot</a>`},
+			notWant: []string{"<img"},
+		},
+		{
+			name: "自定义CSS不能提前结束style标签",
+			opts: func(o *ConverterOptions) { o.CustomCSS = "body{color:red}</style><script>alert(1)</script>" },
+			doc: &parser.MarkdownDocument{
+				Title:   "css",
+				Content: "text\n",
+			},
+			want:    []string{"body{color:red}"},
+			notWant: []string{"</style><script>"},
+		},
+		{
+			name: "自定义模板",
+			opts: func(o *ConverterOptions) {
+				o.Template = `<main data-author="{{index .Metadata "author"}}"><h1>{{.Title}}</h1>{{.Body}}</main>`
+			},
+			doc: &parser.MarkdownDocument{
+				Title:    "a<b",
+				Content:  "**bold**\n",
+				Metadata: map[string]string{"author": "alice"},
+			},
+			want:    []string{`<main data-author="alice"><h1>a&lt;b</h1><p><strong>bold</strong></p>`},
+			notWant: []string{"<!DOCTYPE html>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConverterOptions()
+			if tt.opts != nil {
+				tt.opts(opts)
+			}
+			got, err := NewHTMLConverter(opts).Convert(tt.doc)
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			html := string(got)
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("output missing %q\n%s", want, html)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("output should not contain %q\n%s", notWant, html)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLConverter_ConvertErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		doc      *parser.MarkdownDocument
+	}{
+		{name: "文档为空", doc: nil},
+		{name: "模板语法错误", template: "{{.Title", doc: &parser.MarkdownDocument{Title: "t"}},
+		{name: "模板字段不存在", template: "{{.Missing}}", doc: &parser.MarkdownDocument{Title: "t"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConverterOptions()
+			opts.Template = tt.template
+			_, err := NewHTMLConverter(opts).Convert(tt.doc)
+			var convErr *ConversionError
+			if !errors.As(err, &convErr) {
+				t.Fatalf("Convert() error = %v, want *ConversionError", err)
+			}
+			if convErr.Format != FormatHTML {
+				t.Errorf("Format = %q, want %q", convErr.Format, FormatHTML)
+			}
+		})
+	}
+}
+
+func TestStripFrontmatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "无frontmatter", content: "# Title\n", want: "# Title\n"},
+		{name: "有frontmatter", content: "---\na: 1\n---\n\n# Title\n", want: "# Title\n"},
+		{name: "未闭合", content: "---\na: 1\n# Title\n", want: "---\na: 1\n# Title\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripFrontmatter(tt.content); got != tt.want {
+				t.Errorf("stripFrontmatter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -171,30 +171,6 @@ func (mc *MarkdownConverter) Convert(doc *parser.MarkdownDocument) ([]byte, erro
 	return []byte(result), nil
 }
 
-// Convert 将Markdown文档转换为HTML
-func (hc *HTMLConverter) Convert(doc *parser.MarkdownDocument) ([]byte, error) {
-	if doc == nil {
-		return nil, &ConversionError{
-			Message: "document is nil",
-			Format:  FormatHTML,
-		}
-	}
-
-	// 简单的HTML转换，完整的实现将在Phase 2中完成
-	html := "<!DOCTYPE html>\n<html>\n<head>\n"
-	if doc.Title != "" {
-		html += "<title>" + doc.Title + "</title>\n"
-	}
-	html += "</head>\n<body>\n"
-	if doc.Title != "" {
-		html += "<h1>" + doc.Title + "</h1>\n"
-	}
-	html += "<div>" + doc.Content + "</div>\n"
-	html += "</body>\n</html>"
-
-	return []byte(html), nil
-}
-
 // Convert 将Markdown文档转换为JSON
 func (jc *JSONConverter) Convert(doc *parser.MarkdownDocument) ([]byte, error) {
 	if doc == nil {
@@ -0,0 +1,269 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+// IndexFile 是索引在输出目录中的文件名
+const IndexFile = "index.md"
+
+// Source 是批量导出的数据源
+type Source interface {
+	ListIssues(ctx context.Context, owner, repo string, opts *github.ListIssuesOptions) (*github.IssueList, error)
+}
+
+// RenderFunc 获取并渲染单个Issue或PR，返回写入文件的内容
+type RenderFunc func(ctx context.Context, res *parser.ResourceURL) ([]byte, error)
+
+// Options 批量导出选项
+type Options struct {
+	// 过滤条件
+	State  string // open, closed, all
+	Labels []string
+	Since  time.Time
+	Author string
+
+	Concurrency int    // 同时导出的条目数，限流由GitHub客户端统一等待
+	Dir         string // 输出目录
+	Ext         string // 导出文件的扩展名，例如 ".md"
+	Force       bool   // 忽略本地状态，重新导出全部条目
+}
+
+// DefaultOptions 返回默认导出选项
+func DefaultOptions() *Options {
+	return &Options{
+		State:       "open",
+		Concurrency: 4,
+		Dir:         ".",
+		Ext:         ".md",
+	}
+}
+
+// ParseSince 解析 since 过滤条件，支持RFC3339时间或日期
+func ParseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q, expected RFC3339 or 2006-01-02", s)
+	}
+	return t, nil
+}
+
+// Exporter 批量导出仓库的Issue和PR，并记录状态以便增量同步
+type Exporter struct {
+	source  Source
+	render  RenderFunc
+	options *Options
+}
+
+// NewExporter 创建批量导出器
+func NewExporter(source Source, render RenderFunc, opts *Options) *Exporter {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	return &Exporter{
+		source:  source,
+		render:  render,
+		options: opts,
+	}
+}
+
+// Result 是一次导出的统计
+type Result struct {
+	Listed      int  // 列表返回的条目数
+	Exported    int  // 本次重新导出的条目数
+	Skipped     int  // 自上次导出后没有更新的条目数
+	NotModified bool // 列表未变化，没有发起任何导出
+	Failed      []*ItemError
+}
+
+// ItemError 是单个条目导出失败的原因
+type ItemError struct {
+	Number int
+	Err    error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("#%d: %v", e.Number, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// Export 导出owner/repo中符合条件的Issue和PR，并更新索引和状态文件
+//
+// 列表首页带上次的ETag发起条件请求，未变化时GitHub返回304且不计入限流配额；
+// 列表变化时只重新导出updated_at与状态记录不同的条目。
+// 单个条目失败不会中断导出，失败时不更新列表的ETag，下次运行会重试这些条目
+func (e *Exporter) Export(ctx context.Context, owner, repo string) (*Result, error) {
+	opts := e.options
+	repository := owner + "/" + repo
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	statePath := filepath.Join(opts.Dir, StateFile)
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+	if state.Repository != "" && state.Repository != repository {
+		return nil, fmt.Errorf("%s already contains an export of %s", opts.Dir, state.Repository)
+	}
+	state.Repository = repository
+
+	key := filterKey(opts)
+	listOpts := &github.ListIssuesOptions{
+		State:  opts.State,
+		Labels: opts.Labels,
+		Since:  opts.Since,
+		Author: opts.Author,
+	}
+	if prev := state.Lists[key]; prev != nil && !opts.Force {
+		listOpts.ETag = prev.ETag
+		listOpts.StopBefore = prev.UpdatedAt
+	}
+
+	list, err := e.source.ListIssues(ctx, owner, repo, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Listed: len(list.Issues), NotModified: list.NotModified}
+	if list.NotModified {
+		return result, writeIndex(filepath.Join(opts.Dir, IndexFile), state)
+	}
+
+	var pending []*github.Issue
+	for _, issue := range list.Issues {
+		prev := state.Items[issue.Number]
+		if !opts.Force && prev != nil && prev.UpdatedAt.Equal(issue.UpdatedAt) && fileExists(filepath.Join(opts.Dir, prev.Path)) {
+			result.Skipped++
+			continue
+		}
+		pending = append(pending, issue)
+	}
+
+	exportErr := e.exportAll(ctx, owner, repo, pending, state, result)
+
+	if exportErr == nil && len(result.Failed) == 0 {
+		newest := listOpts.StopBefore
+		if len(list.Issues) > 0 && list.Issues[0].UpdatedAt.After(newest) {
+			newest = list.Issues[0].UpdatedAt
+		}
+		state.Lists[key] = &ListState{ETag: list.ETag, UpdatedAt: newest}
+	}
+
+	// 即使被取消也保存已完成条目的状态，下次运行从这里继续
+	if err := state.Save(statePath); err != nil {
+		return result, err
+	}
+	if err := writeIndex(filepath.Join(opts.Dir, IndexFile), state); err != nil {
+		return result, err
+	}
+	return result, exportErr
+}
+
+// exportAll 用固定数量的worker并发导出条目，ctx取消时停止分发并返回ctx的错误
+func (e *Exporter) exportAll(ctx context.Context, owner, repo string, issues []*github.Issue, state *State, result *Result) error {
+	var mu sync.Mutex
+	jobs := make(chan *github.Issue)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(e.options.Concurrency, 1), len(issues)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for issue := range jobs {
+				item, err := e.exportOne(ctx, owner, repo, issue)
+				mu.Lock()
+				if err != nil {
+					result.Failed = append(result.Failed, &ItemError{Number: issue.Number, Err: err})
+				} else {
+					state.Items[issue.Number] = item
+					result.Exported++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for _, issue := range issues {
+		select {
+		case jobs <- issue:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return err
+}
+
+// exportOne 渲染单个条目并写入文件
+func (e *Exporter) exportOne(ctx context.Context, owner, repo string, issue *github.Issue) (*ItemState, error) {
+	typ, dir := "issue", "issues"
+	if issue.IsPullRequest {
+		typ, dir = "pull", "pulls"
+	}
+	res := &parser.ResourceURL{
+		Type:    typ,
+		Owner:   owner,
+		Repo:    repo,
+		Number:  issue.Number,
+		URL:     issue.HTMLURL,
+		Forge:   parser.ForgeGitHub,
+		BaseURL: "https://github.com",
+	}
+	data, err := e.render(ctx, res)
+	if err != nil {
+		return nil, err
+	}
+
+	rel := path.Join(dir, strconv.Itoa(issue.Number)+e.options.Ext)
+	p := filepath.Join(e.options.Dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+
+	labels := make([]string, 0, len(issue.Labels))
+	for _, l := range issue.Labels {
+		labels = append(labels, l.Name)
+	}
+	return &ItemState{
+		Number:    issue.Number,
+		Type:      typ,
+		Title:     issue.Title,
+		State:     issue.State,
+		Author:    issue.User.Login,
+		Labels:    labels,
+		UpdatedAt: issue.UpdatedAt,
+		Path:      rel,
+	}, nil
+}
+
+// fileExists 判断文件是否存在，导出文件被删除时需要重新导出
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return !errors.Is(err, os.ErrNotExist)
+}
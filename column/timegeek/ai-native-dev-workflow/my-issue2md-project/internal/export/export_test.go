@@ -0,0 +1,273 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+// fakeSource 模拟GitHub列表接口：请求的ETag与当前ETag相同时返回未变化
+type fakeSource struct {
+	etag   string
+	issues []*github.Issue
+	calls  []*github.ListIssuesOptions
+}
+
+func (f *fakeSource) ListIssues(ctx context.Context, owner, repo string, opts *github.ListIssuesOptions) (*github.IssueList, error) {
+	f.calls = append(f.calls, opts)
+	if opts.ETag != "" && opts.ETag == f.etag {
+		return &github.IssueList{ETag: f.etag, NotModified: true}, nil
+	}
+	return &github.IssueList{Issues: f.issues, ETag: f.etag}, nil
+}
+
+// fakeRenderer 记录渲染过的条目，fail中的编号渲染失败
+type fakeRenderer struct {
+	mu       sync.Mutex
+	rendered []string
+	fail     map[int]bool
+}
+
+func (r *fakeRenderer) render(ctx context.Context, res *parser.ResourceURL) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rendered = append(r.rendered, fmt.Sprintf("%s/%d", res.Type, res.Number))
+	if r.fail[res.Number] {
+		return nil, errors.New("boom")
+	}
+	return []byte(fmt.Sprintf("# %s %d\n", res.Type, res.Number)), nil
+}
+
+func testIssue(number int, pr bool, updated string) *github.Issue {
+	updatedAt, _ := time.Parse(time.RFC3339, updated)
+	return &github.Issue{
+		Number:        number,
+		Title:         fmt.Sprintf("Item | %d", number),
+		State:         "open",
+		User:          github.User{Login: "alice"},
+		Labels:        []github.Label{{Name: "bug"}},
+		UpdatedAt:     updatedAt,
+		IsPullRequest: pr,
+	}
+}
+
+func TestExporter_IncrementalSync(t *testing.T) {
+	dir := t.TempDir()
+	source := &fakeSource{
+		etag: `"v1"`,
+		issues: []*github.Issue{
+			testIssue(3, false, "2024-03-03T00:00:00Z"),
+			testIssue(2, true, "2024-03-02T00:00:00Z"),
+			testIssue(1, false, "2024-03-01T00:00:00Z"),
+		},
+	}
+	renderer := &fakeRenderer{}
+	opts := DefaultOptions()
+	opts.Dir = dir
+	exporter := NewExporter(source, renderer.render, opts)
+
+	// 首次导出全部条目
+	result, err := exporter.Export(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if result.Exported != 3 || result.Skipped != 0 {
+		t.Errorf("first run: %+v, want 3 exported", result)
+	}
+	for _, f := range []string{"issues/1.md", "pulls/2.md", "issues/3.md", IndexFile, StateFile} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("missing %s: %v", f, err)
+		}
+	}
+	index, _ := os.ReadFile(filepath.Join(dir, IndexFile))
+	for _, want := range []string{"# o/r", "| [#3](issues/3.md) | Issue | Item \\| 3 | open | @alice | bug | 2024-03-03 |", "| [#2](pulls/2.md) | PR |"} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("index missing %q\n%s", want, index)
+		}
+	}
+	if !strings.Contains(string(index), "[#3]") || strings.Index(string(index), "[#3]") > strings.Index(string(index), "[#1]") {
+		t.Errorf("index should list items in descending order\n%s", index)
+	}
+
+	// 列表未变化：条件请求返回304，不渲染任何条目
+	renderer.rendered = nil
+	result, err = exporter.Export(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !result.NotModified || len(renderer.rendered) != 0 {
+		t.Errorf("second run: %+v rendered %v, want not modified", result, renderer.rendered)
+	}
+	last := source.calls[len(source.calls)-1]
+	if last.ETag != `"v1"` || !last.StopBefore.Equal(source.issues[0].UpdatedAt) {
+		t.Errorf("second run list options = %+v", last)
+	}
+
+	// 只有#2更新：只重新导出#2
+	source.etag = `"v2"`
+	source.issues = []*github.Issue{
+		testIssue(2, true, "2024-03-05T00:00:00Z"),
+		testIssue(3, false, "2024-03-03T00:00:00Z"),
+	}
+	result, err = exporter.Export(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if result.Exported != 1 || result.Skipped != 1 || strings.Join(renderer.rendered, ",") != "pull/2" {
+		t.Errorf("third run: %+v rendered %v, want only pull/2", result, renderer.rendered)
+	}
+
+	state, err := LoadState(filepath.Join(dir, StateFile))
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if len(state.Items) != 3 || !state.Items[2].UpdatedAt.Equal(source.issues[0].UpdatedAt) {
+		t.Errorf("state items = %+v", state.Items)
+	}
+	if got := state.Lists[filterKey(opts)]; got == nil || got.ETag != `"v2"` {
+		t.Errorf("list state = %+v, want ETag v2", got)
+	}
+}
+
+func TestExporter_FailedItemsAreRetried(t *testing.T) {
+	dir := t.TempDir()
+	source := &fakeSource{
+		etag:   `"v1"`,
+		issues: []*github.Issue{testIssue(2, false, "2024-03-02T00:00:00Z"), testIssue(1, false, "2024-03-01T00:00:00Z")},
+	}
+	renderer := &fakeRenderer{fail: map[int]bool{2: true}}
+	opts := DefaultOptions()
+	opts.Dir = dir
+	exporter := NewExporter(source, renderer.render, opts)
+
+	result, err := exporter.Export(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if result.Exported != 1 || len(result.Failed) != 1 || result.Failed[0].Number != 2 {
+		t.Fatalf("result = %+v, want #2 failed", result)
+	}
+
+	// 失败后不记录ETag，下次运行重新列出并只重试失败的条目
+	renderer.fail = nil
+	renderer.rendered = nil
+	result, err = exporter.Export(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if last := source.calls[len(source.calls)-1]; last.ETag != "" {
+		t.Errorf("ETag = %q, want empty after a failed run", last.ETag)
+	}
+	if result.Exported != 1 || result.Skipped != 1 || strings.Join(renderer.rendered, ",") != "issue/2" {
+		t.Errorf("retry: %+v rendered %v, want only issue/2", result, renderer.rendered)
+	}
+}
+
+func TestExporter_ForceAndDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	source := &fakeSource{
+		etag:   `"v1"`,
+		issues: []*github.Issue{testIssue(2, false, "2024-03-02T00:00:00Z"), testIssue(1, false, "2024-03-01T00:00:00Z")},
+	}
+	renderer := &fakeRenderer{}
+	opts := DefaultOptions()
+	opts.Dir = dir
+	if _, err := NewExporter(source, renderer.render, opts).Export(context.Background(), "o", "r"); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// 列表变化时，本地被删除的文件会重新导出
+	source.etag = `"v2"`
+	os.Remove(filepath.Join(dir, "issues", "1.md"))
+	renderer.rendered = nil
+	if _, err := NewExporter(source, renderer.render, opts).Export(context.Background(), "o", "r"); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if strings.Join(renderer.rendered, ",") != "issue/1" {
+		t.Errorf("rendered %v, want issue/1", renderer.rendered)
+	}
+
+	// Force忽略状态，不发送ETag并重新导出全部
+	opts.Force = true
+	renderer.rendered = nil
+	result, err := NewExporter(source, renderer.render, opts).Export(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if last := source.calls[len(source.calls)-1]; last.ETag != "" || !last.StopBefore.IsZero() {
+		t.Errorf("forced list options = %+v, want no ETag", last)
+	}
+	if result.Exported != 2 {
+		t.Errorf("forced run exported %d, want 2", result.Exported)
+	}
+}
+
+func TestExporter_Concurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	var issues []*github.Issue
+	for i := 1; i <= 8; i++ {
+		issues = append(issues, testIssue(i, false, "2024-03-01T00:00:00Z"))
+	}
+	render := func(ctx context.Context, res *parser.ResourceURL) ([]byte, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return []byte("x"), nil
+	}
+
+	opts := DefaultOptions()
+	opts.Dir = t.TempDir()
+	opts.Concurrency = 3
+	result, err := NewExporter(&fakeSource{issues: issues}, render, opts).Export(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if result.Exported != 8 {
+		t.Errorf("Exported = %d, want 8", result.Exported)
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got > 3 {
+		t.Errorf("max concurrent exports = %d, want <= 3", got)
+	}
+}
+
+func TestExporter_RepositoryMismatch(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Dir = t.TempDir()
+	source := &fakeSource{issues: []*github.Issue{testIssue(1, false, "2024-03-01T00:00:00Z")}}
+	renderer := &fakeRenderer{}
+	if _, err := NewExporter(source, renderer.render, opts).Export(context.Background(), "o", "r"); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	_, err := NewExporter(source, renderer.render, opts).Export(context.Background(), "o", "other")
+	if err == nil || !strings.Contains(err.Error(), "already contains an export of o/r") {
+		t.Errorf("Export() error = %v, want repository mismatch", err)
+	}
+}
+
+func TestFilterKey(t *testing.T) {
+	a := &Options{State: "all", Labels: []string{"bug", "ui"}, Author: "alice"}
+	b := &Options{State: "all", Labels: []string{"ui", "bug"}, Author: "alice"}
+	c := &Options{State: "open", Labels: []string{"bug", "ui"}, Author: "alice"}
+	if filterKey(a) != filterKey(b) {
+		t.Errorf("label order should not change the key: %q vs %q", filterKey(a), filterKey(b))
+	}
+	if filterKey(a) == filterKey(c) {
+		t.Errorf("different state should change the key: %q", filterKey(a))
+	}
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// writeIndex 根据状态生成索引文件，按编号倒序列出所有已导出的条目
+func writeIndex(path string, state *State) error {
+	items := make([]*ItemState, 0, len(state.Items))
+	for _, item := range state.Items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Number > items[j].Number
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", state.Repository)
+	fmt.Fprintf(&b, "共 %d 项\n\n", len(items))
+	b.WriteString("| # | 类型 | 标题 | 状态 | 作者 | 标签 | 最后更新 |\n")
+	b.WriteString("|---|------|------|------|------|------|----------|\n")
+	for _, item := range items {
+		kind := "Issue"
+		if item.Type == "pull" {
+			kind = "PR"
+		}
+		fmt.Fprintf(&b, "| [#%d](%s) | %s | %s | %s | @%s | %s | %s |\n",
+			item.Number, item.Path, kind, tableCell(item.Title), item.State, item.Author,
+			tableCell(strings.Join(item.Labels, ", ")), item.UpdatedAt.UTC().Format("2006-01-02"))
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// tableCell 转义表格单元格中的竖线和换行
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StateFile 是增量同步状态在输出目录中的文件名
+const StateFile = ".issue2md-state.json"
+
+// State 是增量同步的本地状态
+type State struct {
+	Repository string                `json:"repository"`
+	Lists      map[string]*ListState `json:"lists"` // 按过滤条件记录
+	Items      map[int]*ItemState    `json:"items"`
+}
+
+// ListState 记录某组过滤条件上次完整同步时的列表状态
+type ListState struct {
+	ETag      string    `json:"etag"`       // 列表首页的ETag，用于条件请求
+	UpdatedAt time.Time `json:"updated_at"` // 列表中最新的更新时间，更早的条目不必再列出
+}
+
+// ItemState 记录一个已导出的Issue或PR
+type ItemState struct {
+	Number    int       `json:"number"`
+	Type      string    `json:"type"` // issue, pull
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	Author    string    `json:"author"`
+	Labels    []string  `json:"labels,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Path      string    `json:"path"` // 相对输出目录的路径
+}
+
+// LoadState 读取状态文件，文件不存在时返回空状态
+func LoadState(path string) (*State, error) {
+	state := &State{
+		Lists: make(map[string]*ListState),
+		Items: make(map[int]*ItemState),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if state.Lists == nil {
+		state.Lists = make(map[string]*ListState)
+	}
+	if state.Items == nil {
+		state.Items = make(map[int]*ItemState)
+	}
+	return state, nil
+}
+
+// Save 写入状态文件，先写临时文件再重命名，中断时不会留下损坏的状态
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// filterKey 把过滤条件编码为状态中列表的键，标签顺序不影响结果
+func filterKey(opts *Options) string {
+	q := url.Values{}
+	q.Set("state", opts.State)
+	if len(opts.Labels) > 0 {
+		labels := append([]string(nil), opts.Labels...)
+		sort.Strings(labels)
+		q.Set("labels", strings.Join(labels, ","))
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if opts.Author != "" {
+		q.Set("author", opts.Author)
+	}
+	return q.Encode()
+}
@@ -0,0 +1,243 @@
+// Package gitea 通过Gitea REST API v1获取Issue和PR，并转换为 github 包的类型
+// Forgejo(例如 codeberg.org)与Gitea的API兼容，同样使用本包
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/rest"
+)
+
+// perPage 是分页请求的每页条数，Gitea默认允许的最大值为50
+const perPage = 50
+
+// Client Gitea客户端，实现 github.IssueSource
+// Gitea的Issue和PR共用编号，评论和时间线接口也与GitHub一样共用
+type Client struct {
+	rest *rest.Client
+}
+
+var (
+	_ github.IssueSource  = (*Client)(nil)
+	_ github.UpdateSource = (*Client)(nil)
+)
+
+// NewClient 创建Gitea客户端，baseURL是实例地址，例如 https://gitea.com
+func NewClient(baseURL, token string) *Client {
+	return NewClientWithHTTPClient(nil, baseURL, token)
+}
+
+// NewClientWithHTTPClient 使用自定义HTTP客户端创建Gitea客户端
+func NewClientWithHTTPClient(httpClient *http.Client, baseURL, token string) *Client {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+	return &Client{
+		rest: rest.NewClient(httpClient, baseURL+"/api/v1", header),
+	}
+}
+
+// repoPath 返回仓库的API路径
+func repoPath(owner, repo string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+// listAll 逐页获取列表，直到响应头Link中没有下一页
+func listAll[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	query := url.Values{"limit": {strconv.Itoa(perPage)}}
+
+	var all []T
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var items []T
+		header, err := c.rest.GetJSON(ctx, path, query, &items)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if !strings.Contains(header.Get("Link"), `rel="next"`) || len(items) == 0 {
+			return all, nil
+		}
+	}
+}
+
+// user 是Gitea用户
+type user struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+	HTMLURL   string `json:"html_url"`
+}
+
+// label 是Gitea标签
+type label struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// milestone 是Gitea里程碑
+type milestone struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	State string `json:"state"`
+}
+
+// comment 是Issue或PR的评论
+type comment struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	User      user      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	HTMLURL   string    `json:"html_url"`
+}
+
+// timelineEvent 是时间线上的一项，评论和评审也在其中
+type timelineEvent struct {
+	ID              int64      `json:"id"`
+	Type            string     `json:"type"` // comment, label, close, reopen, milestone, assignees, change_title, pull_ref ...
+	Body            string     `json:"body"`
+	User            user       `json:"user"`
+	CreatedAt       time.Time  `json:"created_at"`
+	Label           *label     `json:"label"`
+	Milestone       *milestone `json:"milestone"`
+	OldMilestone    *milestone `json:"old_milestone"`
+	Assignee        *user      `json:"assignee"`
+	RemovedAssignee bool       `json:"removed_assignee"`
+	OldTitle        string     `json:"old_title"`
+	NewTitle        string     `json:"new_title"`
+	RefIssue        *issue     `json:"ref_issue"`
+	RefCommitSHA    string     `json:"ref_commit_sha"`
+}
+
+// ListComments 获取Issue或PR的普通评论
+func (c *Client) ListComments(ctx context.Context, owner, repo string, number int, pull bool) ([]*github.Comment, error) {
+	comments, err := listAll[comment](ctx, c, fmt.Sprintf("%s/issues/%d/comments", repoPath(owner, repo), number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments for issue %d from %s/%s: %w", number, owner, repo, err)
+	}
+	result := make([]*github.Comment, 0, len(comments))
+	for _, gt := range comments {
+		result = append(result, &github.Comment{
+			ID:        gt.ID,
+			Body:      gt.Body,
+			User:      convertUser(gt.User),
+			CreatedAt: gt.CreatedAt,
+			UpdatedAt: gt.UpdatedAt,
+			HTMLURL:   gt.HTMLURL,
+		})
+	}
+	return result, nil
+}
+
+// ListEvents 获取Issue或PR的时间线事件，评论和评审由其他接口获取，这里跳过
+func (c *Client) ListEvents(ctx context.Context, owner, repo string, number int, pull bool) ([]*github.TimelineEvent, error) {
+	events, err := listAll[timelineEvent](ctx, c, fmt.Sprintf("%s/issues/%d/timeline", repoPath(owner, repo), number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timeline for issue %d from %s/%s: %w", number, owner, repo, err)
+	}
+	var result []*github.TimelineEvent
+	for _, gt := range events {
+		if e := convertTimelineEvent(gt); e != nil {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// convertTimelineEvent 把Gitea时间线事件转换为GitHub的事件名，不需要展示的事件返回nil
+func convertTimelineEvent(gt timelineEvent) *github.TimelineEvent {
+	e := &github.TimelineEvent{ID: gt.ID, Event: gt.Type, Actor: convertUser(gt.User), CreatedAt: gt.CreatedAt}
+	switch gt.Type {
+	case "comment", "review", "code", "delete_branch", "pull_push":
+		return nil
+	case "label":
+		// body为 "1" 表示添加标签，为空表示移除
+		e.Event = "unlabeled"
+		if gt.Body == "1" {
+			e.Event = "labeled"
+		}
+		if gt.Label != nil {
+			e.Label = &github.Label{Name: gt.Label.Name, Color: gt.Label.Color, Description: gt.Label.Description}
+		}
+	case "close":
+		e.Event = "closed"
+	case "reopen":
+		e.Event = "reopened"
+	case "merge_pull":
+		e.Event = "merged"
+	case "milestone":
+		switch {
+		case gt.Milestone != nil:
+			e.Event, e.Milestone = "milestoned", gt.Milestone.Title
+		case gt.OldMilestone != nil:
+			e.Event, e.Milestone = "demilestoned", gt.OldMilestone.Title
+		}
+	case "assignees":
+		e.Event = "assigned"
+		if gt.RemovedAssignee {
+			e.Event = "unassigned"
+		}
+		if gt.Assignee != nil {
+			assignee := convertUser(*gt.Assignee)
+			e.Assignee = &assignee
+		}
+	case "change_title":
+		e.Event = "renamed"
+		e.Rename = &github.Rename{From: gt.OldTitle, To: gt.NewTitle}
+	case "commit_ref":
+		e.Event = "referenced"
+		e.CommitID = gt.RefCommitSHA
+	case "issue_ref", "pull_ref", "comment_ref":
+		e.Event = "cross-referenced"
+		if ref := gt.RefIssue; ref != nil {
+			e.Source = &github.CrossReference{
+				Number:      ref.Number,
+				Title:       ref.Title,
+				HTMLURL:     ref.HTMLURL,
+				PullRequest: ref.PullRequest != nil,
+			}
+			if ref.Repository != nil {
+				e.Source.Repository = ref.Repository.FullName
+			}
+		}
+	}
+	return e
+}
+
+// convertUser 转换Gitea用户
+func convertUser(u user) github.User {
+	return github.User{
+		Login:     u.Login,
+		ID:        u.ID,
+		AvatarURL: u.AvatarURL,
+		HTMLURL:   u.HTMLURL,
+	}
+}
+
+// convertUsers 转换Gitea用户列表
+func convertUsers(users []user) []github.User {
+	result := make([]github.User, 0, len(users))
+	for _, u := range users {
+		result = append(result, convertUser(u))
+	}
+	return result
+}
+
+// convertLabels 转换标签列表
+func convertLabels(labels []label) []github.Label {
+	result := make([]github.Label, 0, len(labels))
+	for _, l := range labels {
+		result = append(result, github.Label{Name: l.Name, Color: l.Color, Description: l.Description})
+	}
+	return result
+}
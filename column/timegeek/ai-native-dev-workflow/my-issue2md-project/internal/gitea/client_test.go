@@ -0,0 +1,173 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// repo 是测试仓库的API路径
+const repo = "/api/v1/repos/alice/app"
+
+// newTestClient 创建一个指向httptest服务器的Gitea客户端
+// routes的键是请求路径，可以带 ?page=N 区分分页，值是响应体
+func newTestClient(t *testing.T, routes map[string]string) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token test-token" {
+			t.Errorf("Authorization = %q, want token test-token", got)
+		}
+		path := r.URL.Path
+		if page := r.URL.Query().Get("page"); page != "" && page != "1" {
+			path += "?page=" + page
+		}
+		body, ok := routes[path]
+		if !ok {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		if _, ok := routes[r.URL.Path+"?page=2"]; ok && r.URL.Query().Get("page") == "1" {
+			w.Header().Set("Link", `<`+r.URL.Path+`?page=2>; rel="next", <`+r.URL.Path+`?page=2>; rel="last"`)
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewClientWithHTTPClient(server.Client(), server.URL, "test-token")
+}
+
+func TestGetIssue(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		repo + "/issues/7": `{
+			"number": 7, "title": "Crash on start", "body": "It crashes", "state": "closed",
+			"user": {"id": 1, "login": "alice", "html_url": "https://gitea.com/alice"},
+			"labels": [{"name": "bug", "color": "ee0701"}], "milestone": {"title": "v1.0", "state": "open"},
+			"created_at": "2024-01-02T03:04:05Z", "updated_at": "2024-01-03T03:04:05Z", "closed_at": "2024-01-03T03:04:05Z",
+			"html_url": "https://gitea.com/alice/app/issues/7"
+		}`,
+	})
+
+	issue, err := client.GetIssue(context.Background(), "alice", "app", 7)
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if issue.Number != 7 || issue.State != "closed" || issue.ClosedAt == nil || issue.IsPullRequest {
+		t.Errorf("GetIssue() = %+v", issue)
+	}
+	if issue.User.Login != "alice" || len(issue.Labels) != 1 || issue.Labels[0].Color != "ee0701" {
+		t.Errorf("User = %+v, Labels = %+v", issue.User, issue.Labels)
+	}
+	if issue.Milestone == nil || issue.Milestone.Title != "v1.0" {
+		t.Errorf("Milestone = %+v", issue.Milestone)
+	}
+}
+
+func TestListComments(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		repo + "/issues/7/comments":        `[{"id": 1, "body": "first", "user": {"login": "alice"}, "created_at": "2024-01-02T00:00:00Z"}]`,
+		repo + "/issues/7/comments?page=2": `[{"id": 2, "body": "second", "user": {"login": "bob"}, "created_at": "2024-01-02T01:00:00Z"}]`,
+	})
+
+	comments, err := client.ListComments(context.Background(), "alice", "app", 7, false)
+	if err != nil {
+		t.Fatalf("ListComments() error = %v", err)
+	}
+	if len(comments) != 2 || comments[0].Body != "first" || comments[1].User.Login != "bob" {
+		t.Errorf("ListComments() = %+v", comments)
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		repo + "/issues/7/timeline": `[
+			{"id": 1, "type": "comment", "body": "hi"},
+			{"id": 2, "type": "label", "body": "1", "label": {"name": "bug"}},
+			{"id": 3, "type": "label", "body": "", "label": {"name": "bug"}},
+			{"id": 4, "type": "milestone", "milestone": {"title": "v1.0"}},
+			{"id": 5, "type": "milestone", "old_milestone": {"title": "v1.0"}},
+			{"id": 6, "type": "assignees", "assignee": {"login": "bob"}},
+			{"id": 7, "type": "assignees", "assignee": {"login": "bob"}, "removed_assignee": true},
+			{"id": 8, "type": "change_title", "old_title": "a", "new_title": "b"},
+			{"id": 9, "type": "pull_ref", "ref_issue": {"number": 9, "title": "Fix", "pull_request": {}, "repository": {"full_name": "alice/app"}}},
+			{"id": 10, "type": "commit_ref", "ref_commit_sha": "abc123"},
+			{"id": 11, "type": "close"},
+			{"id": 12, "type": "reopen"},
+			{"id": 13, "type": "review"}
+		]`,
+	})
+
+	events, err := client.ListEvents(context.Background(), "alice", "app", 7, false)
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	want := []string{"labeled", "unlabeled", "milestoned", "demilestoned", "assigned", "unassigned",
+		"renamed", "cross-referenced", "referenced", "closed", "reopened"}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Event)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("ListEvents() = %v, want %v", got, want)
+	}
+	if e := events[5]; e.Assignee == nil || e.Assignee.Login != "bob" {
+		t.Errorf("unassigned event = %+v", e)
+	}
+	if e := events[6]; e.Rename == nil || e.Rename.From != "a" || e.Rename.To != "b" {
+		t.Errorf("renamed event = %+v", e)
+	}
+	if e := events[7]; e.Source == nil || e.Source.Number != 9 || !e.Source.PullRequest || e.Source.Repository != "alice/app" {
+		t.Errorf("cross-referenced event = %+v", e.Source)
+	}
+	if e := events[8]; e.CommitID != "abc123" {
+		t.Errorf("referenced event = %+v", e)
+	}
+}
+
+func TestGetPullRequest(t *testing.T) {
+	pull := repo + "/pulls/9"
+	client := newTestClient(t, map[string]string{
+		pull: `{
+			"number": 9, "title": "Fix", "body": "Fixes #7", "state": "closed", "merged": true,
+			"merged_at": "2024-01-03T00:00:00Z", "merged_by": {"login": "bob"}, "user": {"login": "alice"},
+			"head": {"label": "fix", "ref": "fix"}, "base": {"ref": "main"},
+			"additions": 2, "deletions": 1, "changed_files": 1,
+			"created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-03T00:00:00Z"
+		}`,
+		pull + "/commits": `[{"sha": "abc123", "commit": {"message": "fix", "author": {"name": "Alice", "date": "2024-01-01T01:00:00Z"}}}]`,
+		pull + "/reviews": `[
+			{"id": 1, "user": {"login": "bob"}, "state": "REQUEST_CHANGES", "comments_count": 1, "submitted_at": "2024-01-01T02:00:00Z"},
+			{"id": 2, "user": {"login": "carol"}, "state": "COMMENT", "comments_count": 1, "submitted_at": "2024-01-01T03:00:00Z"},
+			{"id": 3, "user": {"login": "bob"}, "state": "APPROVED", "submitted_at": "2024-01-02T00:00:00Z"}
+		]`,
+		pull + "/reviews/1/comments": `[{"id": 11, "pull_request_review_id": 1, "body": "nil check?", "path": "main.go", "position": 12, "user": {"login": "bob"}, "created_at": "2024-01-01T02:00:00Z"}]`,
+		pull + "/reviews/2/comments": `[{"id": 12, "pull_request_review_id": 2, "body": "+1", "path": "main.go", "position": 12, "user": {"login": "carol"}, "created_at": "2024-01-01T03:00:00Z"}]`,
+		pull + ".diff":               "diff --git a/main.go b/main.go\n",
+	})
+
+	pr, err := client.GetPullRequest(context.Background(), "alice", "app", 9, &github.PullRequestOptions{IncludeDiff: true})
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+	if !pr.Merged || pr.MergedBy == nil || pr.MergedBy.Login != "bob" || pr.HeadRef != "fix" || pr.BaseRef != "main" {
+		t.Errorf("GetPullRequest() = %+v", pr)
+	}
+	if len(pr.Commits) != 1 || pr.Commits[0].AuthorName != "Alice" {
+		t.Errorf("Commits = %+v", pr.Commits)
+	}
+	var states []string
+	for _, r := range pr.Reviews {
+		states = append(states, r.State)
+	}
+	if got := strings.Join(states, ","); got != "CHANGES_REQUESTED,COMMENTED,APPROVED" {
+		t.Errorf("review states = %s", got)
+	}
+	if len(pr.ReviewComments) != 2 || pr.ReviewComments[1].InReplyTo != 11 || pr.ReviewComments[0].Side != "RIGHT" {
+		t.Errorf("ReviewComments = %+v", pr.ReviewComments)
+	}
+	if pr.Diff != "diff --git a/main.go b/main.go\n" {
+		t.Errorf("Diff = %q", pr.Diff)
+	}
+}
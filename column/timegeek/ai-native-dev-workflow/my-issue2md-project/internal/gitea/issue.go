@@ -0,0 +1,67 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// issue 是Gitea Issue，PR也会以Issue的形式出现，此时pull_request不为空
+type issue struct {
+	Number      int        `json:"number"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	State       string     `json:"state"` // open, closed
+	User        user       `json:"user"`
+	Labels      []label    `json:"labels"`
+	Assignees   []user     `json:"assignees"`
+	Milestone   *milestone `json:"milestone"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	HTMLURL     string     `json:"html_url"`
+	PullRequest *struct {
+		Merged bool `json:"merged"`
+	} `json:"pull_request"`
+	Repository *struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// GetIssue 获取Issue
+func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (*github.Issue, error) {
+	var gt issue
+	if _, err := c.rest.GetJSON(ctx, fmt.Sprintf("%s/issues/%d", repoPath(owner, repo), number), nil, &gt); err != nil {
+		return nil, fmt.Errorf("failed to get issue %d from %s/%s: %w", number, owner, repo, err)
+	}
+
+	result := &github.Issue{
+		Number:        gt.Number,
+		Title:         gt.Title,
+		Body:          gt.Body,
+		State:         gt.State,
+		User:          convertUser(gt.User),
+		Labels:        convertLabels(gt.Labels),
+		Assignees:     convertUsers(gt.Assignees),
+		CreatedAt:     gt.CreatedAt,
+		UpdatedAt:     gt.UpdatedAt,
+		ClosedAt:      gt.ClosedAt,
+		HTMLURL:       gt.HTMLURL,
+		IsPullRequest: gt.PullRequest != nil,
+	}
+	if gt.Milestone != nil {
+		result.Milestone = &github.Milestone{Title: gt.Milestone.Title, State: gt.Milestone.State}
+	}
+	return result, nil
+}
+
+// GetUpdatedAt 获取Issue或PR的最后更新时间，Gitea的Issue接口同样返回PR
+func (c *Client) GetUpdatedAt(ctx context.Context, owner, repo string, number int, pull bool) (time.Time, error) {
+	issue, err := c.GetIssue(ctx, owner, repo, number)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return issue.UpdatedAt, nil
+}
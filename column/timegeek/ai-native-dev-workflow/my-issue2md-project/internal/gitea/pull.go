@@ -0,0 +1,250 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// pullRequest 是Gitea PR
+type pullRequest struct {
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	Body         string     `json:"body"`
+	State        string     `json:"state"` // open, closed
+	Draft        bool       `json:"draft"`
+	Merged       bool       `json:"merged"`
+	MergedAt     *time.Time `json:"merged_at"`
+	MergedBy     *user      `json:"merged_by"`
+	User         user       `json:"user"`
+	Labels       []label    `json:"labels"`
+	Assignees    []user     `json:"assignees"`
+	Head         branch     `json:"head"`
+	Base         branch     `json:"base"`
+	Additions    int        `json:"additions"`
+	Deletions    int        `json:"deletions"`
+	ChangedFiles int        `json:"changed_files"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	HTMLURL      string     `json:"html_url"`
+}
+
+// branch 是PR的源分支或目标分支
+type branch struct {
+	Label string `json:"label"`
+	Ref   string `json:"ref"`
+}
+
+// commit 是PR中的提交
+type commit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string    `json:"name"`
+			Date time.Time `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+	Author *user `json:"author"` // 提交邮箱未关联Gitea账号时为空
+}
+
+// review 是一次PR评审
+type review struct {
+	ID            int64     `json:"id"`
+	User          *user     `json:"user"`
+	State         string    `json:"state"` // APPROVED, REQUEST_CHANGES, COMMENT, PENDING, REQUEST_REVIEW
+	Body          string    `json:"body"`
+	CommitID      string    `json:"commit_id"`
+	Stale         bool      `json:"stale"`
+	CommentsCount int       `json:"comments_count"`
+	SubmittedAt   time.Time `json:"submitted_at"`
+	HTMLURL       string    `json:"html_url"`
+}
+
+// reviewComment 是评审中的代码行评论
+type reviewComment struct {
+	ID               int64     `json:"id"`
+	ReviewID         int64     `json:"pull_request_review_id"`
+	Body             string    `json:"body"`
+	User             *user     `json:"user"`
+	Path             string    `json:"path"`
+	Position         int       `json:"position"`          // 新文件中的行号，评论在删除侧时为0
+	OriginalPosition int       `json:"original_position"` // 旧文件中的行号
+	DiffHunk         string    `json:"diff_hunk"`
+	CreatedAt        time.Time `json:"created_at"`
+	HTMLURL          string    `json:"html_url"`
+}
+
+// reviewStates 把Gitea的评审状态转换为GitHub的状态
+var reviewStates = map[string]string{
+	"REQUEST_CHANGES": "CHANGES_REQUESTED",
+	"COMMENT":         "COMMENTED",
+}
+
+// GetPullRequest 获取PR，以及提交列表、评审记录和代码行评审评论
+//
+// Gitea的代码行评论没有回复关系，同一文件同一行上的评论按时间顺序视为一个讨论串
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number int, opts *github.PullRequestOptions) (*github.PullRequest, error) {
+	if opts == nil {
+		opts = &github.PullRequestOptions{}
+	}
+	path := fmt.Sprintf("%s/pulls/%d", repoPath(owner, repo), number)
+
+	var gt pullRequest
+	if _, err := c.rest.GetJSON(ctx, path, nil, &gt); err != nil {
+		return nil, fmt.Errorf("failed to get pull request %d from %s/%s: %w", number, owner, repo, err)
+	}
+	pr := convertPullRequest(&gt)
+
+	commits, err := listAll[commit](ctx, c, path+"/commits")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits for pull request %d from %s/%s: %w", number, owner, repo, err)
+	}
+	for _, gc := range commits {
+		pc := &github.Commit{
+			SHA:         gc.SHA,
+			Message:     gc.Commit.Message,
+			AuthorName:  gc.Commit.Author.Name,
+			CommittedAt: gc.Commit.Author.Date,
+			HTMLURL:     gc.HTMLURL,
+		}
+		if gc.Author != nil {
+			author := convertUser(*gc.Author)
+			pc.Author = &author
+		}
+		pr.Commits = append(pr.Commits, pc)
+	}
+
+	reviews, err := listAll[review](ctx, c, path+"/reviews")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews for pull request %d from %s/%s: %w", number, owner, repo, err)
+	}
+	var comments []*github.ReviewComment
+	for _, r := range reviews {
+		pr.Reviews = append(pr.Reviews, convertReview(r))
+		if r.CommentsCount == 0 {
+			continue
+		}
+		var reviewComments []reviewComment
+		if _, err := c.rest.GetJSON(ctx, fmt.Sprintf("%s/reviews/%d/comments", path, r.ID), nil, &reviewComments); err != nil {
+			return nil, fmt.Errorf("failed to get review comments for pull request %d from %s/%s: %w", number, owner, repo, err)
+		}
+		for _, rc := range reviewComments {
+			comments = append(comments, convertReviewComment(rc, r.Stale))
+		}
+	}
+	pr.ReviewComments = threadReviewComments(comments)
+
+	if opts.IncludeDiff {
+		pr.Diff, err = c.rest.GetRaw(ctx, path+".diff", nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get diff for pull request %d from %s/%s: %w", number, owner, repo, err)
+		}
+	}
+
+	return pr, nil
+}
+
+// convertPullRequest 转换PR的基本信息
+func convertPullRequest(gt *pullRequest) *github.PullRequest {
+	head := gt.Head.Label
+	if head == "" {
+		head = gt.Head.Ref
+	}
+	pr := &github.PullRequest{
+		Number:       gt.Number,
+		Title:        gt.Title,
+		Body:         gt.Body,
+		State:        gt.State,
+		Draft:        gt.Draft,
+		Merged:       gt.Merged,
+		MergedAt:     gt.MergedAt,
+		User:         convertUser(gt.User),
+		Labels:       convertLabels(gt.Labels),
+		Assignees:    convertUsers(gt.Assignees),
+		HeadRef:      head,
+		BaseRef:      gt.Base.Ref,
+		Additions:    gt.Additions,
+		Deletions:    gt.Deletions,
+		ChangedFiles: gt.ChangedFiles,
+		CreatedAt:    gt.CreatedAt,
+		UpdatedAt:    gt.UpdatedAt,
+		ClosedAt:     gt.ClosedAt,
+		HTMLURL:      gt.HTMLURL,
+	}
+	if gt.MergedBy != nil {
+		mergedBy := convertUser(*gt.MergedBy)
+		pr.MergedBy = &mergedBy
+	}
+	return pr
+}
+
+// convertReview 转换评审记录
+func convertReview(r review) *github.Review {
+	state := r.State
+	if s, ok := reviewStates[state]; ok {
+		state = s
+	}
+	result := &github.Review{
+		ID:          r.ID,
+		State:       state,
+		Body:        r.Body,
+		SubmittedAt: r.SubmittedAt,
+		CommitID:    r.CommitID,
+		HTMLURL:     r.HTMLURL,
+	}
+	if r.User != nil {
+		result.User = convertUser(*r.User)
+	}
+	return result
+}
+
+// convertReviewComment 转换代码行评论，stale表示所在评审针对的是旧提交
+func convertReviewComment(rc reviewComment, stale bool) *github.ReviewComment {
+	result := &github.ReviewComment{
+		ID:        rc.ID,
+		ReviewID:  rc.ReviewID,
+		Body:      rc.Body,
+		Path:      rc.Path,
+		Line:      rc.Position,
+		Side:      "RIGHT",
+		Outdated:  stale,
+		DiffHunk:  rc.DiffHunk,
+		CreatedAt: rc.CreatedAt,
+		HTMLURL:   rc.HTMLURL,
+	}
+	if rc.Position == 0 {
+		result.Line, result.Side = rc.OriginalPosition, "LEFT"
+	}
+	if rc.User != nil {
+		result.User = convertUser(*rc.User)
+	}
+	return result
+}
+
+// threadReviewComments 按时间排序，并把同一文件同一行上的后续评论设为第一条评论的回复
+func threadReviewComments(comments []*github.ReviewComment) []*github.ReviewComment {
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	type position struct {
+		path string
+		line int
+		side string
+	}
+	roots := make(map[position]int64)
+	for _, c := range comments {
+		key := position{c.Path, c.Line, c.Side}
+		if root, ok := roots[key]; ok {
+			c.InReplyTo = root
+		} else {
+			roots[key] = c.ID
+		}
+	}
+	return comments
+}
@@ -10,8 +10,14 @@ import (
 
 // GetIssue 获取Issue信息
 func (c *GitHubClient) GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*Issue, error) {
-	// 调用 GitHub API 获取 Issue
-	gitHubIssue, _, err := c.Client.Issues.Get(ctx, owner, repo, issueNumber)
+	// 调用 GitHub API 获取 Issue，遇到限流时等待重置后重试
+	var gitHubIssue *github.Issue
+	_, err := c.do(ctx, func() (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		gitHubIssue, resp, err = c.Client.Issues.Get(ctx, owner, repo, issueNumber)
+		return resp, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get issue %d from %s/%s: %w", issueNumber, owner, repo, err)
 	}
@@ -23,8 +29,12 @@ func (c *GitHubClient) GetIssue(ctx context.Context, owner, repo string, issueNu
 
 // GetIssueComments 获取Issue评论
 func (c *GitHubClient) GetIssueComments(ctx context.Context, owner, repo string, issueNumber int) ([]*Comment, error) {
-	// 调用 GitHub API 获取 Issue 评论列表
-	gitHubComments, _, err := c.Client.Issues.ListComments(ctx, owner, repo, issueNumber, nil)
+	// 调用 GitHub API 获取 Issue 评论列表，逐页获取直到最后一页
+	gitHubComments, err := listAll(ctx, c, func(opts *github.ListOptions) ([]*github.IssueComment, *github.Response, error) {
+		return c.Client.Issues.ListComments(ctx, owner, repo, issueNumber, &github.IssueListCommentsOptions{
+			ListOptions: *opts,
+		})
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get comments for issue %d from %s/%s: %w", issueNumber, owner, repo, err)
 	}
@@ -43,6 +53,25 @@ func (c *GitHubClient) GetIssueComments(ctx context.Context, owner, repo string,
 	return comments, nil
 }
 
+// GetIssueTimeline 获取Issue时间线事件(不含评论)
+func (c *GitHubClient) GetIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]*TimelineEvent, error) {
+	gitHubEvents, err := listAll(ctx, c, func(opts *github.ListOptions) ([]*github.Timeline, *github.Response, error) {
+		return c.Client.Issues.ListIssueTimeline(ctx, owner, repo, issueNumber, opts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timeline for issue %d from %s/%s: %w", issueNumber, owner, repo, err)
+	}
+
+	var events []*TimelineEvent
+	for _, gitHubEvent := range gitHubEvents {
+		if event := convertGitHubTimeline(gitHubEvent); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
 // convertGitHubIssue 将GitHub API的Issue转换为内部Issue结构
 func convertGitHubIssue(gitHubIssue *github.Issue) *Issue {
 	if gitHubIssue == nil {
@@ -131,6 +160,9 @@ func convertGitHubIssue(gitHubIssue *github.Issue) *Issue {
 		ClosedAt:  closedAt,
 		URL:       gitHubIssue.GetURL(),
 		HTMLURL:   gitHubIssue.GetHTMLURL(),
+		Reactions: convertGitHubReactions(gitHubIssue.Reactions),
+
+		IsPullRequest: gitHubIssue.IsPullRequest(),
 	}
 }
 
@@ -159,5 +191,90 @@ func convertGitHubComment(gitHubComment *github.IssueComment) *Comment {
 		UpdatedAt: gitHubComment.GetUpdatedAt().Time,
 		URL:       gitHubComment.GetURL(),
 		HTMLURL:   gitHubComment.GetHTMLURL(),
+		Reactions: convertGitHubReactions(gitHubComment.Reactions),
+	}
+}
+
+// convertGitHubUser 将GitHub API的User转换为内部User结构
+func convertGitHubUser(gitHubUser *github.User) User {
+	if gitHubUser == nil {
+		return User{}
+	}
+	return User{
+		Login:     gitHubUser.GetLogin(),
+		ID:        gitHubUser.GetID(),
+		AvatarURL: gitHubUser.GetAvatarURL(),
+		HTMLURL:   gitHubUser.GetHTMLURL(),
+		Type:      gitHubUser.GetType(),
+	}
+}
+
+// convertGitHubReactions 将GitHub API的Reactions转换为内部Reactions结构
+func convertGitHubReactions(gitHubReactions *github.Reactions) Reactions {
+	if gitHubReactions == nil {
+		return Reactions{}
+	}
+	return Reactions{
+		TotalCount: gitHubReactions.GetTotalCount(),
+		ThumbsUp:   gitHubReactions.GetPlusOne(),
+		ThumbsDown: gitHubReactions.GetMinusOne(),
+		Laugh:      gitHubReactions.GetLaugh(),
+		Hooray:     gitHubReactions.GetHooray(),
+		Confused:   gitHubReactions.GetConfused(),
+		Heart:      gitHubReactions.GetHeart(),
+		Rocket:     gitHubReactions.GetRocket(),
+		Eyes:       gitHubReactions.GetEyes(),
 	}
+}
+
+// convertGitHubTimeline 将GitHub API的Timeline转换为内部TimelineEvent结构
+// 评论(commented)已经通过评论接口获取，这里返回nil以避免重复
+func convertGitHubTimeline(gitHubEvent *github.Timeline) *TimelineEvent {
+	if gitHubEvent == nil || gitHubEvent.GetEvent() == "" || gitHubEvent.GetEvent() == "commented" {
+		return nil
+	}
+
+	event := &TimelineEvent{
+		ID:        gitHubEvent.GetID(),
+		Event:     gitHubEvent.GetEvent(),
+		Actor:     convertGitHubUser(gitHubEvent.Actor),
+		CreatedAt: gitHubEvent.GetCreatedAt().Time,
+		CommitID:  gitHubEvent.GetCommitID(),
+	}
+
+	if gitHubEvent.Label != nil {
+		event.Label = &Label{
+			Name:        gitHubEvent.Label.GetName(),
+			Color:       gitHubEvent.Label.GetColor(),
+			Description: gitHubEvent.Label.GetDescription(),
+		}
+	}
+	if gitHubEvent.Assignee != nil {
+		assignee := convertGitHubUser(gitHubEvent.Assignee)
+		event.Assignee = &assignee
+	}
+	if gitHubEvent.Milestone != nil {
+		event.Milestone = gitHubEvent.Milestone.GetTitle()
+	}
+	if gitHubEvent.Rename != nil {
+		event.Rename = &Rename{
+			From: gitHubEvent.Rename.GetFrom(),
+			To:   gitHubEvent.Rename.GetTo(),
+		}
+	}
+	if source := gitHubEvent.Source; source != nil && source.Issue != nil {
+		event.Source = &CrossReference{
+			Number:      source.Issue.GetNumber(),
+			Title:       source.Issue.GetTitle(),
+			HTMLURL:     source.Issue.GetHTMLURL(),
+			Repository:  source.Issue.GetRepository().GetFullName(),
+			PullRequest: source.Issue.IsPullRequest(),
+		}
+		// cross-referenced 事件的操作者有时只出现在source中
+		if event.Actor.Login == "" {
+			event.Actor = convertGitHubUser(source.Actor)
+		}
+	}
+
+	return event
 }
\ No newline at end of file
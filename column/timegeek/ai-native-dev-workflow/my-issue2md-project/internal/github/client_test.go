@@ -491,4 +491,124 @@ func TestConvertGitHubComment(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+// mockTimelineJSON 模拟GitHub Issue Timeline API响应
+const mockTimelineJSON = `[
+	{
+		"id": 1,
+		"event": "labeled",
+		"actor": {"login": "maintainer"},
+		"created_at": "2023-01-10T10:30:00Z",
+		"label": {"name": "bug", "color": "d73a4a"}
+	},
+	{
+		"id": 2,
+		"event": "commented",
+		"actor": {"login": "commenter1"},
+		"created_at": "2023-01-10T11:00:00Z",
+		"body": "First comment on this issue."
+	},
+	{
+		"event": "cross-referenced",
+		"actor": {"login": "contributor"},
+		"created_at": "2023-01-12T09:00:00Z",
+		"source": {
+			"type": "issue",
+			"issue": {
+				"number": 456,
+				"title": "Fix the bug",
+				"html_url": "https://github.com/testowner/testrepo/pull/456",
+				"pull_request": {"url": "https://api.github.com/repos/testowner/testrepo/pulls/456"},
+				"repository": {"full_name": "testowner/testrepo"}
+			}
+		}
+	},
+	{
+		"id": 3,
+		"event": "renamed",
+		"actor": {"login": "testuser"},
+		"created_at": "2023-01-13T09:00:00Z",
+		"rename": {"from": "Old title", "to": "Test Issue Title"}
+	},
+	{
+		"id": 4,
+		"event": "closed",
+		"actor": {"login": "maintainer"},
+		"created_at": "2023-01-20T15:30:00Z",
+		"commit_id": "0123456789abcdef"
+	}
+]`
+
+func TestGetIssueTimeline(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/testowner/testrepo/issues/123/timeline", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(mockTimelineJSON))
+	})
+	client := newTestClient(t, mux)
+
+	got, err := client.GetIssueTimeline(context.Background(), "testowner", "testrepo", 123)
+	if err != nil {
+		t.Fatalf("GetIssueTimeline() unexpected error = %v", err)
+	}
+
+	// commented 事件应被过滤
+	wantEvents := []string{"labeled", "cross-referenced", "renamed", "closed"}
+	if len(got) != len(wantEvents) {
+		t.Fatalf("GetIssueTimeline() length = %v, want %v", len(got), len(wantEvents))
+	}
+	for i, want := range wantEvents {
+		if got[i].Event != want {
+			t.Errorf("GetIssueTimeline()[%d].Event = %v, want %v", i, got[i].Event, want)
+		}
+	}
+
+	if got[0].Label == nil || got[0].Label.Name != "bug" {
+		t.Errorf("labeled event Label = %v, want bug", got[0].Label)
+	}
+	if src := got[1].Source; src == nil || src.Number != 456 || !src.PullRequest || src.Repository != "testowner/testrepo" {
+		t.Errorf("cross-referenced event Source = %+v, want PR testowner/testrepo#456", src)
+	}
+	if got[1].Actor.Login != "contributor" {
+		t.Errorf("cross-referenced event Actor = %v, want contributor", got[1].Actor.Login)
+	}
+	if got[2].Rename == nil || got[2].Rename.From != "Old title" {
+		t.Errorf("renamed event Rename = %v, want from 'Old title'", got[2].Rename)
+	}
+	if got[3].CommitID != "0123456789abcdef" {
+		t.Errorf("closed event CommitID = %v, want 0123456789abcdef", got[3].CommitID)
+	}
+}
+
+func TestConvertGitHubReactions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *github.Reactions
+		expected Reactions
+	}{
+		{
+			name:     "Nil Input",
+			input:    nil,
+			expected: Reactions{},
+		},
+		{
+			name: "Counts",
+			input: &github.Reactions{
+				TotalCount: github.Int(6),
+				PlusOne:    github.Int(3),
+				MinusOne:   github.Int(1),
+				Heart:      github.Int(2),
+			},
+			expected: Reactions{TotalCount: 6, ThumbsUp: 3, ThumbsDown: 1, Heart: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertGitHubReactions(tt.input); got != tt.expected {
+				t.Errorf("convertGitHubReactions() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
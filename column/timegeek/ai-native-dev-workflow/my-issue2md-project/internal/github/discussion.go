@@ -0,0 +1,262 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// discussionPageSize 是每次查询获取的评论和回复条数，GraphQL API允许的最大值为100
+// 嵌套查询的开销是两者的乘积，评论与回复各取50可以控制在单次查询的节点上限内
+const discussionPageSize = 50
+
+// discussionQuery 获取Discussion及一页评论，每条评论附带第一页回复
+const discussionQuery = `query($owner: String!, $repo: String!, $number: Int!, $first: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    discussion(number: $number) {
+      number
+      title
+      body
+      url
+      createdAt
+      updatedAt
+      closed
+      closedAt
+      isAnswered
+      answerChosenAt
+      answerChosenBy { __typename login url avatarUrl }
+      upvoteCount
+      author { __typename login url avatarUrl }
+      category { name emoji isAnswerable }
+      labels(first: 20) { nodes { name color description } }
+      comments(first: $first, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          body
+          url
+          createdAt
+          isAnswer
+          upvoteCount
+          author { __typename login url avatarUrl }
+          replies(first: $first) {
+            pageInfo { hasNextPage endCursor }
+            nodes { id body url createdAt upvoteCount author { __typename login url avatarUrl } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// discussionRepliesQuery 获取某条评论的后续回复
+const discussionRepliesQuery = `query($id: ID!, $first: Int!, $cursor: String) {
+  node(id: $id) {
+    ... on DiscussionComment {
+      replies(first: $first, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes { id body url createdAt upvoteCount author { __typename login url avatarUrl } }
+      }
+    }
+  }
+}`
+
+// gqlActor 对应GraphQL的Actor接口，账号已删除时为null
+type gqlActor struct {
+	Typename  string `json:"__typename"`
+	Login     string `json:"login"`
+	URL       string `json:"url"`
+	AvatarURL string `json:"avatarUrl"`
+}
+
+type gqlPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type gqlReply struct {
+	ID          string    `json:"id"`
+	Body        string    `json:"body"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpvoteCount int       `json:"upvoteCount"`
+	Author      *gqlActor `json:"author"`
+}
+
+type gqlReplyConnection struct {
+	PageInfo gqlPageInfo `json:"pageInfo"`
+	Nodes    []gqlReply  `json:"nodes"`
+}
+
+type gqlComment struct {
+	gqlReply
+	IsAnswer bool               `json:"isAnswer"`
+	Replies  gqlReplyConnection `json:"replies"`
+}
+
+type gqlDiscussion struct {
+	Number         int        `json:"number"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	URL            string     `json:"url"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	Closed         bool       `json:"closed"`
+	ClosedAt       *time.Time `json:"closedAt"`
+	IsAnswered     bool       `json:"isAnswered"`
+	AnswerChosenAt *time.Time `json:"answerChosenAt"`
+	AnswerChosenBy *gqlActor  `json:"answerChosenBy"`
+	UpvoteCount    int        `json:"upvoteCount"`
+	Author         *gqlActor  `json:"author"`
+	Category       struct {
+		Name         string `json:"name"`
+		Emoji        string `json:"emoji"`
+		IsAnswerable bool   `json:"isAnswerable"`
+	} `json:"category"`
+	Labels struct {
+		Nodes []Label `json:"nodes"`
+	} `json:"labels"`
+	Comments struct {
+		PageInfo gqlPageInfo  `json:"pageInfo"`
+		Nodes    []gqlComment `json:"nodes"`
+	} `json:"comments"`
+}
+
+// GetDiscussion 获取Discussion及其全部评论和回复
+func (c *GitHubClient) GetDiscussion(ctx context.Context, owner, repo string, number int) (*Discussion, error) {
+	gql := NewGraphQLClient(c.Client.Client(), graphQLEndpoint(c.Client.BaseURL))
+
+	var discussion *Discussion
+	var cursor *string
+	for {
+		var data struct {
+			Repository *struct {
+				Discussion *gqlDiscussion `json:"discussion"`
+			} `json:"repository"`
+		}
+		err := gql.Query(ctx, discussionQuery, map[string]any{
+			"owner":  owner,
+			"repo":   repo,
+			"number": number,
+			"first":  discussionPageSize,
+			"cursor": cursor,
+		}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get discussion %d from %s/%s: %w", number, owner, repo, err)
+		}
+		if data.Repository == nil || data.Repository.Discussion == nil {
+			return nil, fmt.Errorf("failed to get discussion %d from %s/%s: not found", number, owner, repo)
+		}
+
+		page := data.Repository.Discussion
+		if discussion == nil {
+			discussion = convertGQLDiscussion(page)
+		}
+		for _, node := range page.Comments.Nodes {
+			comment := convertGQLReply(node.gqlReply)
+			comment.IsAnswer = node.IsAnswer
+			for _, reply := range node.Replies.Nodes {
+				comment.Replies = append(comment.Replies, convertGQLReply(reply))
+			}
+			if node.Replies.PageInfo.HasNextPage {
+				more, err := c.listDiscussionReplies(ctx, gql, node.ID, node.Replies.PageInfo.EndCursor)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get replies for discussion %d from %s/%s: %w", number, owner, repo, err)
+				}
+				comment.Replies = append(comment.Replies, more...)
+			}
+			discussion.Comments = append(discussion.Comments, comment)
+		}
+
+		if !page.Comments.PageInfo.HasNextPage {
+			return discussion, nil
+		}
+		endCursor := page.Comments.PageInfo.EndCursor
+		cursor = &endCursor
+	}
+}
+
+// listDiscussionReplies 从cursor之后获取某条评论的全部回复
+func (c *GitHubClient) listDiscussionReplies(ctx context.Context, gql *GraphQLClient, commentID, cursor string) ([]*DiscussionComment, error) {
+	var replies []*DiscussionComment
+	for {
+		var data struct {
+			Node *struct {
+				Replies gqlReplyConnection `json:"replies"`
+			} `json:"node"`
+		}
+		err := gql.Query(ctx, discussionRepliesQuery, map[string]any{
+			"id":     commentID,
+			"first":  discussionPageSize,
+			"cursor": cursor,
+		}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list replies of comment %s: %w", commentID, err)
+		}
+		if data.Node == nil {
+			return nil, fmt.Errorf("failed to list replies of comment %s: not found", commentID)
+		}
+
+		for _, reply := range data.Node.Replies.Nodes {
+			replies = append(replies, convertGQLReply(reply))
+		}
+		if !data.Node.Replies.PageInfo.HasNextPage {
+			return replies, nil
+		}
+		cursor = data.Node.Replies.PageInfo.EndCursor
+	}
+}
+
+// convertGQLDiscussion 将GraphQL的Discussion转换为内部Discussion结构(不含评论)
+func convertGQLDiscussion(d *gqlDiscussion) *Discussion {
+	discussion := &Discussion{
+		Number: d.Number,
+		Title:  d.Title,
+		Body:   d.Body,
+		User:   convertGQLActor(d.Author),
+		Category: DiscussionCategory{
+			Name:         d.Category.Name,
+			Emoji:        d.Category.Emoji,
+			IsAnswerable: d.Category.IsAnswerable,
+		},
+		Labels:         d.Labels.Nodes,
+		Closed:         d.Closed,
+		IsAnswered:     d.IsAnswered,
+		AnswerChosenAt: d.AnswerChosenAt,
+		UpvoteCount:    d.UpvoteCount,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+		ClosedAt:       d.ClosedAt,
+		HTMLURL:        d.URL,
+	}
+	if d.AnswerChosenBy != nil {
+		chosenBy := convertGQLActor(d.AnswerChosenBy)
+		discussion.AnswerChosenBy = &chosenBy
+	}
+	return discussion
+}
+
+// convertGQLReply 将GraphQL的评论或回复转换为内部DiscussionComment结构
+func convertGQLReply(r gqlReply) *DiscussionComment {
+	return &DiscussionComment{
+		ID:          r.ID,
+		Body:        r.Body,
+		User:        convertGQLActor(r.Author),
+		UpvoteCount: r.UpvoteCount,
+		CreatedAt:   r.CreatedAt,
+		HTMLURL:     r.URL,
+	}
+}
+
+// convertGQLActor 将GraphQL的Actor转换为内部User结构，已删除的账号返回空User
+func convertGQLActor(a *gqlActor) User {
+	if a == nil {
+		return User{}
+	}
+	return User{
+		Login:     a.Login,
+		AvatarURL: a.AvatarURL,
+		HTMLURL:   a.URL,
+		Type:      a.Typename,
+	}
+}
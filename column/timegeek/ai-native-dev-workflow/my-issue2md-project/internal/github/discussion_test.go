@@ -0,0 +1,136 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// graphQLFixtureServer 根据请求的查询和变量返回testdata/graphql下录制的响应
+func graphQLFixtureServer(t *testing.T) (*GitHubClient, *[]map[string]any) {
+	t.Helper()
+	var requests []map[string]any
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("graphql method = %s, want POST", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer test-token")
+		}
+
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode graphql request: %v", err)
+		}
+		requests = append(requests, req.Variables)
+
+		var fixture string
+		switch {
+		case strings.Contains(req.Query, "node(id:"):
+			fixture = "discussion_replies.json"
+		case req.Variables["number"] != float64(543):
+			fixture = "discussion_not_found.json"
+		case req.Variables["cursor"] == nil:
+			fixture = "discussion_page1.json"
+		default:
+			fixture = "discussion_page2.json"
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", "graphql", fixture))
+		if err != nil {
+			t.Fatalf("failed to read fixture: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+
+	return newTestClient(t, mux), &requests
+}
+
+func TestGetDiscussion(t *testing.T) {
+	client, requests := graphQLFixtureServer(t)
+
+	got, err := client.GetDiscussion(context.Background(), "testowner", "testrepo", 543)
+	if err != nil {
+		t.Fatalf("GetDiscussion() unexpected error = %v", err)
+	}
+
+	// 两页评论 + 一次补充回复
+	if len(*requests) != 3 {
+		t.Errorf("graphql requests = %d, want 3", len(*requests))
+	}
+	if cursor := (*requests)[2]["cursor"]; cursor != "Y3Vyc29yOnYyOpK0MjAyMy0wMy0wMlQxMDowMDowMFo=" {
+		t.Errorf("second page cursor = %v, want endCursor of the first page", cursor)
+	}
+	if id := (*requests)[1]["id"]; id != "DC_kwDOAAAAAc4AAAAB" {
+		t.Errorf("replies query id = %v, want DC_kwDOAAAAAc4AAAAB", id)
+	}
+
+	if got.Title != "How do I configure the cache?" || got.Category.Name != "Q&A" || !got.Category.IsAnswerable {
+		t.Errorf("GetDiscussion() = %+v, want Q&A discussion", got)
+	}
+	if got.User.Login != "asker" || len(got.Labels) != 1 || got.Labels[0].Name != "question" {
+		t.Errorf("GetDiscussion() author/labels = %v/%v", got.User.Login, got.Labels)
+	}
+	if !got.IsAnswered || got.AnswerChosenBy == nil || got.AnswerChosenBy.Login != "asker" {
+		t.Errorf("GetDiscussion() answer state = %v/%v", got.IsAnswered, got.AnswerChosenBy)
+	}
+
+	if len(got.Comments) != 3 {
+		t.Fatalf("GetDiscussion().Comments length = %v, want 3", len(got.Comments))
+	}
+	if replies := got.Comments[0].Replies; len(replies) != 2 || replies[1].Body != "Then it's probably undocumented." {
+		t.Errorf("first comment replies = %+v, want 2 replies including the paginated one", replies)
+	}
+	answer := got.Answer()
+	if answer == nil || answer.User.Login != "maintainer" {
+		t.Errorf("Answer() = %+v, want the maintainer's comment", answer)
+	}
+	if got.Comments[2].User.Login != "" {
+		t.Errorf("deleted author = %q, want empty", got.Comments[2].User.Login)
+	}
+}
+
+func TestGetDiscussionNotFound(t *testing.T) {
+	client, _ := graphQLFixtureServer(t)
+
+	_, err := client.GetDiscussion(context.Background(), "testowner", "testrepo", 999)
+	if err == nil {
+		t.Fatal("GetDiscussion() expected error, but got nil")
+	}
+	var gqlErrs GraphQLErrors
+	if !errors.As(err, &gqlErrs) || !gqlErrs.HasType("NOT_FOUND") {
+		t.Errorf("GetDiscussion() error = %v, want NOT_FOUND GraphQLErrors", err)
+	}
+}
+
+func TestGraphQLEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		want    string
+	}{
+		{name: "GitHub.com", baseURL: "https://api.github.com/", want: "https://api.github.com/graphql"},
+		{name: "GitHub Enterprise", baseURL: "https://ghe.example.com/api/v3/", want: "https://ghe.example.com/api/graphql"},
+		{name: "Test Server", baseURL: "http://127.0.0.1:8080/", want: "http://127.0.0.1:8080/graphql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.baseURL)
+			if got := graphQLEndpoint(u); got != tt.want {
+				t.Errorf("graphQLEndpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultGraphQLEndpoint 是github.com的GraphQL API地址
+const defaultGraphQLEndpoint = "https://api.github.com/graphql"
+
+// GraphQLClient 是一个最小化的GitHub GraphQL API客户端
+// Discussions只能通过GraphQL API获取，REST接口不提供
+type GraphQLClient struct {
+	httpClient *http.Client
+	endpoint   string
+}
+
+// NewGraphQLClient 创建GraphQL客户端
+// httpClient需要自行附带认证，GraphQL API不支持匿名访问
+func NewGraphQLClient(httpClient *http.Client, endpoint string) *GraphQLClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if endpoint == "" {
+		endpoint = defaultGraphQLEndpoint
+	}
+	return &GraphQLClient{
+		httpClient: httpClient,
+		endpoint:   endpoint,
+	}
+}
+
+// GraphQLError 是GraphQL响应中errors数组的一项
+type GraphQLError struct {
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"` // 例如 NOT_FOUND、RATE_LIMITED
+	Path    []any  `json:"path,omitempty"`
+}
+
+// GraphQLErrors 表示GraphQL响应中返回的错误
+// GraphQL即使出错也会返回200，错误信息在响应体的errors字段里
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// HasType 判断是否包含指定类型的错误
+func (e GraphQLErrors) HasType(typ string) bool {
+	for _, err := range e {
+		if err.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Query 执行一次GraphQL查询，把响应中的data解码到data
+func (g *GraphQLClient) Query(ctx context.Context, query string, variables map[string]any, data any) error {
+	payload, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode graphql request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create graphql request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("graphql request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("graphql request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to decode graphql response: %w", err)
+	}
+	if len(envelope.Errors) > 0 {
+		return envelope.Errors
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		return fmt.Errorf("failed to decode graphql data: %w", err)
+	}
+	return nil
+}
+
+// graphQLEndpoint 由REST API的BaseURL推导GraphQL地址
+// github.com: https://api.github.com/ -> https://api.github.com/graphql
+// GitHub Enterprise: https://host/api/v3/ -> https://host/api/graphql
+func graphQLEndpoint(baseURL *url.URL) string {
+	if baseURL == nil {
+		return defaultGraphQLEndpoint
+	}
+	u := *baseURL
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+		return u.String()
+	}
+	return u.ResolveReference(&url.URL{Path: "graphql"}).String()
+}
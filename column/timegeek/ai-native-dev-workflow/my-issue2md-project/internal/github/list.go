@@ -0,0 +1,118 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v56/github"
+)
+
+// ListIssuesOptions 是列出仓库Issue和PR的过滤条件
+type ListIssuesOptions struct {
+	State  string    // open, closed, all，默认open
+	Labels []string  // 同时带有这些标签
+	Since  time.Time // 只返回此时间之后更新过的
+	Author string    // 创建者
+
+	// ETag 是上次列表首页响应的ETag
+	// 首页没有变化时GitHub返回304，不计入限流配额，此时IssueList.NotModified为true
+	ETag string
+
+	// StopBefore 非零时，列表在包含更新时间早于它的一页之后停止翻页
+	// 列表按更新时间倒序，之后的页面只会更旧，增量同步时不必再获取
+	StopBefore time.Time
+}
+
+// IssueList 是ListIssues的结果，按更新时间倒序
+type IssueList struct {
+	Issues      []*Issue
+	ETag        string // 首页响应的ETag，供下次条件请求使用
+	NotModified bool   // 首页未变化，Issues为空
+}
+
+// ListIssues 列出仓库中符合条件的Issue和PR，PR的IsPullRequest为true
+func (c *GitHubClient) ListIssues(ctx context.Context, owner, repo string, opts *ListIssuesOptions) (*IssueList, error) {
+	if opts == nil {
+		opts = &ListIssuesOptions{}
+	}
+
+	list := &IssueList{}
+	for page := 1; ; {
+		var gitHubIssues []*github.Issue
+		resp, err := c.do(ctx, func() (*github.Response, error) {
+			req, err := c.Client.NewRequest(http.MethodGet, listIssuesURL(owner, repo, opts, page), nil)
+			if err != nil {
+				return nil, err
+			}
+			if page == 1 && opts.ETag != "" {
+				req.Header.Set("If-None-Match", opts.ETag)
+			}
+			gitHubIssues = nil
+			return c.Client.Do(ctx, req, &gitHubIssues)
+		})
+		if page == 1 && resp != nil && resp.StatusCode == http.StatusNotModified {
+			list.ETag = opts.ETag
+			list.NotModified = true
+			return list, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list issues of %s/%s (page %d): %w", owner, repo, page, err)
+		}
+		if page == 1 {
+			list.ETag = resp.Header.Get("ETag")
+		}
+
+		reachedOld := false
+		for _, gitHubIssue := range gitHubIssues {
+			if gitHubIssue == nil {
+				continue
+			}
+			issue := convertGitHubIssue(gitHubIssue)
+			list.Issues = append(list.Issues, issue)
+			if !opts.StopBefore.IsZero() && issue.UpdatedAt.Before(opts.StopBefore) {
+				reachedOld = true
+			}
+		}
+
+		if resp.NextPage == 0 || reachedOld {
+			return list, nil
+		}
+		page = resp.NextPage
+
+		if resp.Rate.Limit > 0 && resp.Rate.Remaining == 0 {
+			if err := c.wait(ctx, untilReset(resp.Rate)); err != nil {
+				return nil, fmt.Errorf("waiting for rate limit reset: %w", err)
+			}
+		}
+	}
+}
+
+// listIssuesURL 构造Issue列表的请求路径，按更新时间倒序
+func listIssuesURL(owner, repo string, opts *ListIssuesOptions, page int) string {
+	q := url.Values{}
+	q.Set("state", "open")
+	if opts.State != "" {
+		q.Set("state", opts.State)
+	}
+	if len(opts.Labels) > 0 {
+		q.Set("labels", strings.Join(opts.Labels, ","))
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if opts.Author != "" {
+		q.Set("creator", opts.Author)
+	}
+	q.Set("sort", "updated")
+	q.Set("direction", "desc")
+	q.Set("per_page", strconv.Itoa(perPage))
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	return fmt.Sprintf("repos/%s/%s/issues?%s", url.PathEscape(owner), url.PathEscape(repo), q.Encode())
+}
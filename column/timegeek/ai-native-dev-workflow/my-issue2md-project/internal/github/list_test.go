@@ -0,0 +1,147 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// issuePage 生成一页Issue列表JSON，updated依次为对应的更新时间，编号为偶数的是PR
+func issuePage(first int, updated ...string) string {
+	items := make([]string, 0, len(updated))
+	for i, u := range updated {
+		number := first + i
+		pr := ""
+		if number%2 == 0 {
+			pr = fmt.Sprintf(`, "pull_request": {"url": "https://api.github.com/repos/o/r/pulls/%d"}`, number)
+		}
+		items = append(items, fmt.Sprintf(`{"number": %d, "title": "item %d", "state": "open", "user": {"login": "alice"}, "updated_at": %q%s}`, number, number, u, pr))
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+func TestListIssues(t *testing.T) {
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/issues", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		q := r.URL.Query()
+		want := map[string]string{
+			"state": "all", "labels": "bug,help wanted", "since": "2024-01-01T00:00:00Z",
+			"creator": "alice", "sort": "updated", "direction": "desc", "per_page": "100",
+		}
+		for k, v := range want {
+			if got := q.Get(k); got != v {
+				t.Errorf("query %s = %q, want %q", k, got, v)
+			}
+		}
+
+		switch q.Get("page") {
+		case "":
+			if r.Header.Get("If-None-Match") != `"old"` {
+				t.Errorf("If-None-Match = %q, want %q", r.Header.Get("If-None-Match"), `"old"`)
+			}
+			w.Header().Set("ETag", `"page1"`)
+			w.Header().Set("Link", `<https://api.github.com/repositories/1/issues?page=2>; rel="next"`)
+			fmt.Fprint(w, issuePage(1, "2024-03-05T00:00:00Z", "2024-03-04T00:00:00Z"))
+		case "2":
+			if r.Header.Get("If-None-Match") != "" {
+				t.Error("If-None-Match should only be sent for the first page")
+			}
+			fmt.Fprint(w, issuePage(3, "2024-03-03T00:00:00Z"))
+		default:
+			t.Errorf("unexpected page %q", q.Get("page"))
+		}
+	})
+	client := newTestClient(t, mux)
+
+	list, err := client.ListIssues(context.Background(), "o", "r", &ListIssuesOptions{
+		State:  "all",
+		Labels: []string{"bug", "help wanted"},
+		Since:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Author: "alice",
+		ETag:   `"old"`,
+	})
+	if err != nil {
+		t.Fatalf("ListIssues() error = %v", err)
+	}
+	if list.NotModified || list.ETag != `"page1"` {
+		t.Errorf("NotModified = %v, ETag = %q", list.NotModified, list.ETag)
+	}
+	if len(list.Issues) != 3 || requests != 2 {
+		t.Fatalf("got %d issues in %d requests, want 3 in 2", len(list.Issues), requests)
+	}
+	if list.Issues[0].IsPullRequest || !list.Issues[1].IsPullRequest {
+		t.Errorf("IsPullRequest = %v, %v, want false, true", list.Issues[0].IsPullRequest, list.Issues[1].IsPullRequest)
+	}
+}
+
+func TestListIssuesNotModified(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+		fmt.Fprint(w, issuePage(1, "2024-03-05T00:00:00Z"))
+	})
+	client := newTestClient(t, mux)
+
+	tests := []struct {
+		name            string
+		etag            string
+		wantNotModified bool
+		wantETag        string
+		wantCount       int
+	}{
+		{name: "ETag未变化", etag: `"v1"`, wantNotModified: true, wantETag: `"v1"`, wantCount: 0},
+		{name: "ETag已变化", etag: `"v0"`, wantNotModified: false, wantETag: `"v2"`, wantCount: 1},
+		{name: "首次请求", etag: "", wantNotModified: false, wantETag: `"v2"`, wantCount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := client.ListIssues(context.Background(), "o", "r", &ListIssuesOptions{ETag: tt.etag})
+			if err != nil {
+				t.Fatalf("ListIssues() error = %v", err)
+			}
+			if list.NotModified != tt.wantNotModified || list.ETag != tt.wantETag || len(list.Issues) != tt.wantCount {
+				t.Errorf("ListIssues() = {NotModified: %v, ETag: %q, %d issues}, want {%v, %q, %d}",
+					list.NotModified, list.ETag, len(list.Issues), tt.wantNotModified, tt.wantETag, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestListIssuesStopBefore(t *testing.T) {
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/issues", func(w http.ResponseWriter, r *http.Request) {
+		page := atomic.AddInt32(&requests, 1)
+		w.Header().Set("Link", fmt.Sprintf(`<https://api.github.com/repositories/1/issues?page=%d>; rel="next"`, page+1))
+		switch page {
+		case 1:
+			fmt.Fprint(w, issuePage(1, "2024-03-05T00:00:00Z", "2024-03-04T00:00:00Z"))
+		case 2:
+			fmt.Fprint(w, issuePage(3, "2024-03-03T00:00:00Z", "2024-02-01T00:00:00Z"))
+		default:
+			t.Errorf("page %d should not be requested", page)
+			fmt.Fprint(w, "[]")
+		}
+	})
+	client := newTestClient(t, mux)
+
+	list, err := client.ListIssues(context.Background(), "o", "r", &ListIssuesOptions{
+		StopBefore: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("ListIssues() error = %v", err)
+	}
+	if len(list.Issues) != 4 || requests != 2 {
+		t.Errorf("got %d issues in %d requests, want 4 in 2", len(list.Issues), requests)
+	}
+}
@@ -0,0 +1,243 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v56/github"
+)
+
+// GetPullRequest 获取PR信息，以及提交列表、评审记录和代码行评审评论
+func (c *GitHubClient) GetPullRequest(ctx context.Context, owner, repo string, number int, opts *PullRequestOptions) (*PullRequest, error) {
+	if opts == nil {
+		opts = &PullRequestOptions{}
+	}
+
+	var gitHubPR *github.PullRequest
+	_, err := c.do(ctx, func() (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		gitHubPR, resp, err = c.Client.PullRequests.Get(ctx, owner, repo, number)
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request %d from %s/%s: %w", number, owner, repo, err)
+	}
+	pr := convertGitHubPullRequest(gitHubPR)
+
+	gitHubCommits, err := listAll(ctx, c, func(listOpts *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+		return c.Client.PullRequests.ListCommits(ctx, owner, repo, number, listOpts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits for pull request %d from %s/%s: %w", number, owner, repo, err)
+	}
+	for _, gitHubCommit := range gitHubCommits {
+		if gitHubCommit != nil {
+			pr.Commits = append(pr.Commits, convertGitHubCommit(gitHubCommit))
+		}
+	}
+
+	gitHubReviews, err := listAll(ctx, c, func(listOpts *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+		return c.Client.PullRequests.ListReviews(ctx, owner, repo, number, listOpts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews for pull request %d from %s/%s: %w", number, owner, repo, err)
+	}
+	for _, gitHubReview := range gitHubReviews {
+		if gitHubReview != nil {
+			pr.Reviews = append(pr.Reviews, convertGitHubReview(gitHubReview))
+		}
+	}
+
+	gitHubReviewComments, err := listAll(ctx, c, func(listOpts *github.ListOptions) ([]*github.PullRequestComment, *github.Response, error) {
+		return c.Client.PullRequests.ListComments(ctx, owner, repo, number, &github.PullRequestListCommentsOptions{
+			Sort:        "created",
+			Direction:   "asc",
+			ListOptions: *listOpts,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review comments for pull request %d from %s/%s: %w", number, owner, repo, err)
+	}
+	for _, gitHubReviewComment := range gitHubReviewComments {
+		if gitHubReviewComment != nil {
+			pr.ReviewComments = append(pr.ReviewComments, convertGitHubReviewComment(gitHubReviewComment))
+		}
+	}
+
+	if opts.IncludeDiff {
+		_, err := c.do(ctx, func() (*github.Response, error) {
+			var resp *github.Response
+			var err error
+			pr.Diff, resp, err = c.Client.PullRequests.GetRaw(ctx, owner, repo, number, github.RawOptions{Type: github.Diff})
+			return resp, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get diff for pull request %d from %s/%s: %w", number, owner, repo, err)
+		}
+	}
+
+	return pr, nil
+}
+
+// GroupReviewThreads 把评审评论按回复关系分组为讨论串，并按文件和行号排序
+// 回复的根评论不在列表中时(例如已被删除)，回复自成一个讨论串
+func GroupReviewThreads(comments []*ReviewComment) []*ReviewThread {
+	byRoot := make(map[int64]*ReviewThread)
+	var threads []*ReviewThread
+	for _, c := range comments {
+		if c == nil {
+			continue
+		}
+		if thread, ok := byRoot[c.InReplyTo]; ok && c.InReplyTo != 0 {
+			thread.Comments = append(thread.Comments, c)
+			continue
+		}
+		thread := &ReviewThread{
+			Path:     c.Path,
+			Line:     c.Line,
+			Outdated: c.Outdated,
+			DiffHunk: c.DiffHunk,
+			Comments: []*ReviewComment{c},
+		}
+		byRoot[c.ID] = thread
+		threads = append(threads, thread)
+	}
+
+	for _, thread := range threads {
+		sort.SliceStable(thread.Comments, func(i, j int) bool {
+			return thread.Comments[i].CreatedAt.Before(thread.Comments[j].CreatedAt)
+		})
+	}
+	sort.SliceStable(threads, func(i, j int) bool {
+		if threads[i].Path != threads[j].Path {
+			return threads[i].Path < threads[j].Path
+		}
+		return threads[i].Line < threads[j].Line
+	})
+	return threads
+}
+
+// convertGitHubPullRequest 将GitHub API的PullRequest转换为内部PullRequest结构
+func convertGitHubPullRequest(gitHubPR *github.PullRequest) *PullRequest {
+	if gitHubPR == nil {
+		return nil
+	}
+
+	var labels []Label
+	for _, label := range gitHubPR.Labels {
+		if label != nil {
+			labels = append(labels, Label{
+				Name:        label.GetName(),
+				Color:       label.GetColor(),
+				Description: label.GetDescription(),
+			})
+		}
+	}
+
+	var assignees []User
+	for _, assignee := range gitHubPR.Assignees {
+		if assignee != nil {
+			assignees = append(assignees, convertGitHubUser(assignee))
+		}
+	}
+
+	var mergedBy *User
+	if gitHubPR.MergedBy != nil {
+		user := convertGitHubUser(gitHubPR.MergedBy)
+		mergedBy = &user
+	}
+
+	return &PullRequest{
+		Number:       gitHubPR.GetNumber(),
+		Title:        gitHubPR.GetTitle(),
+		Body:         gitHubPR.GetBody(),
+		State:        gitHubPR.GetState(),
+		Draft:        gitHubPR.GetDraft(),
+		Merged:       gitHubPR.GetMerged() || gitHubPR.MergedAt != nil,
+		MergedAt:     timestampPtr(gitHubPR.MergedAt),
+		MergedBy:     mergedBy,
+		User:         convertGitHubUser(gitHubPR.User),
+		Labels:       labels,
+		Assignees:    assignees,
+		HeadRef:      gitHubPR.GetHead().GetLabel(),
+		BaseRef:      gitHubPR.GetBase().GetRef(),
+		Additions:    gitHubPR.GetAdditions(),
+		Deletions:    gitHubPR.GetDeletions(),
+		ChangedFiles: gitHubPR.GetChangedFiles(),
+		CreatedAt:    gitHubPR.GetCreatedAt().Time,
+		UpdatedAt:    gitHubPR.GetUpdatedAt().Time,
+		ClosedAt:     timestampPtr(gitHubPR.ClosedAt),
+		URL:          gitHubPR.GetURL(),
+		HTMLURL:      gitHubPR.GetHTMLURL(),
+	}
+}
+
+// convertGitHubCommit 将GitHub API的RepositoryCommit转换为内部Commit结构
+func convertGitHubCommit(gitHubCommit *github.RepositoryCommit) *Commit {
+	commit := &Commit{
+		SHA:         gitHubCommit.GetSHA(),
+		Message:     gitHubCommit.GetCommit().GetMessage(),
+		AuthorName:  gitHubCommit.GetCommit().GetAuthor().GetName(),
+		CommittedAt: gitHubCommit.GetCommit().GetAuthor().GetDate().Time,
+		HTMLURL:     gitHubCommit.GetHTMLURL(),
+	}
+	if gitHubCommit.Author != nil {
+		author := convertGitHubUser(gitHubCommit.Author)
+		commit.Author = &author
+	}
+	return commit
+}
+
+// convertGitHubReview 将GitHub API的PullRequestReview转换为内部Review结构
+func convertGitHubReview(gitHubReview *github.PullRequestReview) *Review {
+	return &Review{
+		ID:          gitHubReview.GetID(),
+		User:        convertGitHubUser(gitHubReview.User),
+		State:       gitHubReview.GetState(),
+		Body:        gitHubReview.GetBody(),
+		SubmittedAt: gitHubReview.GetSubmittedAt().Time,
+		CommitID:    gitHubReview.GetCommitID(),
+		HTMLURL:     gitHubReview.GetHTMLURL(),
+	}
+}
+
+// convertGitHubReviewComment 将GitHub API的PullRequestComment转换为内部ReviewComment结构
+// 代码变动后评论过期时line为空，此时使用original_line并标记为outdated
+func convertGitHubReviewComment(gitHubComment *github.PullRequestComment) *ReviewComment {
+	line := gitHubComment.GetLine()
+	startLine := gitHubComment.GetStartLine()
+	outdated := gitHubComment.Line == nil
+	if outdated {
+		line = gitHubComment.GetOriginalLine()
+		startLine = gitHubComment.GetOriginalStartLine()
+	}
+
+	return &ReviewComment{
+		ID:        gitHubComment.GetID(),
+		ReviewID:  gitHubComment.GetPullRequestReviewID(),
+		InReplyTo: gitHubComment.GetInReplyTo(),
+		User:      convertGitHubUser(gitHubComment.User),
+		Body:      gitHubComment.GetBody(),
+		Path:      gitHubComment.GetPath(),
+		Line:      line,
+		StartLine: startLine,
+		Side:      gitHubComment.GetSide(),
+		Outdated:  outdated,
+		DiffHunk:  gitHubComment.GetDiffHunk(),
+		CreatedAt: gitHubComment.GetCreatedAt().Time,
+		HTMLURL:   gitHubComment.GetHTMLURL(),
+		Reactions: convertGitHubReactions(gitHubComment.Reactions),
+	}
+}
+
+// timestampPtr 把可能为空的Timestamp转换为*time.Time
+func timestampPtr(ts *github.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
@@ -0,0 +1,190 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockPullRequestJSON 模拟GitHub Pull Request API响应
+const mockPullRequestJSON = `{
+	"number": 42,
+	"title": "Add feature",
+	"body": "This PR adds a feature.",
+	"state": "closed",
+	"merged": true,
+	"merged_at": "2023-02-03T12:00:00Z",
+	"merged_by": {"login": "maintainer"},
+	"user": {"login": "author", "html_url": "https://github.com/author"},
+	"head": {"label": "author:feature", "ref": "feature"},
+	"base": {"label": "testowner:main", "ref": "main"},
+	"additions": 10,
+	"deletions": 2,
+	"changed_files": 3,
+	"created_at": "2023-02-01T10:00:00Z",
+	"updated_at": "2023-02-03T12:00:00Z",
+	"closed_at": "2023-02-03T12:00:00Z",
+	"html_url": "https://github.com/testowner/testrepo/pull/42"
+}`
+
+const mockPullCommitsJSON = `[
+	{
+		"sha": "1111111aaaaaaa",
+		"commit": {"message": "Add feature\n\nLonger description", "author": {"name": "Author Name", "date": "2023-02-01T09:00:00Z"}},
+		"author": {"login": "author"}
+	},
+	{
+		"sha": "2222222bbbbbbb",
+		"commit": {"message": "Fix review comments", "author": {"name": "Unlinked Author", "date": "2023-02-02T09:00:00Z"}}
+	}
+]`
+
+const mockPullReviewsJSON = `[
+	{"id": 501, "user": {"login": "reviewer"}, "state": "CHANGES_REQUESTED", "body": "Please fix.", "submitted_at": "2023-02-01T12:00:00Z"},
+	{"id": 502, "user": {"login": "reviewer"}, "state": "APPROVED", "body": "", "submitted_at": "2023-02-02T12:00:00Z"}
+]`
+
+const mockPullCommentsJSON = `[
+	{
+		"id": 901, "pull_request_review_id": 501, "user": {"login": "reviewer"},
+		"body": "Handle the error here.", "path": "main.go", "line": 12, "side": "RIGHT",
+		"diff_hunk": "@@ -10,3 +10,4 @@ func main() {\n \tx := f()\n+\tg(x)",
+		"created_at": "2023-02-01T12:00:00Z"
+	},
+	{
+		"id": 902, "pull_request_review_id": 503, "in_reply_to_id": 901, "user": {"login": "author"},
+		"body": "Done.", "path": "main.go", "line": 12, "side": "RIGHT",
+		"diff_hunk": "@@ -10,3 +10,4 @@ func main() {\n \tx := f()\n+\tg(x)",
+		"created_at": "2023-02-02T08:00:00Z"
+	},
+	{
+		"id": 903, "pull_request_review_id": 501, "user": {"login": "reviewer"},
+		"body": "Outdated remark.", "path": "a.go", "original_line": 3, "side": "RIGHT",
+		"diff_hunk": "@@ -1,2 +1,3 @@\n+package a",
+		"created_at": "2023-02-01T12:00:00Z"
+	}
+]`
+
+const mockPullDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -10,3 +10,4 @@ func main() {
+ 	x := f()
++	g(x)
+`
+
+func TestGetPullRequest(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/testowner/testrepo/pulls/42", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "diff") {
+			w.Write([]byte(mockPullDiff))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(mockPullRequestJSON))
+	})
+	mux.HandleFunc("/repos/testowner/testrepo/pulls/42/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(mockPullCommitsJSON))
+	})
+	mux.HandleFunc("/repos/testowner/testrepo/pulls/42/reviews", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(mockPullReviewsJSON))
+	})
+	mux.HandleFunc("/repos/testowner/testrepo/pulls/42/comments", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(mockPullCommentsJSON))
+	})
+
+	tests := []struct {
+		name     string
+		opts     *PullRequestOptions
+		wantDiff bool
+	}{
+		{name: "Without Diff", opts: nil, wantDiff: false},
+		{name: "With Diff", opts: &PullRequestOptions{IncludeDiff: true}, wantDiff: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, mux)
+
+			got, err := client.GetPullRequest(context.Background(), "testowner", "testrepo", 42, tt.opts)
+			if err != nil {
+				t.Fatalf("GetPullRequest() unexpected error = %v", err)
+			}
+
+			if got.Number != 42 || !got.Merged || got.MergedBy == nil || got.MergedBy.Login != "maintainer" {
+				t.Errorf("GetPullRequest() = %+v, want merged PR #42 by maintainer", got)
+			}
+			if got.HeadRef != "author:feature" || got.BaseRef != "main" {
+				t.Errorf("GetPullRequest() refs = %v -> %v, want author:feature -> main", got.HeadRef, got.BaseRef)
+			}
+			if len(got.Commits) != 2 || got.Commits[0].Author == nil || got.Commits[1].Author != nil {
+				t.Errorf("GetPullRequest().Commits = %+v, want 2 commits, only the first linked to a user", got.Commits)
+			}
+			if len(got.Reviews) != 2 || got.Reviews[0].State != "CHANGES_REQUESTED" {
+				t.Errorf("GetPullRequest().Reviews = %+v, want 2 reviews", got.Reviews)
+			}
+			if len(got.ReviewComments) != 3 {
+				t.Fatalf("GetPullRequest().ReviewComments length = %v, want 3", len(got.ReviewComments))
+			}
+			if c := got.ReviewComments[2]; !c.Outdated || c.Line != 3 {
+				t.Errorf("outdated review comment = %+v, want Outdated with original line 3", c)
+			}
+			if (got.Diff != "") != tt.wantDiff {
+				t.Errorf("GetPullRequest().Diff = %q, wantDiff %v", got.Diff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestGroupReviewThreads(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2023, 2, 1, h, 0, 0, 0, time.UTC) }
+	comments := []*ReviewComment{
+		{ID: 1, Path: "b.go", Line: 20, CreatedAt: at(1)},
+		{ID: 2, Path: "a.go", Line: 5, CreatedAt: at(2)},
+		{ID: 3, InReplyTo: 1, Path: "b.go", Line: 20, CreatedAt: at(4)},
+		{ID: 4, InReplyTo: 1, Path: "b.go", Line: 20, CreatedAt: at(3)},
+		{ID: 5, Path: "b.go", Line: 7, CreatedAt: at(5)},
+		{ID: 6, InReplyTo: 99, Path: "c.go", Line: 1, CreatedAt: at(6)}, // 根评论已删除
+	}
+
+	threads := GroupReviewThreads(comments)
+
+	wantOrder := []struct {
+		path string
+		line int
+		ids  []int64
+	}{
+		{"a.go", 5, []int64{2}},
+		{"b.go", 7, []int64{5}},
+		{"b.go", 20, []int64{1, 4, 3}},
+		{"c.go", 1, []int64{6}},
+	}
+	if len(threads) != len(wantOrder) {
+		t.Fatalf("GroupReviewThreads() length = %v, want %v", len(threads), len(wantOrder))
+	}
+	for i, want := range wantOrder {
+		got := threads[i]
+		if got.Path != want.path || got.Line != want.line {
+			t.Errorf("thread[%d] = %s:%d, want %s:%d", i, got.Path, got.Line, want.path, want.line)
+		}
+		var ids []int64
+		for _, c := range got.Comments {
+			ids = append(ids, c.ID)
+		}
+		if len(ids) != len(want.ids) {
+			t.Errorf("thread[%d] comment IDs = %v, want %v", i, ids, want.ids)
+			continue
+		}
+		for j := range ids {
+			if ids[j] != want.ids[j] {
+				t.Errorf("thread[%d] comment IDs = %v, want %v", i, ids, want.ids)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v56/github"
+)
+
+const (
+	// perPage 是分页请求的每页条数，GitHub REST API允许的最大值为100
+	perPage = 100
+
+	// maxRateLimitRetries 是单个请求因限流而重试的最大次数
+	maxRateLimitRetries = 3
+
+	// defaultSecondaryWait 是次级限流未给出Retry-After时的等待时间
+	defaultSecondaryWait = time.Minute
+)
+
+// sleepContext 等待d，ctx被取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// untilReset 返回距离限流重置还需等待的时间，已经重置时返回0
+func untilReset(rate github.Rate) time.Duration {
+	return max(time.Until(rate.Reset.Time), 0)
+}
+
+// wait 等待d，优先使用注入的sleep函数
+func (c *GitHubClient) wait(ctx context.Context, d time.Duration) error {
+	if c.sleep != nil {
+		return c.sleep(ctx, d)
+	}
+	return sleepContext(ctx, d)
+}
+
+// do 执行一次API调用，遇到限流错误时等待到限流重置后重试
+//
+// go-github会把X-RateLimit-Remaining和X-RateLimit-Reset解析到Response.Rate，
+// 配额耗尽(403/429)时返回RateLimitError，次级限流时返回AbuseRateLimitError
+func (c *GitHubClient) do(ctx context.Context, call func() (*github.Response, error)) (*github.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := call()
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= maxRateLimitRetries {
+			return resp, err
+		}
+
+		var rateErr *github.RateLimitError
+		var abuseErr *github.AbuseRateLimitError
+		switch {
+		case errors.As(err, &rateErr):
+			if err := c.wait(ctx, untilReset(rateErr.Rate)); err != nil {
+				return resp, fmt.Errorf("waiting for rate limit reset: %w", err)
+			}
+		case errors.As(err, &abuseErr):
+			d := abuseErr.GetRetryAfter()
+			if d <= 0 {
+				d = defaultSecondaryWait
+			}
+			if err := c.wait(ctx, d); err != nil {
+				return resp, fmt.Errorf("waiting for secondary rate limit: %w", err)
+			}
+		default:
+			return resp, err
+		}
+	}
+}
+
+// listAll 获取列表接口的所有分页
+// 每页响应后检查剩余配额，配额耗尽且还有下一页时，先等到限流重置再继续
+func listAll[T any](ctx context.Context, c *GitHubClient, list func(opts *github.ListOptions) ([]T, *github.Response, error)) ([]T, error) {
+	opts := &github.ListOptions{PerPage: perPage}
+	var all []T
+	for {
+		var page []T
+		resp, err := c.do(ctx, func() (*github.Response, error) {
+			var resp *github.Response
+			var err error
+			page, resp, err = list(opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list page %d: %w", max(opts.Page, 1), err)
+		}
+		all = append(all, page...)
+
+		if resp == nil || resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+
+		if resp.Rate.Limit > 0 && resp.Rate.Remaining == 0 {
+			if err := c.wait(ctx, untilReset(resp.Rate)); err != nil {
+				return nil, fmt.Errorf("waiting for rate limit reset: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient 创建一个指向httptest服务器的GitHub客户端
+func newTestClient(t *testing.T, handler http.Handler) *GitHubClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	githubClient := NewClientWithHTTPClient(server.Client(), "test-token")
+	githubClient.Client.BaseURL, _ = url.Parse(server.URL + "/")
+	return githubClient
+}
+
+// commentPage 生成第page页的评论JSON，每页n条，ID从(page-1)*n+1开始
+func commentPage(page, n int) string {
+	body := "["
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			body += ","
+		}
+		id := (page-1)*n + i + 1
+		body += fmt.Sprintf(`{"id": %d, "body": "comment %d", "user": {"login": "user%d"}, "created_at": "2023-01-10T11:00:00Z"}`, id, id, id)
+	}
+	return body + "]"
+}
+
+func TestGetIssueCommentsPagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		pages     int
+		perPage   int
+		wantCount int
+	}{
+		{name: "Single Page", pages: 1, perPage: 2, wantCount: 2},
+		{name: "Three Pages", pages: 3, perPage: 100, wantCount: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				if got := r.URL.Query().Get("per_page"); got != "100" {
+					t.Errorf("per_page = %q, want %q", got, "100")
+				}
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				if page == 0 {
+					page = 1
+				}
+				if page < tt.pages {
+					next := fmt.Sprintf("http://%s%s?page=%d&per_page=100", r.Host, r.URL.Path, page+1)
+					w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(commentPage(page, tt.perPage)))
+			})
+			client := newTestClient(t, mux)
+
+			got, err := client.GetIssueComments(context.Background(), "o", "r", 1)
+			if err != nil {
+				t.Fatalf("GetIssueComments() unexpected error = %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("GetIssueComments() length = %v, want %v", len(got), tt.wantCount)
+			}
+			if requests != int32(tt.pages) {
+				t.Errorf("server received %d requests, want %d", requests, tt.pages)
+			}
+			if len(got) > 0 && got[len(got)-1].ID != int64(tt.wantCount) {
+				t.Errorf("last comment ID = %v, want %v", got[len(got)-1].ID, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRateLimitHandling(t *testing.T) {
+	tests := []struct {
+		name string
+		// respond 按请求序号(从1开始)写出响应
+		respond   func(w http.ResponseWriter, r *http.Request, n int)
+		wantCount int
+		wantWaits int
+		minWait   time.Duration
+	}{
+		{
+			// 第一页耗尽配额，客户端应等到X-RateLimit-Reset后再取第二页
+			name: "Remaining Exhausted Between Pages",
+			respond: func(w http.ResponseWriter, r *http.Request, n int) {
+				w.Header().Set("X-RateLimit-Limit", "60")
+				if n == 1 {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+					next := fmt.Sprintf("http://%s%s?page=2&per_page=100", r.Host, r.URL.Path)
+					w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+				} else {
+					w.Header().Set("X-RateLimit-Remaining", "59")
+					w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(commentPage(n, 1)))
+			},
+			wantCount: 2,
+			wantWaits: 1,
+		},
+		{
+			// 配额耗尽时GitHub返回403，客户端应等待后重试同一页
+			name: "Primary Rate Limit Error",
+			respond: func(w http.ResponseWriter, r *http.Request, n int) {
+				w.Header().Set("X-RateLimit-Limit", "60")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+				w.Header().Set("Content-Type", "application/json")
+				if n == 1 {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(`{"message": "API rate limit exceeded for 127.0.0.1."}`))
+					return
+				}
+				w.Header().Set("X-RateLimit-Remaining", "59")
+				w.Write([]byte(commentPage(1, 1)))
+			},
+			wantCount: 1,
+			wantWaits: 1,
+		},
+		{
+			// 次级限流按Retry-After等待
+			name: "Secondary Rate Limit Error",
+			respond: func(w http.ResponseWriter, r *http.Request, n int) {
+				w.Header().Set("Content-Type", "application/json")
+				if n == 1 {
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(`{"message": "You have exceeded a secondary rate limit.", "documentation_url": "https://docs.github.com/rest/overview/resources-in-the-rest-api#secondary-rate-limits"}`))
+					return
+				}
+				w.Write([]byte(commentPage(1, 1)))
+			},
+			wantCount: 1,
+			wantWaits: 1,
+			minWait:   time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+				tt.respond(w, r, int(atomic.AddInt32(&requests, 1)))
+			})
+			client := newTestClient(t, mux)
+
+			var waits []time.Duration
+			client.sleep = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				// 必须真正等待：go-github会在本地拒绝限流重置前的请求
+				return sleepContext(ctx, d)
+			}
+
+			got, err := client.GetIssueComments(context.Background(), "o", "r", 1)
+			if err != nil {
+				t.Fatalf("GetIssueComments() unexpected error = %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("GetIssueComments() length = %v, want %v", len(got), tt.wantCount)
+			}
+			if len(waits) != tt.wantWaits {
+				t.Fatalf("waited %d times (%v), want %d", len(waits), waits, tt.wantWaits)
+			}
+			if waits[0] < tt.minWait {
+				t.Errorf("wait = %v, want at least %v", waits[0], tt.minWait)
+			}
+		})
+	}
+}
+
+func TestRateLimitWaitHonoursContext(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+	})
+	client := newTestClient(t, mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetIssue(ctx, "o", "r", 1)
+	if err == nil {
+		t.Fatal("GetIssue() expected error, but got nil")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetIssue() took %v, want it to stop when the context is done", elapsed)
+	}
+}
@@ -0,0 +1,73 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v56/github"
+
+	"github.com/bigwhite/my-issue2md/internal/rest"
+)
+
+// IssueSource 是Issue和PR的数据源，GitHub、GitLab、Gitea各有一个实现
+// 其他平台的数据转换为本包的类型，由同一个解析器和转换器渲染
+type IssueSource interface {
+	// GetIssue 获取Issue
+	GetIssue(ctx context.Context, owner, repo string, number int) (*Issue, error)
+	// GetPullRequest 获取PR(GitLab的Merge Request)，包括提交、评审和代码行评论
+	GetPullRequest(ctx context.Context, owner, repo string, number int, opts *PullRequestOptions) (*PullRequest, error)
+	// ListComments 获取Issue或PR的普通评论，按创建时间排序
+	// 有的平台Issue和PR的编号互相独立，pull指明编号属于PR
+	ListComments(ctx context.Context, owner, repo string, number int, pull bool) ([]*Comment, error)
+	// ListEvents 获取Issue或PR的时间线事件，按创建时间排序
+	ListEvents(ctx context.Context, owner, repo string, number int, pull bool) ([]*TimelineEvent, error)
+}
+
+// DiscussionSource 是支持Discussion的数据源，目前只有GitHub
+type DiscussionSource interface {
+	GetDiscussion(ctx context.Context, owner, repo string, number int) (*Discussion, error)
+}
+
+// UpdateSource 可以单独查询Issue或PR最后更新时间的数据源，用于判断缓存是否过期
+type UpdateSource interface {
+	GetUpdatedAt(ctx context.Context, owner, repo string, number int, pull bool) (time.Time, error)
+}
+
+// GetUpdatedAt 获取Issue或PR的最后更新时间，GitHub的Issue接口同样返回PR
+func (c *GitHubClient) GetUpdatedAt(ctx context.Context, owner, repo string, number int, pull bool) (time.Time, error) {
+	issue, err := c.GetIssue(ctx, owner, repo, number)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return issue.UpdatedAt, nil
+}
+
+// StatusCode 返回数据源错误对应的HTTP状态码，不是HTTP错误时返回0
+// 可以识别GitHub REST、GraphQL(NOT_FOUND视为404)以及GitLab、Gitea的错误
+func StatusCode(err error) int {
+	var githubErr *github.ErrorResponse
+	if errors.As(err, &githubErr) && githubErr.Response != nil {
+		return githubErr.Response.StatusCode
+	}
+	var gqlErrs GraphQLErrors
+	if errors.As(err, &gqlErrs) && gqlErrs.HasType("NOT_FOUND") {
+		return http.StatusNotFound
+	}
+	var apiErr *rest.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// ListComments 获取Issue或PR的普通评论，GitHub的PR与Issue共用评论接口
+func (c *GitHubClient) ListComments(ctx context.Context, owner, repo string, number int, pull bool) ([]*Comment, error) {
+	return c.GetIssueComments(ctx, owner, repo, number)
+}
+
+// ListEvents 获取Issue或PR的时间线事件，GitHub的PR与Issue共用时间线接口
+func (c *GitHubClient) ListEvents(ctx context.Context, owner, repo string, number int, pull bool) ([]*TimelineEvent, error) {
+	return c.GetIssueTimeline(ctx, owner, repo, number)
+}
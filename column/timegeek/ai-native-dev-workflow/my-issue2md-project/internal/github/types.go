@@ -10,13 +10,19 @@ import (
 
 // Client 定义GitHub客户端接口
 type Client interface {
-	GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*Issue, error)
+	IssueSource
+	DiscussionSource
 	GetIssueComments(ctx context.Context, owner, repo string, issueNumber int) ([]*Comment, error)
+	GetIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]*TimelineEvent, error)
+	ListIssues(ctx context.Context, owner, repo string, opts *ListIssuesOptions) (*IssueList, error)
 }
 
 // GitHubClient GitHub客户端实现
 type GitHubClient struct {
 	Client *github.Client
+
+	// sleep 用于等待限流重置，为nil时使用sleepContext，测试中可替换以记录等待时长
+	sleep func(ctx context.Context, d time.Duration) error
 }
 
 // NewClient 创建新的GitHub客户端
@@ -37,19 +43,23 @@ func NewClientWithHTTPClient(httpClient *http.Client, token string) *GitHubClien
 
 // Issue 表示一个GitHub Issue
 type Issue struct {
-	Number      int       `json:"number"`
-	Title       string    `json:"title"`
-	Body        string    `json:"body"`
-	State       string    `json:"state"`
-	User        User      `json:"user"`
-	Labels      []Label   `json:"labels"`
-	Assignees   []User    `json:"assignees"`
-	Milestone   *Milestone `json:"milestone,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	ClosedAt    *time.Time `json:"closed_at,omitempty"`
-	URL         string    `json:"url"`
-	HTMLURL     string    `json:"html_url"`
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	User      User       `json:"user"`
+	Labels    []Label    `json:"labels"`
+	Assignees []User     `json:"assignees"`
+	Milestone *Milestone `json:"milestone,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	URL       string     `json:"url"`
+	HTMLURL   string     `json:"html_url"`
+	Reactions Reactions  `json:"reactions"`
+
+	// IsPullRequest 表示这是一个PR，Issue列表接口会同时返回Issue和PR
+	IsPullRequest bool `json:"is_pull_request,omitempty"`
 }
 
 // Comment 表示Issue评论
@@ -61,6 +71,184 @@ type Comment struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	URL       string    `json:"url"`
 	HTMLURL   string    `json:"html_url"`
+	Reactions Reactions `json:"reactions"`
+}
+
+// PullRequest 表示一个GitHub Pull Request
+// PR的普通评论和时间线与Issue共用接口，通过GetIssueComments和GetIssueTimeline获取
+type PullRequest struct {
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	Body         string     `json:"body"`
+	State        string     `json:"state"` // open, closed
+	Draft        bool       `json:"draft"`
+	Merged       bool       `json:"merged"`
+	MergedAt     *time.Time `json:"merged_at,omitempty"`
+	MergedBy     *User      `json:"merged_by,omitempty"`
+	User         User       `json:"user"`
+	Labels       []Label    `json:"labels"`
+	Assignees    []User     `json:"assignees"`
+	HeadRef      string     `json:"head_ref"`
+	BaseRef      string     `json:"base_ref"`
+	Additions    int        `json:"additions"`
+	Deletions    int        `json:"deletions"`
+	ChangedFiles int        `json:"changed_files"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	URL          string     `json:"url"`
+	HTMLURL      string     `json:"html_url"`
+
+	Commits        []*Commit        `json:"commits,omitempty"`
+	Reviews        []*Review        `json:"reviews,omitempty"`
+	ReviewComments []*ReviewComment `json:"review_comments,omitempty"`
+	Diff           string           `json:"diff,omitempty"` // 完整的unified diff，仅在PullRequestOptions.IncludeDiff时获取
+}
+
+// PullRequestOptions 控制GetPullRequest获取的内容
+type PullRequestOptions struct {
+	IncludeDiff bool // 获取完整的unified diff，大型PR的diff可能有数MB
+}
+
+// Commit 表示PR中的一个提交
+type Commit struct {
+	SHA         string    `json:"sha"`
+	Message     string    `json:"message"`
+	AuthorName  string    `json:"author_name"`
+	Author      *User     `json:"author,omitempty"` // 提交邮箱未关联GitHub账号时为空
+	CommittedAt time.Time `json:"committed_at"`
+	HTMLURL     string    `json:"html_url"`
+}
+
+// Review 表示一次PR评审
+type Review struct {
+	ID          int64     `json:"id"`
+	User        User      `json:"user"`
+	State       string    `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED
+	Body        string    `json:"body"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	CommitID    string    `json:"commit_id"`
+	HTMLURL     string    `json:"html_url"`
+}
+
+// ReviewComment 表示PR代码行上的评审评论
+type ReviewComment struct {
+	ID        int64     `json:"id"`
+	ReviewID  int64     `json:"review_id"`
+	InReplyTo int64     `json:"in_reply_to,omitempty"` // 回复的根评论ID，根评论为0
+	User      User      `json:"user"`
+	Body      string    `json:"body"`
+	Path      string    `json:"path"`
+	Line      int       `json:"line"`       // 评论所在行，代码变动后评论过期时为0
+	StartLine int       `json:"start_line"` // 多行评论的起始行
+	Side      string    `json:"side"`       // LEFT(删除侧), RIGHT(新增侧)
+	Outdated  bool      `json:"outdated"`
+	DiffHunk  string    `json:"diff_hunk"`
+	CreatedAt time.Time `json:"created_at"`
+	HTMLURL   string    `json:"html_url"`
+	Reactions Reactions `json:"reactions"`
+}
+
+// ReviewThread 表示代码某一行上的一组评审评论：一条根评论及其回复
+type ReviewThread struct {
+	Path     string           `json:"path"`
+	Line     int              `json:"line"`
+	Outdated bool             `json:"outdated"`
+	DiffHunk string           `json:"diff_hunk"`
+	Comments []*ReviewComment `json:"comments"`
+}
+
+// Discussion 表示一个GitHub Discussion，通过GraphQL API获取
+type Discussion struct {
+	Number         int                  `json:"number"`
+	Title          string               `json:"title"`
+	Body           string               `json:"body"`
+	User           User                 `json:"user"`
+	Category       DiscussionCategory   `json:"category"`
+	Labels         []Label              `json:"labels"`
+	Closed         bool                 `json:"closed"`
+	IsAnswered     bool                 `json:"is_answered"`
+	AnswerChosenAt *time.Time           `json:"answer_chosen_at,omitempty"`
+	AnswerChosenBy *User                `json:"answer_chosen_by,omitempty"`
+	UpvoteCount    int                  `json:"upvote_count"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	ClosedAt       *time.Time           `json:"closed_at,omitempty"`
+	HTMLURL        string               `json:"html_url"`
+	Comments       []*DiscussionComment `json:"comments"`
+}
+
+// Answer 返回被标记为答案的评论，没有答案时返回nil
+func (d *Discussion) Answer() *DiscussionComment {
+	for _, c := range d.Comments {
+		if c.IsAnswer {
+			return c
+		}
+	}
+	return nil
+}
+
+// DiscussionCategory 表示Discussion分类，例如 Q&A、Ideas
+type DiscussionCategory struct {
+	Name         string `json:"name"`
+	Emoji        string `json:"emoji"`         // 例如 ":pray:"
+	IsAnswerable bool   `json:"is_answerable"` // 只有可回答的分类才能标记答案
+}
+
+// DiscussionComment 表示Discussion的评论，顶层评论可以有一层回复
+type DiscussionComment struct {
+	ID          string               `json:"id"` // GraphQL节点ID
+	Body        string               `json:"body"`
+	User        User                 `json:"user"`
+	IsAnswer    bool                 `json:"is_answer"`
+	UpvoteCount int                  `json:"upvote_count"`
+	CreatedAt   time.Time            `json:"created_at"`
+	HTMLURL     string               `json:"html_url"`
+	Replies     []*DiscussionComment `json:"replies,omitempty"`
+}
+
+// Reactions 表示Issue或评论的reactions统计
+type Reactions struct {
+	TotalCount int `json:"total_count"`
+	ThumbsUp   int `json:"thumbs_up"`
+	ThumbsDown int `json:"thumbs_down"`
+	Laugh      int `json:"laugh"`
+	Hooray     int `json:"hooray"`
+	Confused   int `json:"confused"`
+	Heart      int `json:"heart"`
+	Rocket     int `json:"rocket"`
+	Eyes       int `json:"eyes"`
+}
+
+// TimelineEvent 表示Issue时间线上的一个事件，例如标签变更、关闭、交叉引用
+// 评论不在其中，评论通过GetIssueComments获取
+type TimelineEvent struct {
+	ID        int64     `json:"id,omitempty"`
+	Event     string    `json:"event"` // labeled, unlabeled, closed, reopened, cross-referenced ...
+	Actor     User      `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Label     *Label          `json:"label,omitempty"`     // labeled, unlabeled
+	Assignee  *User           `json:"assignee,omitempty"`  // assigned, unassigned
+	Milestone string          `json:"milestone,omitempty"` // milestoned, demilestoned
+	Rename    *Rename         `json:"rename,omitempty"`    // renamed
+	Source    *CrossReference `json:"source,omitempty"`    // cross-referenced
+	CommitID  string          `json:"commit_id,omitempty"` // closed, referenced
+}
+
+// Rename 表示标题修改
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CrossReference 表示引用了当前Issue的另一个Issue或PR
+type CrossReference struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	HTMLURL     string `json:"html_url"`
+	Repository  string `json:"repository,omitempty"` // owner/repo，与当前仓库相同时可能为空
+	PullRequest bool   `json:"pull_request"`
 }
 
 // User 表示GitHub用户
@@ -98,4 +286,4 @@ type Repository struct {
 	FullName string `json:"full_name"`
 	URL      string `json:"url"`
 	HTMLURL  string `json:"html_url"`
-}
\ No newline at end of file
+}
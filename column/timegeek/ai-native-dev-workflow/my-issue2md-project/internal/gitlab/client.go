@@ -0,0 +1,248 @@
+// Package gitlab 通过GitLab REST API v4获取Issue和Merge Request，并转换为 github 包的类型
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/rest"
+)
+
+// perPage 是分页请求的每页条数，GitLab允许的最大值为100
+const perPage = 100
+
+// Client GitLab客户端，实现 github.IssueSource
+// 项目路径可以包含多级群组，owner为群组路径，例如 "gitlab-org/api"
+type Client struct {
+	rest *rest.Client
+}
+
+var (
+	_ github.IssueSource  = (*Client)(nil)
+	_ github.UpdateSource = (*Client)(nil)
+)
+
+// NewClient 创建GitLab客户端，baseURL是实例地址，例如 https://gitlab.com
+func NewClient(baseURL, token string) *Client {
+	return NewClientWithHTTPClient(nil, baseURL, token)
+}
+
+// NewClientWithHTTPClient 使用自定义HTTP客户端创建GitLab客户端
+func NewClientWithHTTPClient(httpClient *http.Client, baseURL, token string) *Client {
+	header := http.Header{}
+	if token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	}
+	return &Client{
+		rest: rest.NewClient(httpClient, baseURL+"/api/v4", header),
+	}
+}
+
+// itemPath 返回Issue或Merge Request的API路径
+func itemPath(owner, repo string, number int, pull bool) string {
+	kind := "issues"
+	if pull {
+		kind = "merge_requests"
+	}
+	return "/projects/" + url.PathEscape(owner+"/"+repo) + "/" + kind + "/" + strconv.Itoa(number)
+}
+
+// listAll 逐页获取列表，直到响应头X-Next-Page为空
+func listAll[T any](ctx context.Context, c *Client, path string, query url.Values) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	var all []T
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		var items []T
+		header, err := c.rest.GetJSON(ctx, path, query, &items)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		page = header.Get("X-Next-Page")
+	}
+	return all, nil
+}
+
+// user 是GitLab用户
+type user struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+	WebURL    string `json:"web_url"`
+}
+
+// milestone 是GitLab里程碑
+type milestone struct {
+	IID   int    `json:"iid"`
+	Title string `json:"title"`
+	State string `json:"state"`
+}
+
+// note 是Issue或Merge Request上的一条note，system note是GitLab自动生成的操作记录
+type note struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"` // 普通评论为空，代码行评论为 DiffNote
+	Body      string    `json:"body"`
+	Author    user      `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	System    bool      `json:"system"`
+	Position  *position `json:"position"`
+}
+
+// position 是DiffNote在diff中的位置
+type position struct {
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+	OldLine int    `json:"old_line"`
+	NewLine int    `json:"new_line"`
+}
+
+// labelEvent 是标签变更事件
+type labelEvent struct {
+	ID        int64     `json:"id"`
+	User      user      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	Action    string    `json:"action"` // add, remove
+	Label     *struct {
+		Name        string `json:"name"`
+		Color       string `json:"color"`
+		Description string `json:"description"`
+	} `json:"label"`
+}
+
+// stateEvent 是状态变更事件
+type stateEvent struct {
+	ID        int64     `json:"id"`
+	User      user      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	State     string    `json:"state"` // closed, reopened, merged
+}
+
+// milestoneEvent 是里程碑变更事件
+type milestoneEvent struct {
+	ID        int64      `json:"id"`
+	User      user       `json:"user"`
+	CreatedAt time.Time  `json:"created_at"`
+	Action    string     `json:"action"` // add, remove
+	Milestone *milestone `json:"milestone"`
+}
+
+// ListComments 获取Issue或Merge Request的普通评论，不包括system note和代码行评论
+func (c *Client) ListComments(ctx context.Context, owner, repo string, number int, pull bool) ([]*github.Comment, error) {
+	notes, err := listAll[note](ctx, c, itemPath(owner, repo, number, pull)+"/notes", url.Values{
+		"sort":     {"asc"},
+		"order_by": {"created_at"},
+	})
+	if err != nil {
+		return nil, wrapError(err, "comments", owner, repo, number, pull)
+	}
+
+	var comments []*github.Comment
+	for _, n := range notes {
+		if n.System || n.Type == "DiffNote" {
+			continue
+		}
+		comments = append(comments, &github.Comment{
+			ID:        n.ID,
+			Body:      n.Body,
+			User:      convertUser(n.Author),
+			CreatedAt: n.CreatedAt,
+			UpdatedAt: n.UpdatedAt,
+		})
+	}
+	return comments, nil
+}
+
+// ListEvents 获取标签、状态和里程碑的变更事件，按时间排序
+func (c *Client) ListEvents(ctx context.Context, owner, repo string, number int, pull bool) ([]*github.TimelineEvent, error) {
+	path := itemPath(owner, repo, number, pull)
+
+	labelEvents, err := listAll[labelEvent](ctx, c, path+"/resource_label_events", nil)
+	if err != nil {
+		return nil, wrapError(err, "label events", owner, repo, number, pull)
+	}
+	stateEvents, err := listAll[stateEvent](ctx, c, path+"/resource_state_events", nil)
+	if err != nil {
+		return nil, wrapError(err, "state events", owner, repo, number, pull)
+	}
+	milestoneEvents, err := listAll[milestoneEvent](ctx, c, path+"/resource_milestone_events", nil)
+	if err != nil {
+		return nil, wrapError(err, "milestone events", owner, repo, number, pull)
+	}
+
+	var events []*github.TimelineEvent
+	for _, e := range labelEvents {
+		event := &github.TimelineEvent{ID: e.ID, Event: "labeled", Actor: convertUser(e.User), CreatedAt: e.CreatedAt}
+		if e.Action == "remove" {
+			event.Event = "unlabeled"
+		}
+		if e.Label != nil {
+			event.Label = &github.Label{Name: e.Label.Name, Color: e.Label.Color, Description: e.Label.Description}
+		}
+		events = append(events, event)
+	}
+	for _, e := range stateEvents {
+		events = append(events, &github.TimelineEvent{ID: e.ID, Event: e.State, Actor: convertUser(e.User), CreatedAt: e.CreatedAt})
+	}
+	for _, e := range milestoneEvents {
+		event := &github.TimelineEvent{ID: e.ID, Event: "milestoned", Actor: convertUser(e.User), CreatedAt: e.CreatedAt}
+		if e.Action == "remove" {
+			event.Event = "demilestoned"
+		}
+		if e.Milestone != nil {
+			event.Milestone = e.Milestone.Title
+		}
+		events = append(events, event)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	return events, nil
+}
+
+// convertUser 转换GitLab用户
+func convertUser(u user) github.User {
+	return github.User{
+		Login:     u.Username,
+		ID:        u.ID,
+		AvatarURL: u.AvatarURL,
+		HTMLURL:   u.WebURL,
+	}
+}
+
+// convertUsers 转换GitLab用户列表
+func convertUsers(users []user) []github.User {
+	result := make([]github.User, 0, len(users))
+	for _, u := range users {
+		result = append(result, convertUser(u))
+	}
+	return result
+}
+
+// convertLabels 转换标签，GitLab默认只返回标签名
+func convertLabels(names []string) []github.Label {
+	labels := make([]github.Label, 0, len(names))
+	for _, name := range names {
+		labels = append(labels, github.Label{Name: name})
+	}
+	return labels
+}
+
+// convertState 把GitLab的 opened 转换为 open，其余状态保持不变
+func convertState(state string) string {
+	if state == "opened" {
+		return "open"
+	}
+	return state
+}
@@ -0,0 +1,204 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/rest"
+)
+
+// project 是测试使用的多级群组项目，API路径中斜杠编码为%2F
+const project = "/api/v4/projects/group%2Fsub%2Fapp"
+
+// newTestClient 创建一个指向httptest服务器的GitLab客户端
+// routes的键是转义后的请求路径，可以带 ?page=N 区分分页，值是响应体
+func newTestClient(t *testing.T, routes map[string]string) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "test-token" {
+			t.Errorf("PRIVATE-TOKEN = %q, want test-token", got)
+		}
+		path := r.URL.EscapedPath()
+		if page := r.URL.Query().Get("page"); page != "" && page != "1" {
+			path += "?page=" + page
+		}
+		body, ok := routes[path]
+		if !ok {
+			http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+			return
+		}
+		if _, ok := routes[r.URL.EscapedPath()+"?page=2"]; ok && r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewClientWithHTTPClient(server.Client(), server.URL, "test-token")
+}
+
+func TestGetIssue(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		project + "/issues/7": `{
+			"iid": 7, "title": "Crash on start", "description": "It crashes", "state": "opened",
+			"author": {"id": 1, "username": "alice", "web_url": "https://gitlab.com/alice"},
+			"labels": ["bug", "p1"], "milestone": {"iid": 3, "title": "v1.0", "state": "active"},
+			"created_at": "2024-01-02T03:04:05Z", "updated_at": "2024-01-03T03:04:05Z",
+			"web_url": "https://gitlab.com/group/sub/app/-/issues/7", "upvotes": 3, "downvotes": 1
+		}`,
+	})
+
+	issue, err := client.GetIssue(context.Background(), "group/sub", "app", 7)
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if issue.Number != 7 || issue.State != "open" || issue.Body != "It crashes" {
+		t.Errorf("GetIssue() = %+v", issue)
+	}
+	if issue.User.Login != "alice" || issue.User.HTMLURL != "https://gitlab.com/alice" {
+		t.Errorf("User = %+v", issue.User)
+	}
+	if len(issue.Labels) != 2 || issue.Labels[1].Name != "p1" {
+		t.Errorf("Labels = %+v", issue.Labels)
+	}
+	if issue.Milestone == nil || issue.Milestone.Title != "v1.0" {
+		t.Errorf("Milestone = %+v", issue.Milestone)
+	}
+	if issue.Reactions.ThumbsUp != 3 || issue.Reactions.ThumbsDown != 1 || issue.Reactions.TotalCount != 4 {
+		t.Errorf("Reactions = %+v", issue.Reactions)
+	}
+}
+
+func TestListComments(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		project + "/issues/7/notes": `[
+			{"id": 1, "body": "first", "author": {"username": "alice"}, "created_at": "2024-01-02T00:00:00Z"},
+			{"id": 2, "body": "added ~bug label", "system": true, "author": {"username": "bob"}, "created_at": "2024-01-02T01:00:00Z"}
+		]`,
+		project + "/issues/7/notes?page=2": `[
+			{"id": 3, "body": "on a line", "type": "DiffNote", "author": {"username": "bob"}, "created_at": "2024-01-02T02:00:00Z"},
+			{"id": 4, "body": "second", "author": {"username": "bob"}, "created_at": "2024-01-02T03:00:00Z"}
+		]`,
+	})
+
+	comments, err := client.ListComments(context.Background(), "group/sub", "app", 7, false)
+	if err != nil {
+		t.Fatalf("ListComments() error = %v", err)
+	}
+	var bodies []string
+	for _, c := range comments {
+		bodies = append(bodies, c.Body)
+	}
+	if got := strings.Join(bodies, ","); got != "first,second" {
+		t.Errorf("ListComments() bodies = %s, want first,second (system and diff notes skipped, both pages read)", got)
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		project + "/merge_requests/5/resource_label_events": `[
+			{"id": 1, "action": "add", "label": {"name": "bug"}, "user": {"username": "alice"}, "created_at": "2024-01-02T01:00:00Z"},
+			{"id": 2, "action": "remove", "label": {"name": "bug"}, "user": {"username": "alice"}, "created_at": "2024-01-02T04:00:00Z"}
+		]`,
+		project + "/merge_requests/5/resource_state_events": `[
+			{"id": 3, "state": "merged", "user": {"username": "bob"}, "created_at": "2024-01-02T05:00:00Z"}
+		]`,
+		project + "/merge_requests/5/resource_milestone_events": `[
+			{"id": 4, "action": "add", "milestone": {"title": "v1.0"}, "user": {"username": "bob"}, "created_at": "2024-01-02T02:00:00Z"}
+		]`,
+	})
+
+	events, err := client.ListEvents(context.Background(), "group/sub", "app", 5, true)
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	want := []string{"labeled:bug", "milestoned:v1.0", "unlabeled:bug", "merged:"}
+	if len(events) != len(want) {
+		t.Fatalf("ListEvents() returned %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		got := e.Event + ":" + e.Milestone
+		if e.Label != nil {
+			got = e.Event + ":" + e.Label.Name
+		}
+		if got != want[i] {
+			t.Errorf("event %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestGetPullRequest(t *testing.T) {
+	mr := project + "/merge_requests/5"
+	client := newTestClient(t, map[string]string{
+		mr: `{
+			"iid": 5, "title": "Fix crash", "description": "Fixes #7", "state": "merged",
+			"author": {"username": "alice"}, "merged_by": {"username": "bob"},
+			"merged_at": "2024-01-03T00:00:00Z", "source_branch": "fix", "target_branch": "main",
+			"changes_count": "1", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-03T00:00:00Z",
+			"web_url": "https://gitlab.com/group/sub/app/-/merge_requests/5"
+		}`,
+		mr + "/commits": `[{"id": "abc123", "message": "fix crash", "author_name": "Alice", "committed_date": "2024-01-01T01:00:00Z"}]`,
+		mr + "/discussions": `[
+			{"id": "d1", "notes": [{"id": 10, "system": true, "body": "approved this merge request", "author": {"username": "bob"}, "created_at": "2024-01-02T00:00:00Z"}]},
+			{"id": "d2", "notes": [
+				{"id": 11, "type": "DiffNote", "body": "nil check?", "author": {"username": "bob"}, "created_at": "2024-01-01T02:00:00Z",
+				 "position": {"old_path": "main.go", "new_path": "main.go", "new_line": 12}},
+				{"id": 12, "type": "DiffNote", "body": "done", "author": {"username": "alice"}, "created_at": "2024-01-01T03:00:00Z",
+				 "position": {"old_path": "main.go", "new_path": "main.go", "new_line": 12}}
+			]},
+			{"id": "d3", "notes": [{"id": 13, "body": "LGTM", "author": {"username": "carol"}, "created_at": "2024-01-01T04:00:00Z"}]}
+		]`,
+		mr + "/diffs": `[{"old_path": "main.go", "new_path": "main.go", "diff": "@@ -1,2 +1,2 @@\n-old\n+new\n+more\n"}]`,
+	})
+
+	pr, err := client.GetPullRequest(context.Background(), "group/sub", "app", 5, &github.PullRequestOptions{IncludeDiff: true})
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+	if pr.State != "closed" || !pr.Merged || pr.MergedBy == nil || pr.MergedBy.Login != "bob" {
+		t.Errorf("merge state = %s merged=%v by=%+v", pr.State, pr.Merged, pr.MergedBy)
+	}
+	if pr.HeadRef != "fix" || pr.BaseRef != "main" {
+		t.Errorf("refs = %s -> %s", pr.HeadRef, pr.BaseRef)
+	}
+	if len(pr.Commits) != 1 || pr.Commits[0].SHA != "abc123" {
+		t.Errorf("Commits = %+v", pr.Commits)
+	}
+	if len(pr.Reviews) != 1 || pr.Reviews[0].State != "APPROVED" || pr.Reviews[0].User.Login != "bob" {
+		t.Errorf("Reviews = %+v", pr.Reviews)
+	}
+	if len(pr.ReviewComments) != 2 {
+		t.Fatalf("ReviewComments = %d, want 2", len(pr.ReviewComments))
+	}
+	root, reply := pr.ReviewComments[0], pr.ReviewComments[1]
+	if root.Path != "main.go" || root.Line != 12 || root.Side != "RIGHT" || root.InReplyTo != 0 {
+		t.Errorf("root comment = %+v", root)
+	}
+	if reply.InReplyTo != root.ID {
+		t.Errorf("reply.InReplyTo = %d, want %d", reply.InReplyTo, root.ID)
+	}
+	if pr.Additions != 2 || pr.Deletions != 1 || pr.ChangedFiles != 1 {
+		t.Errorf("diff stats = +%d -%d files=%d", pr.Additions, pr.Deletions, pr.ChangedFiles)
+	}
+	if !strings.HasPrefix(pr.Diff, "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@") {
+		t.Errorf("Diff = %q", pr.Diff)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	client := newTestClient(t, map[string]string{})
+
+	_, err := client.GetIssue(context.Background(), "group/sub", "app", 404)
+	var apiErr *rest.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("GetIssue() error = %v, want a 404 APIError", err)
+	}
+	if !strings.Contains(err.Error(), "issue 404 from group/sub/app") {
+		t.Errorf("error %q lacks context", err)
+	}
+}
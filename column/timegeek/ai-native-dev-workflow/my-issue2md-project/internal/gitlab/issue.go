@@ -0,0 +1,83 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// issue 是GitLab Issue，number对应iid(项目内编号)
+type issue struct {
+	IID         int        `json:"iid"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	State       string     `json:"state"` // opened, closed
+	Author      user       `json:"author"`
+	Assignees   []user     `json:"assignees"`
+	Labels      []string   `json:"labels"`
+	Milestone   *milestone `json:"milestone"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	WebURL      string     `json:"web_url"`
+	Upvotes     int        `json:"upvotes"`
+	Downvotes   int        `json:"downvotes"`
+}
+
+// GetIssue 获取Issue
+func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (*github.Issue, error) {
+	var gl issue
+	if _, err := c.rest.GetJSON(ctx, itemPath(owner, repo, number, false), nil, &gl); err != nil {
+		return nil, fmt.Errorf("failed to get issue %d from %s/%s: %w", number, owner, repo, err)
+	}
+
+	result := &github.Issue{
+		Number:    gl.IID,
+		Title:     gl.Title,
+		Body:      gl.Description,
+		State:     convertState(gl.State),
+		User:      convertUser(gl.Author),
+		Labels:    convertLabels(gl.Labels),
+		Assignees: convertUsers(gl.Assignees),
+		CreatedAt: gl.CreatedAt,
+		UpdatedAt: gl.UpdatedAt,
+		ClosedAt:  gl.ClosedAt,
+		HTMLURL:   gl.WebURL,
+		Reactions: convertVotes(gl.Upvotes, gl.Downvotes),
+	}
+	if gl.Milestone != nil {
+		result.Milestone = &github.Milestone{Title: gl.Milestone.Title, Number: gl.Milestone.IID, State: gl.Milestone.State}
+	}
+	return result, nil
+}
+
+// GetUpdatedAt 获取Issue或Merge Request的最后更新时间
+func (c *Client) GetUpdatedAt(ctx context.Context, owner, repo string, number int, pull bool) (time.Time, error) {
+	var item struct {
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	if _, err := c.rest.GetJSON(ctx, itemPath(owner, repo, number, pull), nil, &item); err != nil {
+		return time.Time{}, wrapError(err, "updated time", owner, repo, number, pull)
+	}
+	return item.UpdatedAt, nil
+}
+
+// convertVotes 把GitLab的赞和踩转换为reactions统计
+func convertVotes(up, down int) github.Reactions {
+	return github.Reactions{
+		TotalCount: up + down,
+		ThumbsUp:   up,
+		ThumbsDown: down,
+	}
+}
+
+// wrapError 为列表请求的错误加上上下文
+func wrapError(err error, what, owner, repo string, number int, pull bool) error {
+	kind := "issue"
+	if pull {
+		kind = "merge request"
+	}
+	return fmt.Errorf("failed to get %s for %s %d from %s/%s: %w", what, kind, number, owner, repo, err)
+}
@@ -0,0 +1,202 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// mergeRequest 是GitLab Merge Request
+type mergeRequest struct {
+	IID          int        `json:"iid"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	State        string     `json:"state"` // opened, closed, merged, locked
+	Draft        bool       `json:"draft"`
+	Author       user       `json:"author"`
+	Assignees    []user     `json:"assignees"`
+	Labels       []string   `json:"labels"`
+	SourceBranch string     `json:"source_branch"`
+	TargetBranch string     `json:"target_branch"`
+	MergedBy     *user      `json:"merged_by"`
+	MergedAt     *time.Time `json:"merged_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	WebURL       string     `json:"web_url"`
+	ChangesCount string     `json:"changes_count"` // 例如 "3"，文件很多时为 "1000+"
+}
+
+// commit 是Merge Request中的提交
+type commit struct {
+	ID            string    `json:"id"`
+	Message       string    `json:"message"`
+	AuthorName    string    `json:"author_name"`
+	CommittedDate time.Time `json:"committed_date"`
+	WebURL        string    `json:"web_url"`
+}
+
+// discussion 是Merge Request上的一个讨论串，代码行评论以DiffNote开头
+type discussion struct {
+	ID    string `json:"id"`
+	Notes []note `json:"notes"`
+}
+
+// diff 是Merge Request中一个文件的变更
+type diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"` // 从第一个 @@ 开始，不含文件头
+	NewFile     bool   `json:"new_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// GetPullRequest 获取Merge Request，以及提交、批准记录和代码行评论
+//
+// GitLab没有评审结论，"approved this merge request" 的system note转换为APPROVED评审；
+// 代码行评论来自以DiffNote开头的讨论串，同一讨论串中的后续note作为回复
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number int, opts *github.PullRequestOptions) (*github.PullRequest, error) {
+	if opts == nil {
+		opts = &github.PullRequestOptions{}
+	}
+	path := itemPath(owner, repo, number, true)
+
+	var mr mergeRequest
+	if _, err := c.rest.GetJSON(ctx, path, nil, &mr); err != nil {
+		return nil, fmt.Errorf("failed to get merge request %d from %s/%s: %w", number, owner, repo, err)
+	}
+	pr := convertMergeRequest(&mr)
+
+	commits, err := listAll[commit](ctx, c, path+"/commits", nil)
+	if err != nil {
+		return nil, wrapError(err, "commits", owner, repo, number, true)
+	}
+	for _, gl := range commits {
+		pr.Commits = append(pr.Commits, &github.Commit{
+			SHA:         gl.ID,
+			Message:     gl.Message,
+			AuthorName:  gl.AuthorName,
+			CommittedAt: gl.CommittedDate,
+			HTMLURL:     gl.WebURL,
+		})
+	}
+
+	discussions, err := listAll[discussion](ctx, c, path+"/discussions", nil)
+	if err != nil {
+		return nil, wrapError(err, "discussions", owner, repo, number, true)
+	}
+	for _, d := range discussions {
+		if len(d.Notes) == 0 {
+			continue
+		}
+		root := d.Notes[0]
+		switch {
+		case root.System && strings.HasPrefix(root.Body, "approved this merge request"):
+			pr.Reviews = append(pr.Reviews, &github.Review{
+				ID:          root.ID,
+				User:        convertUser(root.Author),
+				State:       "APPROVED",
+				SubmittedAt: root.CreatedAt,
+			})
+		case root.Type == "DiffNote":
+			for _, n := range d.Notes {
+				pr.ReviewComments = append(pr.ReviewComments, convertDiffNote(n, root.ID))
+			}
+		}
+	}
+
+	if opts.IncludeDiff {
+		diffs, err := listAll[diff](ctx, c, path+"/diffs", nil)
+		if err != nil {
+			return nil, wrapError(err, "diff", owner, repo, number, true)
+		}
+		pr.Diff, pr.Additions, pr.Deletions = unifiedDiff(diffs)
+		pr.ChangedFiles = len(diffs)
+	}
+
+	return pr, nil
+}
+
+// convertMergeRequest 转换Merge Request的基本信息
+func convertMergeRequest(mr *mergeRequest) *github.PullRequest {
+	changed, _ := strconv.Atoi(strings.TrimSuffix(mr.ChangesCount, "+"))
+	pr := &github.PullRequest{
+		Number:       mr.IID,
+		Title:        mr.Title,
+		Body:         mr.Description,
+		State:        convertState(mr.State),
+		Draft:        mr.Draft,
+		Merged:       mr.State == "merged",
+		MergedAt:     mr.MergedAt,
+		User:         convertUser(mr.Author),
+		Labels:       convertLabels(mr.Labels),
+		Assignees:    convertUsers(mr.Assignees),
+		HeadRef:      mr.SourceBranch,
+		BaseRef:      mr.TargetBranch,
+		ChangedFiles: changed,
+		CreatedAt:    mr.CreatedAt,
+		UpdatedAt:    mr.UpdatedAt,
+		ClosedAt:     mr.ClosedAt,
+		HTMLURL:      mr.WebURL,
+	}
+	if pr.Merged {
+		pr.State = "closed"
+	}
+	if mr.MergedBy != nil {
+		mergedBy := convertUser(*mr.MergedBy)
+		pr.MergedBy = &mergedBy
+	}
+	return pr
+}
+
+// convertDiffNote 转换代码行评论，rootID是所在讨论串第一条note的ID
+func convertDiffNote(n note, rootID int64) *github.ReviewComment {
+	comment := &github.ReviewComment{
+		ID:        n.ID,
+		User:      convertUser(n.Author),
+		Body:      n.Body,
+		CreatedAt: n.CreatedAt,
+	}
+	if n.ID != rootID {
+		comment.InReplyTo = rootID
+	}
+	if p := n.Position; p != nil {
+		comment.Path, comment.Line, comment.Side = p.NewPath, p.NewLine, "RIGHT"
+		if p.NewLine == 0 {
+			comment.Path, comment.Line, comment.Side = p.OldPath, p.OldLine, "LEFT"
+		}
+	}
+	return comment
+}
+
+// unifiedDiff 把各文件的变更拼接为unified diff，并统计增删行数
+func unifiedDiff(diffs []diff) (text string, additions, deletions int) {
+	var b strings.Builder
+	for _, d := range diffs {
+		oldName, newName := "a/"+d.OldPath, "b/"+d.NewPath
+		if d.NewFile {
+			oldName = "/dev/null"
+		}
+		if d.DeletedFile {
+			newName = "/dev/null"
+		}
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- %s\n+++ %s\n", d.OldPath, d.NewPath, oldName, newName)
+		b.WriteString(d.Diff)
+		if d.Diff != "" && !strings.HasSuffix(d.Diff, "\n") {
+			b.WriteString("\n")
+		}
+		for _, line := range strings.Split(d.Diff, "\n") {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				additions++
+			case strings.HasPrefix(line, "-"):
+				deletions++
+			}
+		}
+	}
+	return b.String(), additions, deletions
+}
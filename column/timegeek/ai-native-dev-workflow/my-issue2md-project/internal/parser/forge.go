@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 支持的代码托管平台
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+	ForgeGitea  = "gitea" // 也用于Forgejo
+)
+
+// DefaultHosts 内置的主机与平台对应关系，自建实例通过 ForgeURLParser 的hosts参数添加
+var DefaultHosts = map[string]string{
+	"github.com":   ForgeGitHub,
+	"gitlab.com":   ForgeGitLab,
+	"gitea.com":    ForgeGitea,
+	"codeberg.org": ForgeGitea,
+}
+
+// ForgeURLParser 按主机名把URL分派给对应平台的解析规则
+type ForgeURLParser struct {
+	hosts  map[string]string
+	github *GitHubURLParser
+}
+
+// NewForgeURLParser 创建按主机分派的URL解析器
+// hosts是额外的 主机名->平台 映射，用于自建的GitLab或Gitea实例，会覆盖 DefaultHosts 中的同名项
+func NewForgeURLParser(hosts map[string]string) (*ForgeURLParser, error) {
+	merged := make(map[string]string, len(DefaultHosts)+len(hosts))
+	for host, forge := range DefaultHosts {
+		merged[host] = forge
+	}
+	for host, forge := range hosts {
+		switch forge {
+		case ForgeGitLab, ForgeGitea:
+		default:
+			// GitHub Enterprise的API地址与github.com不同，暂不支持
+			return nil, fmt.Errorf("unsupported forge %q for host %s, expected %s or %s", forge, host, ForgeGitLab, ForgeGitea)
+		}
+		merged[strings.ToLower(host)] = forge
+	}
+	return &ForgeURLParser{hosts: merged, github: NewURLParser()}, nil
+}
+
+// Parse 解析Issue、PR/Merge Request或Discussion的URL
+func (p *ForgeURLParser) Parse(rawURL string) (*ResourceURL, error) {
+	if rawURL == "" {
+		return nil, fmt.Errorf("empty URL")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("invalid URL: %s", rawURL)
+	}
+	host := strings.ToLower(u.Host)
+	forge, ok := p.hosts[host]
+	if !ok {
+		return nil, fmt.Errorf("invalid URL: unknown host %s, use --host %s=gitlab or --host %s=gitea for self-hosted instances", host, host, host)
+	}
+
+	var res *ResourceURL
+	switch forge {
+	case ForgeGitHub:
+		res, err = p.github.Parse(rawURL)
+	case ForgeGitLab:
+		res, err = p.parseGitLabPath(u.Path)
+	case ForgeGitea:
+		res, err = p.parseGiteaPath(u.Path)
+	}
+	if err != nil {
+		if forge == ForgeGitHub {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	res.Forge = forge
+	res.BaseURL = u.Scheme + "://" + u.Host
+	res.URL = rawURL
+	return res, nil
+}
+
+// Validate 验证URL格式
+func (p *ForgeURLParser) Validate(rawURL string) error {
+	_, err := p.Parse(rawURL)
+	return err
+}
+
+// SupportedTypes 返回支持的资源类型
+func (p *ForgeURLParser) SupportedTypes() []string {
+	return []string{"issue", "pull", "discussion"}
+}
+
+// parseGitLabPath 解析GitLab路径 /group[/subgroup...]/project/-/(issues|merge_requests)/N
+func (p *ForgeURLParser) parseGitLabPath(path string) (*ResourceURL, error) {
+	project, item, ok := strings.Cut(strings.Trim(path, "/"), "/-/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported URL type")
+	}
+	slash := strings.LastIndex(project, "/")
+	if slash <= 0 || slash == len(project)-1 {
+		return nil, fmt.Errorf("missing owner or repository")
+	}
+
+	parts := strings.Split(item, "/")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("missing number component")
+	}
+	var resourceType string
+	switch parts[0] {
+	case "issues":
+		resourceType = "issue"
+	case "merge_requests":
+		resourceType = "pull"
+	default:
+		return nil, fmt.Errorf("unsupported URL type: %s", parts[0])
+	}
+	number, err := p.github.parseNumber(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceURL{
+		Type:   resourceType,
+		Owner:  project[:slash],
+		Repo:   project[slash+1:],
+		Number: number,
+	}, nil
+}
+
+// parseGiteaPath 解析Gitea路径 /owner/repo/(issues|pulls)/N
+func (p *ForgeURLParser) parseGiteaPath(path string) (*ResourceURL, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("insufficient path components")
+	}
+	if parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("missing owner or repository")
+	}
+	var resourceType string
+	switch parts[2] {
+	case "issues":
+		resourceType = "issue"
+	case "pulls":
+		resourceType = "pull"
+	default:
+		return nil, fmt.Errorf("unsupported URL type: %s", parts[2])
+	}
+	number, err := p.github.parseNumber(parts[3])
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceURL{
+		Type:   resourceType,
+		Owner:  parts[0],
+		Repo:   parts[1],
+		Number: number,
+	}, nil
+}
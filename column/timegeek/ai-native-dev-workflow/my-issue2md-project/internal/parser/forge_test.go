@@ -0,0 +1,141 @@
+package parser
+
+import "testing"
+
+func TestForgeURLParser(t *testing.T) {
+	p, err := NewForgeURLParser(map[string]string{
+		"git.example.com":  ForgeGitLab,
+		"code.example.org": ForgeGitea,
+	})
+	if err != nil {
+		t.Fatalf("NewForgeURLParser() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		rawURL        string
+		wantForge     string
+		wantBaseURL   string
+		wantType      string
+		wantOwner     string
+		wantRepo      string
+		wantNumber    int
+		errorContains string
+	}{
+		{
+			name:        "GitHub issue",
+			rawURL:      "https://github.com/facebook/react/issues/12345",
+			wantForge:   ForgeGitHub,
+			wantBaseURL: "https://github.com",
+			wantType:    "issue",
+			wantOwner:   "facebook",
+			wantRepo:    "react",
+			wantNumber:  12345,
+		},
+		{
+			name:        "GitLab merge request in nested group",
+			rawURL:      "https://gitlab.com/gitlab-org/api/client-go/-/merge_requests/42",
+			wantForge:   ForgeGitLab,
+			wantBaseURL: "https://gitlab.com",
+			wantType:    "pull",
+			wantOwner:   "gitlab-org/api",
+			wantRepo:    "client-go",
+			wantNumber:  42,
+		},
+		{
+			name:        "self-hosted GitLab issue",
+			rawURL:      "https://git.example.com/team/app/-/issues/7",
+			wantForge:   ForgeGitLab,
+			wantBaseURL: "https://git.example.com",
+			wantType:    "issue",
+			wantOwner:   "team",
+			wantRepo:    "app",
+			wantNumber:  7,
+		},
+		{
+			name:        "Codeberg pull request",
+			rawURL:      "https://codeberg.org/forgejo/forgejo/pulls/100",
+			wantForge:   ForgeGitea,
+			wantBaseURL: "https://codeberg.org",
+			wantType:    "pull",
+			wantOwner:   "forgejo",
+			wantRepo:    "forgejo",
+			wantNumber:  100,
+		},
+		{
+			name:        "self-hosted Gitea over http",
+			rawURL:      "http://code.example.org/alice/app/issues/3",
+			wantForge:   ForgeGitea,
+			wantBaseURL: "http://code.example.org",
+			wantType:    "issue",
+			wantOwner:   "alice",
+			wantRepo:    "app",
+			wantNumber:  3,
+		},
+		{
+			name:          "unknown host",
+			rawURL:        "https://git.unknown.dev/alice/app/issues/3",
+			errorContains: "unknown host git.unknown.dev",
+		},
+		{
+			name:          "GitLab URL without /-/",
+			rawURL:        "https://gitlab.com/group/app/issues/3",
+			errorContains: "unsupported URL type",
+		},
+		{
+			name:          "GitLab project without group",
+			rawURL:        "https://gitlab.com/app/-/issues/3",
+			errorContains: "missing owner or repository",
+		},
+		{
+			name:          "Gitea wiki page",
+			rawURL:        "https://gitea.com/alice/app/wiki/Home",
+			errorContains: "unsupported URL type: wiki",
+		},
+		{
+			name:          "Gitea invalid number",
+			rawURL:        "https://gitea.com/alice/app/issues/abc",
+			errorContains: "invalid number format",
+		},
+		{
+			name:          "GitHub error is unchanged",
+			rawURL:        "https://github.com/facebook/react/wiki/1",
+			errorContains: "unsupported URL type: wiki",
+		},
+		{
+			name:          "empty URL",
+			rawURL:        "",
+			errorContains: "empty URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Parse(tt.rawURL)
+			if tt.errorContains != "" {
+				if err == nil || !containsError(err.Error(), tt.errorContains) {
+					t.Fatalf("Parse(%q) error = %v, want error containing %q", tt.rawURL, err, tt.errorContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.rawURL, err)
+			}
+			if got.Forge != tt.wantForge || got.BaseURL != tt.wantBaseURL {
+				t.Errorf("Forge, BaseURL = %s, %s, want %s, %s", got.Forge, got.BaseURL, tt.wantForge, tt.wantBaseURL)
+			}
+			if got.Type != tt.wantType || got.Owner != tt.wantOwner || got.Repo != tt.wantRepo || got.Number != tt.wantNumber {
+				t.Errorf("Parse(%q) = %+v", tt.rawURL, got)
+			}
+			if got.URL != tt.rawURL {
+				t.Errorf("URL = %s, want %s", got.URL, tt.rawURL)
+			}
+		})
+	}
+}
+
+func TestNewForgeURLParserRejectsGitHubHosts(t *testing.T) {
+	if _, err := NewForgeURLParser(map[string]string{"github.example.com": ForgeGitHub}); err == nil {
+		t.Error("NewForgeURLParser() accepted a custom GitHub host")
+	}
+}
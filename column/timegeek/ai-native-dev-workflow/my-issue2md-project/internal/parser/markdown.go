@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// timeLayout 是Markdown正文中的时间格式
+const timeLayout = "2006-01-02 15:04:05 UTC"
+
+// Parse 将Issue、评论和时间线事件渲染为Markdown文档
+// 评论与事件按时间先后交错排列，时间相同时评论在前，布局由模板决定
+func (p *MarkdownParser) Parse(issue *github.Issue, comments []*github.Comment, events []*github.TimelineEvent) (*MarkdownDocument, error) {
+	if issue == nil {
+		return nil, NewProcessingError("issue is nil", "INVALID_INPUT", "")
+	}
+
+	status := issueStatus(issue)
+	metadata := map[string]string{
+		"title":          issue.Title,
+		"url":            issue.HTMLURL,
+		"author":         issue.User.Login,
+		"created_at":     issue.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at":     issue.UpdatedAt.UTC().Format(time.RFC3339),
+		"status":         status,
+		"type":           "issue",
+		"total_comments": strconv.Itoa(len(comments)),
+	}
+
+	content, err := p.render(&TemplateData{
+		Type:         "issue",
+		Number:       issue.Number,
+		Title:        issue.Title,
+		URL:          issue.HTMLURL,
+		Author:       issue.User,
+		Status:       status,
+		Labels:       labelNames(issue.Labels),
+		CreatedAt:    issue.CreatedAt,
+		UpdatedAt:    issue.UpdatedAt,
+		Body:         issue.Body,
+		CommentCount: len(comments),
+		Reactions:    &issue.Reactions,
+		Timeline:     p.buildTimeline(comments, events),
+		Issue:        issue,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MarkdownDocument{
+		Title:    issue.Title,
+		Content:  content,
+		Metadata: metadata,
+	}, nil
+}
+
+// reactionLine 返回正文或评论后的一行reactions统计，没有reaction时返回空串
+func (p *MarkdownParser) reactionLine(r github.Reactions) string {
+	if !p.options.IncludeReactions {
+		return ""
+	}
+	var parts []string
+	for _, rc := range reactionCounts(r) {
+		if rc.Count == 0 {
+			continue
+		}
+		label := ":" + rc.Key + ":"
+		if p.options.EmojisEnabled {
+			label = rc.Emoji
+		}
+		parts = append(parts, fmt.Sprintf("%s %d", label, rc.Count))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// describeEvent 把时间线事件描述为一句话
+func (p *MarkdownParser) describeEvent(e *github.TimelineEvent) string {
+	var action string
+	switch e.Event {
+	case "labeled", "unlabeled":
+		verb := "added"
+		if e.Event == "unlabeled" {
+			verb = "removed"
+		}
+		name := ""
+		if e.Label != nil {
+			name = e.Label.Name
+		}
+		action = fmt.Sprintf("%s the `%s` label", verb, name)
+	case "assigned", "unassigned":
+		if e.Assignee == nil || e.Assignee.Login == e.Actor.Login {
+			action = "self-" + e.Event + " this"
+		} else {
+			action = fmt.Sprintf("%s %s", e.Event, p.userRef(*e.Assignee))
+		}
+	case "milestoned":
+		action = fmt.Sprintf("added this to the `%s` milestone", e.Milestone)
+	case "demilestoned":
+		action = fmt.Sprintf("removed this from the `%s` milestone", e.Milestone)
+	case "renamed":
+		if e.Rename != nil {
+			action = fmt.Sprintf("changed the title from %q to %q", e.Rename.From, e.Rename.To)
+		} else {
+			action = "changed the title"
+		}
+	case "closed":
+		action = "closed this"
+		if e.CommitID != "" {
+			action += " in " + shortSHA(e.CommitID)
+		}
+	case "reopened":
+		action = "reopened this"
+	case "referenced":
+		action = "referenced this"
+		if e.CommitID != "" {
+			action += " in commit " + shortSHA(e.CommitID)
+		}
+	case "cross-referenced":
+		action = "mentioned this"
+		if s := e.Source; s != nil {
+			kind := "issue"
+			if s.PullRequest {
+				kind = "pull request"
+			}
+			ref := "#" + strconv.Itoa(s.Number)
+			if s.Repository != "" {
+				ref = s.Repository + ref
+			}
+			action = fmt.Sprintf("mentioned this in %s [%s %s](%s)", kind, ref, s.Title, s.HTMLURL)
+		}
+	default:
+		action = strings.ReplaceAll(e.Event, "_", " ")
+	}
+
+	line := p.userRef(e.Actor) + " " + action
+	if p.options.IncludeTimestamps {
+		line += " · " + formatTime(e.CreatedAt)
+	}
+	return line
+}
+
+// userRef 返回用户的引用形式，开启用户链接时渲染为主页链接
+func (p *MarkdownParser) userRef(u github.User) string {
+	if u.Login == "" {
+		return "@ghost"
+	}
+	if !p.options.IncludeUserLinks {
+		return "@" + u.Login
+	}
+	url := u.HTMLURL
+	if url == "" {
+		url = "https://github.com/" + u.Login
+	}
+	return fmt.Sprintf("[@%s](%s)", u.Login, url)
+}
+
+// body 规范化正文：统一换行符，并保证以换行结尾
+func (p *MarkdownParser) body(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return "*No description provided.*\n"
+	}
+	return s + "\n"
+}
+
+// reactionCount 是单个reaction的统计
+type reactionCount struct {
+	Key   string
+	Emoji string
+	Count int
+}
+
+// reactionCounts 按GitHub界面的顺序列出所有reaction
+func reactionCounts(r github.Reactions) []reactionCount {
+	return []reactionCount{
+		{"thumbs_up", "👍", r.ThumbsUp},
+		{"thumbs_down", "👎", r.ThumbsDown},
+		{"laugh", "😄", r.Laugh},
+		{"hooray", "🎉", r.Hooray},
+		{"confused", "😕", r.Confused},
+		{"heart", "❤️", r.Heart},
+		{"rocket", "🚀", r.Rocket},
+		{"eyes", "👀", r.Eyes},
+	}
+}
+
+// issueStatus 返回Issue状态，例如 "open"、"closed"
+func issueStatus(issue *github.Issue) string {
+	if issue.State == "" {
+		return "open"
+	}
+	return strings.ToLower(issue.State)
+}
+
+// titleCase 将首字母大写，例如 "open" -> "Open"
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// formatTime 以UTC格式化时间
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// shortSHA 返回提交ID的前7位
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// ParseDiscussion 将Discussion渲染为Markdown文档
+// 被采纳的答案紧跟在描述之后单独展示，并在评论列表中标记
+func (p *MarkdownParser) ParseDiscussion(discussion *github.Discussion) (*MarkdownDocument, error) {
+	if discussion == nil {
+		return nil, NewProcessingError("discussion is nil", "INVALID_INPUT", "")
+	}
+
+	status := discussionStatus(discussion)
+	metadata := map[string]string{
+		"title":          discussion.Title,
+		"url":            discussion.HTMLURL,
+		"author":         discussion.User.Login,
+		"created_at":     discussion.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at":     discussion.UpdatedAt.UTC().Format(time.RFC3339),
+		"status":         status,
+		"type":           "discussion",
+		"total_comments": strconv.Itoa(len(discussion.Comments)),
+		"category":       discussion.Category.Name,
+	}
+
+	content, err := p.render(&TemplateData{
+		Type:         "discussion",
+		Number:       discussion.Number,
+		Title:        discussion.Title,
+		URL:          discussion.HTMLURL,
+		Author:       discussion.User,
+		Status:       status,
+		Labels:       labelNames(discussion.Labels),
+		CreatedAt:    discussion.CreatedAt,
+		UpdatedAt:    discussion.UpdatedAt,
+		Body:         discussion.Body,
+		CommentCount: len(discussion.Comments),
+		Answer:       discussion.Answer(),
+		Discussion:   discussion,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MarkdownDocument{
+		Title:    discussion.Title,
+		Content:  content,
+		Metadata: metadata,
+	}, nil
+}
+
+// upvoteLine 返回Discussion评论的赞同数，没有赞同时返回空串
+func (p *MarkdownParser) upvoteLine(count int) string {
+	if !p.options.IncludeReactions || count == 0 {
+		return ""
+	}
+	label := "upvotes"
+	if p.options.EmojisEnabled {
+		label = "⬆️"
+	}
+	return fmt.Sprintf("%s %d", label, count)
+}
+
+// discussionStatus 返回Discussion状态：closed、answered 或 open
+func discussionStatus(d *github.Discussion) string {
+	switch {
+	case d.Closed:
+		return "closed"
+	case d.IsAnswered || d.Answer() != nil:
+		return "answered"
+	default:
+		return "open"
+	}
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+func testDiscussion() *github.Discussion {
+	return &github.Discussion{
+		Number:         543,
+		Title:          "How do I configure the cache?",
+		Body:           "I can't find it.",
+		User:           github.User{Login: "asker"},
+		Category:       github.DiscussionCategory{Name: "Q&A", Emoji: ":pray:", IsAnswerable: true},
+		IsAnswered:     true,
+		AnswerChosenBy: &github.User{Login: "asker"},
+		UpvoteCount:    7,
+		CreatedAt:      day(1),
+		UpdatedAt:      day(5),
+		HTMLURL:        "https://github.com/testowner/testrepo/discussions/543",
+		Comments: []*github.DiscussionComment{
+			{
+				Body: "Have you checked the docs?", User: github.User{Login: "helper"}, CreatedAt: day(2),
+				Replies: []*github.DiscussionComment{
+					{Body: "Yes, nothing there.", User: github.User{Login: "asker"}, CreatedAt: day(2)},
+				},
+			},
+			{Body: "Set `cache_dir`.", User: github.User{Login: "maintainer"}, CreatedAt: day(3), IsAnswer: true, UpvoteCount: 5},
+		},
+	}
+}
+
+func TestMarkdownParserParseDiscussion(t *testing.T) {
+	tests := []struct {
+		name        string
+		discussion  *github.Discussion
+		opts        *Options
+		wantInOrder []string
+		wantAbsent  []string
+	}{
+		{
+			name:       "Answered Discussion",
+			discussion: testDiscussion(),
+			opts:       DefaultOptions(),
+			wantInOrder: []string{
+				`status: "answered"`,
+				`type: "discussion"`,
+				`category: "Q&A"`,
+				"# How do I configure the cache? - Answered",
+				"**分类:** :pray: Q&A",
+				"**赞同数:** 7",
+				"## Description\n\nI can't find it.\n",
+				"## Accepted Answer\n\n[@maintainer](https://github.com/maintainer) answered - 2023-01-03 10:00:00 UTC，由 [@asker](https://github.com/asker) 采纳\n\nSet `cache_dir`.\n",
+				"## Comments (2)",
+				"### [@helper](https://github.com/helper)",
+				"#### ↳ [@asker](https://github.com/asker)",
+				"Yes, nothing there.",
+				"### ✅ [@maintainer](https://github.com/maintainer) - 2023-01-03 10:00:00 UTC [Accepted Answer]",
+				"⬆️ 5",
+			},
+		},
+		{
+			name: "Open Discussion Without Answer",
+			discussion: &github.Discussion{
+				Title:    "An idea",
+				Category: github.DiscussionCategory{Name: "Ideas"},
+			},
+			opts: &Options{IncludeComments: true},
+			wantInOrder: []string{
+				"# An idea - Open",
+				"## Description\n\n*No description provided.*\n",
+			},
+			wantAbsent: []string{"Accepted Answer", "## Comments"},
+		},
+		{
+			name:       "Plain Output",
+			discussion: testDiscussion(),
+			opts:       &Options{IncludeComments: true},
+			wantInOrder: []string{
+				"## Accepted Answer\n\n@maintainer answered，由 @asker 采纳",
+				"### @maintainer [Accepted Answer]",
+			},
+			wantAbsent: []string{"✅", "⬆️", ":pray:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewParser(tt.opts).ParseDiscussion(tt.discussion)
+			if err != nil {
+				t.Fatalf("ParseDiscussion() unexpected error = %v", err)
+			}
+
+			rest := doc.Content
+			for _, want := range tt.wantInOrder {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("ParseDiscussion() output missing %q (or out of order); got:\n%s", want, doc.Content)
+				}
+				rest = rest[i+len(want):]
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(doc.Content, absent) {
+					t.Errorf("ParseDiscussion() output unexpectedly contains %q; got:\n%s", absent, doc.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscussionStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		discussion *github.Discussion
+		want       string
+	}{
+		{name: "Open", discussion: &github.Discussion{}, want: "open"},
+		{name: "Answered", discussion: &github.Discussion{IsAnswered: true}, want: "answered"},
+		{name: "Closed Wins Over Answered", discussion: &github.Discussion{Closed: true, IsAnswered: true}, want: "closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := discussionStatus(tt.discussion); got != tt.want {
+				t.Errorf("discussionStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
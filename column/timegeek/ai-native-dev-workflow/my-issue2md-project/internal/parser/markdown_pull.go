@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// maxHunkLines 是评审讨论串中展示的diff上下文行数(不含hunk头)
+// GitHub返回的diff_hunk从hunk开头一直到被评论的行，可能很长，只保留最后几行
+const maxHunkLines = 8
+
+// ParsePullRequest 将PR渲染为Markdown文档
+// 默认模板包括描述、提交列表、评审结论、按文件和行号分组的代码评审讨论串、普通评论与时间线，
+// 以及可选的完整diff
+func (p *MarkdownParser) ParsePullRequest(pr *github.PullRequest, comments []*github.Comment, events []*github.TimelineEvent) (*MarkdownDocument, error) {
+	if pr == nil {
+		return nil, NewProcessingError("pull request is nil", "INVALID_INPUT", "")
+	}
+
+	status := pullRequestStatus(pr)
+	metadata := map[string]string{
+		"title":          pr.Title,
+		"url":            pr.HTMLURL,
+		"author":         pr.User.Login,
+		"created_at":     pr.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at":     pr.UpdatedAt.UTC().Format(time.RFC3339),
+		"status":         status,
+		"type":           "pr",
+		"total_comments": strconv.Itoa(len(comments)),
+		"head":           pr.HeadRef,
+		"base":           pr.BaseRef,
+	}
+
+	content, err := p.render(&TemplateData{
+		Type:         "pr",
+		Number:       pr.Number,
+		Title:        pr.Title,
+		URL:          pr.HTMLURL,
+		Author:       pr.User,
+		Status:       status,
+		Labels:       labelNames(pr.Labels),
+		CreatedAt:    pr.CreatedAt,
+		UpdatedAt:    pr.UpdatedAt,
+		Body:         pr.Body,
+		CommentCount: len(comments),
+		Timeline:     p.buildTimeline(comments, events),
+		PullRequest:  pr,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MarkdownDocument{
+		Title:    pr.Title,
+		Content:  content,
+		Metadata: metadata,
+	}, nil
+}
+
+// visibleReviews 返回需要展示的评审结论
+// 没有正文的COMMENTED评审只是代码行评论的容器，不单独列出
+func visibleReviews(reviews []*github.Review) []*github.Review {
+	var shown []*github.Review
+	for _, r := range reviews {
+		if r.State == "COMMENTED" && strings.TrimSpace(r.Body) == "" {
+			continue
+		}
+		if r.State == "PENDING" {
+			continue
+		}
+		shown = append(shown, r)
+	}
+	return shown
+}
+
+// threadHeading 返回评审讨论串的标题，例如 "Line 12"、"Lines 3-5 (outdated)"
+func threadHeading(t *github.ReviewThread) string {
+	heading := "File"
+	if t.Line > 0 {
+		heading = "Line " + strconv.Itoa(t.Line)
+		if len(t.Comments) > 0 {
+			if start := t.Comments[0].StartLine; start > 0 && start < t.Line {
+				heading = fmt.Sprintf("Lines %d-%d", start, t.Line)
+			}
+		}
+	}
+	if t.Outdated {
+		heading += " (outdated)"
+	}
+	return heading
+}
+
+// reviewMarker 返回评审状态的标记
+func (p *MarkdownParser) reviewMarker(state string) string {
+	if !p.options.EmojisEnabled {
+		return "[" + state + "]"
+	}
+	switch state {
+	case "APPROVED":
+		return "✅"
+	case "CHANGES_REQUESTED":
+		return "❌"
+	case "DISMISSED":
+		return "🚫"
+	default:
+		return "💬"
+	}
+}
+
+// reviewVerb 把评审状态描述为动词短语
+func reviewVerb(state string) string {
+	switch state {
+	case "APPROVED":
+		return "approved these changes"
+	case "CHANGES_REQUESTED":
+		return "requested changes"
+	case "DISMISSED":
+		return "reviewed (dismissed)"
+	default:
+		return "reviewed"
+	}
+}
+
+// pullRequestStatus 返回PR状态：merged、closed、draft 或 open
+func pullRequestStatus(pr *github.PullRequest) string {
+	switch {
+	case pr.Merged:
+		return "merged"
+	case strings.EqualFold(pr.State, "closed"):
+		return "closed"
+	case pr.Draft:
+		return "draft"
+	default:
+		return "open"
+	}
+}
+
+// trimHunk 保留diff hunk的头部(@@ 行)和最后n行
+func trimHunk(hunk string, n int) string {
+	lines := strings.Split(strings.TrimRight(hunk, "\n"), "\n")
+	if len(lines) <= n+1 {
+		return strings.Join(lines, "\n")
+	}
+	header := lines[0]
+	if !strings.HasPrefix(header, "@@") {
+		return strings.Join(lines[len(lines)-n:], "\n")
+	}
+	return header + "\n" + strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// fenced 返回代码块，围栏长度超过内容中最长的连续反引号，避免内容提前结束代码块
+func fenced(lang, content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fmt.Sprintf("%s%s\n%s\n%s", fence, lang, strings.TrimRight(content, "\n"), fence)
+}
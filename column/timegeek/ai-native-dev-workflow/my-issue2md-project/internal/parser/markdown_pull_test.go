@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+func testPullRequest() *github.PullRequest {
+	mergedAt := day(6)
+	return &github.PullRequest{
+		Number:       42,
+		Title:        "Add feature",
+		Body:         "This PR adds a feature.",
+		State:        "closed",
+		Merged:       true,
+		MergedAt:     &mergedAt,
+		User:         github.User{Login: "author"},
+		HeadRef:      "author:feature",
+		BaseRef:      "main",
+		Additions:    10,
+		Deletions:    2,
+		ChangedFiles: 3,
+		CreatedAt:    day(1),
+		UpdatedAt:    day(6),
+		HTMLURL:      "https://github.com/testowner/testrepo/pull/42",
+		Commits: []*github.Commit{
+			{SHA: "1111111aaaaaaa", Message: "Add feature\n\nLonger description", Author: &github.User{Login: "author"}},
+			{SHA: "2222222bbbbbbb", Message: "Fix review comments", AuthorName: "Unlinked Author"},
+		},
+		Reviews: []*github.Review{
+			{User: github.User{Login: "reviewer"}, State: "CHANGES_REQUESTED", Body: "Please fix.", SubmittedAt: day(2)},
+			{User: github.User{Login: "reviewer"}, State: "COMMENTED", SubmittedAt: day(3)},
+			{User: github.User{Login: "reviewer"}, State: "APPROVED", SubmittedAt: day(5)},
+		},
+		ReviewComments: []*github.ReviewComment{
+			{ID: 1, User: github.User{Login: "reviewer"}, Body: "Handle the error here.", Path: "main.go", Line: 12,
+				DiffHunk: "@@ -1,10 +1,11 @@\n l1\n l2\n l3\n l4\n l5\n l6\n l7\n l8\n l9\n+\tg(x)", CreatedAt: day(2)},
+			{ID: 2, InReplyTo: 1, User: github.User{Login: "author"}, Body: "Done.", Path: "main.go", Line: 12, CreatedAt: day(3)},
+			{ID: 3, User: github.User{Login: "reviewer"}, Body: "Old remark.", Path: "a.go", Line: 3, Outdated: true,
+				DiffHunk: "@@ -1,2 +1,3 @@\n+```go", CreatedAt: day(2)},
+		},
+		Diff: "diff --git a/main.go b/main.go\n+\tg(x)\n",
+	}
+}
+
+func TestMarkdownParserParsePullRequest(t *testing.T) {
+	comments := []*github.Comment{
+		{ID: 10, Body: "LGTM overall", User: github.User{Login: "carol"}, CreatedAt: day(4)},
+	}
+	events := []*github.TimelineEvent{
+		{Event: "closed", Actor: github.User{Login: "maintainer"}, CreatedAt: day(6)},
+	}
+
+	tests := []struct {
+		name        string
+		opts        *Options
+		wantInOrder []string
+		wantAbsent  []string
+	}{
+		{
+			name: "Default Options",
+			opts: DefaultOptions(),
+			wantInOrder: []string{
+				`status: "merged"`,
+				`type: "pr"`,
+				`head: "author:feature"`,
+				"# Add feature - Merged",
+				"**分支:** `author:feature` → `main`",
+				"**变更:** +10 −2，3 个文件",
+				"**合并时间:** 2023-01-06 10:00:00 UTC",
+				"## Description\n\nThis PR adds a feature.\n",
+				"## Commits (2)",
+				"- `1111111` Add feature — [@author](https://github.com/author)",
+				"- `2222222` Fix review comments — Unlinked Author",
+				"## Reviews (2)",
+				"### ❌ [@reviewer](https://github.com/reviewer) requested changes",
+				"Please fix.",
+				"### ✅ [@reviewer](https://github.com/reviewer) approved these changes",
+				"## Review Comments (2)",
+				"### `a.go`",
+				"#### Line 3 (outdated)",
+				"````diff\n@@ -1,2 +1,3 @@\n+```go\n````",
+				"### `main.go`",
+				"#### Line 12",
+				"```diff\n@@ -1,10 +1,11 @@\n l3\n",
+				"Handle the error here.",
+				"Done.",
+				"## Comments (1)",
+				"LGTM overall",
+				"closed this",
+			},
+			wantAbsent: []string{"Longer description", "## Diff", " l2\n"},
+		},
+		{
+			name: "With Diff",
+			opts: &Options{IncludeDiff: true},
+			wantInOrder: []string{
+				"## Commits (2)",
+				"## Reviews (2)",
+				"## Diff\n\n```diff\ndiff --git a/main.go b/main.go\n+\tg(x)\n```\n",
+			},
+			wantAbsent: []string{"## Review Comments", "## Comments", "✅"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewParser(tt.opts).ParsePullRequest(testPullRequest(), comments, events)
+			if err != nil {
+				t.Fatalf("ParsePullRequest() unexpected error = %v", err)
+			}
+
+			rest := doc.Content
+			for _, want := range tt.wantInOrder {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("ParsePullRequest() output missing %q (or out of order); got:\n%s", want, doc.Content)
+				}
+				rest = rest[i+len(want):]
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(doc.Content, absent) {
+					t.Errorf("ParsePullRequest() output unexpectedly contains %q; got:\n%s", absent, doc.Content)
+				}
+			}
+			if doc.Metadata["type"] != "pr" {
+				t.Errorf("ParsePullRequest().Metadata[type] = %v, want pr", doc.Metadata["type"])
+			}
+		})
+	}
+}
+
+func TestPullRequestStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		pr   *github.PullRequest
+		want string
+	}{
+		{name: "Open", pr: &github.PullRequest{State: "open"}, want: "open"},
+		{name: "Draft", pr: &github.PullRequest{State: "open", Draft: true}, want: "draft"},
+		{name: "Closed", pr: &github.PullRequest{State: "closed"}, want: "closed"},
+		{name: "Merged", pr: &github.PullRequest{State: "closed", Merged: true}, want: "merged"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pullRequestStatus(tt.pr); got != tt.want {
+				t.Errorf("pullRequestStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePullRequestNil(t *testing.T) {
+	if _, err := NewParser(nil).ParsePullRequest(nil, nil, nil); err == nil {
+		t.Error("ParsePullRequest(nil) expected error, but got nil")
+	}
+}
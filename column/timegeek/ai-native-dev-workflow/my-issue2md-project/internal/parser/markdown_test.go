@@ -0,0 +1,194 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// day 返回2023年1月的某一天的10:00 UTC
+func day(d int) time.Time {
+	return time.Date(2023, 1, d, 10, 0, 0, 0, time.UTC)
+}
+
+func testIssue() *github.Issue {
+	return &github.Issue{
+		Number:    123,
+		Title:     "Test Issue Title",
+		Body:      "Issue body\r\nsecond line",
+		State:     "closed",
+		User:      github.User{Login: "testuser", HTMLURL: "https://github.com/testuser"},
+		CreatedAt: day(1),
+		UpdatedAt: day(5),
+		HTMLURL:   "https://github.com/testowner/testrepo/issues/123",
+		Reactions: github.Reactions{TotalCount: 3, ThumbsUp: 2, Heart: 1},
+	}
+}
+
+func testComments() []*github.Comment {
+	return []*github.Comment{
+		{ID: 1, Body: "First comment", User: github.User{Login: "alice"}, CreatedAt: day(2)},
+		{ID: 2, Body: "Second comment", User: github.User{Login: "bob"}, CreatedAt: day(4),
+			Reactions: github.Reactions{TotalCount: 1, Rocket: 1}},
+	}
+}
+
+func testEvents() []*github.TimelineEvent {
+	return []*github.TimelineEvent{
+		{Event: "labeled", Actor: github.User{Login: "maintainer"}, CreatedAt: day(3), Label: &github.Label{Name: "bug"}},
+		{Event: "cross-referenced", Actor: github.User{Login: "carol"}, CreatedAt: day(3).Add(time.Hour),
+			Source: &github.CrossReference{Number: 456, Title: "Fix it", HTMLURL: "https://github.com/testowner/testrepo/pull/456", Repository: "testowner/testrepo", PullRequest: true}},
+		{Event: "closed", Actor: github.User{Login: "maintainer"}, CreatedAt: day(5), CommitID: "0123456789abcdef"},
+	}
+}
+
+func TestMarkdownParserParse(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *Options
+		// wantInOrder 必须按顺序出现在输出中
+		wantInOrder []string
+		wantAbsent  []string
+	}{
+		{
+			name: "Default Options Interleave Comments And Events",
+			opts: DefaultOptions(),
+			wantInOrder: []string{
+				"---\ntitle: \"Test Issue Title\"",
+				"reaction_counts:\n  thumbs_up: 2\n",
+				"total_comments: 2\n---",
+				"# Test Issue Title - Closed",
+				"**作者:** [@testuser](https://github.com/testuser)",
+				"## Description\n\nIssue body\nsecond line\n",
+				"👍 2 · ❤️ 1",
+				"## Comments (2)",
+				"### [@alice](https://github.com/alice) - 2023-01-02 10:00:00 UTC",
+				"> [@maintainer](https://github.com/maintainer) added the `bug` label · 2023-01-03 10:00:00 UTC",
+				"mentioned this in pull request [testowner/testrepo#456 Fix it](https://github.com/testowner/testrepo/pull/456)",
+				"### [@bob](https://github.com/bob)",
+				"🚀 1",
+				"closed this in 0123456",
+			},
+		},
+		{
+			name: "Plain Output Without Optional Parts",
+			opts: &Options{IncludeComments: true},
+			wantInOrder: []string{
+				"# Test Issue Title - Closed",
+				"## Comments (2)",
+				"### @alice\n",
+				"### @bob\n",
+			},
+			wantAbsent: []string{"---", "**作者:**", "👍", "labeled", "2023-01-02"},
+		},
+		{
+			name: "Reactions Without Emojis",
+			opts: &Options{IncludeReactions: true},
+			wantInOrder: []string{
+				":thumbs_up: 2 · :heart: 1",
+			},
+			wantAbsent: []string{"## Comments", "👍"},
+		},
+		{
+			name: "Events Only",
+			opts: &Options{IncludeEvents: true},
+			wantInOrder: []string{
+				"## Timeline",
+				"> @maintainer added the `bug` label",
+				"> @maintainer closed this in 0123456",
+			},
+			wantAbsent: []string{"First comment"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewParser(tt.opts).Parse(testIssue(), testComments(), testEvents())
+			if err != nil {
+				t.Fatalf("Parse() unexpected error = %v", err)
+			}
+
+			rest := doc.Content
+			for _, want := range tt.wantInOrder {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("Parse() output missing %q (or out of order); got:\n%s", want, doc.Content)
+				}
+				rest = rest[i+len(want):]
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(doc.Content, absent) {
+					t.Errorf("Parse() output unexpectedly contains %q; got:\n%s", absent, doc.Content)
+				}
+			}
+
+			if doc.Title != "Test Issue Title" {
+				t.Errorf("Parse().Title = %v, want %v", doc.Title, "Test Issue Title")
+			}
+			if doc.Metadata["total_comments"] != "2" {
+				t.Errorf("Parse().Metadata[total_comments] = %v, want 2", doc.Metadata["total_comments"])
+			}
+		})
+	}
+}
+
+func TestMarkdownParserParseNilIssue(t *testing.T) {
+	if _, err := NewParser(nil).Parse(nil, nil, nil); err == nil {
+		t.Error("Parse(nil) expected error, but got nil")
+	}
+}
+
+func TestDescribeEvent(t *testing.T) {
+	p := NewParser(&Options{})
+	tests := []struct {
+		name  string
+		event *github.TimelineEvent
+		want  string
+	}{
+		{
+			name:  "Unlabeled",
+			event: &github.TimelineEvent{Event: "unlabeled", Actor: github.User{Login: "a"}, Label: &github.Label{Name: "wontfix"}},
+			want:  "@a removed the `wontfix` label",
+		},
+		{
+			name:  "Self Assigned",
+			event: &github.TimelineEvent{Event: "assigned", Actor: github.User{Login: "a"}, Assignee: &github.User{Login: "a"}},
+			want:  "@a self-assigned this",
+		},
+		{
+			name:  "Assigned Other",
+			event: &github.TimelineEvent{Event: "assigned", Actor: github.User{Login: "a"}, Assignee: &github.User{Login: "b"}},
+			want:  "@a assigned @b",
+		},
+		{
+			name:  "Renamed",
+			event: &github.TimelineEvent{Event: "renamed", Actor: github.User{Login: "a"}, Rename: &github.Rename{From: "x", To: "y"}},
+			want:  `@a changed the title from "x" to "y"`,
+		},
+		{
+			name:  "Milestoned",
+			event: &github.TimelineEvent{Event: "milestoned", Actor: github.User{Login: "a"}, Milestone: "v1.0"},
+			want:  "@a added this to the `v1.0` milestone",
+		},
+		{
+			name:  "Unknown Event",
+			event: &github.TimelineEvent{Event: "marked_as_duplicate", Actor: github.User{Login: "a"}},
+			want:  "@a marked as duplicate",
+		},
+		{
+			name:  "Ghost Actor",
+			event: &github.TimelineEvent{Event: "reopened"},
+			want:  "@ghost reopened this",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.describeEvent(tt.event); got != tt.want {
+				t.Errorf("describeEvent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
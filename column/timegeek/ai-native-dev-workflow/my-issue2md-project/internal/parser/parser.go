@@ -6,13 +6,15 @@ import (
 	"strings"
 )
 
-// ResourceURL 表示解析后的GitHub资源URL
+// ResourceURL 表示解析后的资源URL
 type ResourceURL struct {
-	Type   string // "issue", "pull", "discussion"
-	Owner  string
-	Repo   string
-	Number int
-	URL    string // 原始URL
+	Type    string // "issue", "pull", "discussion"；GitLab的Merge Request也是 "pull"
+	Owner   string // GitLab中为群组路径，可以包含多级，例如 "group/subgroup"
+	Repo    string
+	Number  int
+	URL     string // 原始URL
+	Forge   string // 所在平台，见 ForgeGitHub 等常量
+	BaseURL string // 平台实例地址，例如 https://gitlab.example.com
 }
 
 // URLParser 定义URL解析接口
@@ -67,11 +69,13 @@ func (p *GitHubURLParser) Parse(rawURL string) (*ResourceURL, error) {
 
 	// 构造返回结果
 	return &ResourceURL{
-		Type:   resultType,
-		Owner:  owner,
-		Repo:   repo,
-		Number: number,
-		URL:    rawURL,
+		Type:    resultType,
+		Owner:   owner,
+		Repo:    repo,
+		Number:  number,
+		URL:     rawURL,
+		Forge:   ForgeGitHub,
+		BaseURL: "https://github.com",
 	}, nil
 }
 
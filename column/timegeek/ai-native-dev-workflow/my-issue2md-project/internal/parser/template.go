@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+// DefaultTemplate 是默认内置模板的名称
+const DefaultTemplate = "default"
+
+// templateFS 包含内置模板
+// *.md.tmpl 是可以通过名称选择的布局，其余 *.tmpl 只定义布局共用的片段
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// TemplateData 是传给输出模板的数据
+// Issue、PR和Discussion共用这些字段，类型特有的内容通过 Issue、PullRequest、Discussion 访问
+type TemplateData struct {
+	Type         string      // issue, pr, discussion
+	Number       int         // 编号
+	Title        string      // 标题
+	URL          string      // 网页地址
+	Author       github.User // 作者
+	Status       string      // open, closed；PR还有 merged、draft，Discussion还有 answered
+	Labels       []string    // 标签名
+	CreatedAt    time.Time   // 创建时间
+	UpdatedAt    time.Time   // 最后更新时间
+	Body         string      // 正文原文
+	CommentCount int         // 评论总数
+
+	// Reactions 是正文的reactions统计，只有Issue有
+	Reactions *github.Reactions
+
+	// Timeline 是按时间排序的评论与时间线事件，只包含选项开启的部分，Discussion没有时间线
+	Timeline []TimelineEntry
+
+	// Answer 是Discussion被采纳的答案，没有时为nil
+	Answer *github.DiscussionComment
+
+	// 原始数据，与Type对应的一项不为nil
+	Issue       *github.Issue
+	PullRequest *github.PullRequest
+	Discussion  *github.Discussion
+
+	// Metadata 与 MarkdownDocument.Metadata 相同
+	Metadata map[string]string
+	Options  *Options
+}
+
+// TimelineEntry 是时间线上的一项，Comment和Event只有一个不为nil
+type TimelineEntry struct {
+	CreatedAt time.Time
+	Comment   *github.Comment
+	Event     *github.TimelineEvent
+}
+
+// BuiltinTemplates 返回内置模板的名称
+func BuiltinTemplates() []string {
+	names, _ := fs.Glob(templateFS, "templates/*.md.tmpl")
+	for i, name := range names {
+		names[i] = strings.TrimSuffix(path.Base(name), ".md.tmpl")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuiltinTemplate 返回内置模板的内容
+func BuiltinTemplate(name string) (string, bool) {
+	data, err := templateFS.ReadFile("templates/" + name + ".md.tmpl")
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// ValidateTemplate 检查模板能否解析，用于在获取数据之前发现模板错误
+func ValidateTemplate(text string) error {
+	_, err := NewParser(&Options{Template: text}).template()
+	return err
+}
+
+// template 解析内置片段和要执行的模板，返回的模板以布局为入口
+func (p *MarkdownParser) template() (*template.Template, error) {
+	text := p.options.Template
+	if text == "" {
+		text, _ = BuiltinTemplate(DefaultTemplate)
+	}
+
+	tmpl, err := template.New("partials").Funcs(p.templateFuncs()).ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse builtin templates: %w", err)
+	}
+	tmpl, err = tmpl.New("layout").Parse(text)
+	if err != nil {
+		return nil, NewProcessingError("invalid template", "INVALID_TEMPLATE", err.Error())
+	}
+	return tmpl, nil
+}
+
+// render 执行模板
+func (p *MarkdownParser) render(data *TemplateData) (string, error) {
+	tmpl, err := p.template()
+	if err != nil {
+		return "", err
+	}
+	data.Options = p.options
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", NewProcessingError("failed to render template", "TEMPLATE_ERROR", err.Error())
+	}
+	return buf.String(), nil
+}
+
+// templateFuncs 返回模板可用的辅助函数，与渲染选项相关的函数遵循解析器选项
+func (p *MarkdownParser) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// 时间
+		"date":    formatDate,
+		"rfc3339": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+
+		// 用户与文本
+		"user":      p.userRef,
+		"body":      p.body,
+		"quote":     quote,
+		"quoted":    strconv.Quote,
+		"title":     titleCase,
+		"firstLine": firstLine,
+		"trim":      strings.TrimSpace,
+		"join":      strings.Join,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"fence":     fenced,
+
+		// reactions与时间线
+		"reactions":      p.reactionLine,
+		"reactionCounts": reactionCounts,
+		"upvotes":        p.upvoteLine,
+		"event":          p.describeEvent,
+
+		// PR
+		"sha":            shortSHA,
+		"hunk":           func(s string) string { return trimHunk(s, maxHunkLines) },
+		"visibleReviews": visibleReviews,
+		"reviewMarker":   p.reviewMarker,
+		"reviewVerb":     reviewVerb,
+		"reviewThreads":  github.GroupReviewThreads,
+		"threadHeading":  threadHeading,
+	}
+}
+
+// buildTimeline 按选项收集评论与事件，并按时间先后排序，时间相同时评论在前
+func (p *MarkdownParser) buildTimeline(comments []*github.Comment, events []*github.TimelineEvent) []TimelineEntry {
+	var items []TimelineEntry
+	if p.options.IncludeComments {
+		for _, c := range comments {
+			if c != nil {
+				items = append(items, TimelineEntry{CreatedAt: c.CreatedAt, Comment: c})
+			}
+		}
+	}
+	if p.options.IncludeEvents {
+		for _, e := range events {
+			if e != nil {
+				items = append(items, TimelineEntry{CreatedAt: e.CreatedAt, Event: e})
+			}
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items
+}
+
+// labelNames 返回标签名列表
+func labelNames(labels []github.Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// formatDate 以UTC格式化时间，可以指定Go时间格式，默认为 timeLayout
+func formatDate(t time.Time, layout ...string) string {
+	if len(layout) > 0 {
+		return t.UTC().Format(layout[0])
+	}
+	return formatTime(t)
+}
+
+// quote 把文本转换为Markdown引用块，每行加上 "> " 前缀
+func quote(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// firstLine 返回文本的第一行，例如提交消息的标题
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bigwhite/my-issue2md/internal/github"
+)
+
+func TestBuiltinTemplates(t *testing.T) {
+	names := BuiltinTemplates()
+	if strings.Join(names, ",") != "compact,default" {
+		t.Errorf("BuiltinTemplates() = %v, want [compact default]", names)
+	}
+	for _, name := range names {
+		text, ok := BuiltinTemplate(name)
+		if !ok || text == "" {
+			t.Errorf("BuiltinTemplate(%q) not found", name)
+		}
+		if err := ValidateTemplate(text); err != nil {
+			t.Errorf("ValidateTemplate(%q) error = %v", name, err)
+		}
+	}
+	if _, ok := BuiltinTemplate("partials"); ok {
+		t.Error("partials should not be selectable as a template")
+	}
+}
+
+func TestMarkdownParserCustomTemplate(t *testing.T) {
+	issue := testIssue()
+	issue.Labels = []github.Label{{Name: "bug"}, {Name: "ui"}}
+
+	tests := []struct {
+		name     string
+		template string
+		opts     func(*Options)
+		want     string
+	}{
+		{
+			name:     "Fields And Helpers",
+			template: `{{.Type}} #{{.Number}} {{.Title}} by {{user .Author}} on {{date .CreatedAt "2006-01-02"}} [{{join .Labels ", "}}] {{.CommentCount}}`,
+			want:     "issue #123 Test Issue Title by [@testuser](https://github.com/testuser) on 2023-01-01 [bug, ui] 2",
+		},
+		{
+			name:     "User Links Disabled",
+			template: `{{user .Author}}`,
+			opts:     func(o *Options) { o.IncludeUserLinks = false },
+			want:     "@testuser",
+		},
+		{
+			name:     "Quote And Body",
+			template: `{{quote (body .Body)}}`,
+			want:     "> Issue body\n> second line\n",
+		},
+		{
+			name:     "Timeline Entries",
+			template: `{{range .Timeline}}{{if .Comment}}c:{{.Comment.User.Login}} {{else}}e:{{.Event.Event}} {{end}}{{end}}`,
+			want:     "c:alice e:labeled e:cross-referenced c:bob e:closed ",
+		},
+		{
+			name:     "Events Disabled",
+			template: `{{range .Timeline}}{{.Comment.User.Login}} {{end}}`,
+			opts:     func(o *Options) { o.IncludeEvents = false },
+			want:     "alice bob ",
+		},
+		{
+			name:     "Builtin Partial",
+			template: `{{template "frontmatter" .}}{{.Metadata.status}}`,
+			want:     "total_comments: 2\n---\n\nclosed",
+		},
+		{
+			name:     "Event Description",
+			template: `{{range .Timeline}}{{with .Event}}{{event .}}{{"\n"}}{{end}}{{end}}`,
+			opts:     func(o *Options) { o.IncludeTimestamps = false; o.IncludeUserLinks = false },
+			want:     "@maintainer added the `bug` label\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			if tt.opts != nil {
+				tt.opts(opts)
+			}
+			opts.Template = tt.template
+			doc, err := NewParser(opts).Parse(issue, testComments(), testEvents())
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !strings.Contains(doc.Content, tt.want) {
+				t.Errorf("Content = %q, want it to contain %q", doc.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownParserCompactTemplate(t *testing.T) {
+	text, _ := BuiltinTemplate("compact")
+	opts := DefaultOptions()
+	opts.Template = text
+	p := NewParser(opts)
+
+	doc, err := p.Parse(testIssue(), testComments(), testEvents())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := "# Test Issue Title\n\n- Type: issue #123\n- Status: closed\n- Author: @testuser\n- Created: 2023-01-01\n" +
+		"- URL: https://github.com/testowner/testrepo/issues/123\n\nIssue body\nsecond line\n\n" +
+		"## @alice (2023-01-02)\n\nFirst comment\n\n## @bob (2023-01-04)\n\nSecond comment\n"
+	if doc.Content != want {
+		t.Errorf("Content =\n%s\nwant\n%s", doc.Content, want)
+	}
+
+	doc, err = p.ParseDiscussion(testDiscussion())
+	if err != nil {
+		t.Fatalf("ParseDiscussion() error = %v", err)
+	}
+	rest := doc.Content
+	for _, want := range []string{
+		"# How do I configure the cache?",
+		"- Status: answered",
+		"## Accepted answer by @maintainer\n\nSet `cache_dir`.\n",
+		"## @helper (2023-01-02)\n\nHave you checked the docs?\n\n> @asker: Yes, nothing there.\n",
+	} {
+		i := strings.Index(rest, want)
+		if i < 0 {
+			t.Fatalf("ParseDiscussion() output missing %q (or out of order); got:\n%s", want, doc.Content)
+		}
+		rest = rest[i+len(want):]
+	}
+}
+
+func TestMarkdownParserTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantCode string
+	}{
+		{name: "Syntax Error", template: `{{if .Title}}`, wantCode: "INVALID_TEMPLATE"},
+		{name: "Unknown Function", template: `{{nope .Title}}`, wantCode: "INVALID_TEMPLATE"},
+		{name: "Unknown Field", template: `{{.Nope}}`, wantCode: "TEMPLATE_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Template = tt.template
+			_, err := NewParser(opts).Parse(testIssue(), nil, nil)
+			var perr *ProcessingError
+			if !errors.As(err, &perr) || perr.Code != tt.wantCode {
+				t.Errorf("Parse() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+
+	if err := ValidateTemplate(`{{end}}`); err == nil {
+		t.Error("ValidateTemplate() should reject an invalid template")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "one", want: "> one\n"},
+		{in: "one\n\ntwo\n", want: "> one\n>\n> two\n"},
+	}
+	for _, tt := range tests {
+		if got := quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 
 // Parser 定义Markdown解析器接口
 type Parser interface {
-	Parse(issue *github.Issue, comments []*github.Comment) (*MarkdownDocument, error)
+	Parse(issue *github.Issue, comments []*github.Comment, events []*github.TimelineEvent) (*MarkdownDocument, error)
+	ParsePullRequest(pr *github.PullRequest, comments []*github.Comment, events []*github.TimelineEvent) (*MarkdownDocument, error)
+	ParseDiscussion(discussion *github.Discussion) (*MarkdownDocument, error)
 }
 
 // MarkdownParser Markdown解析器实现
@@ -17,17 +19,26 @@ type MarkdownParser struct {
 // Options 解析器配置选项
 type Options struct {
 	IncludeComments    bool `json:"include_comments"`
+	IncludeEvents      bool `json:"include_events"`    // 标签变更、关闭、交叉引用等时间线事件
+	IncludeReactions   bool `json:"include_reactions"` // 正文与评论的reactions统计
+	IncludeDiff        bool `json:"include_diff"`      // PR的完整unified diff
 	IncludeMetadata    bool `json:"include_metadata"`
 	IncludeTimestamps  bool `json:"include_timestamps"`
 	IncludeUserLinks   bool `json:"include_user_links"`
 	EmojisEnabled      bool `json:"emojis_enabled"`
 	PreserveLineBreaks bool `json:"preserve_line_breaks"`
+
+	// Template 是自定义输出模板(text/template)，为空时使用内置的默认模板
+	// 模板数据见 TemplateData，可以调用内置片段，例如 {{template "frontmatter" .}}
+	Template string `json:"template,omitempty"`
 }
 
 // DefaultOptions 返回默认解析器选项
 func DefaultOptions() *Options {
 	return &Options{
 		IncludeComments:    true,
+		IncludeEvents:      true,
+		IncludeReactions:   true,
 		IncludeMetadata:    true,
 		IncludeTimestamps:  true,
 		IncludeUserLinks:   true,
@@ -0,0 +1,107 @@
+// Package rest 是GitLab、Gitea等平台客户端共用的最小化REST API客户端
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client 发送GET请求并解码JSON响应
+type Client struct {
+	httpClient *http.Client
+	baseURL    string      // API根地址，例如 https://gitlab.com/api/v4
+	header     http.Header // 每个请求都附带的请求头，例如认证信息
+}
+
+// NewClient 创建REST客户端，httpClient为nil时使用http.DefaultClient
+func NewClient(httpClient *http.Client, baseURL string, header http.Header) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		header:     header,
+	}
+}
+
+// APIError 是非2xx响应
+type APIError struct {
+	StatusCode int
+	Status     string
+	Message    string // 响应体的前一部分，通常是平台返回的错误信息
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return e.Status
+	}
+	return e.Status + ": " + e.Message
+}
+
+// GetJSON 请求path，把JSON响应解码到v，返回响应头用于分页
+// path需要自行转义，例如GitLab项目路径中的斜杠需要编码为%2F
+func (c *Client) GetJSON(ctx context.Context, path string, query url.Values, v any) (http.Header, error) {
+	resp, err := c.get(ctx, path, query, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+	return resp.Header, nil
+}
+
+// GetRaw 请求path并返回响应体原文，例如diff
+func (c *Client) GetRaw(ctx context.Context, path string, query url.Values) (string, error) {
+	resp, err := c.get(ctx, path, query, "text/plain")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response of %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// get 发送请求，非2xx响应返回APIError
+func (c *Client) get(ctx context.Context, path string, query url.Values, accept string) (*http.Response, error) {
+	rawURL := c.baseURL + path
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %s failed: %w", path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Message:    strings.TrimSpace(string(body)),
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Token"); got != "secret" {
+			t.Errorf("X-Token = %q, want secret", got)
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/projects/a%2Fb":
+			if r.URL.Query().Get("page") != "2" {
+				t.Errorf("query = %s, want page=2", r.URL.RawQuery)
+			}
+			w.Header().Set("X-Next-Page", "3")
+			w.Write([]byte(`{"name":"b"}`))
+		case "/api/raw.diff":
+			w.Write([]byte("diff --git"))
+		default:
+			http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("X-Token", "secret")
+	client := NewClient(server.Client(), server.URL+"/api/", header)
+	ctx := context.Background()
+
+	var project struct{ Name string }
+	respHeader, err := client.GetJSON(ctx, "/projects/"+url.PathEscape("a/b"), url.Values{"page": {"2"}}, &project)
+	if err != nil {
+		t.Fatalf("GetJSON() error = %v", err)
+	}
+	if project.Name != "b" || respHeader.Get("X-Next-Page") != "3" {
+		t.Errorf("GetJSON() = %+v, X-Next-Page = %q", project, respHeader.Get("X-Next-Page"))
+	}
+
+	raw, err := client.GetRaw(ctx, "/raw.diff", nil)
+	if err != nil || raw != "diff --git" {
+		t.Errorf("GetRaw() = %q, %v", raw, err)
+	}
+
+	_, err = client.GetJSON(ctx, "/missing", nil, &project)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != `{"message":"404 Not found"}` {
+		t.Errorf("GetJSON() error = %v, want a 404 APIError", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Package service 组装数据源、解析器和转换器，把资源URL渲染为文档，CLI和Web服务共用
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/converter"
+	"github.com/bigwhite/my-issue2md/internal/gitea"
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/gitlab"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+// NewSource 按资源所在平台创建数据源
+func NewSource(res *parser.ResourceURL, cfg *config.Config) github.IssueSource {
+	switch res.Forge {
+	case parser.ForgeGitLab:
+		return gitlab.NewClient(res.BaseURL, cfg.GitLabToken)
+	case parser.ForgeGitea:
+		return gitea.NewClient(res.BaseURL, cfg.GiteaToken)
+	default:
+		return github.NewClient(cfg.GitHubToken)
+	}
+}
+
+// FetchDocument 获取资源并渲染为Markdown文档，Issue和PR可以来自任意平台，Discussion只有GitHub支持
+func FetchDocument(ctx context.Context, source github.IssueSource, p *parser.MarkdownParser, res *parser.ResourceURL, cfg *config.Config) (*parser.MarkdownDocument, error) {
+	if res.Type == "discussion" {
+		discussions, ok := source.(github.DiscussionSource)
+		if !ok {
+			return nil, fmt.Errorf("discussions are not supported on %s", res.BaseURL)
+		}
+		discussion, err := discussions.GetDiscussion(ctx, res.Owner, res.Repo, res.Number)
+		if err != nil {
+			return nil, err
+		}
+		return p.ParseDiscussion(discussion)
+	}
+
+	// GitLab的Issue和Merge Request编号互相独立，因此评论与时间线需要区分资源类型
+	pull := res.Type == "pull"
+	var comments []*github.Comment
+	var events []*github.TimelineEvent
+	var err error
+	if cfg.Parser.IncludeComments {
+		if comments, err = source.ListComments(ctx, res.Owner, res.Repo, res.Number, pull); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Parser.IncludeEvents {
+		if events, err = source.ListEvents(ctx, res.Owner, res.Repo, res.Number, pull); err != nil {
+			return nil, err
+		}
+	}
+
+	if pull {
+		pr, err := source.GetPullRequest(ctx, res.Owner, res.Repo, res.Number, &github.PullRequestOptions{
+			IncludeDiff: cfg.Parser.IncludeDiff,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return p.ParsePullRequest(pr, comments, events)
+	}
+
+	issue, err := source.GetIssue(ctx, res.Owner, res.Repo, res.Number)
+	if err != nil {
+		return nil, err
+	}
+	return p.Parse(issue, comments, events)
+}
+
+// UpdatedAt 返回资源的最后更新时间，用于判断缓存是否过期
+// 数据源不支持单独查询更新时间(例如Discussion)时ok为false
+func UpdatedAt(ctx context.Context, source github.IssueSource, res *parser.ResourceURL) (updatedAt time.Time, ok bool, err error) {
+	updates, supported := source.(github.UpdateSource)
+	if !supported || res.Type == "discussion" {
+		return time.Time{}, false, nil
+	}
+	updatedAt, err = updates.GetUpdatedAt(ctx, res.Owner, res.Repo, res.Number, res.Type == "pull")
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return updatedAt, true, nil
+}
+
+// Initialize 按配置初始化解析器和转换器，数据源按资源所在平台由 NewSource 创建
+func Initialize(cfg *config.Config) (*parser.MarkdownParser, converter.Converter, error) {
+	// 初始化解析器
+	tmpl, err := LoadTemplate(cfg.Parser.Template)
+	if err != nil {
+		return nil, nil, err
+	}
+	parserOptions := &parser.Options{
+		IncludeComments:    cfg.Parser.IncludeComments,
+		IncludeEvents:      cfg.Parser.IncludeEvents,
+		IncludeReactions:   cfg.Parser.IncludeReactions,
+		IncludeDiff:        cfg.Parser.IncludeDiff,
+		IncludeMetadata:    cfg.Parser.IncludeMetadata,
+		IncludeTimestamps:  cfg.Parser.IncludeTimestamps,
+		IncludeUserLinks:   cfg.Parser.IncludeUserLinks,
+		EmojisEnabled:      cfg.Parser.EmojisEnabled,
+		PreserveLineBreaks: cfg.Parser.PreserveLineBreaks,
+		Template:           tmpl,
+	}
+	return parser.NewParser(parserOptions), NewConverter(cfg.Output.Format), nil
+}
+
+// NewConverter 创建输出格式对应的转换器，未知格式按Markdown处理
+func NewConverter(format string) converter.Converter {
+	converterOptions := converter.DefaultConverterOptions()
+	switch format {
+	case "html":
+		return converter.NewHTMLConverter(converterOptions)
+	case "json":
+		return converter.NewJSONConverter(converterOptions)
+	default:
+		return converter.NewMarkdownConverter(converterOptions)
+	}
+}
+
+// FileExt 返回输出格式对应的文件扩展名
+func FileExt(format string) string {
+	switch format {
+	case "html":
+		return ".html"
+	case "json":
+		return ".json"
+	default:
+		return ".md"
+	}
+}
+
+// LoadTemplate 读取Markdown模板：内置模板名或模板文件路径，为空时使用默认模板
+func LoadTemplate(nameOrPath string) (string, error) {
+	if nameOrPath == "" {
+		return "", nil
+	}
+	if text, ok := parser.BuiltinTemplate(nameOrPath); ok {
+		return text, nil
+	}
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return "", fmt.Errorf("template %q is neither a builtin template (%s) nor a readable file: %w",
+			nameOrPath, strings.Join(parser.BuiltinTemplates(), ", "), err)
+	}
+	if err := parser.ValidateTemplate(string(data)); err != nil {
+		return "", fmt.Errorf("invalid template %s: %w", nameOrPath, err)
+	}
+	return string(data), nil
+}
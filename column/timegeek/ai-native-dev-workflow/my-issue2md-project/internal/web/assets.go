@@ -0,0 +1,45 @@
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// sharedAddressSpace 是运营商级NAT使用的 100.64.0.0/10，net.IP.IsPrivate 不包含它
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicHTTPClient 返回归档任务下载图片和附件使用的HTTP客户端
+//
+// 文档中的链接由Issue作者控制，不加限制时可以借服务端访问内网地址，
+// 因此只允许连接公网地址。检查在建立连接时对解析后的IP进行，重定向和DNS重绑定同样受限；
+// 不使用环境变量中的代理，否则检查的将是代理的地址
+func publicHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("refusing to connect to non-public address %s", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport, Timeout: 2 * time.Minute}
+}
+
+// isPublicIP 判断是否为公网地址
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip))
+}
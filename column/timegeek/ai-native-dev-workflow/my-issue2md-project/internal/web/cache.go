@@ -0,0 +1,141 @@
+package web
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+// Cache 是转换结果的两级缓存：内存中的LRU，以及可选的磁盘目录
+//
+// 键包含资源的更新时间，资源更新后旧条目不会再命中，内存中由LRU淘汰；
+// 磁盘上的旧文件不会自动清理，可以随时删除整个目录
+type Cache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // 最近使用的在前，元素为 *cacheEntry
+	items map[string]*list.Element
+	dir   string
+}
+
+// cacheEntry 是一条缓存的转换结果
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+// NewCache 创建缓存，size是内存中保留的条目数，dir为空时不使用磁盘缓存
+func NewCache(size int, dir string) (*Cache, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+	return &Cache{
+		size:  max(size, 1),
+		order: list.New(),
+		items: make(map[string]*list.Element),
+		dir:   dir,
+	}, nil
+}
+
+// cacheKey 由资源、资源的更新时间和影响输出的参数计算缓存键
+func cacheKey(res *parser.ResourceURL, updatedAt time.Time, cfg *config.Config) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s/%s\n%s\n%d\n%s\n", res.Forge, res.BaseURL,
+		strings.ToLower(res.Owner), strings.ToLower(res.Repo), res.Type, res.Number,
+		updatedAt.UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(h, "%s\n%+v\n", cfg.Output.Format, cfg.Parser)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get 返回缓存的转换结果，内存未命中时读取磁盘
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*cacheEntry).data, true
+	}
+	c.mu.Unlock()
+
+	if c.dir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	c.add(key, data)
+	return data, true
+}
+
+// Put 保存转换结果，写入磁盘失败时返回错误，内存中的条目仍然有效
+func (c *Cache) Put(key string, data []byte) error {
+	c.add(key, data)
+	if c.dir == "" {
+		return nil
+	}
+
+	// 先写临时文件再重命名，并发读取不会看到写了一半的文件
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Len 返回内存中的条目数
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// add 把条目放入内存LRU，超出容量时淘汰最久未使用的条目
+func (c *Cache) add(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry).data = data
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, data: data})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// path 返回条目在磁盘上的路径，按键的前两位分目录，避免单个目录文件过多
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
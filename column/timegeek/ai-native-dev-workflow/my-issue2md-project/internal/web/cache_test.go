@@ -0,0 +1,94 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+func TestCacheLRU(t *testing.T) {
+	cache, err := NewCache(2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Put("aa1", []byte("1"))
+	cache.Put("aa2", []byte("2"))
+	cache.Get("aa1") // aa1 变为最近使用
+	cache.Put("aa3", []byte("3"))
+
+	if _, ok := cache.Get("aa2"); ok {
+		t.Error("aa2 should have been evicted")
+	}
+	for _, key := range []string{"aa1", "aa3"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s should still be cached", key)
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+}
+
+func TestCacheDisk(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(1, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Put("aa1", []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Put("aa2", []byte("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	// aa1 已被内存淘汰，从磁盘读回；新的缓存实例同样可以读到
+	if data, ok := cache.Get("aa1"); !ok || string(data) != "first" {
+		t.Errorf("Get(aa1) = %q, %v", data, ok)
+	}
+	reopened, err := NewCache(1, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := reopened.Get("aa2"); !ok || string(data) != "second" {
+		t.Errorf("reopened Get(aa2) = %q, %v", data, ok)
+	}
+	if _, ok := reopened.Get("bb1"); ok {
+		t.Error("Get(bb1) should miss")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	res := &parser.ResourceURL{Forge: parser.ForgeGitHub, BaseURL: "https://github.com", Owner: "Owner", Repo: "Repo", Type: "issue", Number: 1}
+	updated := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.DefaultConfig()
+	base := cacheKey(res, updated, cfg)
+
+	lower := *res
+	lower.Owner, lower.Repo = "owner", "repo"
+	if cacheKey(&lower, updated, cfg) != base {
+		t.Error("owner and repo should be case-insensitive")
+	}
+
+	noComments := *cfg
+	noComments.Parser.IncludeComments = false
+	html := *cfg
+	html.Output.Format = "html"
+	otherNumber := *res
+	otherNumber.Number = 2
+	for name, key := range map[string]string{
+		"updated_at": cacheKey(res, updated.Add(time.Second), cfg),
+		"options":    cacheKey(res, updated, &noComments),
+		"format":     cacheKey(res, updated, &html),
+		"number":     cacheKey(&otherNumber, updated, cfg),
+	} {
+		if key == base {
+			t.Errorf("changing %s should change the key", name)
+		}
+	}
+	if got := len(base); got != 64 {
+		t.Errorf("key length = %d, want 64", got)
+	}
+}
@@ -0,0 +1,298 @@
+package web
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bigwhite/my-issue2md/internal/archive"
+	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/export"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+	"github.com/bigwhite/my-issue2md/internal/service"
+)
+
+// jobRequest 是提交异步任务的JSON请求体
+// 设置repository时批量导出整个仓库，否则把url指向的资源连同图片和附件打包归档
+type jobRequest struct {
+	convertRequest
+
+	Repository string   `json:"repository"` // owner/repo，只支持GitHub
+	State      string   `json:"state"`      // open, closed, all
+	Labels     []string `json:"labels"`
+	Since      string   `json:"since"` // RFC3339 或 2006-01-02
+	Author     string   `json:"author"`
+}
+
+// jobResponse 是任务状态的响应，附带查询状态和下载结果的地址
+type jobResponse struct {
+	Job
+	StatusURL string `json:"status_url"`
+	ResultURL string `json:"result_url,omitempty"`
+}
+
+func newJobResponse(job Job) jobResponse {
+	resp := jobResponse{Job: job, StatusURL: "/api/v1/jobs/" + job.ID}
+	if job.Status == JobSucceeded {
+		resp.ResultURL = resp.StatusURL + "/result"
+	}
+	return resp
+}
+
+// handleSubmitJob 提交归档或批量导出任务，立即返回202和任务状态
+func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "jobs must be submitted as application/json")
+		return
+	}
+	req := &jobRequest{}
+	if err := decodeRequest(w, r, req, nil); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.validateOptions(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.format = req.Format; req.format == "" {
+		req.format = "markdown"
+	}
+	cfg := req.apply(s.cfg)
+
+	var kind string
+	var run JobFunc
+	if req.Repository != "" {
+		owner, repo, opts, err := req.exportOptions()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := cfg.ValidateFor(parser.ForgeGitHub); err != nil {
+			writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("server is not configured for %s: %v", parser.ForgeGitHub, err))
+			return
+		}
+		kind, run = "export", s.exportJob(owner, repo, opts, cfg)
+	} else {
+		if strings.TrimSpace(req.URL) == "" {
+			writeError(w, http.StatusBadRequest, "url or repository is required")
+			return
+		}
+		res, err := s.urls.Parse(req.URL)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := cfg.ValidateFor(res.Forge); err != nil {
+			writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("server is not configured for %s: %v", res.Forge, err))
+			return
+		}
+		kind, run = "archive", s.archiveJob(res, cfg)
+	}
+
+	job, err := s.jobs.Submit(kind, run)
+	if errors.Is(err, ErrQueueFull) {
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := newJobResponse(job)
+	w.Header().Set("Location", resp.StatusURL)
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// exportOptions 把请求中的过滤条件转换为导出选项
+func (req *jobRequest) exportOptions() (owner, repo string, opts *export.Options, err error) {
+	owner, repo, ok := strings.Cut(req.Repository, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", nil, fmt.Errorf("invalid repository %q, expected owner/repo", req.Repository)
+	}
+	opts = export.DefaultOptions()
+	switch req.State {
+	case "":
+	case "open", "closed", "all":
+		opts.State = req.State
+	default:
+		return "", "", nil, fmt.Errorf("invalid state %q, expected open, closed or all", req.State)
+	}
+	if req.Since != "" {
+		if opts.Since, err = export.ParseSince(req.Since); err != nil {
+			return "", "", nil, err
+		}
+	}
+	opts.Labels = req.Labels
+	opts.Author = req.Author
+	opts.Ext = service.FileExt(req.format)
+	return owner, repo, opts, nil
+}
+
+// handleJob 处理 /api/v1/jobs/{id} 和 /api/v1/jobs/{id}/result
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/"), "/")
+	switch rest {
+	case "":
+		job, ok := s.jobs.Get(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, newJobResponse(job))
+	case "result":
+		s.serveJobResult(w, r, id)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// serveJobResult 以附件形式返回任务的结果文件
+func (s *Server) serveJobResult(w http.ResponseWriter, r *http.Request, id string) {
+	job, ok := s.jobs.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	path, ok := s.jobs.ResultPath(id)
+	if !ok {
+		writeError(w, http.StatusConflict, fmt.Sprintf("job is %s, the result is only available after it succeeded", job.Status))
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		writeError(w, http.StatusGone, "job result is no longer available")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read job result")
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, sanitizeFilename(filepath.Base(path))))
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// archiveJob 返回归档单个资源的任务：下载文档引用的图片和附件，打包为zip
+func (s *Server) archiveJob(res *parser.ResourceURL, cfg *config.Config) JobFunc {
+	return func(ctx context.Context, dir string) (string, string, error) {
+		p, conv, err := service.Initialize(cfg)
+		if err != nil {
+			return "", "", err
+		}
+		doc, err := service.FetchDocument(ctx, s.newSource(res, cfg), p, res, cfg)
+		if err != nil {
+			return "", "", err
+		}
+
+		opts := archive.DefaultOptions()
+		opts.Workers = cfg.Output.ArchiveWorkers
+		opts.Token = cfg.GitHubToken
+		opts.HTTPClient = s.assetClient
+		bundle, err := archive.NewArchiver(opts).Collect(ctx, doc)
+		if err != nil {
+			return "", "", err
+		}
+		content, err := conv.Convert(bundle.Document)
+		if err != nil {
+			return "", "", err
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("%s-%d.zip", sanitizeFilename(res.Repo), res.Number))
+		if err := bundle.Write(path, "index"+service.FileExt(cfg.Output.Format), content, false); err != nil {
+			return "", "", err
+		}
+		return path, fmt.Sprintf("%d assets archived, %d failed", len(bundle.Assets), len(bundle.Failed)), nil
+	}
+}
+
+// exportJob 返回批量导出仓库的任务，导出目录打包为zip
+// 单个条目失败不会使任务失败，失败的条目数记录在summary中
+func (s *Server) exportJob(owner, repo string, opts *export.Options, cfg *config.Config) JobFunc {
+	return func(ctx context.Context, dir string) (string, string, error) {
+		p, conv, err := service.Initialize(cfg)
+		if err != nil {
+			return "", "", err
+		}
+		render := func(ctx context.Context, res *parser.ResourceURL) ([]byte, error) {
+			doc, err := service.FetchDocument(ctx, s.newSource(res, cfg), p, res, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return conv.Convert(doc)
+		}
+
+		name := sanitizeFilename(owner + "-" + repo)
+		opts.Dir = filepath.Join(dir, name)
+		result, err := export.NewExporter(s.newLister(cfg), render, opts).Export(ctx, owner, repo)
+		if err != nil {
+			return "", "", err
+		}
+		path := filepath.Join(dir, name+".zip")
+		if err := zipDir(opts.Dir, path); err != nil {
+			return "", "", err
+		}
+		return path, fmt.Sprintf("%d items listed, %d exported, %d failed",
+			result.Listed, result.Exported, len(result.Failed)), nil
+	}
+}
+
+// zipDir 把目录src中的文件打包为dest
+func zipDir(src, dest string) error {
+	f, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	zw := zip.NewWriter(f)
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(w, in)
+		return err
+	})
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 任务状态
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+const (
+	// jobQueueSize 是等待执行的任务数上限
+	jobQueueSize = 64
+
+	// jobTTL 是任务结束后保留状态和结果文件的时长
+	jobTTL = time.Hour
+
+	// jobTimeout 是单个任务的最长执行时间
+	jobTimeout = 30 * time.Minute
+)
+
+// ErrQueueFull 表示等待执行的任务过多
+var ErrQueueFull = errors.New("too many pending jobs, try again later")
+
+// JobFunc 执行任务，把结果文件写入dir并返回其路径，summary是给客户端看的简短结果说明
+type JobFunc func(ctx context.Context, dir string) (path, summary string, err error)
+
+// Job 是一个异步任务的状态
+type Job struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"` // archive, export
+	Status     string     `json:"status"`
+	Summary    string     `json:"summary,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	dir  string // 任务的工作目录
+	path string // 结果文件
+	run  JobFunc
+}
+
+// Jobs 用固定数量的worker执行异步任务，任务和结果只保存在本进程中
+type Jobs struct {
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	queue chan *Job
+	dir   string
+
+	// redact 在保存错误信息前去掉其中的敏感内容
+	redact func(string) string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewJobs 创建任务队列并启动workers个worker，任务的结果文件写在dir下
+func NewJobs(workers int, dir string, redact func(string) string) *Jobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &Jobs{
+		jobs:   make(map[string]*Job),
+		queue:  make(chan *Job, jobQueueSize),
+		dir:    dir,
+		redact: redact,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < max(workers, 1); i++ {
+		j.wg.Add(1)
+		go j.worker()
+	}
+	return j
+}
+
+// Submit 提交任务，队列已满时返回ErrQueueFull
+func (j *Jobs) Submit(kind string, run JobFunc) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+	job := &Job{
+		ID:        id,
+		Kind:      kind,
+		Status:    JobQueued,
+		CreatedAt: time.Now(),
+		dir:       filepath.Join(j.dir, id),
+		run:       run,
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.expire(time.Now())
+	select {
+	case j.queue <- job:
+	default:
+		return Job{}, ErrQueueFull
+	}
+	j.jobs[id] = job
+	return *job, nil
+}
+
+// Get 返回任务状态的副本
+func (j *Jobs) Get(id string) (Job, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// ResultPath 返回成功任务的结果文件
+func (j *Jobs) ResultPath(id string) (string, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok || job.Status != JobSucceeded {
+		return "", false
+	}
+	return job.path, true
+}
+
+// Close 取消正在执行的任务，等待worker退出并删除全部结果文件
+func (j *Jobs) Close() {
+	j.cancel()
+	j.wg.Wait()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for id, job := range j.jobs {
+		os.RemoveAll(job.dir)
+		delete(j.jobs, id)
+	}
+}
+
+// worker 逐个执行队列中的任务
+func (j *Jobs) worker() {
+	defer j.wg.Done()
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case job := <-j.queue:
+			j.execute(job)
+		}
+	}
+}
+
+// execute 执行一个任务并记录结果
+func (j *Jobs) execute(job *Job) {
+	j.setStatus(job, JobRunning)
+
+	var path, summary string
+	err := os.MkdirAll(job.dir, 0o755)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(j.ctx, jobTimeout)
+		path, summary, err = job.run(ctx, job.dir)
+		cancel()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	job.FinishedAt = &now
+	job.run = nil
+	if err != nil {
+		log.Printf("job %s (%s) failed: %s", job.ID, job.Kind, j.redact(err.Error()))
+		job.Status = JobFailed
+		job.Error = j.redact(err.Error())
+		return
+	}
+	job.Status, job.path, job.Summary = JobSucceeded, path, summary
+}
+
+// setStatus 更新任务状态
+func (j *Jobs) setStatus(job *Job, status string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job.Status = status
+}
+
+// expire 删除结束超过jobTTL的任务及其结果文件，调用方需持有锁
+func (j *Jobs) expire(now time.Time) {
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > jobTTL {
+			os.RemoveAll(job.dir)
+			delete(j.jobs, id)
+		}
+	}
+}
+
+// newJobID 生成随机的任务ID，知道ID即可下载结果，因此不能使用可猜测的序号
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobsLifecycle(t *testing.T) {
+	jobs := NewJobs(1, t.TempDir(), func(s string) string { return strings.ReplaceAll(s, "secret", "[REDACTED]") })
+	defer jobs.Close()
+
+	ok, err := jobs.Submit("archive", func(ctx context.Context, dir string) (string, string, error) {
+		path := filepath.Join(dir, "result.zip")
+		return path, "done", os.WriteFile(path, []byte("zip"), 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := jobs.Submit("export", func(ctx context.Context, dir string) (string, string, error) {
+		return "", "", errors.New("token secret rejected")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok.ID == failed.ID || len(ok.ID) != 32 {
+		t.Errorf("job ids %q and %q should be distinct random ids", ok.ID, failed.ID)
+	}
+
+	job := waitForStatus(t, jobs, ok.ID)
+	if job.Status != JobSucceeded || job.Summary != "done" || job.FinishedAt == nil {
+		t.Errorf("job = %+v, want succeeded", job)
+	}
+	if path, found := jobs.ResultPath(ok.ID); !found || filepath.Base(path) != "result.zip" {
+		t.Errorf("ResultPath() = %q, %v", path, found)
+	}
+
+	job = waitForStatus(t, jobs, failed.ID)
+	if job.Status != JobFailed || job.Error != "token [REDACTED] rejected" {
+		t.Errorf("job = %+v, want failed with redacted error", job)
+	}
+	if _, found := jobs.ResultPath(failed.ID); found {
+		t.Error("failed job should have no result")
+	}
+}
+
+func TestJobsExpire(t *testing.T) {
+	jobs := NewJobs(1, t.TempDir(), func(s string) string { return s })
+	defer jobs.Close()
+
+	job, err := jobs.Submit("archive", func(ctx context.Context, dir string) (string, string, error) {
+		return filepath.Join(dir, "result.zip"), "", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForStatus(t, jobs, job.ID)
+
+	jobs.mu.Lock()
+	jobs.expire(time.Now().Add(jobTTL + time.Minute))
+	jobs.mu.Unlock()
+	if _, found := jobs.Get(job.ID); found {
+		t.Error("finished job should expire after jobTTL")
+	}
+}
+
+func TestJobsClose(t *testing.T) {
+	jobs := NewJobs(1, t.TempDir(), func(s string) string { return s })
+
+	started := make(chan struct{})
+	job, err := jobs.Submit("export", func(ctx context.Context, dir string) (string, string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", "", ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	jobs.Close() // 取消正在执行的任务，不会一直阻塞
+	if _, found := jobs.Get(job.ID); found {
+		t.Error("Close should remove all jobs")
+	}
+}
+
+// waitForStatus 等待任务结束
+func waitForStatus(t *testing.T, jobs *Jobs, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, found := jobs.Get(id)
+		if !found {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.Status == JobSucceeded || job.Status == JobFailed {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish: %+v", id, job)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
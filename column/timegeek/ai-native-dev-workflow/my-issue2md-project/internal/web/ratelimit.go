@@ -0,0 +1,120 @@
+package web
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxClients 是限流器记录的客户端数上限，超出后清理令牌已经补满的客户端；
+// 仍然超出时淘汰最久未访问的客户端
+const maxClients = 10000
+
+// RateLimiter 按客户端限制请求速率的令牌桶
+// 每个客户端最多积累burst个令牌，每分钟补充perMinute个，每个请求消耗一个
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // 每秒补充的令牌数
+	burst   float64
+	clients map[string]*bucket
+
+	now func() time.Time // 测试中可替换
+}
+
+// bucket 是一个客户端的令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter 创建限流器，perMinute不大于0时返回nil，表示不限流
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(max(burst, 1)),
+		clients: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow 为client消耗一个令牌，令牌不足时返回false和需要等待的时间
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.clients[client]
+	if !ok {
+		if len(l.clients) >= maxClients {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep 删除令牌已经补满的客户端，它们与新客户端没有区别
+// 所有客户端都很活跃时，按最后访问时间淘汰最旧的十分之一，保证map不会超过上限
+func (l *RateLimiter) sweep(now time.Time) {
+	for client, b := range l.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+	if len(l.clients) < maxClients {
+		return
+	}
+
+	clients := make([]string, 0, len(l.clients))
+	for client := range l.clients {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return l.clients[clients[i]].last.Before(l.clients[clients[j]].last)
+	})
+	evict := len(clients) - maxClients + maxClients/10
+	for _, client := range clients[:evict] {
+		delete(l.clients, client)
+	}
+}
+
+// clientAddr 返回用于限流的客户端地址
+// trustProxy为true时使用X-Forwarded-For中的最后一个地址，即可信的反向代理实际看到的对端地址；
+// 前面的地址由客户端自己填写，可以任意伪造。只应在可信的反向代理之后开启
+func clientAddr(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			forwarded := values[len(values)-1]
+			if i := strings.LastIndex(forwarded, ","); i >= 0 {
+				forwarded = forwarded[i+1:]
+			}
+			if last := strings.TrimSpace(forwarded); last != "" {
+				return last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(60, 2) // 每秒补充一个令牌
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("a"); !ok {
+			t.Fatalf("request %d within burst was rejected", i)
+		}
+	}
+	ok, wait := limiter.Allow("a")
+	if ok {
+		t.Fatal("request beyond burst was allowed")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("wait = %v, want (0, 1s]", wait)
+	}
+	if ok, _ := limiter.Allow("b"); !ok {
+		t.Error("another client should have its own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Error("a token should be refilled after one second")
+	}
+	if ok, _ := limiter.Allow("a"); ok {
+		t.Error("only one token should be refilled")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := NewRateLimiter(0, 10)
+	if limiter != nil {
+		t.Fatal("NewRateLimiter(0) should return nil")
+	}
+	for i := 0; i < 100; i++ {
+		if ok, _ := limiter.Allow("a"); !ok {
+			t.Fatal("nil limiter should allow every request")
+		}
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	tests := []struct {
+		name       string
+		remote     string
+		forwarded  string
+		trustProxy bool
+		want       string
+	}{
+		{"remote addr", "192.0.2.1:1234", "", false, "192.0.2.1"},
+		{"ignore forwarded", "192.0.2.1:1234", "198.51.100.7", false, "192.0.2.1"},
+		{"trust forwarded", "192.0.2.1:1234", "198.51.100.7", true, "198.51.100.7"},
+		{"spoofed forwarded", "192.0.2.1:1234", "203.0.113.66, 198.51.100.7", true, "198.51.100.7"},
+		{"empty last entry", "192.0.2.1:1234", "198.51.100.7, ", true, "192.0.2.1"},
+		{"trust without header", "192.0.2.1:1234", "", true, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := clientAddr(r, tt.trustProxy); got != tt.want {
+				t.Errorf("clientAddr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterCapsClients(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(1, 2) // 令牌补充很慢，所有客户端都处于活跃状态
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < maxClients*2; i++ {
+		now = now.Add(time.Millisecond)
+		limiter.Allow(strconv.Itoa(i))
+		if n := len(limiter.clients); n > maxClients {
+			t.Fatalf("after %d clients the limiter tracks %d, want at most %d", i+1, n, maxClients)
+		}
+	}
+	// 淘汰的是最久未访问的客户端
+	if _, ok := limiter.clients[strconv.Itoa(maxClients*2-1)]; !ok {
+		t.Error("the most recent client was evicted")
+	}
+	if _, ok := limiter.clients["0"]; ok {
+		t.Error("the oldest client was not evicted")
+	}
+}
@@ -0,0 +1,253 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+)
+
+// formats 是支持的输出格式及其Content-Type
+var formats = map[string]string{
+	"markdown": "text/markdown; charset=utf-8",
+	"html":     "text/html; charset=utf-8",
+	"json":     "application/json",
+}
+
+// mediaTypes 是Accept中可以识别的媒体类型与输出格式的对应关系
+var mediaTypes = map[string]string{
+	"text/markdown":    "markdown",
+	"text/x-markdown":  "markdown",
+	"text/plain":       "markdown",
+	"text/*":           "markdown",
+	"*/*":              "markdown",
+	"text/html":        "html",
+	"application/json": "json",
+}
+
+// errNotAcceptable 表示Accept中没有可以提供的格式
+var errNotAcceptable = errors.New("none of the accepted media types is supported, use text/markdown, text/html or application/json")
+
+// convertRequest 是转换请求的参数，来自JSON请求体，或者查询参数和表单
+type convertRequest struct {
+	URL      string `json:"url"`
+	Format   string `json:"format"`   // markdown, html, json，为空时按Accept协商
+	Template string `json:"template"` // 内置模板名，不接受服务端的文件路径
+	Download bool   `json:"download"` // 以附件形式返回
+
+	// 为nil时使用服务端的默认配置
+	Comments   *bool `json:"comments"`
+	Events     *bool `json:"events"`
+	Reactions  *bool `json:"reactions"`
+	Diff       *bool `json:"diff"`
+	Metadata   *bool `json:"metadata"`
+	Timestamps *bool `json:"timestamps"`
+
+	format string // 协商后的输出格式
+}
+
+// validate 检查请求参数
+func (req *convertRequest) validate() error {
+	if strings.TrimSpace(req.URL) == "" {
+		return errors.New("url is required")
+	}
+	return req.validateOptions()
+}
+
+// validateOptions 检查输出格式和模板
+func (req *convertRequest) validateOptions() error {
+	if req.Format != "" {
+		if _, ok := formats[req.Format]; !ok {
+			return fmt.Errorf("unsupported format %q, expected markdown, html or json", req.Format)
+		}
+	}
+	if req.Template != "" {
+		if _, ok := parser.BuiltinTemplate(req.Template); !ok {
+			return fmt.Errorf("unknown template %q, expected one of: %s",
+				req.Template, strings.Join(parser.BuiltinTemplates(), ", "))
+		}
+	}
+	return nil
+}
+
+// apply 返回应用了请求参数的配置副本，base不会被修改
+func (req *convertRequest) apply(base *config.Config) *config.Config {
+	cfg := *base
+	cfg.Output.Format = req.format
+	cfg.Parser.Template = req.Template
+	for _, opt := range []struct {
+		value  *bool
+		target *bool
+	}{
+		{req.Comments, &cfg.Parser.IncludeComments},
+		{req.Events, &cfg.Parser.IncludeEvents},
+		{req.Reactions, &cfg.Parser.IncludeReactions},
+		{req.Diff, &cfg.Parser.IncludeDiff},
+		{req.Metadata, &cfg.Parser.IncludeMetadata},
+		{req.Timestamps, &cfg.Parser.IncludeTimestamps},
+	} {
+		if opt.value != nil {
+			*opt.target = *opt.value
+		}
+	}
+	return &cfg
+}
+
+// readConvertRequest 读取转换请求，JSON请求体之外的参数从查询参数和表单中读取
+func readConvertRequest(w http.ResponseWriter, r *http.Request) (*convertRequest, error) {
+	req := &convertRequest{}
+	if err := decodeRequest(w, r, req, req.fromForm); err != nil {
+		return nil, err
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// decodeRequest 按Content-Type把请求体解码到v，非JSON请求调用fromForm读取表单
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any, fromForm func(form formValues) error) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Method == http.MethodPost && mediaType == "application/json" {
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(v); err != nil {
+			return fmt.Errorf("invalid JSON body: %w", err)
+		}
+		return nil
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("invalid form: %w", err)
+	}
+	return fromForm(formValues(r.Form))
+}
+
+// fromForm 从查询参数或表单读取请求参数
+func (req *convertRequest) fromForm(form formValues) error {
+	req.URL = form.get("url")
+	req.Format = form.get("format")
+	req.Template = form.get("template")
+
+	var err error
+	if req.Download, err = form.boolean("download", false); err != nil {
+		return err
+	}
+	for name, target := range map[string]**bool{
+		"comments":   &req.Comments,
+		"events":     &req.Events,
+		"reactions":  &req.Reactions,
+		"diff":       &req.Diff,
+		"metadata":   &req.Metadata,
+		"timestamps": &req.Timestamps,
+	} {
+		if !form.has(name) {
+			continue
+		}
+		value, err := form.boolean(name, false)
+		if err != nil {
+			return err
+		}
+		*target = &value
+	}
+	return nil
+}
+
+// formValues 是查询参数和表单的值
+// 同名参数取最后一个，表单中的复选框前放一个值为false的隐藏字段即可表示未勾选
+type formValues map[string][]string
+
+func (f formValues) has(name string) bool {
+	return len(f[name]) > 0
+}
+
+func (f formValues) get(name string) string {
+	values := f[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[len(values)-1])
+}
+
+func (f formValues) boolean(name string, def bool) (bool, error) {
+	value := f.get(name)
+	if value == "" {
+		return def, nil
+	}
+	if value == "on" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q for %s, expected true or false", value, name)
+	}
+	return b, nil
+}
+
+// negotiate 确定输出格式：显式指定的format优先，否则按Accept的q值选择，Accept为空时输出Markdown
+func negotiate(format, accept string) (string, error) {
+	if format != "" {
+		if _, ok := formats[format]; !ok {
+			return "", fmt.Errorf("unsupported format %q, expected markdown, html or json", format)
+		}
+		return format, nil
+	}
+	if strings.TrimSpace(accept) == "" {
+		return "markdown", nil
+	}
+
+	type candidate struct {
+		format   string
+		q        float64
+		specific int // 具体类型优先于通配符
+		order    int
+	}
+	var candidates []candidate
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		specific := 2
+		if mediaType == "*/*" {
+			specific = 0
+		} else if strings.HasSuffix(mediaType, "/*") {
+			specific = 1
+		}
+		candidates = append(candidates, candidate{format, q, specific, i})
+	}
+	if len(candidates) == 0 {
+		return "", errNotAcceptable
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.q != b.q {
+			return a.q > b.q
+		}
+		if a.specific != b.specific {
+			return a.specific > b.specific
+		}
+		return a.order < b.order
+	})
+	return candidates[0].format, nil
+}
@@ -0,0 +1,333 @@
+// Package web 实现issue2mdweb的HTTP API：同步转换、异步归档与批量导出任务，以及一个简单的表单页面
+//
+// 服务端的访问令牌只用于请求代码托管平台，不会出现在任何响应中；
+// 客户端可以读到服务端令牌有权访问的全部内容，部署时应据此选择令牌的权限
+package web
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/export"
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+	"github.com/bigwhite/my-issue2md/internal/service"
+)
+
+const (
+	// convertTimeout 是一次同步转换的最长时间，更大的导出应使用异步任务
+	convertTimeout = 60 * time.Second
+
+	// maxRequestBody 是JSON请求体的大小上限
+	maxRequestBody = 1 << 20
+)
+
+//go:embed form.html
+var formFS embed.FS
+
+// Server 是issue2mdweb的HTTP服务
+type Server struct {
+	cfg     *config.Config
+	name    string
+	version string
+	urls    *parser.ForgeURLParser
+	cache   *Cache
+	limiter *RateLimiter
+	jobs    *Jobs
+	jobDir  string
+	form    *template.Template
+	mux     *http.ServeMux
+
+	// newSource、newLister和assetClient在测试中替换为假实现
+	newSource   func(res *parser.ResourceURL, cfg *config.Config) github.IssueSource
+	newLister   func(cfg *config.Config) export.Source
+	assetClient *http.Client
+}
+
+// NewServer 按配置创建服务，name和version显示在首页和健康检查中
+func NewServer(cfg *config.Config, name, version string) (*Server, error) {
+	urls, err := parser.NewForgeURLParser(cfg.Hosts)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := NewCache(cfg.Web.CacheSize, cfg.Web.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+	form, err := template.ParseFS(formFS, "form.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse form template: %w", err)
+	}
+	jobDir, err := os.MkdirTemp("", "issue2mdweb-jobs-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job directory: %w", err)
+	}
+
+	s := &Server{
+		cfg:         cfg,
+		name:        name,
+		version:     version,
+		urls:        urls,
+		cache:       cache,
+		limiter:     NewRateLimiter(cfg.Web.RateLimit, cfg.Web.RateBurst),
+		jobDir:      jobDir,
+		form:        form,
+		mux:         http.NewServeMux(),
+		newSource:   service.NewSource,
+		newLister:   func(cfg *config.Config) export.Source { return github.NewClient(cfg.GitHubToken) },
+		assetClient: publicHTTPClient(),
+	}
+	s.jobs = NewJobs(cfg.Web.JobWorkers, jobDir, s.redact)
+	s.routes()
+	return s, nil
+}
+
+// Handler 返回服务的HTTP处理器
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Close 停止异步任务并删除任务结果
+func (s *Server) Close() {
+	s.jobs.Close()
+	os.RemoveAll(s.jobDir)
+}
+
+// routes 设置路由，转换和提交任务受限流保护，查询任务状态不受限制
+func (s *Server) routes() {
+	s.mux.HandleFunc("/", s.handleIndex)
+	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.Handle("/api/v1/convert", s.rateLimited(http.HandlerFunc(s.handleConvert)))
+	s.mux.Handle("/api/v1/jobs", s.rateLimited(http.HandlerFunc(s.handleSubmitJob)))
+	s.mux.HandleFunc("/api/v1/jobs/", s.handleJob)
+}
+
+// rateLimited 按客户端限流，超出时返回429和Retry-After
+func (s *Server) rateLimited(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := s.limiter.Allow(clientAddr(r, s.cfg.Web.TrustProxy))
+		if !ok {
+			w.Header().Set("Retry-After", fmt.Sprint(int(wait.Seconds())+1))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleHealth 健康检查
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":  "ok",
+		"service": s.name,
+		"version": s.version,
+	})
+}
+
+// handleIndex 首页：转换表单
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := s.form.Execute(w, map[string]any{
+		"Name":      s.name,
+		"Version":   s.version,
+		"Templates": parser.BuiltinTemplates(),
+	})
+	if err != nil {
+		log.Printf("failed to render form: %v", err)
+	}
+}
+
+// handleConvert 同步转换一个资源
+//
+// GET使用查询参数，POST接受JSON或表单；未指定format时按Accept协商输出格式。
+// 能够查询资源更新时间时，结果按资源和更新时间缓存，X-Cache响应头给出命中情况
+func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req, err := readConvertRequest(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.format, err = negotiate(req.Format, r.Header.Get("Accept"))
+	if errors.Is(err, errNotAcceptable) {
+		writeError(w, http.StatusNotAcceptable, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := s.urls.Parse(req.URL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cfg := req.apply(s.cfg)
+	if err := cfg.ValidateFor(res.Forge); err != nil {
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("server is not configured for %s: %v", res.Forge, err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), convertTimeout)
+	defer cancel()
+	source := s.newSource(res, cfg)
+
+	updatedAt, cacheable, err := service.UpdatedAt(ctx, source, res)
+	if err != nil {
+		s.writeUpstreamError(w, err)
+		return
+	}
+	var key string
+	if cacheable {
+		key = cacheKey(res, updatedAt, cfg)
+		if notModified(w, r, key) {
+			return
+		}
+		if data, ok := s.cache.Get(key); ok {
+			s.writeDocument(w, req, res, key, data, "HIT")
+			return
+		}
+	}
+
+	p, conv, err := service.Initialize(cfg)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, s.redact(err.Error()))
+		return
+	}
+	doc, err := service.FetchDocument(ctx, source, p, res, cfg)
+	if err != nil {
+		s.writeUpstreamError(w, err)
+		return
+	}
+	data, err := conv.Convert(doc)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, s.redact(err.Error()))
+		return
+	}
+
+	status := "BYPASS"
+	if cacheable {
+		status = "MISS"
+		if err := s.cache.Put(key, data); err != nil {
+			log.Printf("cache: %v", err)
+		}
+	}
+	s.writeDocument(w, req, res, key, data, status)
+}
+
+// writeDocument 写出转换结果，key不为空时附带ETag
+func (s *Server) writeDocument(w http.ResponseWriter, req *convertRequest, res *parser.ResourceURL, key string, data []byte, cacheStatus string) {
+	h := w.Header()
+	h.Set("Content-Type", formats[req.format])
+	h.Set("Vary", "Accept")
+	h.Set("X-Cache", cacheStatus)
+	if key != "" {
+		h.Set("ETag", etag(key))
+		h.Set("Cache-Control", "private, no-cache")
+	} else {
+		h.Set("Cache-Control", "no-store")
+	}
+	if req.Download {
+		h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d%s"`,
+			sanitizeFilename(res.Repo), res.Number, service.FileExt(req.format)))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// etag 由缓存键生成ETag
+func etag(key string) string {
+	return `"` + key[:32] + `"`
+}
+
+// notModified 客户端的If-None-Match与当前结果一致时返回304
+func notModified(w http.ResponseWriter, r *http.Request, key string) bool {
+	match := r.Header.Get("If-None-Match")
+	if match == "" {
+		return false
+	}
+	tag := etag(key)
+	for _, candidate := range strings.Split(match, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag || candidate == "*" {
+			w.Header().Set("ETag", tag)
+			w.Header().Set("Vary", "Accept")
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// writeUpstreamError 把数据源的错误转换为响应，资源不存在时返回404，其余返回502或504
+func (s *Server) writeUpstreamError(w http.ResponseWriter, err error) {
+	message := s.redact(err.Error())
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, "timed out fetching the resource, use /api/v1/jobs for large items")
+	case github.StatusCode(err) == http.StatusNotFound:
+		writeError(w, http.StatusNotFound, message)
+	default:
+		log.Printf("upstream error: %s", message)
+		writeError(w, http.StatusBadGateway, message)
+	}
+}
+
+// redact 去掉文本中的服务端令牌
+func (s *Server) redact(text string) string {
+	for _, token := range []string{s.cfg.GitHubToken, s.cfg.GitLabToken, s.cfg.GiteaToken} {
+		if token != "" {
+			text = strings.ReplaceAll(text, token, "[REDACTED]")
+		}
+	}
+	return text
+}
+
+// writeJSON 写出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
+
+// writeError 写出 {"error": message} 形式的错误响应
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// sanitizeFilename 去掉文件名中可能破坏Content-Disposition的字符
+func sanitizeFilename(name string) string {
+	name = path.Base(name)
+	return strings.Map(func(r rune) rune {
+		if r == '"' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,483 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bigwhite/my-issue2md/internal/config"
+	"github.com/bigwhite/my-issue2md/internal/export"
+	"github.com/bigwhite/my-issue2md/internal/github"
+	"github.com/bigwhite/my-issue2md/internal/parser"
+	"github.com/bigwhite/my-issue2md/internal/rest"
+)
+
+const testToken = "ghp_supersecrettoken"
+
+// fakeSource 是内存中的数据源，记录完整获取Issue的次数
+type fakeSource struct {
+	mu        sync.Mutex
+	issues    map[int]*github.Issue
+	fetches   int
+	failWith  error
+	updatedAt time.Time
+}
+
+func newFakeSource() *fakeSource {
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &fakeSource{
+		updatedAt: updated,
+		issues: map[int]*github.Issue{
+			1: {
+				Number:    1,
+				Title:     "Crash on startup",
+				Body:      "It crashes.",
+				State:     "open",
+				User:      github.User{Login: "alice"},
+				CreatedAt: updated.Add(-time.Hour),
+				UpdatedAt: updated,
+				HTMLURL:   "https://github.com/owner/repo/issues/1",
+			},
+		},
+	}
+}
+
+func (f *fakeSource) issue(number int) (*github.Issue, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failWith != nil {
+		return nil, f.failWith
+	}
+	issue, ok := f.issues[number]
+	if !ok {
+		return nil, &rest.APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	}
+	copied := *issue
+	copied.UpdatedAt = f.updatedAt
+	return &copied, nil
+}
+
+func (f *fakeSource) GetIssue(ctx context.Context, owner, repo string, number int) (*github.Issue, error) {
+	f.mu.Lock()
+	f.fetches++
+	f.mu.Unlock()
+	return f.issue(number)
+}
+
+func (f *fakeSource) GetPullRequest(ctx context.Context, owner, repo string, number int, opts *github.PullRequestOptions) (*github.PullRequest, error) {
+	return nil, &rest.APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+}
+
+func (f *fakeSource) ListComments(ctx context.Context, owner, repo string, number int, pull bool) ([]*github.Comment, error) {
+	return []*github.Comment{{
+		ID:        1,
+		Body:      "Same here.",
+		User:      github.User{Login: "bob"},
+		CreatedAt: f.updatedAt,
+	}}, nil
+}
+
+func (f *fakeSource) ListEvents(ctx context.Context, owner, repo string, number int, pull bool) ([]*github.TimelineEvent, error) {
+	return nil, nil
+}
+
+func (f *fakeSource) GetUpdatedAt(ctx context.Context, owner, repo string, number int, pull bool) (time.Time, error) {
+	issue, err := f.issue(number)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return issue.UpdatedAt, nil
+}
+
+func (f *fakeSource) ListIssues(ctx context.Context, owner, repo string, opts *github.ListIssuesOptions) (*github.IssueList, error) {
+	issue, _ := f.issue(1)
+	return &github.IssueList{Issues: []*github.Issue{issue}}, nil
+}
+
+func (f *fakeSource) fetchCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fetches
+}
+
+func newTestServer(t *testing.T, configure func(cfg *config.Config)) (*Server, *fakeSource) {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.GitHubToken = testToken
+	cfg.Web.RateLimit = 0
+	if configure != nil {
+		configure(cfg)
+	}
+	srv, err := NewServer(cfg, "issue2mdweb", "test")
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	t.Cleanup(srv.Close)
+
+	source := newFakeSource()
+	srv.newSource = func(res *parser.ResourceURL, cfg *config.Config) github.IssueSource { return source }
+	srv.newLister = func(cfg *config.Config) export.Source { return source }
+	return srv, source
+}
+
+func do(srv *Server, method, target string, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func convertURL(extra string) string {
+	return "/api/v1/convert?url=" + url.QueryEscape("https://github.com/owner/repo/issues/1") + extra
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		accept  string
+		want    string
+		wantErr bool
+	}{
+		{"empty accept", "", "", "markdown", false},
+		{"markdown", "", "text/markdown", "markdown", false},
+		{"html", "", "text/html", "html", false},
+		{"json", "", "application/json", "json", false},
+		{"wildcard", "", "*/*", "markdown", false},
+		{"browser", "", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "html", false},
+		{"q values", "", "text/html;q=0.5, application/json", "json", false},
+		{"specific beats wildcard", "", "*/*, application/json", "json", false},
+		{"q zero", "", "application/json;q=0, text/html", "html", false},
+		{"explicit format wins", "json", "text/html", "json", false},
+		{"not acceptable", "", "image/png", "", true},
+		{"unknown format", "pdf", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := negotiate(tt.format, tt.accept)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("negotiate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("negotiate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertContentNegotiation(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		wantStatus  int
+		wantType    string
+		wantContent string
+	}{
+		{"markdown", convertURL(""), "text/markdown", http.StatusOK, "text/markdown", "# Crash on startup"},
+		{"html", convertURL(""), "text/html", http.StatusOK, "text/html", "<h1"},
+		{"json", convertURL(""), "application/json", http.StatusOK, "application/json", `"title"`},
+		{"format overrides accept", convertURL("&format=json"), "text/html", http.StatusOK, "application/json", `"title"`},
+		{"not acceptable", convertURL(""), "image/png", http.StatusNotAcceptable, "application/json", "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(srv, http.MethodGet, tt.target, "", map[string]string{"Accept": tt.accept})
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantContent) {
+				t.Errorf("body does not contain %q:\n%s", tt.wantContent, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK && rec.Header().Get("Vary") != "Accept" {
+				t.Errorf("Vary = %q, want Accept", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestConvertRequestBodies(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+
+	// 表单：隐藏字段false在前，勾选的复选框true在后
+	form := url.Values{
+		"url":      {"https://github.com/owner/repo/issues/1"},
+		"comments": {"false"},
+		"download": {"true"},
+	}
+	rec := do(srv, http.MethodPost, "/api/v1/convert", form.Encode(),
+		map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("form status = %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "Same here.") {
+		t.Errorf("comments=false should exclude comments:\n%s", rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="repo-1.md"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	form.Add("comments", "true")
+	rec = do(srv, http.MethodPost, "/api/v1/convert", form.Encode(),
+		map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+	if !strings.Contains(rec.Body.String(), "Same here.") {
+		t.Errorf("the last comments value should win:\n%s", rec.Body)
+	}
+
+	body := `{"url": "https://github.com/owner/repo/issues/1", "format": "json", "comments": false}`
+	rec = do(srv, http.MethodPost, "/api/v1/convert", body, map[string]string{"Content-Type": "application/json"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("JSON status = %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestConvertCache(t *testing.T) {
+	srv, source := newTestServer(t, nil)
+
+	rec := do(srv, http.MethodGet, convertURL(""), "", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("first request: status = %d, X-Cache = %q", rec.Code, rec.Header().Get("X-Cache"))
+	}
+	first := rec.Body.String()
+	tag := rec.Header().Get("ETag")
+
+	rec = do(srv, http.MethodGet, convertURL(""), "", nil)
+	if rec.Header().Get("X-Cache") != "HIT" || rec.Body.String() != first {
+		t.Errorf("second request: X-Cache = %q, want HIT with the same body", rec.Header().Get("X-Cache"))
+	}
+	if got := source.fetchCount(); got != 1 {
+		t.Errorf("issue fetched %d times, want 1", got)
+	}
+
+	rec = do(srv, http.MethodGet, convertURL(""), "", map[string]string{"If-None-Match": tag})
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match status = %d, want 304", rec.Code)
+	}
+
+	// 不同的选项是不同的缓存条目
+	rec = do(srv, http.MethodGet, convertURL("&comments=false"), "", nil)
+	if rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("different options: X-Cache = %q, want MISS", rec.Header().Get("X-Cache"))
+	}
+
+	// 资源更新后重新获取
+	source.mu.Lock()
+	source.updatedAt = source.updatedAt.Add(time.Minute)
+	source.mu.Unlock()
+	rec = do(srv, http.MethodGet, convertURL(""), "", map[string]string{"If-None-Match": tag})
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("after update: status = %d, X-Cache = %q, want 200 MISS", rec.Code, rec.Header().Get("X-Cache"))
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	srv, source := newTestServer(t, nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{"missing url", http.MethodGet, "/api/v1/convert", http.StatusBadRequest},
+		{"invalid url", http.MethodGet, "/api/v1/convert?url=not-a-url", http.StatusBadRequest},
+		{"unknown format", http.MethodGet, convertURL("&format=pdf"), http.StatusBadRequest},
+		{"template path", http.MethodGet, convertURL("&template=/etc/passwd"), http.StatusBadRequest},
+		{"invalid boolean", http.MethodGet, convertURL("&comments=maybe"), http.StatusBadRequest},
+		{"not found", http.MethodGet, "/api/v1/convert?url=" + url.QueryEscape("https://github.com/owner/repo/issues/2"), http.StatusNotFound},
+		{"method", http.MethodDelete, convertURL(""), http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(srv, tt.method, tt.target, "", nil)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["error"] == "" {
+				t.Errorf("body is not a JSON error: %s", rec.Body)
+			}
+		})
+	}
+
+	// 上游错误中的令牌不能返回给客户端
+	source.failWith = fmt.Errorf("request with token %s failed", testToken)
+	rec := do(srv, http.MethodGet, convertURL(""), "", nil)
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("upstream error status = %d, want 502", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), testToken) {
+		t.Errorf("response leaks the token: %s", rec.Body)
+	}
+}
+
+func TestConvertRateLimit(t *testing.T) {
+	srv, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.Web.RateLimit = 60
+		cfg.Web.RateBurst = 2
+	})
+
+	for i := 0; i < 2; i++ {
+		if rec := do(srv, http.MethodGet, convertURL(""), "", nil); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d", i, rec.Code)
+		}
+	}
+	rec := do(srv, http.MethodGet, convertURL(""), "", nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After header is missing")
+	}
+
+	// 健康检查和首页不受限流影响
+	if rec := do(srv, http.MethodGet, "/health", "", nil); rec.Code != http.StatusOK {
+		t.Errorf("/health status = %d", rec.Code)
+	}
+}
+
+func TestIndexPage(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+
+	rec := do(srv, http.MethodGet, "/", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`action="/api/v1/convert"`, `<option value="default" selected>`, `<option value="compact">`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("form does not contain %q", want)
+		}
+	}
+	if strings.Contains(body, testToken) {
+		t.Error("form leaks the token")
+	}
+
+	if rec := do(srv, http.MethodGet, "/missing", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("/missing status = %d, want 404", rec.Code)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+
+	tests := []struct {
+		name      string
+		body      string
+		wantKind  string
+		wantFiles []string
+	}{
+		{"archive", `{"url": "https://github.com/owner/repo/issues/1"}`, "archive", []string{"index.md", "manifest.json"}},
+		{"export", `{"repository": "owner/repo", "state": "all", "format": "json"}`, "export", []string{"index.md", "issues/1.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(srv, http.MethodPost, "/api/v1/jobs", tt.body, map[string]string{"Content-Type": "application/json"})
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("submit status = %d: %s", rec.Code, rec.Body)
+			}
+			var submitted jobResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &submitted); err != nil {
+				t.Fatal(err)
+			}
+			if submitted.Kind != tt.wantKind || rec.Header().Get("Location") != submitted.StatusURL {
+				t.Fatalf("submitted = %+v, Location = %q", submitted, rec.Header().Get("Location"))
+			}
+
+			job := waitForJob(t, srv, submitted.StatusURL)
+			if job.Status != JobSucceeded {
+				t.Fatalf("job = %+v, want succeeded", job)
+			}
+
+			rec = do(srv, http.MethodGet, job.ResultURL, "", nil)
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+				t.Fatalf("result status = %d, Content-Type = %q", rec.Code, rec.Header().Get("Content-Type"))
+			}
+			zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+			if err != nil {
+				t.Fatalf("result is not a zip: %v", err)
+			}
+			names := map[string]bool{}
+			for _, f := range zr.File {
+				names[f.Name] = true
+			}
+			for _, want := range tt.wantFiles {
+				if !names[want] {
+					t.Errorf("zip has %v, missing %s", names, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJobErrors(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"not json", http.MethodPost, "/api/v1/jobs", "application/x-www-form-urlencoded", "url=x", http.StatusUnsupportedMediaType},
+		{"empty", http.MethodPost, "/api/v1/jobs", "application/json", `{}`, http.StatusBadRequest},
+		{"unknown field", http.MethodPost, "/api/v1/jobs", "application/json", `{"path": "/etc"}`, http.StatusBadRequest},
+		{"bad repository", http.MethodPost, "/api/v1/jobs", "application/json", `{"repository": "owner"}`, http.StatusBadRequest},
+		{"bad state", http.MethodPost, "/api/v1/jobs", "application/json", `{"repository": "o/r", "state": "merged"}`, http.StatusBadRequest},
+		{"unknown job", http.MethodGet, "/api/v1/jobs/0123", "", "", http.StatusNotFound},
+		{"unknown result", http.MethodGet, "/api/v1/jobs/0123/result", "", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(srv, tt.method, tt.target, tt.body, map[string]string{"Content-Type": tt.contentType})
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
+
+// waitForJob 轮询任务状态直到结束
+func waitForJob(t *testing.T, srv *Server, statusURL string) jobResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := do(srv, http.MethodGet, statusURL, "", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status request = %d: %s", rec.Code, rec.Body)
+		}
+		var job jobResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == JobSucceeded || job.Status == JobFailed {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
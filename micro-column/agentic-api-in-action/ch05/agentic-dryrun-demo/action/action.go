@@ -0,0 +1,73 @@
+package action
+
+import (
+	"fmt"
+	"time"
+)
+
+// Action 是一个 TRANSACT 类动作，执行被拆成三个阶段：
+//
+//	Validate: 业务校验，无论是否 Dry Run 都必须执行
+//	Plan:     计算预测的副作用和警告，不允许写入状态
+//	Commit:   产生真实的、不可逆的副作用，只在非 Dry Run 时执行
+//
+// 三个阶段共享同一个事务，Dry Run 只执行前两个阶段
+// 事务在进入 Commit 之前是只读的，Validate 与 Plan 中调用 tx.Set 会返回 ErrReadOnly
+type Action interface {
+	// Name 返回动作名称，如 "payment"
+	Name() string
+	Validate(tx *Tx) error
+	Plan(tx *Tx) (*Plan, error)
+	// Commit 通过 tx.Set 写入状态，返回给 AI 的执行结果
+	Commit(tx *Tx) (any, error)
+}
+
+// Plan 是 Plan 阶段的产物：预测的副作用与供 AI 决策参考的警告
+type Plan struct {
+	SideEffects any      `json:"predicted_side_effects"` // 各动作自己定义的强类型结构
+	Warnings    []string `json:"warnings"`
+}
+
+// Status 是动作执行的结果状态
+type Status string
+
+const (
+	StatusSimulationSuccess Status = "SIMULATION_SUCCESS"
+	StatusSuccess           Status = "SUCCESS"
+	StatusFailed            Status = "FAILED"
+)
+
+// Report 是所有 TRANSACT 动作统一的响应结构
+type Report struct {
+	Action  string `json:"action"` // 固定为 "TRANSACT"
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	DryRun  bool   `json:"dry_run"`
+	Message string `json:"message,omitempty"`
+
+	// Dry Run 时返回：预测的副作用、警告，以及用于提交这份计划的令牌
+	PredictedSideEffects any        `json:"predicted_side_effects,omitempty"`
+	Warnings             []string   `json:"warnings,omitempty"`
+	PlanToken            string     `json:"plan_token,omitempty"`
+	PlanExpiresAt        *time.Time `json:"plan_expires_at,omitempty"`
+
+	// 真实执行时返回
+	Result any `json:"result,omitempty"`
+
+	// 失败时返回
+	Code    string         `json:"code,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Error 是动作执行失败的原因，携带 HTTP 状态码和供 AI 修正参数的细节
+type Error struct {
+	HTTPStatus int
+	Code       string // 机器可读的错误码，如 "INSUFFICIENT_BALANCE"
+	Message    string
+	Details    map[string]any
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
@@ -0,0 +1,201 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// DefaultPlanTTL 是 Dry Run 签发的计划令牌的有效期
+const DefaultPlanTTL = 5 * time.Minute
+
+// Options 是所有 TRANSACT 动作共享的执行选项，与动作自己的参数放在同一个请求体中
+type Options struct {
+	// TestMode 如果为 true，则仅模拟执行，不产生真实副作用 (也可以用 X-Dry-Run 请求头)
+	TestMode bool `json:"test_mode"`
+	// PlanToken 是 Dry Run 返回的令牌 (也可以用 X-Plan-Token 请求头)
+	// 携带令牌提交时，状态或参数与 Dry Run 时不一致会被拒绝
+	PlanToken string `json:"plan_token"`
+}
+
+// Executor 按 Validate -> Plan -> Commit 的顺序执行动作
+type Executor struct {
+	store  *Store
+	signer *planSigner
+}
+
+// NewExecutor 创建执行器
+func NewExecutor(store *Store) *Executor {
+	return &Executor{
+		store:  store,
+		signer: newPlanSigner(DefaultPlanTTL),
+	}
+}
+
+// Execute 执行一个动作
+// dryRun 为 true 时只执行 Validate 和 Plan，返回模拟报告和计划令牌；
+// 否则在 Commit 之前校验 planToken (如果提供)，并以事务方式写入状态。
+// 返回的 error 总是 *Error
+func (e *Executor) Execute(act Action, dryRun bool, planToken string) (*Report, error) {
+	request, err := json.Marshal(act)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	// 1. 第一阶段：无论是否 Dry Run，都必须执行的【业务校验逻辑】
+	tx := e.store.Begin()
+	if err := act.Validate(tx); err != nil {
+		return nil, asError(err)
+	}
+
+	// 2. 第二阶段：计算预测的副作用
+	plan, err := act.Plan(tx)
+	if err != nil {
+		return nil, asError(err)
+	}
+	claims := planClaims{
+		Action:  act.Name(),
+		Request: request,
+		Reads:   tx.readVersions(),
+		Plan:    plan,
+	}
+
+	// Dry Run 拦截点 (The Safety Net)：到此为止，返回预测报告和计划令牌
+	if dryRun {
+		token, expiresAt, err := e.signer.sign(claims)
+		if err != nil {
+			return nil, internalError(err)
+		}
+		return &Report{
+			Action:               "TRANSACT",
+			Name:                 act.Name(),
+			Status:               StatusSimulationSuccess,
+			DryRun:               true,
+			Message:              "Dry run completed successfully. No side effects were produced.",
+			PredictedSideEffects: plan.SideEffects,
+			Warnings:             plan.Warnings,
+			PlanToken:            token,
+			PlanExpiresAt:        &expiresAt,
+		}, nil
+	}
+
+	if planToken != "" {
+		if err := e.signer.verify(planToken, claims); err != nil {
+			return nil, asError(err)
+		}
+	}
+
+	// 3. 第三阶段：只有真实请求，才会执行【不可逆的副作用】
+	// 事务直到这里才允许写入，Validate、Plan 和 Dry Run 中的 tx.Set 都会失败
+	tx.enableWrites()
+	result, err := act.Commit(tx)
+	if err != nil {
+		return nil, asError(err)
+	}
+	if err := e.store.Commit(tx); err != nil {
+		if errors.Is(err, ErrConflict) {
+			return nil, &Error{
+				HTTPStatus: http.StatusConflict,
+				Code:       "STATE_CONFLICT",
+				Message:    "The state changed while the action was executing. Retry with a new dry run.",
+			}
+		}
+		return nil, internalError(err)
+	}
+
+	// 记录审计日志 (在真实系统中极其重要)
+	fmt.Printf("[AUDIT] Real transaction executed: %s %s\n", act.Name(), request)
+
+	return &Report{
+		Action:  "TRANSACT",
+		Name:    act.Name(),
+		Status:  StatusSuccess,
+		Message: "Action committed successfully.",
+		Result:  result,
+	}, nil
+}
+
+// Handler 把动作包装为 gin 处理函数，newAction 为每个请求创建一个新的动作 (请求参数)
+func (e *Executor) Handler(newAction func() Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		act := newAction()
+		var opts Options
+		if err := c.ShouldBindBodyWith(act, binding.JSON); err != nil {
+			writeError(c, act.Name(), &Error{
+				HTTPStatus: http.StatusBadRequest,
+				Code:       "INVALID_PARAMETERS",
+				Message:    fmt.Sprintf("Invalid %s parameters: %v", act.Name(), err),
+			})
+			return
+		}
+		if err := c.ShouldBindBodyWith(&opts, binding.JSON); err != nil {
+			writeError(c, act.Name(), &Error{
+				HTTPStatus: http.StatusBadRequest,
+				Code:       "INVALID_PARAMETERS",
+				Message:    fmt.Sprintf("Invalid options: %v", err),
+			})
+			return
+		}
+
+		// 无法识别的 X-Dry-Run 直接拒绝，绝不能当作真实执行
+		dryRun := opts.TestMode
+		if header := c.GetHeader("X-Dry-Run"); header != "" {
+			headerDryRun, err := strconv.ParseBool(header)
+			if err != nil {
+				writeError(c, act.Name(), &Error{
+					HTTPStatus: http.StatusBadRequest,
+					Code:       "INVALID_PARAMETERS",
+					Message:    fmt.Sprintf("Invalid X-Dry-Run header %q, expected true or false", header),
+				})
+				return
+			}
+			dryRun = dryRun || headerDryRun
+		}
+		planToken := opts.PlanToken
+		if header := c.GetHeader("X-Plan-Token"); header != "" {
+			planToken = header
+		}
+
+		report, err := e.Execute(act, dryRun, planToken)
+		if err != nil {
+			writeError(c, act.Name(), asError(err))
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// writeError 以统一的报告结构返回失败
+func writeError(c *gin.Context, name string, err *Error) {
+	c.JSON(err.HTTPStatus, &Report{
+		Action:  "TRANSACT",
+		Name:    name,
+		Status:  StatusFailed,
+		Code:    err.Code,
+		Error:   err.Message,
+		Details: err.Details,
+	})
+}
+
+// asError 把动作返回的错误统一转换为 *Error，未知错误按内部错误处理
+func asError(err error) *Error {
+	var actionErr *Error
+	if errors.As(err, &actionErr) {
+		return actionErr
+	}
+	return internalError(err)
+}
+
+func internalError(err error) *Error {
+	return &Error{
+		HTTPStatus: http.StatusInternalServerError,
+		Code:       "INTERNAL_ERROR",
+		Message:    err.Error(),
+	}
+}
@@ -0,0 +1,260 @@
+package action
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// debit 是测试用的扣款动作，与 handlers.PaymentRequest 的三个阶段一致
+type debit struct {
+	Account string  `json:"account" binding:"required"`
+	Amount  float64 `json:"amount" binding:"required,gt=0"`
+
+	writeInPlan  bool   // 为 true 时在 Plan 阶段违规写入
+	beforeCommit func() // 可选，在 Commit 阶段读取余额之后调用
+}
+
+func (d *debit) Name() string { return "debit" }
+
+func (d *debit) Validate(tx *Tx) error {
+	balance, ok := tx.Get(d.Account)
+	if !ok {
+		return &Error{HTTPStatus: http.StatusNotFound, Code: "ACCOUNT_NOT_FOUND", Message: "account not found"}
+	}
+	if balance < d.Amount {
+		return &Error{HTTPStatus: http.StatusPaymentRequired, Code: "INSUFFICIENT_BALANCE", Message: "insufficient balance"}
+	}
+	return nil
+}
+
+func (d *debit) Plan(tx *Tx) (*Plan, error) {
+	balance, _ := tx.Get(d.Account)
+	if d.writeInPlan {
+		if err := tx.Set(d.Account, balance-d.Amount); err != nil {
+			return nil, err
+		}
+	}
+	return &Plan{SideEffects: map[string]float64{"new_balance": balance - d.Amount}}, nil
+}
+
+func (d *debit) Commit(tx *Tx) (any, error) {
+	balance, _ := tx.Get(d.Account)
+	if d.beforeCommit != nil {
+		d.beforeCommit()
+	}
+	if err := tx.Set(d.Account, balance-d.Amount); err != nil {
+		return nil, err
+	}
+	return map[string]float64{"new_balance": balance - d.Amount}, nil
+}
+
+func newTestExecutor() (*Executor, *Store) {
+	store := NewStore(map[string]float64{"alice": 100})
+	return NewExecutor(store), store
+}
+
+// balance 读取已提交的余额与版本号
+func balance(s *Store, key string) (float64, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key], s.versions[key]
+}
+
+func assertCode(t *testing.T, err error, status int, code string) {
+	t.Helper()
+	var actionErr *Error
+	if !errors.As(err, &actionErr) {
+		t.Fatalf("err = %v, want *Error %s", err, code)
+	}
+	if actionErr.HTTPStatus != status || actionErr.Code != code {
+		t.Fatalf("err = %d %s, want %d %s", actionErr.HTTPStatus, actionErr.Code, status, code)
+	}
+}
+
+func TestDryRunProducesNoWrite(t *testing.T) {
+	e, store := newTestExecutor()
+
+	report, err := e.Execute(&debit{Account: "alice", Amount: 30}, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != StatusSimulationSuccess || !report.DryRun || report.PlanToken == "" {
+		t.Fatalf("report = %+v, want a simulation with a plan token", report)
+	}
+	if value, version := balance(store, "alice"); value != 100 || version != 1 {
+		t.Fatalf("balance = %v (version %d), want 100 (version 1)", value, version)
+	}
+}
+
+func TestCommitWithValidToken(t *testing.T) {
+	e, store := newTestExecutor()
+
+	dry, err := e.Execute(&debit{Account: "alice", Amount: 30}, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := e.Execute(&debit{Account: "alice", Amount: 30}, false, dry.PlanToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != StatusSuccess {
+		t.Fatalf("status = %s, want %s", report.Status, StatusSuccess)
+	}
+	if value, _ := balance(store, "alice"); value != 70 {
+		t.Fatalf("balance = %v, want 70", value)
+	}
+}
+
+func TestPlanStaleAfterBalanceChange(t *testing.T) {
+	e, store := newTestExecutor()
+
+	dry, err := e.Execute(&debit{Account: "alice", Amount: 30}, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Dry Run 之后余额被其他请求修改
+	if _, err := e.Execute(&debit{Account: "alice", Amount: 5}, false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = e.Execute(&debit{Account: "alice", Amount: 30}, false, dry.PlanToken)
+	assertCode(t, err, http.StatusConflict, "PLAN_STALE")
+	if value, _ := balance(store, "alice"); value != 95 {
+		t.Fatalf("balance = %v, want 95", value)
+	}
+}
+
+func TestChangedAmountRejected(t *testing.T) {
+	e, store := newTestExecutor()
+
+	dry, err := e.Execute(&debit{Account: "alice", Amount: 30}, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = e.Execute(&debit{Account: "alice", Amount: 60}, false, dry.PlanToken)
+	assertCode(t, err, http.StatusConflict, "PLAN_STALE")
+	if value, _ := balance(store, "alice"); value != 100 {
+		t.Fatalf("balance = %v, want 100", value)
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	e, store := newTestExecutor()
+
+	dry, err := e.Execute(&debit{Account: "alice", Amount: 30}, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.signer.now = func() time.Time { return time.Now().Add(DefaultPlanTTL + time.Minute) }
+
+	_, err = e.Execute(&debit{Account: "alice", Amount: 30}, false, dry.PlanToken)
+	assertCode(t, err, http.StatusConflict, "PLAN_EXPIRED")
+	if value, _ := balance(store, "alice"); value != 100 {
+		t.Fatalf("balance = %v, want 100", value)
+	}
+}
+
+func TestTokenReplayedAfterCommit(t *testing.T) {
+	e, store := newTestExecutor()
+
+	dry, err := e.Execute(&debit{Account: "alice", Amount: 30}, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Execute(&debit{Account: "alice", Amount: 30}, false, dry.PlanToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// 提交改变了余额的版本号，同一个令牌不能再扣一次款
+	_, err = e.Execute(&debit{Account: "alice", Amount: 30}, false, dry.PlanToken)
+	assertCode(t, err, http.StatusConflict, "PLAN_STALE")
+	if value, _ := balance(store, "alice"); value != 70 {
+		t.Fatalf("balance = %v, want 70", value)
+	}
+}
+
+func TestConcurrentCommitsConflict(t *testing.T) {
+	e, store := newTestExecutor()
+
+	// 两个请求都读到同一版本的余额后才继续提交
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	barrier := func() {
+		arrived.Done()
+		arrived.Wait()
+	}
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = e.Execute(&debit{Account: "alice", Amount: 30, beforeCommit: barrier}, false, "")
+		}()
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assertCode(t, err, http.StatusConflict, "STATE_CONFLICT")
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d commits succeeded, want exactly 1 (errs = %v)", succeeded, errs)
+	}
+	if value, _ := balance(store, "alice"); value != 70 {
+		t.Fatalf("balance = %v, want 70", value)
+	}
+}
+
+func TestSetIsReadOnlyBeforeCommit(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		e, store := newTestExecutor()
+
+		_, err := e.Execute(&debit{Account: "alice", Amount: 30, writeInPlan: true}, dryRun, "")
+		if err == nil {
+			t.Fatalf("dryRun=%v: write in Plan should fail", dryRun)
+		}
+		assertCode(t, err, http.StatusInternalServerError, "INTERNAL_ERROR")
+		if value, version := balance(store, "alice"); value != 100 || version != 1 {
+			t.Fatalf("dryRun=%v: balance = %v (version %d), want 100 (version 1)", dryRun, value, version)
+		}
+	}
+
+	tx := NewStore(nil).Begin()
+	if err := tx.Set("alice", 1); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Set on a new tx = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestHandlerRejectsInvalidDryRunHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e, store := newTestExecutor()
+	r := gin.New()
+	r.POST("/debit", e.Handler(func() Action { return &debit{} }))
+
+	req := httptest.NewRequest(http.MethodPost, "/debit", strings.NewReader(`{"account":"alice","amount":30}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Dry-Run", "maybe")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// 无法识别的 X-Dry-Run 绝不能被当作真实执行
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "INVALID_PARAMETERS") {
+		t.Fatalf("response = %d %s, want 400 INVALID_PARAMETERS", w.Code, w.Body)
+	}
+	if value, _ := balance(store, "alice"); value != 100 {
+		t.Fatalf("balance = %v, want 100", value)
+	}
+}
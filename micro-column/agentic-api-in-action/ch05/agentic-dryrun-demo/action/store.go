@@ -0,0 +1,103 @@
+package action
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrConflict 表示事务读取过的状态在提交前已被其他请求修改
+var ErrConflict = errors.New("state changed by another request, please retry")
+
+// ErrReadOnly 表示在 Validate、Plan 阶段或 Dry Run 中试图写入状态
+var ErrReadOnly = errors.New("transaction is read-only outside the commit phase")
+
+// Store 是带版本号的内存状态存储 (模拟后端数据库)
+// 每个键每被写入一次，版本号加一，用于发现 Dry Run 与真实执行之间的状态变化
+type Store struct {
+	mu       sync.Mutex
+	values   map[string]float64
+	versions map[string]uint64
+}
+
+// NewStore 用初始数据创建状态存储
+func NewStore(initial map[string]float64) *Store {
+	s := &Store{
+		values:   make(map[string]float64),
+		versions: make(map[string]uint64),
+	}
+	for key, value := range initial {
+		s.values[key] = value
+		s.versions[key] = 1
+	}
+	return s
+}
+
+// Begin 开启一个只读事务，执行器进入 Commit 阶段时才允许写入
+// 事务内的读取会记录版本号，写入先缓存在事务中，直到 Commit 才真正生效
+func (s *Store) Begin() *Tx {
+	return &Tx{
+		store:  s,
+		reads:  make(map[string]uint64),
+		writes: make(map[string]float64),
+	}
+}
+
+// Commit 提交事务：读取过的键版本号都未变化时才写入，否则返回 ErrConflict (乐观锁)
+func (s *Store) Commit(tx *Tx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, version := range tx.reads {
+		if s.versions[key] != version {
+			return ErrConflict
+		}
+	}
+	for key, value := range tx.writes {
+		s.values[key] = value
+		s.versions[key]++
+	}
+	return nil
+}
+
+// Tx 是一次动作执行中的事务视图
+type Tx struct {
+	store  *Store
+	reads  map[string]uint64 // 读取过的键及当时的版本号，0 表示读取时不存在
+	writes map[string]float64
+	// writable 只在 Commit 阶段为 true，Dry Run 的事务自始至终都是只读的
+	writable bool
+}
+
+// Get 读取一个键，优先返回本事务中尚未提交的写入
+func (tx *Tx) Get(key string) (float64, bool) {
+	if value, ok := tx.writes[key]; ok {
+		return value, true
+	}
+
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+	value, exists := tx.store.values[key]
+	if _, seen := tx.reads[key]; !seen {
+		tx.reads[key] = tx.store.versions[key]
+	}
+	return value, exists
+}
+
+// Set 在事务中写入一个键，只能在 Commit 阶段调用，否则返回 ErrReadOnly
+func (tx *Tx) Set(key string, value float64) error {
+	if !tx.writable {
+		return ErrReadOnly
+	}
+	tx.writes[key] = value
+	return nil
+}
+
+// enableWrites 进入 Commit 阶段，由执行器在校验完计划令牌之后调用
+func (tx *Tx) enableWrites() {
+	tx.writable = true
+}
+
+// readVersions 返回事务读取过的键和版本号，用于生成计划令牌
+func (tx *Tx) readVersions() map[string]uint64 {
+	return tx.reads
+}
@@ -0,0 +1,97 @@
+package action
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// planSigner 为 Dry Run 的计划签发令牌
+//
+// 令牌绑定了动作名、请求参数、Plan 阶段读取到的状态版本和预测结果。
+// 真实执行时用当前状态重新计算一遍，只要任何一项不同，令牌就对不上，
+// 从而保证 AI 提交的正是它在 Dry Run 中看到的那份计划
+type planSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// newPlanSigner 创建签名器，密钥在进程启动时随机生成，重启后旧令牌全部失效
+func newPlanSigner(ttl time.Duration) *planSigner {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &planSigner{key: key, ttl: ttl, now: time.Now}
+}
+
+// planClaims 是参与签名的内容，json.Marshal 对 map 的键排序，序列化结果是确定的
+type planClaims struct {
+	Action    string            `json:"action"`
+	Request   json.RawMessage   `json:"request"`
+	Reads     map[string]uint64 `json:"reads"`
+	Plan      *Plan             `json:"plan"`
+	ExpiresAt int64             `json:"expires_at"`
+}
+
+// sign 签发令牌，格式为 "<过期时间戳>.<签名>"
+func (s *planSigner) sign(claims planClaims) (string, time.Time, error) {
+	expiresAt := s.now().Add(s.ttl).Truncate(time.Second)
+	claims.ExpiresAt = expiresAt.Unix()
+	mac, err := s.mac(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return fmt.Sprintf("%d.%s", claims.ExpiresAt, mac), expiresAt, nil
+}
+
+// verify 校验令牌是否与当前重新计算的计划一致
+func (s *planSigner) verify(token string, claims planClaims) error {
+	expires, mac, ok := strings.Cut(token, ".")
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if !ok || err != nil {
+		return &Error{
+			HTTPStatus: http.StatusBadRequest,
+			Code:       "INVALID_PLAN_TOKEN",
+			Message:    "Malformed plan token.",
+		}
+	}
+	if s.now().Unix() > expiresAt {
+		return &Error{
+			HTTPStatus: http.StatusConflict,
+			Code:       "PLAN_EXPIRED",
+			Message:    "The plan token has expired. Run a new dry run before committing.",
+		}
+	}
+
+	claims.ExpiresAt = expiresAt
+	expected, err := s.mac(claims)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(mac), []byte(expected)) {
+		return &Error{
+			HTTPStatus: http.StatusConflict,
+			Code:       "PLAN_STALE",
+			Message:    "The state or the request changed since the dry run. Run a new dry run and review the plan again.",
+		}
+	}
+	return nil
+}
+
+// mac 计算 claims 的 HMAC-SHA256 签名
+func (s *planSigner) mac(claims planClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode plan: %w", err)
+	}
+	h := hmac.New(sha256.New, s.key)
+	h.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)), nil
+}
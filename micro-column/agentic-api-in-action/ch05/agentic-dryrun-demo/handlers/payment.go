@@ -1,99 +1,104 @@
 package handlers
 
 import (
+	"agentic-dryrun-demo/action"
 	"fmt"
 	"net/http"
-
-	"github.com/gin-gonic/gin"
 )
 
-// PaymentRequest 定义了支付所需的参数，以及极其重要的 TestMode 标志
+// PaymentRequest 定义了支付所需的参数
+// test_mode 和 plan_token 等执行选项由 action.Options 统一处理
 type PaymentRequest struct {
 	UserID string  `json:"user_id" binding:"required"`
 	Amount float64 `json:"amount" binding:"required,gt=0"`
-	// TestMode 如果为 true，则仅模拟执行，不产生真实扣款
-	TestMode bool `json:"test_mode"`
 }
 
-// 模拟的后端数据库状态
-var userBalance = map[string]float64{
-	"user_123": 100.00,
+// PaymentEffects 是支付的副作用，Dry Run 时为预测值
+type PaymentEffects struct {
+	DeductedAmount float64 `json:"deducted_amount"`
+	NewBalance     float64 `json:"predicted_new_balance"`
 }
 
-// ProcessPayment 处理支付请求，支持 Dry Run 模式
-func ProcessPayment(c *gin.Context) {
-	var req PaymentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment parameters"})
-		return
-	}
+// PaymentResult 是真实扣款后的结果
+type PaymentResult struct {
+	DeductedAmount float64 `json:"deducted_amount"`
+	NewBalance     float64 `json:"new_balance"`
+}
 
-	// 1. 第一阶段：无论是否是 TestMode，都必须执行的【业务校验逻辑】
+// lowBalanceThreshold 扣款后余额低于该值时给出警告
+const lowBalanceThreshold = 10.0
 
+// NewPayment 为每个请求创建一个支付动作，供 action.Executor.Handler 使用
+func NewPayment() action.Action {
+	return &PaymentRequest{}
+}
+
+// BalanceKey 返回用户余额在状态存储中的键
+func BalanceKey(userID string) string {
+	return "balance:" + userID
+}
+
+// Name 返回动作名称
+func (p *PaymentRequest) Name() string {
+	return "payment"
+}
+
+// Validate 执行无论是否 Dry Run 都必须的业务校验
+func (p *PaymentRequest) Validate(tx *action.Tx) error {
 	// 校验 1：用户是否存在
-	currentBalance, exists := userBalance[req.UserID]
+	currentBalance, exists := tx.Get(BalanceKey(p.UserID))
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status": "FAILED",
-			"error":  fmt.Sprintf("User %s not found", req.UserID),
-		})
-		return
+		return &action.Error{
+			HTTPStatus: http.StatusNotFound,
+			Code:       "USER_NOT_FOUND",
+			Message:    fmt.Sprintf("User %s not found", p.UserID),
+		}
 	}
 
 	// 校验 2：余额是否充足
-	if currentBalance < req.Amount {
-		c.JSON(http.StatusPaymentRequired, gin.H{
-			"status": "FAILED",
-			"error":  "Insufficient balance",
-			"details": gin.H{
+	if currentBalance < p.Amount {
+		return &action.Error{
+			HTTPStatus: http.StatusPaymentRequired,
+			Code:       "INSUFFICIENT_BALANCE",
+			Message:    "Insufficient balance",
+			Details: map[string]any{
 				"current_balance": currentBalance,
-				"shortfall":       req.Amount - currentBalance,
+				"shortfall":       p.Amount - currentBalance,
 			},
-		})
-		return
+		}
 	}
+	return nil
+}
 
-	// 2. 第二阶段：Dry Run 拦截点 (The Safety Net)
-
-	// 如果是测试模式，到此为止！构造预测报告并返回。
-	if req.TestMode {
-		predictedNewBalance := currentBalance - req.Amount
+// Plan 预测扣款后的余额，并生成供 AI 决策参考的警告
+func (p *PaymentRequest) Plan(tx *action.Tx) (*action.Plan, error) {
+	currentBalance, _ := tx.Get(BalanceKey(p.UserID))
+	predictedNewBalance := currentBalance - p.Amount
 
-		// 模拟生成一些警告信息，供 AI 决策参考
-		var warnings []string
-		if predictedNewBalance < 10.0 {
-			warnings = append(warnings, "Warning: Balance will drop below $10.00 after this transaction.")
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"action":  "TRANSACT",
-			"status":  "SIMULATION_SUCCESS",
-			"message": "Dry run completed successfully. No funds were actually deducted.",
-			"predicted_side_effects": gin.H{
-				"deducted_amount":       req.Amount,
-				"predicted_new_balance": predictedNewBalance,
-			},
-			"warnings": warnings,
-		})
-		return
+	var warnings []string
+	if predictedNewBalance < lowBalanceThreshold {
+		warnings = append(warnings, fmt.Sprintf("Warning: Balance will drop below $%.2f after this transaction.", lowBalanceThreshold))
 	}
 
-	// 3. 第三阶段：只有真实的生产请求，才会执行【不可逆的副作用】(The Side Effects)
-
-	// 实际扣款 (模拟更新数据库)
-	userBalance[req.UserID] = currentBalance - req.Amount
-	actualNewBalance := userBalance[req.UserID]
+	return &action.Plan{
+		SideEffects: PaymentEffects{
+			DeductedAmount: p.Amount,
+			NewBalance:     predictedNewBalance,
+		},
+		Warnings: warnings,
+	}, nil
+}
 
-	// 记录审计日志 (在真实系统中极其重要)
-	fmt.Printf("[AUDIT] Real transaction executed for User: %s, Amount: $%.2f\n", req.UserID, req.Amount)
+// Commit 实际扣款 (写入事务，由执行器统一提交)
+func (p *PaymentRequest) Commit(tx *action.Tx) (any, error) {
+	currentBalance, _ := tx.Get(BalanceKey(p.UserID))
+	newBalance := currentBalance - p.Amount
+	if err := tx.Set(BalanceKey(p.UserID), newBalance); err != nil {
+		return nil, err
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"action":  "TRANSACT",
-		"status":  "SUCCESS",
-		"message": "Payment processed successfully.",
-		"result": gin.H{
-			"deducted_amount": req.Amount,
-			"new_balance":     actualNewBalance,
-		},
-	})
+	return PaymentResult{
+		DeductedAmount: p.Amount,
+		NewBalance:     newBalance,
+	}, nil
 }
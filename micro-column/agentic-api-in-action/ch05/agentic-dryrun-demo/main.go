@@ -1,6 +1,7 @@
 package main
 
 import (
+	"agentic-dryrun-demo/action"
 	"agentic-dryrun-demo/handlers"
 	"log"
 
@@ -10,10 +11,18 @@ import (
 func main() {
 	r := gin.Default()
 
+	// 模拟的后端数据库状态
+	store := action.NewStore(map[string]float64{
+		handlers.BalanceKey("user_123"): 100.00,
+	})
+	executor := action.NewExecutor(store)
+
 	agenticAPI := r.Group("/agentic/v1")
 	{
 		// 注册高危的支付动作接口
-		agenticAPI.POST("/transact/payment", handlers.ProcessPayment)
+		// Dry Run: 请求体中 "test_mode": true，或请求头 X-Dry-Run: true
+		// 真实执行时可携带 Dry Run 返回的 plan_token (或 X-Plan-Token)，确保执行的正是预演过的计划
+		agenticAPI.POST("/transact/payment", executor.Handler(handlers.NewPayment))
 	}
 
 	log.Println("Agentic Dry Run Server running on http://localhost:8080")
@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"rod-demo/internal/controller"
 	"rod-demo/internal/middleware"
 	"rod-demo/internal/router"
+	"rod-demo/pkg/auth"
+	"rod-demo/pkg/idempotency"
 	"rod-demo/pkg/limiter"
+	"rod-demo/pkg/outbox"
+	"rod-demo/pkg/pagination"
 	"rod-demo/pkg/redis"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,30 +24,118 @@ func main() {
 	// 初始化 Redis
 	redis.Init()
 	rateLimiter := limiter.NewLimiter(redis.Client)
+	concurrencyLimiter := limiter.NewConcurrencyLimiter(redis.Client)
 
-	r := gin.Default()
-
-	// 定义限流规则
-	// 例如：每分钟 10 次，允许突发 10 次
-	generalLimit := limiter.LimitDefinition{
-		Rate:   10,
-		Period: time.Minute,
-		Burst:  10,
+	// 配置分页 Token 的签名密钥
+	// 多实例部署时必须共享同一组密钥，否则 A 实例签发的 Token 在 B 实例上无法校验
+	if keys := pageTokenKeyring(); keys != nil {
+		pagination.SetDefault(pagination.NewManager(pagination.NewHMACCodec(keys), pagination.DefaultTTL))
 	}
 
+	r := gin.Default()
+
 	// 注册全局错误处理中间件
 	r.Use(middleware.ErrorHandler())
 
 	// API 版本控制（这也是一种资源层级）
 	v1 := r.Group("/api/v1")
 
+	// 认证：解析 API Key / JWT，并注入权限表
+	// 必须先于幂等中间件执行，后续中间件才能拿到调用方身份
+	v1.Use(middleware.Authenticate(newAuthenticator(), router.Permissions))
+
 	// 使用幂等性中间件
 	// 通常只针对非 GET/DELETE 请求启用
-	v1.Use(middleware.Idempotency())
+	// 并发的重复请求最多等待 10 秒，拿到第一个请求的结果后直接返回
+	v1.Use(middleware.Idempotency(idempotency.NewRedisStore(redis.Client), 10*time.Second))
+
+	// 限流：按调用方的套餐 (free / pro) 选择配额，规则定义见 router.Plans
+	// 匿名请求按 IP 计数，已认证的请求按调用方计数
+	plans := middleware.PlanByPrincipal(router.Plans, router.DefaultPlan)
+	v1.Use(middleware.Quota(rateLimiter, middleware.PrincipalKeyStrategy, plans))
+	v1.Use(middleware.ConcurrencyLimit(concurrencyLimiter, middleware.PrincipalKeyStrategy, plans))
 
-	v1.Use(middleware.RateLimit(rateLimiter, middleware.IPKeyStrategy, generalLimit))
+	// 发件箱：Handler 暂存的领域事件在请求成功后写入，由 Webhook 分发器异步投递
+	events := outbox.NewMemoryStore()
+	v1.Use(middleware.Outbox(events))
 
-	router.SetupRoutes(r)
+	background := router.SetupRoutes(v1, events)
+
+	// 后台定期永久清除过了保留期的软删除资源
+	stopPurger := controller.StartPurger(time.Minute, background.Purgers...)
+
+	// 注意：必须使用 r.Run 启动，它会还原路由中转义的冒号 (如 /users\:batchGet)，
+	// 直接把 r 交给 http.Server 时这些自定义方法会全部 404
+	errCh := make(chan error, 1)
+	go func() { errCh <- r.Run(":8080") }()
+
+	// 收到退出信号后停止后台任务，stop 会等待正在进行的一轮清理结束，避免进程退出时执行到一半
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-errCh:
+		log.Printf("server stopped: %v", err)
+	case <-ctx.Done():
+		log.Println("shutting down")
+	}
+	stopPurger()
+	background.StopDispatcher()
+}
 
-	r.Run(":8080")
+// pageTokenKeyring 根据环境变量构建分页 Token 的密钥环，未配置时返回 nil (使用进程内随机密钥)
+// - PAGE_TOKEN_KEYS: 多把密钥，格式 "v2:secret2,v1:secret1"，用于密钥轮换
+// - PAGE_TOKEN_ACTIVE_KEY: 签发新 Token 使用的 Key ID，默认为 PAGE_TOKEN_KEYS 中的第一把
+// - PAGE_TOKEN_SECRET: 单把密钥的简写，等价于 PAGE_TOKEN_KEYS="v1:<secret>"
+func pageTokenKeyring() *pagination.Keyring {
+	spec := os.Getenv("PAGE_TOKEN_KEYS")
+	if spec == "" {
+		secret := os.Getenv("PAGE_TOKEN_SECRET")
+		if secret == "" {
+			return nil
+		}
+		spec = "v1:" + secret
+	}
+	keys, err := pagination.ParseKeyring(spec, os.Getenv("PAGE_TOKEN_ACTIVE_KEY"))
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
+// newAuthenticator 根据环境变量构建认证器
+// - DEMO_API_KEY: 登记一个拥有全部 scope 的演示 API Key (只保存其哈希)
+// - JWT_HS256_SECRET: HS256 共享密钥
+// - JWKS_FILE: RS256 公钥集合文件路径
+// - JWT_ISSUER / JWT_AUDIENCE: 期望的 iss / aud
+func newAuthenticator() *auth.Authenticator {
+	apiKeys := auth.NewMemoryAPIKeyStore()
+	if key := os.Getenv("DEMO_API_KEY"); key != "" {
+		apiKeys.Add(auth.HashAPIKey(key), auth.Principal{
+			ID:   "demo",
+			Plan: "pro",
+			Scopes: []string{
+				"users.write", "orders.write", "operations.read", "ai.generate", "ai.chat",
+				"webhooks.manage",
+			},
+		})
+	}
+
+	opts := auth.JWTOptions{
+		HMACSecret: []byte(os.Getenv("JWT_HS256_SECRET")),
+		Issuer:     os.Getenv("JWT_ISSUER"),
+		Audience:   os.Getenv("JWT_AUDIENCE"),
+		Leeway:     30 * time.Second,
+	}
+	if path := os.Getenv("JWKS_FILE"); path != "" {
+		keys, err := auth.LoadJWKS(path)
+		if err != nil {
+			panic("failed to load jwks: " + err.Error())
+		}
+		opts.RSAKeys = keys
+	}
+
+	return &auth.Authenticator{
+		APIKeys: apiKeys,
+		JWT:     auth.NewJWTVerifier(opts),
+	}
 }
@@ -1,19 +1,27 @@
 package controller
 
 import (
-	"io"
+	"context"
 	"net/http"
+	"rod-demo/internal/middleware"
 	"rod-demo/internal/task"
+	"rod-demo/pkg/errs"
+	"rod-demo/pkg/stream"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-type AIController struct{}
+type AIController struct {
+	// streams 管理所有 SSE 流，生成过程与 HTTP 连接解耦，断线后可按 Last-Event-ID 续传
+	streams *stream.Hub
+}
 
 func NewAIController() *AIController {
-	return &AIController{}
+	return &AIController{
+		streams: stream.NewHub(stream.DefaultOptions),
+	}
 }
 
 // GenerateImage 处理 LRO 任务提交
@@ -32,8 +40,8 @@ func (c *AIController) GenerateImage(ctx *gin.Context) {
 	// 2. 生成 Operation ID
 	opID := uuid.New().String()
 
-	// 3. 触发后台异步任务
-	task.StartImageGeneration(opID, req.Prompt)
+	// 3. 触发后台异步任务，完成事件只推送给发起者的 Webhook 订阅
+	task.StartImageGeneration(opID, middleware.CurrentOwner(ctx), req.Prompt)
 
 	// 4. 立即返回 LRO 对象 (初始状态)
 	op := task.GetOperation(opID)
@@ -42,43 +50,89 @@ func (c *AIController) GenerateImage(ctx *gin.Context) {
 
 // ChatStream 处理流式对话
 // Custom Method: POST /chat:stream
+// 如果请求携带 Last-Event-ID 且对应的流仍在缓冲中，则从断点续传，而不是重新生成
+// 对话流绑定创建者，其他调用方即使拿到流 ID 也无法续传
 func (c *AIController) ChatStream(ctx *gin.Context) {
-	// 1. 设置 SSE 专用 Header
-	ctx.Header("Content-Type", "text/event-stream")
-	ctx.Header("Cache-Control", "no-cache")
-	ctx.Header("Connection", "keep-alive")
-	// 告诉 Nginx 不要在缓冲区等待，立即下发
-	ctx.Header("X-Accel-Buffering", "no")
-
-	// 2. 开启流
-	// c.Stream 会持续保持连接，直到返回 false 或客户端断开
-	ctx.Stream(func(w io.Writer) bool {
-		// 模拟：LLM 逐字生成
-		words := []string{"Hello", " ", "I", " ", "am", " ", "Tony", " ", "Bai", "."}
-
-		for _, word := range words {
-			// 模拟思考延迟
-			time.Sleep(2 * time.Second)
-
-			// 检查客户端是否断开 (Context Done)
-			// 这是一个非常重要的细节，防止服务端空转浪费 Token
+	owner := middleware.CurrentOwner(ctx)
+	if s, ok := c.streams.Resume(stream.LastEventID(ctx), owner); ok {
+		c.streams.Serve(ctx, s)
+		return
+	}
+
+	s := c.streams.Open(uuid.New().String(), owner, chatProducer)
+	c.streams.Serve(ctx, s)
+}
+
+// ResumeChatStream 按流 ID 续传对话
+// 映射路由: GET /chat/streams/:id
+// 浏览器原生 EventSource 只能发 GET，重连时会自动带上 Last-Event-ID
+func (c *AIController) ResumeChatStream(ctx *gin.Context) {
+	s, ok := c.streams.Lookup(ctx.Param("id"), middleware.CurrentOwner(ctx))
+	if !ok {
+		ctx.Error(errs.New(errs.ErrNotFound, "stream not found or expired"))
+		return
+	}
+	c.streams.Serve(ctx, s)
+}
+
+// WatchOperation 以 SSE 推送 LRO 的进度，替代客户端轮询
+// 映射路由: GET /operations/:id/watch
+func (c *AIController) WatchOperation(ctx *gin.Context) {
+	opID := ctx.Param("id")
+	if task.GetOperation(opID) == nil {
+		ctx.Error(errs.New(errs.ErrNotFound, "operation not found"))
+		return
+	}
+
+	// 同一个 Operation 的多个监听者共享一个流 (owner 为空)，与 GET /operations/:id 的可见范围一致
+	s := c.streams.Open("operations/"+opID, "", operationProducer(opID))
+	c.streams.Serve(ctx, s)
+}
+
+// chatProducer 模拟 LLM 逐字生成
+// 客户端全部断开且超过空闲时间后 ctx 会被取消，此时立即停止生成，防止服务端空转浪费 Token
+func chatProducer(ctx context.Context, emit stream.Emitter) error {
+	words := []string{"Hello", " ", "I", " ", "am", " ", "Tony", " ", "Bai", "."}
+
+	for _, word := range words {
+		// 模拟思考延迟
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+
+		// 格式: data: {"delta": "..."}
+		if err := emit("message", map[string]string{"delta": word}); err != nil {
+			return err
+		}
+	}
+
+	// 发送结束信号
+	return emit("done", "[DONE]")
+}
+
+// operationProducer 将 Operation 的状态变化转换为 SSE 事件
+func operationProducer(opID string) stream.Producer {
+	return func(ctx context.Context, emit stream.Emitter) error {
+		updates, cancel, ok := task.Watch(opID)
+		if !ok {
+			return errs.New(errs.ErrNotFound, "operation not found")
+		}
+		defer cancel()
+
+		for {
 			select {
 			case <-ctx.Done():
-				return false // 停止生成
-			default:
-				// 发送 SSE 事件
-				// 格式: data: {"delta": "..."}\n\n
-				ctx.SSEvent("message", map[string]string{
-					"delta": word,
-				})
-
-				// 告诉 Gin 立即将数据推送到网络，不要等待
-				ctx.Writer.Flush()
+				return ctx.Err()
+			case op := <-updates:
+				if op.Done {
+					return emit("done", op)
+				}
+				if err := emit("progress", op); err != nil {
+					return err
+				}
 			}
 		}
-
-		// 发送结束信号
-		ctx.SSEvent("done", "[DONE]")
-		return false // 返回 false 结束流
-	})
+	}
 }
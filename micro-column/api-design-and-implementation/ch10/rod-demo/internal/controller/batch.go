@@ -0,0 +1,401 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"rod-demo/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// 批量请求的限制
+const (
+	MaxBatchSize      = 100     // 单次批量操作的最大条目数
+	MaxBatchBodyBytes = 1 << 20 // 批量请求体的最大字节数 (1MB)
+)
+
+// BatchService 是可选接口 (AIP-231 ~ AIP-235)
+// Service 实现它即可提供原生的批量能力 (如一条 SQL 批量插入、在同一个事务中完成)，
+// 原生实现必须是原子的：要么全部成功，要么全部失败。失败时用 WithIndex 标明出错的条目
+// 未实现时，BaseController 会退化为逐条调用 CRUDService：
+// - batchGet / batchCreate 的原子模式分别靠"任一失败即整体失败"与补偿删除保证
+// - batchUpdate / batchDelete 无法可靠补偿 (更新前的状态与物理删除都无法恢复)，只支持部分成功模式
+type BatchService[T any, CreateReq any, UpdateReq any] interface {
+	BatchGet(ctx *gin.Context, ids []string) ([]*T, error)
+	BatchCreate(ctx *gin.Context, reqs []*CreateReq) ([]*T, error)
+	BatchUpdate(ctx *gin.Context, reqs []BatchUpdateItem[UpdateReq]) ([]*T, error)
+	// BatchDelete 软删除资源返回被标记的资源，物理删除返回 nil
+	BatchDelete(ctx *gin.Context, ids []string) ([]*T, error)
+}
+
+// BatchController 定义批量方法的 HTTP Handler，BaseController 默认实现
+type BatchController interface {
+	BatchGet(c *gin.Context)    // GET  /collection:batchGet?ids=1&ids=2
+	BatchCreate(c *gin.Context) // POST /collection:batchCreate
+	BatchUpdate(c *gin.Context) // POST /collection:batchUpdate
+	BatchDelete(c *gin.Context) // POST /collection:batchDelete
+}
+
+// BatchUpdateItem 是批量更新中的单个条目
+type BatchUpdateItem[U any] struct {
+	ID     string `json:"id"`
+	Update *U     `json:"update"`
+}
+
+// batchRequest 是批量写操作的通用请求体
+// 条目先以 RawMessage 接收，再逐条绑定与校验，这样部分成功模式下可以给出逐条的错误
+type batchRequest struct {
+	Requests []json.RawMessage `json:"requests"`
+	// AllowPartialSuccess 为 false (默认) 时是原子模式：任意一条失败，整个请求失败
+	// (batchUpdate / batchDelete 的原子模式要求资源实现 BatchService，否则返回 400)
+	// 为 true 时是部分成功模式：逐条执行，响应中返回每一条的结果
+	AllowPartialSuccess bool `json:"allow_partial_success"`
+}
+
+// batchDeleteRequest 是批量删除的请求体 (AIP-235)
+type batchDeleteRequest struct {
+	IDs                 []string `json:"ids"`
+	AllowPartialSuccess bool     `json:"allow_partial_success"`
+}
+
+// BatchResult 是部分成功模式下单个条目的结果，Item 与 Error 二选一
+type BatchResult[T any] struct {
+	Index int                  `json:"index"`
+	Item  *T                   `json:"item,omitempty"`
+	Error *errs.ProblemDetails `json:"error,omitempty"`
+}
+
+// BatchResponse 是批量操作的响应
+// 原子模式返回 Items；部分成功模式返回 Results
+type BatchResponse[T any] struct {
+	Items   []*T             `json:"items,omitempty"`
+	Results []BatchResult[T] `json:"results,omitempty"`
+}
+
+// BatchGet 处理 GET /resources:batchGet?ids=a&ids=b (AIP-231)
+// 默认任何一个 ID 不存在，整个请求返回 404；allow_partial_success=true 时逐条返回
+func (bc *BaseController[T, C, U]) BatchGet(c *gin.Context) {
+	ids := c.QueryArray("ids")
+	if err := checkBatchSize(len(ids)); err != nil {
+		c.Error(err)
+		return
+	}
+	partial := c.Query("allow_partial_success") == "true"
+
+	if bs, ok := bc.Service.(BatchService[T, C, U]); ok && !partial {
+		items, err := bs.BatchGet(c, ids)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, BatchResponse[T]{Items: items})
+		return
+	}
+
+	results := make([]BatchResult[T], len(ids))
+	for i, id := range ids {
+		item, err := bc.get(c, id)
+		results[i] = newBatchResult(c, i, item, err)
+		if err != nil && !partial {
+			c.Error(err)
+			return
+		}
+	}
+	bc.renderBatch(c, results, partial)
+}
+
+// BatchCreate 处理 POST /resources:batchCreate (AIP-233)
+func (bc *BaseController[T, C, U]) BatchCreate(c *gin.Context) {
+	req, ok := bindBatchRequest(c)
+	if !ok {
+		return
+	}
+
+	// 1. 逐条绑定与校验 (与单条 Create 使用相同的 binding 规则)
+	reqs := make([]*C, len(req.Requests))
+	bindErrs := make([]error, len(req.Requests))
+	for i, raw := range req.Requests {
+		reqs[i], bindErrs[i] = bindItem[C](raw)
+		if bindErrs[i] != nil && !req.AllowPartialSuccess {
+			c.Error(WithIndex(bindErrs[i], i))
+			return
+		}
+	}
+
+	// 2. 原子模式优先使用原生批量实现
+	if bs, ok := bc.Service.(BatchService[T, C, U]); ok && !req.AllowPartialSuccess {
+		items, err := bs.BatchCreate(c, reqs)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		for _, item := range items {
+			bc.emit(c, "created", "", item)
+		}
+		c.JSON(http.StatusOK, BatchResponse[T]{Items: items})
+		return
+	}
+
+	// 3. 退化为逐条创建
+	results := make([]BatchResult[T], len(reqs))
+	for i := range reqs {
+		if bindErrs[i] != nil {
+			results[i] = newBatchResult[T](c, i, nil, bindErrs[i])
+			continue
+		}
+		item, err := bc.Service.Create(c, reqs[i])
+		if err != nil && !req.AllowPartialSuccess {
+			// 原子模式下回滚已创建的条目 (尽力而为的补偿，真正的原子性需要实现 BatchService)
+			// 请求失败，已暂存的事件不会提交
+			bc.compensateCreate(c, results[:i])
+			c.Error(WithIndex(err, i))
+			return
+		}
+		if err == nil {
+			bc.emit(c, "created", "", item)
+		}
+		results[i] = newBatchResult(c, i, item, err)
+	}
+	bc.renderBatch(c, results, req.AllowPartialSuccess)
+}
+
+// BatchUpdate 处理 POST /resources:batchUpdate (AIP-234)
+func (bc *BaseController[T, C, U]) BatchUpdate(c *gin.Context) {
+	req, ok := bindBatchRequest(c)
+	if !ok {
+		return
+	}
+
+	items := make([]BatchUpdateItem[U], len(req.Requests))
+	bindErrs := make([]error, len(req.Requests))
+	for i, raw := range req.Requests {
+		items[i], bindErrs[i] = bindUpdateItem[U](raw)
+		if bindErrs[i] != nil && !req.AllowPartialSuccess {
+			c.Error(WithIndex(bindErrs[i], i))
+			return
+		}
+	}
+
+	if bs, ok := bc.Service.(BatchService[T, C, U]); ok && !req.AllowPartialSuccess {
+		updated, err := bs.BatchUpdate(c, items)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		for _, item := range updated {
+			bc.emit(c, "updated", "", item)
+		}
+		c.JSON(http.StatusOK, BatchResponse[T]{Items: updated})
+		return
+	}
+	if !req.AllowPartialSuccess {
+		c.Error(atomicUnsupported("batchUpdate"))
+		return
+	}
+
+	// 部分成功模式：逐条更新
+	results := make([]BatchResult[T], len(items))
+	for i, it := range items {
+		if bindErrs[i] != nil {
+			results[i] = newBatchResult[T](c, i, nil, bindErrs[i])
+			continue
+		}
+		item, err := bc.Service.Update(c, it.ID, it.Update)
+		if err == nil && item == nil {
+			err = errs.New(errs.ErrNotFound, "resource not found with id "+it.ID)
+		}
+		if err == nil {
+			bc.emit(c, "updated", it.ID, item)
+		}
+		results[i] = newBatchResult(c, i, item, err)
+	}
+	bc.renderBatch(c, results, true)
+}
+
+// BatchDelete 处理 POST /resources:batchDelete (AIP-235)
+// 使用 POST 而不是 DELETE，因为 DELETE 请求体在很多代理中不被支持
+func (bc *BaseController[T, C, U]) BatchDelete(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxBatchBodyBytes)
+
+	var req batchDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bodyError(err))
+		return
+	}
+	if err := checkBatchSize(len(req.IDs)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if bs, ok := bc.Service.(BatchService[T, C, U]); ok && !req.AllowPartialSuccess {
+		deleted, err := bs.BatchDelete(c, req.IDs)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		for i, id := range req.IDs {
+			var item *T
+			if i < len(deleted) {
+				item = deleted[i]
+			}
+			bc.emit(c, "deleted", id, item)
+		}
+		// 与单条 Delete 一致：软删除返回被标记的资源，物理删除返回 204
+		if deleted != nil {
+			c.JSON(http.StatusOK, BatchResponse[T]{Items: deleted})
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if !req.AllowPartialSuccess {
+		c.Error(atomicUnsupported("batchDelete"))
+		return
+	}
+
+	// 部分成功模式：逐条删除
+	results := make([]BatchResult[T], len(req.IDs))
+	for i, id := range req.IDs {
+		// 与单条 Delete 一致：开启软删除的资源只打标记
+		item, err := bc.delete(c, id)
+		if err == nil {
+			bc.emit(c, "deleted", id, item)
+		}
+		results[i] = newBatchResult(c, i, item, err)
+	}
+	c.JSON(http.StatusOK, BatchResponse[T]{Results: results})
+}
+
+// atomicUnsupported 资源没有原生的事务性批量实现时，拒绝原子模式的批量写
+// 逐条执行再预检查并不可靠 (预检查与执行之间状态可能变化，失败后也无法回滚)，宁可明确拒绝
+func atomicUnsupported(method string) error {
+	return errs.New(errs.ErrInvalidArgument, "atomic "+method+" is not supported for this resource, set allow_partial_success to true").
+		WithDetails(map[string]interface{}{"field": "allow_partial_success"})
+}
+
+// get 查询单个资源，兼容 Service 返回 (nil, nil) 表示不存在的写法
+func (bc *BaseController[T, C, U]) get(c *gin.Context, id string) (*T, error) {
+	item, err := bc.Service.Get(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, errs.New(errs.ErrNotFound, "resource not found with id "+id)
+	}
+	return item, nil
+}
+
+// compensateCreate 删除原子模式下已经创建成功的条目
+// 资源需要暴露 ID 才能回滚，这里通过 JSON 的 "id" 字段获取
+func (bc *BaseController[T, C, U]) compensateCreate(c *gin.Context, created []BatchResult[T]) {
+	for _, r := range created {
+		if r.Item == nil {
+			continue
+		}
+		if id := resourceID(r.Item); id != "" {
+			_ = bc.Service.Delete(c, id)
+		}
+	}
+}
+
+func (bc *BaseController[T, C, U]) renderBatch(c *gin.Context, results []BatchResult[T], partial bool) {
+	if partial {
+		c.JSON(http.StatusOK, BatchResponse[T]{Results: results})
+		return
+	}
+	items := make([]*T, len(results))
+	for i, r := range results {
+		items[i] = r.Item
+	}
+	c.JSON(http.StatusOK, BatchResponse[T]{Items: items})
+}
+
+func newBatchResult[T any](c *gin.Context, index int, item *T, err error) BatchResult[T] {
+	if err != nil {
+		problem := errs.NewProblem(errs.From(err), c.Request.RequestURI)
+		return BatchResult[T]{Index: index, Error: &problem}
+	}
+	return BatchResult[T]{Index: index, Item: item}
+}
+
+// bindBatchRequest 绑定批量写请求，并检查请求体大小与条目数
+func bindBatchRequest(c *gin.Context) (*batchRequest, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxBatchBodyBytes)
+
+	var req batchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bodyError(err))
+		return nil, false
+	}
+	if err := checkBatchSize(len(req.Requests)); err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return &req, true
+}
+
+// bindItem 反序列化并校验单个条目
+func bindItem[C any](raw json.RawMessage) (*C, error) {
+	var item C
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, errs.Wrap(errs.ErrBadRequest, "invalid request parameters", err)
+	}
+	if err := binding.Validator.ValidateStruct(&item); err != nil {
+		return nil, errs.Wrap(errs.ErrBadRequest, "invalid request parameters", err)
+	}
+	return &item, nil
+}
+
+func bindUpdateItem[U any](raw json.RawMessage) (BatchUpdateItem[U], error) {
+	var item BatchUpdateItem[U]
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return item, errs.Wrap(errs.ErrBadRequest, "invalid request parameters", err)
+	}
+	if item.ID == "" || item.Update == nil {
+		return item, errs.New(errs.ErrBadRequest, "id and update are required")
+	}
+	if err := binding.Validator.ValidateStruct(item.Update); err != nil {
+		return item, errs.Wrap(errs.ErrBadRequest, "invalid request parameters", err)
+	}
+	return item, nil
+}
+
+func checkBatchSize(n int) error {
+	if n == 0 {
+		return errs.New(errs.ErrInvalidArgument, "batch must contain at least one item")
+	}
+	if n > MaxBatchSize {
+		return errs.New(errs.ErrInvalidArgument, "too many items in batch").
+			WithDetails(map[string]interface{}{
+				"max_batch_size": MaxBatchSize,
+				"actual":         n,
+			})
+	}
+	return nil
+}
+
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errs.New(errs.ErrRequestTooLarge, "request body too large").
+			WithDetails(map[string]interface{}{"max_bytes": MaxBatchBodyBytes})
+	}
+	return errs.Wrap(errs.ErrBadRequest, "invalid request parameters", err)
+}
+
+// WithIndex 在原子模式的失败响应中标明是第几条出错，原生 BatchService 实现也应使用
+func WithIndex(err error, index int) error {
+	appErr := errs.From(err)
+	details := map[string]interface{}{"index": index}
+	for k, v := range appErr.Details {
+		details[k] = v
+	}
+	return &errs.AppError{
+		Type:    appErr.Type,
+		Message: appErr.Message,
+		Details: details,
+		Cause:   appErr.Cause,
+	}
+}
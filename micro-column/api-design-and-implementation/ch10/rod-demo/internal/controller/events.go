@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"rod-demo/pkg/outbox"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Emit 在当前请求中记录一个领域事件，请求成功结束后写入发件箱，再由 Webhook 分发器投递
+// typ 形如 "{resource}.{action}"，例如 "order.cancelled"
+func Emit(c *gin.Context, typ, subject string, data any) {
+	evt, err := outbox.NewEvent(typ, subject, data)
+	if err != nil {
+		log.Printf("emit %s for %s failed: %v", typ, subject, err)
+		return
+	}
+	outbox.Record(c, evt)
+}
+
+// EventOptOut 由不产生领域事件的领域实体实现
+// 例如 Webhook 订阅本身：把它的变更推送给订阅方没有意义，还可能泄露签名密钥
+type EventOptOut interface {
+	NoEvents()
+}
+
+// emit 记录标准方法产生的事件，事件类型为 "{resource}.{action}"
+// item 为 nil (物理删除) 时，事件数据只包含资源 ID
+func (bc *BaseController[T, C, U]) emit(c *gin.Context, action, id string, item *T) {
+	if _, ok := any(new(T)).(EventOptOut); ok {
+		return
+	}
+	var data any = map[string]string{"id": id}
+	if item != nil {
+		data = item
+		if id == "" {
+			id = resourceID(item)
+		}
+	}
+	Emit(c, eventResource[T]()+"."+action, id, data)
+}
+
+// eventResource 由领域实体的类型名推导事件中的资源名
+// 例如 domain.Order -> "order"，domain.WebhookSubscription -> "webhook_subscription"
+func eventResource[T any]() string {
+	name := reflect.TypeOf((*T)(nil)).Elem().Name()
+
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// resourceID 通过 JSON 的 "id" 字段获取资源 ID
+func resourceID(item any) string {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return ""
+	}
+	var ref struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(b, &ref)
+	return ref.ID
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"rod-demo/internal/domain"
 	"rod-demo/pkg/errs"
 	"rod-demo/pkg/fieldmask"
 	"strings"
@@ -32,9 +33,15 @@ type BaseController[T any, CreateReq any, UpdateReq any] struct {
 
 // NewBaseController 创建一个新的泛型控制器实例
 func NewBaseController[T any, C any, U any](s CRUDService[T, C, U]) *BaseController[T, C, U] {
-	return &BaseController[T, C, U]{
+	bc := &BaseController[T, C, U]{
 		Service: s,
 	}
+
+	// 领域实体声明了软删除，但 Service 没有实现对应能力，属于编程错误，启动时直接暴露
+	if _, ok := any(new(T)).(domain.SoftDeletable); ok && !bc.SoftDeleteEnabled() {
+		panic("controller: soft-deletable resource requires a SoftDeleteService")
+	}
+	return bc
 }
 
 // Create 处理 POST /resources 请求
@@ -56,7 +63,10 @@ func (bc *BaseController[T, C, U]) Create(c *gin.Context) {
 		return
 	}
 
-	// 3. 成功返回 201 Created 和创建后的资源
+	// 3. 记录领域事件，请求成功后写入发件箱
+	bc.emit(c, "created", "", result)
+
+	// 4. 成功返回 201 Created 和创建后的资源
 	c.JSON(http.StatusCreated, result)
 }
 
@@ -130,7 +140,8 @@ func (bc *BaseController[T, C, U]) List(c *gin.Context) {
 	// 调用 Service 层获取列表
 	results, err := bc.Service.List(c, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// 交给 ErrorHandler 渲染，例如非法 page_token 会被映射为 400
+		c.Error(err)
 		return
 	}
 
@@ -144,8 +155,12 @@ func (bc *BaseController[T, C, U]) List(c *gin.Context) {
 	// --- 核心修改开始 ---
 	fieldsParam := c.Query("fields")
 	if fieldsParam != "" {
-		fields := strings.Split(fieldsParam, ",")
-		// Prune 函数同时支持 Struct 和 Slice，直接传入即可
+		// 掩码作用于列表中的每个资源，分页字段始终保留
+		// 例如 fields=id,name 实际裁剪为 items.id, items.name, next_page_token, total_size
+		fields := []string{"next_page_token", "total_size"}
+		for _, f := range strings.Split(fieldsParam, ",") {
+			fields = append(fields, "items."+strings.TrimSpace(f))
+		}
 		prunedData, err := fieldmask.Prune(results, fields)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -173,11 +188,17 @@ func (bc *BaseController[T, C, U]) Update(c *gin.Context) {
 	// 2. 调用 Service 执行局部更新
 	result, err := bc.Service.Update(c, id, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// 3. 成功返回 200 OK 和更新后的完整资源
+	// 3. 记录领域事件
+	// 兼容 Service 返回 (nil, nil) 表示不存在的写法，此时没有发生变更
+	if result != nil {
+		bc.emit(c, "updated", id, result)
+	}
+
+	// 4. 成功返回 200 OK 和更新后的完整资源
 	c.JSON(http.StatusOK, result)
 }
 
@@ -185,14 +206,21 @@ func (bc *BaseController[T, C, U]) Update(c *gin.Context) {
 func (bc *BaseController[T, C, U]) Delete(c *gin.Context) {
 	id := c.Param("id")
 
-	// 1. 调用 Service 执行删除
-	err := bc.Service.Delete(c, id)
+	// 1. 调用 Service 执行删除 (开启软删除的资源只打标记)
+	result, err := bc.delete(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+	bc.emit(c, "deleted", id, result)
+
+	// 2. 软删除遵循 AIP-164，返回 200 和带有 delete_time / expire_time 的资源
+	if result != nil {
+		c.JSON(http.StatusOK, result)
 		return
 	}
 
-	// 2. 成功返回 204 No Content
+	// 3. 物理删除成功返回 204 No Content
 	// 根据 HTTP 语义，删除成功后不需要返回 Body
 	c.Status(http.StatusNoContent)
 }
@@ -1,9 +1,29 @@
 package controller
 
+import (
+	"rod-demo/pkg/pagination"
+	"strconv"
+)
+
 // ListRequest 定义符合 AIP-158 的标准分页请求参数
 type ListRequest struct {
 	PageSize  int    `form:"page_size"`  // 对应 ?page_size=10
 	PageToken string `form:"page_token"` // 对应 ?page_token=abc...
+	Filter    string `form:"filter"`     // 对应 ?filter=... (AIP-160)
+	OrderBy   string `form:"order_by"`   // 对应 ?order_by=... (AIP-132)
+	// ShowDeleted 对应 ?show_deleted=true (AIP-164)，为 true 时列表包含已软删除的资源
+	ShowDeleted bool `form:"show_deleted"`
+}
+
+// TokenQuery 返回需要绑定到 page_token 的查询参数
+// 翻页过程中这些参数一旦变化，旧的 page_token 会被拒绝 (400)
+func (r ListRequest) TokenQuery() pagination.Query {
+	return pagination.Query{
+		"page_size":    strconv.Itoa(r.PageSize),
+		"filter":       r.Filter,
+		"order_by":     r.OrderBy,
+		"show_deleted": strconv.FormatBool(r.ShowDeleted),
+	}
 }
 
 // ListResponse 定义符合 AIP-158 的标准分页响应结构
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"rod-demo/internal/domain"
+	"rod-demo/pkg/errs"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SoftDeleteRetention 软删除资源的保留期，过期后由 Purger 永久清除
+const SoftDeleteRetention = 30 * 24 * time.Hour
+
+// SoftDeleteService 是软删除资源 (领域实体实现了 domain.SoftDeletable) 的 Service 必须实现的接口
+// - SoftDelete: 只打删除标记，返回标记后的资源
+// - Undelete: 撤销删除标记
+// - Purge: 永久清除所有 expire_time 已过的资源，返回清除的条数
+//
+// 约定 (AIP-164)：Get 仍能查到已删除的资源；List 默认不返回，除非 show_deleted=true
+type SoftDeleteService[T any] interface {
+	SoftDelete(ctx *gin.Context, id string, retention time.Duration) (*T, error)
+	Undelete(ctx *gin.Context, id string) (*T, error)
+	Purge(ctx context.Context, now time.Time) (int, error)
+}
+
+// SoftDeleteController 定义 :undelete 自定义方法
+type SoftDeleteController interface {
+	// SoftDeleteEnabled 资源是否开启了软删除，RegisterResource 据此决定是否挂载 undelete 路由
+	SoftDeleteEnabled() bool
+	// Undelete 恢复已删除的资源 (POST /collection/:id/undelete)
+	Undelete(c *gin.Context)
+}
+
+// Purger 由后台任务周期性调用
+type Purger interface {
+	Purge(ctx context.Context, now time.Time) (int, error)
+}
+
+// softDeleter 返回软删除实现；领域实体未实现 domain.SoftDeletable 时返回 false
+func (bc *BaseController[T, C, U]) softDeleter() (SoftDeleteService[T], bool) {
+	if _, ok := any(new(T)).(domain.SoftDeletable); !ok {
+		return nil, false
+	}
+	sd, ok := bc.Service.(SoftDeleteService[T])
+	return sd, ok
+}
+
+// SoftDeleteEnabled 实现 SoftDeleteController
+func (bc *BaseController[T, C, U]) SoftDeleteEnabled() bool {
+	_, ok := bc.softDeleter()
+	return ok
+}
+
+// Undelete 处理 POST /resources/:id/undelete (AIP-164)
+func (bc *BaseController[T, C, U]) Undelete(c *gin.Context) {
+	sd, ok := bc.softDeleter()
+	if !ok {
+		c.Error(errs.New(errs.ErrNotFound, "undelete is not supported for this resource"))
+		return
+	}
+
+	result, err := sd.Undelete(c, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	bc.emit(c, "undeleted", c.Param("id"), result)
+	c.JSON(http.StatusOK, result)
+}
+
+// Purge 实现 Purger，未开启软删除的资源什么也不做
+func (bc *BaseController[T, C, U]) Purge(ctx context.Context, now time.Time) (int, error) {
+	sd, ok := bc.softDeleter()
+	if !ok {
+		return 0, nil
+	}
+	return sd.Purge(ctx, now)
+}
+
+// delete 根据资源是否开启软删除，选择打标记或物理删除
+// 软删除时返回被标记的资源；物理删除时返回 nil
+func (bc *BaseController[T, C, U]) delete(c *gin.Context, id string) (*T, error) {
+	if sd, ok := bc.softDeleter(); ok {
+		return sd.SoftDelete(c, id, SoftDeleteRetention)
+	}
+	return nil, bc.Service.Delete(c, id)
+}
+
+// StartPurger 启动后台清理任务，每隔 interval 永久清除一次过期的软删除资源
+// 返回的 stop 函数用于优雅停机：取消后台任务，并等待正在进行的一轮清理结束
+func StartPurger(interval time.Duration, purgers ...Purger) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				for _, p := range purgers {
+					n, err := p.Purge(ctx, now)
+					if err != nil {
+						log.Printf("purge failed: %v", err)
+						continue
+					}
+					if n > 0 {
+						log.Printf("purged %d expired resources", n)
+					}
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -7,6 +7,9 @@ type Operation struct {
 	Metadata interface{} `json:"metadata"` // 进度或上下文
 	Response interface{} `json:"response"` // 成功返回值
 	Error    interface{} `json:"error"`    // 失败返回值
+
+	// Owner 发起该操作的调用方，完成事件只投递给其 Webhook 订阅
+	Owner string `json:"-"`
 }
 
 // OperationMetadata 具体的进度信息
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// SoftDeletable 遵循 Google AIP-164 软删除规范
+// 领域实体实现该接口即可开启软删除：DELETE 只打标记，资源在 expire_time 之前可通过 :undelete 恢复
+type SoftDeletable interface {
+	IsDeleted() bool
+	MarkDeleted(now time.Time, retention time.Duration)
+	Restore()
+	Expired(now time.Time) bool
+}
+
+// SoftDelete 可嵌入到领域实体中，提供 delete_time / expire_time 字段及默认实现
+type SoftDelete struct {
+	DeleteTime *time.Time `json:"delete_time,omitempty"` // 删除时间，为空表示未删除
+	ExpireTime *time.Time `json:"expire_time,omitempty"` // 过期时间，之后资源会被永久清除 (Purge)
+}
+
+func (s *SoftDelete) IsDeleted() bool {
+	return s.DeleteTime != nil
+}
+
+// MarkDeleted 标记为已删除，retention 为可恢复的保留期
+func (s *SoftDelete) MarkDeleted(now time.Time, retention time.Duration) {
+	expire := now.Add(retention)
+	s.DeleteTime = &now
+	s.ExpireTime = &expire
+}
+
+// Restore 撤销删除标记
+func (s *SoftDelete) Restore() {
+	s.DeleteTime = nil
+	s.ExpireTime = nil
+}
+
+// Expired 判断已删除的资源是否已过保留期，可以被永久清除
+func (s *SoftDelete) Expired(now time.Time) bool {
+	return s.ExpireTime != nil && !now.Before(*s.ExpireTime)
+}
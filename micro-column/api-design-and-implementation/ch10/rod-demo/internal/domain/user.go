@@ -14,4 +14,7 @@ type User struct {
 	Age      int          `json:"age"`
 	IsActive bool         `json:"is_active"`
 	Profile  *UserProfile `json:"profile"` // 嵌套字段
+
+	// 开启软删除 (AIP-164)，JSON 中展开为 delete_time / expire_time
+	SoftDelete
 }
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// WebhookSubscription 是一个 Webhook 订阅
+// 集成方注册后，订阅的资源变更事件会被签名推送到 URL，无需再轮询
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"` // 支持 "order.cancelled"、"order.*"、"*"
+	Active     bool      `json:"active"`
+	CreateTime time.Time `json:"create_time"`
+
+	// Secret 用于 HMAC 签名，只在创建时返回一次
+	Secret string `json:"secret,omitempty"`
+
+	// Owner 创建该订阅的调用方，订阅只对其可见，也只接收其产生的事件
+	Owner string `json:"-"`
+}
+
+// NoEvents 实现 controller.EventOptOut：订阅的变更不产生领域事件
+func (WebhookSubscription) NoEvents() {}
@@ -0,0 +1,23 @@
+package dto
+
+// CreateWebhookRequest 用于 POST /webhooks
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+	// Secret 可选，不传时由服务端生成
+	Secret string `json:"secret" binding:"omitempty,min=16"`
+}
+
+// UpdateWebhookRequest 用于 PATCH /webhooks/:id
+type UpdateWebhookRequest struct {
+	URL *string `json:"url" binding:"omitempty,url"`
+	// 切片本身可以用 nil 表示"未传"，无需使用指针
+	EventTypes []string `json:"event_types" binding:"omitempty,min=1,dive,required"`
+	Active     *bool    `json:"active"`
+}
+
+// ReplayWebhookRequest 用于 POST /webhooks/:id/replay
+type ReplayWebhookRequest struct {
+	// DeliveryIDs 为空时重放全部死信
+	DeliveryIDs []string `json:"delivery_ids"`
+}
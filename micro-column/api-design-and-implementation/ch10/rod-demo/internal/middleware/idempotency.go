@@ -2,11 +2,13 @@ package middleware
 
 import (
 	"bytes"
-
 	"context"
-	"encoding/json"
+	"errors"
+	"io"
+	"log"
 	"net/http"
-	"rod-demo/pkg/redis"
+	"rod-demo/pkg/errs"
+	"rod-demo/pkg/idempotency"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -31,18 +33,21 @@ func (w *bodyDumpResponseWriter) WriteString(s string) (int, error) {
 
 const (
 	HeaderIdempotencyKey = "X-Idempotency-Key"
-	KeyPrefix            = "idemp:"
-	LockExpire           = 24 * time.Hour // 幂等键有效期，通常设为24小时
-)
+	// HeaderIdempotentReplayed 标记该响应是重放的缓存结果
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
 
-// 缓存的响应结构
-type cachedResponse struct {
-	Status  int                 `json:"status"`
-	Headers map[string][]string `json:"headers"`
-	Body    string              `json:"body"`
-}
+	LockExpire       = 24 * time.Hour  // 幂等键有效期，通常设为24小时
+	ProcessingExpire = 5 * time.Minute // 处理中占位的有效期，防止进程崩溃后 Key 被永久锁住
+	pollInterval     = 50 * time.Millisecond
 
-func Idempotency() gin.HandlerFunc {
+	// MaxIdempotentBodyBytes 计算指纹时最多读取的请求体字节数，与批量接口的上限一致
+	MaxIdempotentBodyBytes = 1 << 20
+)
+
+// Idempotency 幂等中间件
+// store: 幂等记录存储 (Redis / 内存)
+// waitTimeout: 并发的重复请求最多等待多久，等到第一个请求完成后直接返回其结果；<= 0 表示不等待
+func Idempotency(store idempotency.Store, waitTimeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 0. 方法过滤
 		// 根据 HTTP 语义，GET, HEAD, OPTIONS, DELETE 天然幂等（或者只读），无需幂等键保护
@@ -66,82 +71,156 @@ func Idempotency() gin.HandlerFunc {
 			return
 		}
 
-		redisKey := KeyPrefix + key
-		ctx := context.Background()
+		// 2. 计算请求指纹
+		// 读取请求体后需要放回去，后续 Handler 还要绑定
+		// 先限制大小再读取，超限时直接返回 413，此时尚未占用幂等键
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.Error(errs.New(errs.ErrRequestTooLarge, "request body too large").
+					WithDetails(map[string]interface{}{"max_bytes": MaxIdempotentBodyBytes}))
+			} else {
+				c.Error(errs.Wrap(errs.ErrBadRequest, "failed to read request body", err))
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
 
-		// 2. 检查 Key 是否存在 (使用 SETNX 实现原子锁)
-		// 状态定义：
-		// - 不存在: 第一次请求，抢锁成功
-		// - 存在: 重复请求
+		principal := "anonymous"
+		if p := CurrentPrincipal(c); p != nil {
+			principal = p.Kind + ":" + p.ID
+		}
+		fingerprint := idempotency.Fingerprint(c.Request.Method, c.Request.URL.Path, principal, body)
+		// 幂等键按调用方隔离，不同调用方碰巧使用相同的 Key 不会互相影响
+		storeKey := principal + ":" + key
 
-		// 这里我们尝试抢锁，为了简单，我们用 Redis String 存储状态
-		// 实际生产可以使用 Lua 脚本保证更复杂的原子性
+		ctx := c.Request.Context()
 
-		// 尝试获取结果缓存
-		val, err := redis.Client.Get(ctx, redisKey).Result()
+		// 3. 尝试占位
+		existing, acquired, err := store.Begin(ctx, storeKey, fingerprint, ProcessingExpire)
+		if err != nil {
+			// 存储不可用时拒绝请求 (Fail Closed)：放行可能导致重复扣款等副作用
+			c.Error(errs.Wrap(errs.ErrUnavailable, "idempotency store unavailable", err))
+			c.Abort()
+			return
+		}
 
-		if err == nil {
-			// 2.1 Case A: Key 存在
-			if val == "PROCESSING" {
-				// 正在处理中，发生并发请求
-				c.JSON(http.StatusConflict, gin.H{
-					"error": "Duplicate request, processing in progress",
-				})
+		// 4. 重复请求
+		if !acquired {
+			if existing.Fingerprint != fingerprint {
+				c.Error(errs.New(errs.ErrIdempotencyKeyReused,
+					"idempotency key was already used with a different request"))
 				c.Abort()
 				return
 			}
 
-			// 处理完毕，直接返回缓存的结果
-			var resp cachedResponse
-			json.Unmarshal([]byte(val), &resp)
-
-			// 恢复 Header
-			for k, v := range resp.Headers {
-				for _, s := range v {
-					c.Header(k, s)
-				}
+			// 第一个请求仍在处理中：等待其完成，而不是直接返回 409
+			rec, err := waitForCompletion(ctx, store, storeKey, existing, waitTimeout)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
 			}
-			// 恢复 Status 和 Body
-			c.Data(resp.Status, c.Writer.Header().Get("Content-Type"), []byte(resp.Body))
-			c.Abort() // 阻断后续 Handler 执行
-			return
-		}
 
-		// 2.2 Case B: Key 不存在，我们需要抢锁
-		// SETNX: Set if Not Exists
-		success, _ := redis.Client.SetNX(ctx, redisKey, "PROCESSING", LockExpire).Result()
-		if !success {
-			// 抢锁失败（极低概率并发），视为冲突
-			c.JSON(http.StatusConflict, gin.H{"error": "Concurrent request conflict"})
-			c.Abort()
+			replay(c, rec.Response)
+			c.Abort() // 阻断后续 Handler 执行
 			return
 		}
 
-		// 3. 包装 ResponseWriter 以捕获响应
+		// 5. 第一个请求：包装 ResponseWriter 以捕获响应
 		dumpWriter := &bodyDumpResponseWriter{
 			ResponseWriter: c.Writer,
 			body:           bytes.NewBufferString(""),
 		}
 		c.Writer = dumpWriter
 
-		// 4. 执行业务逻辑
+		// 6. 执行业务逻辑
 		c.Next()
 
-		// 5. 业务执行完毕，缓存响应结果
+		// 通过 c.Error 返回的错误本应由外层的 ErrorHandler 渲染，但它在本中间件返回之后才执行，
+		// 此时 Status() 仍是默认的 200、Body 为空。提前渲染，保证下面按最终状态码决定缓存还是释放
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			renderError(c)
+		}
+
+		// 7. 业务执行完毕，缓存响应结果
+		// 客户端可能已经断开，但结果仍需落盘，避免其重试时重复执行
+		saveCtx := context.WithoutCancel(ctx)
+
 		// 注意：只缓存成功的或特定的错误码，视业务需求而定
 		if c.Writer.Status() < 500 {
-			cacheObj := cachedResponse{
-				Status:  c.Writer.Status(),
-				Headers: c.Writer.Header(),
-				Body:    dumpWriter.body.String(),
+			rec := &idempotency.Record{
+				State:       idempotency.StateCompleted,
+				Fingerprint: fingerprint,
+				Response: &idempotency.Response{
+					Status:  c.Writer.Status(),
+					Headers: c.Writer.Header().Clone(),
+					Body:    dumpWriter.body.Bytes(),
+				},
+			}
+			if err := store.Complete(saveCtx, storeKey, rec, LockExpire); err != nil {
+				// 响应已经发出，只能记录日志；尽量释放占位，避免重试在 ProcessingExpire 内一直拿到 409
+				log.Printf("idempotency: complete key %q failed: %v", storeKey, err)
+				if err := store.Release(saveCtx, storeKey); err != nil {
+					log.Printf("idempotency: release key %q failed: %v", storeKey, err)
+				}
 			}
-			jsonBytes, _ := json.Marshal(cacheObj)
-
-			// 更新 Redis，将 "PROCESSING" 替换为 真实结果
-			redis.Client.Set(ctx, redisKey, string(jsonBytes), LockExpire)
 		} else {
 			// 如果业务报错（500），通常应该删除 Key，允许客户端重试
-			redis.Client.Del(ctx, redisKey)
+			if err := store.Release(saveCtx, storeKey); err != nil {
+				log.Printf("idempotency: release key %q failed: %v", storeKey, err)
+			}
+		}
+	}
+}
+
+// waitForCompletion 轮询等待第一个请求完成
+func waitForCompletion(ctx context.Context, store idempotency.Store, key string, rec *idempotency.Record, timeout time.Duration) (*idempotency.Record, error) {
+	if rec.State == idempotency.StateCompleted {
+		return rec, nil
+	}
+	if timeout <= 0 {
+		return nil, errs.New(errs.ErrConflict, "a request with the same idempotency key is in progress")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errs.New(errs.ErrConflict, "a request with the same idempotency key is still in progress").
+				WithDetails(map[string]interface{}{"retry_after": "1s"})
+		case <-ticker.C:
+			rec, err := store.Get(ctx, key)
+			if errors.Is(err, idempotency.ErrNotFound) {
+				// 第一个请求失败 (5xx) 后释放了 Key，让客户端重试
+				return nil, errs.New(errs.ErrConflict, "the original request failed, please retry")
+			}
+			if err != nil {
+				return nil, errs.Wrap(errs.ErrUnavailable, "idempotency store unavailable", err)
+			}
+			if rec.State == idempotency.StateCompleted {
+				return rec, nil
+			}
+		}
+	}
+}
+
+// replay 重放缓存的响应
+func replay(c *gin.Context, resp *idempotency.Response) {
+	// 恢复 Header
+	for k, v := range resp.Headers {
+		for _, s := range v {
+			c.Writer.Header().Add(k, s)
 		}
 	}
+	c.Header(HeaderIdempotentReplayed, "true")
+	// 恢复 Status 和 Body
+	c.Data(resp.Status, c.Writer.Header().Get("Content-Type"), resp.Body)
 }
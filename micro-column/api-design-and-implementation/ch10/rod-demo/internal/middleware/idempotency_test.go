@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rod-demo/pkg/errs"
+	"rod-demo/pkg/idempotency"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newIdempotentRouter 按 main 中的顺序挂载 ErrorHandler 与幂等中间件
+func newIdempotentRouter(store idempotency.Store, handler gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.Use(Idempotency(store, time.Second))
+	r.POST("/orders", handler)
+	return r
+}
+
+func postOrder(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderIdempotencyKey, key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysSuccess(t *testing.T) {
+	calls := 0
+	r := newIdempotentRouter(idempotency.NewMemoryStore(), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": "o-1"})
+	})
+
+	first := postOrder(r, "k1", `{"amount":1}`)
+	second := postOrder(r, "k1", `{"amount":1}`)
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Fatal("replayed response is not marked")
+	}
+}
+
+func TestIdempotencyReplaysClientError(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	calls := 0
+	r := newIdempotentRouter(store, func(c *gin.Context) {
+		calls++
+		c.Error(errs.New(errs.ErrBadRequest, "amount must be positive"))
+	})
+
+	first := postOrder(r, "k1", `{"amount":-1}`)
+	if first.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", first.Code)
+	}
+	if !strings.Contains(first.Body.String(), "amount must be positive") {
+		t.Fatalf("body = %q, want the problem details", first.Body)
+	}
+
+	// 4xx 是确定性的结果，缓存后原样重放，而不是重放一个空的 200
+	second := postOrder(r, "k1", `{"amount":-1}`)
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if second.Code != http.StatusBadRequest || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Fatal("replayed response is not marked")
+	}
+	if ct := second.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("content type = %q", ct)
+	}
+}
+
+func TestIdempotencyReleasesServerError(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	calls := 0
+	r := newIdempotentRouter(store, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.Error(errors.New("database is down"))
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": "o-1"})
+	})
+
+	first := postOrder(r, "k1", `{"amount":1}`)
+	if first.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", first.Code)
+	}
+	// 5xx 后立即释放 Key，不能等到 LockExpire
+	if _, err := store.Get(t.Context(), "anonymous:k1"); !errors.Is(err, idempotency.ErrNotFound) {
+		t.Fatalf("key still held after 5xx: %v", err)
+	}
+
+	second := postOrder(r, "k1", `{"amount":1}`)
+	if calls != 2 {
+		t.Fatalf("handler called %d times, want 2", calls)
+	}
+	if second.Code != http.StatusCreated || second.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Fatalf("retry after 5xx = %d (replayed=%q), want a fresh 201",
+			second.Code, second.Header().Get(HeaderIdempotentReplayed))
+	}
+}
+
+func TestIdempotencyRejectsReusedKey(t *testing.T) {
+	r := newIdempotentRouter(idempotency.NewMemoryStore(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": "o-1"})
+	})
+
+	postOrder(r, "k1", `{"amount":1}`)
+	w := postOrder(r, "k1", `{"amount":2}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", w.Code)
+	}
+}
+
+func TestIdempotencyRejectsOversizedBody(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	calls := 0
+	r := newIdempotentRouter(store, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": "o-1"})
+	})
+
+	w := postOrder(r, "k1", `{"note":"`+strings.Repeat("x", MaxIdempotentBodyBytes)+`"}`)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", w.Code)
+	}
+	if calls != 0 {
+		t.Fatalf("handler called %d times, want 0", calls)
+	}
+	// 超限的请求不能占用幂等键
+	if _, err := store.Get(t.Context(), "anonymous:k1"); !errors.Is(err, idempotency.ErrNotFound) {
+		t.Fatalf("key reserved by an oversized request: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"rod-demo/pkg/outbox"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Outbox 在请求成功结束后，把 Handler 暂存的领域事件提交到发件箱
+//
+// 演示中的 Service 是内存存储，没有数据库事务，这里以"请求成功"作为提交边界：
+// 请求失败 (4xx/5xx 或 panic) 时暂存的事件全部丢弃，与业务数据的回滚保持一致
+// 生产环境应在业务事务内写入 outbox 表，而不是依赖中间件
+func Outbox(store outbox.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		events := outbox.Staged(c)
+		if len(events) == 0 {
+			return
+		}
+		if c.Writer.Status() >= 400 || len(c.Errors) > 0 {
+			return
+		}
+
+		// 事件归属于发起请求的调用方，Webhook 只推送给该调用方自己的订阅
+		owner := CurrentOwner(c)
+		for i := range events {
+			if events[i].Owner == "" {
+				events[i].Owner = owner
+			}
+		}
+
+		// 客户端断开不应影响事件落盘
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := store.Append(ctx, events...); err != nil {
+			log.Printf("outbox: append %d events failed: %v", len(events), err)
+		}
+	}
+}
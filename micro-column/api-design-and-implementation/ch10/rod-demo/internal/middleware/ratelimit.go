@@ -2,7 +2,7 @@ package middleware
 
 import (
 	"fmt"
-	"net/http"
+	"rod-demo/pkg/errs"
 	"rod-demo/pkg/limiter"
 	"strconv"
 	"time"
@@ -23,53 +23,111 @@ func APIKeyStrategy(c *gin.Context) string {
 	return "apikey:" + c.GetHeader("X-API-Key")
 }
 
+// 常用策略：基于已认证的调用方，匿名请求退化为按 IP 限流
+// 需要放在 Authenticate 之后
+func PrincipalKeyStrategy(c *gin.Context) string {
+	if p := CurrentPrincipal(c); p != nil {
+		return "principal:" + p.Kind + ":" + p.ID
+	}
+	return IPKeyStrategy(c)
+}
+
+// PlanResolver 解析当前请求适用的配额套餐
+type PlanResolver func(c *gin.Context) limiter.Plan
+
+// StaticPlan 所有请求使用同一个套餐
+// 套餐中有无效规则时 panic，配置错误在启动时暴露
+func StaticPlan(plan limiter.Plan) PlanResolver {
+	mustValidPlan(plan)
+	return func(c *gin.Context) limiter.Plan {
+		return plan
+	}
+}
+
+// PlanByPrincipal 按调用方 (API Key / JWT) 上的套餐名解析配额
+// 匿名请求或未知套餐使用 fallback，套餐中有无效规则时 panic
+func PlanByPrincipal(plans map[string]limiter.Plan, fallback string) PlanResolver {
+	for _, plan := range plans {
+		mustValidPlan(plan)
+	}
+	return func(c *gin.Context) limiter.Plan {
+		if p := CurrentPrincipal(c); p != nil {
+			if plan, ok := plans[p.Plan]; ok {
+				return plan
+			}
+		}
+		return plans[fallback]
+	}
+}
+
+func mustValidPlan(plan limiter.Plan) {
+	if err := plan.Validate(); err != nil {
+		panic("ratelimit: " + err.Error())
+	}
+}
+
 // RateLimit 创建限流中间件
 // l: 限流器实例
 // keyGen: Key 生成策略
 // limit: 限流规则
 func RateLimit(l *limiter.Limiter, keyGen KeyStrategy, limit limiter.LimitDefinition) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 1. 生成限流 Key
-		// 建议加上 request path，实现针对具体接口的限流
-		key := fmt.Sprintf("%s:%s", keyGen(c), c.FullPath())
+	return Quota(l, keyGen, StaticPlan(limiter.Plan{Name: "default", Default: limit}))
+}
 
-		if c.FullPath() == "" {
-			// 404 的情况，也应该限流，防止扫描器
-			key = fmt.Sprintf("%s:404", keyGen(c))
+// Quota 创建多级配额中间件
+// 先按调用方解析套餐，再按路由组 / 方法选出具体规则，每条规则独立计数
+func Quota(l *limiter.Limiter, keyGen KeyStrategy, resolve PlanResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1. 选出生效的规则
+		// 404 的情况，也应该限流，防止扫描器
+		path := c.FullPath()
+		if path == "" {
+			path = "404"
 		}
+		rule := resolve(c).RuleFor(c.Request.Method, path)
+
+		// 2. 生成限流 Key：调用方 + 规则，同一调用方在不同路由组下的配额互不影响
+		key := fmt.Sprintf("%s:%s", keyGen(c), rule.Name)
 
-		// 2. 检查限流
-		res, err := l.Allow(c, key, limit)
+		// 3. 检查限流
+		// Redis 不可用时 Limiter 内部会降级为本地 GCRA 限流，这里无需特殊处理
+		res, err := l.Allow(c, key, rule.Limit)
 		if err != nil {
-			// Redis 挂了怎么办？
-			// Fail Open: 允许通过 (保障可用性)
-			// Fail Closed: 拒绝请求 (保障安全性)
-			// 这里演示 Fail Open，记录日志即可
-			fmt.Printf("Rate limit redis error: %v\n", err)
-			c.Next()
+			c.Error(errs.Wrap(errs.ErrUnavailable, "rate limiter unavailable", err))
+			c.Abort()
 			return
 		}
 
-		// 3. 设置标准 Header (无论成功失败都建议设置，让客户端感知配额)
-		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		// 4. 设置标准 Header (无论成功失败都建议设置，让客户端感知配额)
+		// IETF draft-ietf-httpapi-ratelimit-headers:
+		//   RateLimit-Policy: "free:/api/v1/users";q=10;w=60
+		//   RateLimit:        "free:/api/v1/users";r=3;t=12
+		window := int(rule.Limit.Period / time.Second)
+		// q 是每个窗口的配额 (Rate)，而不是突发量 (Burst)
+		c.Header("RateLimit-Policy", fmt.Sprintf("%q;q=%d;w=%d", rule.Name, rule.Limit.Rate, window))
+		c.Header("RateLimit", fmt.Sprintf("%q;r=%d;t=%d", rule.Name, res.Remaining, ceilSeconds(res.ResetAfter)))
+
+		// 兼容旧客户端的非标准 Header
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.Limit.Rate))
 		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
 		c.Header("X-RateLimit-Reset", strconv.FormatInt(res.ResetAfter.Milliseconds(), 10))
 
-		// 4. 判断结果
+		// 5. 判断结果
 		if res.Allowed == 0 {
 			// 被限流了
 			// 计算 Retry-After (秒)
-			retryAfter := int(res.RetryAfter / time.Second)
+			retryAfter := ceilSeconds(res.RetryAfter)
 			if retryAfter < 1 {
 				retryAfter = 1
 			}
 			c.Header("Retry-After", strconv.Itoa(retryAfter))
 
 			// 返回 429
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Too Many Requests",
-				"retry_after": fmt.Sprintf("%ds", retryAfter),
-			})
+			c.Error(errs.New(errs.ErrQuotaExceeded, "Too Many Requests").
+				WithDetails(map[string]interface{}{
+					"policy":      rule.Name,
+					"retry_after": fmt.Sprintf("%ds", retryAfter),
+				}))
 			c.Abort()
 			return
 		}
@@ -77,3 +135,37 @@ func RateLimit(l *limiter.Limiter, keyGen KeyStrategy, limit limiter.LimitDefini
 		c.Next()
 	}
 }
+
+// ConcurrencyLimit 限制同一调用方对昂贵接口的同时在途请求数
+// 只对套餐中配置了 Concurrency 的路由生效
+func ConcurrencyLimit(l *limiter.ConcurrencyLimiter, keyGen KeyStrategy, resolve PlanResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		selector, max, ok := resolve(c).ConcurrencyFor(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		release, ok := l.Acquire(c, fmt.Sprintf("%s:%s", keyGen(c), selector), max)
+		if !ok {
+			c.Header("Retry-After", "1")
+			c.Error(errs.New(errs.ErrQuotaExceeded, "Too Many Concurrent Requests").
+				WithDetails(map[string]interface{}{
+					"policy":         selector,
+					"max_concurrent": max,
+				}))
+			c.Abort()
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rod-demo/pkg/limiter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRateLimitPolicyReportsQuota(t *testing.T) {
+	// 连不上的 Redis，限流器降级为本地 GCRA
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer rdb.Close()
+
+	r := gin.New()
+	r.Use(RateLimit(limiter.NewLimiter(rdb), IPKeyStrategy,
+		limiter.LimitDefinition{Rate: 5, Period: time.Hour, Burst: 2}))
+	r.GET("/images", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/images", nil))
+
+	// q 是每个窗口的配额 (Rate)，不是突发量 (Burst)
+	if got, want := w.Header().Get("RateLimit-Policy"), `"default:default";q=5;w=3600`; got != want {
+		t.Fatalf("RateLimit-Policy = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "5" {
+		t.Fatalf("X-RateLimit-Limit = %q, want 5", got)
+	}
+}
+
+func TestPlanByPrincipalRejectsZeroRate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("PlanByPrincipal should panic on a plan with zero rate")
+		}
+	}()
+	PlanByPrincipal(map[string]limiter.Plan{
+		"free": {Name: "free", Default: limiter.LimitDefinition{Rate: 0, Period: time.Minute, Burst: 10}},
+	}, "free")
+}
@@ -178,6 +178,9 @@ func (ctrl *OrderController) Cancel(c *gin.Context) {
 		return
 	}
 
+	// 状态流转同样是资源变更，通知订阅方，免得集成方轮询 /orders
+	controller.Emit(c, "order.cancelled", order.ID, order)
+
 	// 返回更新后的资源
 	c.JSON(http.StatusOK, order)
 }
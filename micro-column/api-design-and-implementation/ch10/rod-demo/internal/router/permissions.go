@@ -0,0 +1,45 @@
+package router
+
+import "rod-demo/pkg/auth"
+
+// Permissions 声明每个接口所需的 scope (满足其一即可)
+// 新增资源或自定义方法时必须在这里登记，否则默认拒绝访问
+var Permissions = auth.Policy{
+	// users
+	"users.list":     {"users.read", "users.write"},
+	"users.get":      {"users.read", "users.write"},
+	"users.create":   {"users.write"},
+	"users.update":   {"users.write"},
+	"users.delete":   {"users.write"},
+	"users.undelete": {"users.write"},
+
+	// orders
+	"orders.list":   {"orders.read", "orders.write"},
+	"orders.get":    {"orders.read", "orders.write"},
+	"orders.create": {"orders.write"},
+	"orders.update": {"orders.write"},
+	"orders.delete": {"orders.write"},
+	"orders.cancel": {"orders.write"},
+
+	// operations (LRO)
+	"operations.list":  {"operations.read"},
+	"operations.get":   {"operations.read"},
+	"operations.watch": {"operations.read"},
+	// Operation 只能由系统创建和更新，显式声明为不对任何 scope 开放
+	"operations.create": {},
+	"operations.update": {},
+	"operations.delete": {},
+
+	// webhooks 订阅 (管理类接口统一使用一个 scope)
+	"webhooks.list":        {"webhooks.manage"},
+	"webhooks.get":         {"webhooks.manage"},
+	"webhooks.create":      {"webhooks.manage"},
+	"webhooks.update":      {"webhooks.manage"},
+	"webhooks.delete":      {"webhooks.manage"},
+	"webhooks.deadletters": {"webhooks.manage"},
+	"webhooks.replay":      {"webhooks.manage"},
+
+	// AI
+	"images.generate": {"ai.generate"},
+	"chat.stream":     {"ai.chat"},
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"rod-demo/pkg/limiter"
+	"time"
+)
+
+// DefaultPlan 匿名调用方或未声明套餐的调用方使用的套餐
+const DefaultPlan = "free"
+
+// Plans 声明各套餐的配额
+// 选择器写法见 limiter.Plan：路由组前缀，或 "METHOD 前缀"
+var Plans = map[string]limiter.Plan{
+	"free": {
+		Name:    "free",
+		Default: limiter.LimitDefinition{Rate: 10, Period: time.Minute, Burst: 10},
+		Routes: map[string]limiter.LimitDefinition{
+			"/api/v1/users":             {Rate: 30, Period: time.Minute, Burst: 30},
+			"POST /api/v1/users":        {Rate: 5, Period: time.Minute, Burst: 5},
+			"/api/v1/orders":            {Rate: 30, Period: time.Minute, Burst: 30},
+			"/api/v1/operations":        {Rate: 60, Period: time.Minute, Burst: 60},
+			"POST /api/v1/images":       {Rate: 5, Period: time.Hour, Burst: 2},
+			"POST /api/v1/chat/stream":  {Rate: 20, Period: time.Hour, Burst: 5},
+			"GET /api/v1/chat/streams/": {Rate: 60, Period: time.Minute, Burst: 60},
+		},
+		Concurrency: map[string]int{
+			"POST /api/v1/images/generate": 1,
+			"POST /api/v1/chat/stream":     1,
+		},
+	},
+	"pro": {
+		Name:    "pro",
+		Default: limiter.LimitDefinition{Rate: 100, Period: time.Minute, Burst: 100},
+		Routes: map[string]limiter.LimitDefinition{
+			"/api/v1/users":             {Rate: 300, Period: time.Minute, Burst: 300},
+			"POST /api/v1/users":        {Rate: 60, Period: time.Minute, Burst: 60},
+			"/api/v1/orders":            {Rate: 300, Period: time.Minute, Burst: 300},
+			"/api/v1/operations":        {Rate: 600, Period: time.Minute, Burst: 600},
+			"POST /api/v1/images":       {Rate: 100, Period: time.Hour, Burst: 20},
+			"POST /api/v1/chat/stream":  {Rate: 500, Period: time.Hour, Burst: 50},
+			"GET /api/v1/chat/streams/": {Rate: 600, Period: time.Minute, Burst: 600},
+		},
+		Concurrency: map[string]int{
+			"POST /api/v1/images/generate": 4,
+			"POST /api/v1/chat/stream":     8,
+		},
+	},
+}
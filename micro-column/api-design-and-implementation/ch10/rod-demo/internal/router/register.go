@@ -1,57 +1,122 @@
 package router
 
 import (
+	"net/http"
 	"rod-demo/internal/controller"
+	"rod-demo/internal/middleware"
+	"rod-demo/internal/order"
+	"rod-demo/internal/subscription"
+	"rod-demo/internal/task"
 	"rod-demo/internal/user"
+	"rod-demo/pkg/auth"
+	"rod-demo/pkg/outbox"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// resourceRoutes 是注册一个资源时的上下文，供 Option 使用
+type resourceRoutes struct {
+	parent *gin.RouterGroup // 资源所在的父路由组，例如 /api/v1
+	group  *gin.RouterGroup // 资源集合的路由组，例如 /api/v1/users
+	name   string           // 资源名 (复数)，例如 "users"
+	ctrl   controller.StandardController
+}
+
 // Option 定义路由注册的选项函数
-type Option func(*gin.RouterGroup)
+type Option func(*resourceRoutes)
 
 // WithCustomMethod 用于注册自定义方法
 // method: HTTP动词 (e.g., "POST")
 // action: 动作名称 (e.g., "cancel")
 // handler: 处理函数
+// 对应的权限名为 "{resource}.{action}"，如 "orders.cancel"
 func WithCustomMethod(method, action string, handler gin.HandlerFunc) Option {
-	return func(g *gin.RouterGroup) {
+	return func(rr *resourceRoutes) {
 		// 注册路径: /:id/action
 		// 例如: POST /orders/:id/cancel
-		g.Handle(method, "/:id/"+action, handler)
+		rr.group.Handle(method, "/:id/"+action, guard(rr.name, action), handler)
+	}
+}
+
+// WithBatchMethods 挂载 AIP-231 ~ AIP-235 批量方法
+// 控制器必须实现 controller.BatchController (嵌入 BaseController 即可)
+//
+//	GET  /users:batchGet?ids=1&ids=2
+//	POST /users:batchCreate
+//	POST /users:batchUpdate
+//	POST /users:batchDelete
+//
+// 批量方法复用对应标准方法的权限，例如 batchCreate 需要 "users.create"
+// 注意：冒号需要转义，否则 Gin 会把它当作路径参数；转义会在 r.Run 时被还原
+func WithBatchMethods() Option {
+	return func(rr *resourceRoutes) {
+		bc, ok := rr.ctrl.(controller.BatchController)
+		if !ok {
+			panic("router: " + rr.name + " controller does not implement controller.BatchController")
+		}
+		prefix := "/" + rr.name + "\\:"
+		rr.parent.GET(prefix+"batchGet", guard(rr.name, "get"), bc.BatchGet)
+		rr.parent.POST(prefix+"batchCreate", guard(rr.name, "create"), bc.BatchCreate)
+		rr.parent.POST(prefix+"batchUpdate", guard(rr.name, "update"), bc.BatchUpdate)
+		rr.parent.POST(prefix+"batchDelete", guard(rr.name, "delete"), bc.BatchDelete)
 	}
 }
 
+// guard 为路由生成授权中间件
+func guard(resource, method string) gin.HandlerFunc {
+	return middleware.RequirePermission(auth.Permission(resource, method))
+}
+
 // RegisterResource 将一个标准控制器注册到 Gin 的路由组中
 // resourceName 必须是复数，例如 "users"
+// 每个标准方法都会挂上授权检查，权限名为 "{resourceName}.{list|get|create|update|delete}"
 func RegisterResource(r *gin.RouterGroup, resourceName string, ctrl controller.StandardController, options ...Option) {
 	// 创建资源集合的路由组，例如 /api/v1/users
 	// 这里体现了 ROD 的层级思想：URL 即资源路径
 	group := r.Group("/" + resourceName)
 	{
 		// 集合操作
-		group.GET("", ctrl.List)    // GET /users
-		group.POST("", ctrl.Create) // POST /users
+		group.GET("", guard(resourceName, "list"), ctrl.List)      // GET /users
+		group.POST("", guard(resourceName, "create"), ctrl.Create) // POST /users
 
 		// 单个资源操作，:id 代表资源标识符
-		group.GET("/:id", ctrl.Get)       // GET /users/:id
-		group.PATCH("/:id", ctrl.Update)  // PATCH /users/:id
-		group.DELETE("/:id", ctrl.Delete) // DELETE /users/:id
+		group.GET("/:id", guard(resourceName, "get"), ctrl.Get)          // GET /users/:id
+		group.PATCH("/:id", guard(resourceName, "update"), ctrl.Update)  // PATCH /users/:id
+		group.DELETE("/:id", guard(resourceName, "delete"), ctrl.Delete) // DELETE /users/:id
+	}
+
+	// 领域实体开启了软删除 (AIP-164) 时，自动挂载恢复方法
+	// POST /users/:id/undelete
+	if sd, ok := ctrl.(controller.SoftDeleteController); ok && sd.SoftDeleteEnabled() {
+		group.POST("/:id/undelete", guard(resourceName, "undelete"), sd.Undelete)
 	}
 
 	// 应用自定义选项
+	rr := &resourceRoutes{parent: r, group: group, name: resourceName, ctrl: ctrl}
 	for _, opt := range options {
-		opt(group)
+		opt(rr)
 	}
 }
 
 // SetupRoutes 路由注册入口
-func SetupRoutes(r *gin.Engine) {
-	v1 := r.Group("/api/v1")
-
+// v1 是已挂载了全局中间件 (认证、幂等、限流、发件箱) 的 /api/v1 路由组
+//
+// 注意：早期版本的签名是 SetupRoutes(r *gin.Engine)，内部重新 r.Group("/api/v1")。
+// 新建的路由组不会继承 main 中挂在 v1 上的中间件，所以当时幂等与限流实际上从未生效。
+// 改为直接接收 v1 之后，这些中间件对所有业务路由生效，行为变化如下：
+//   - 未认证的请求会被 RequirePermission 拒绝 (401)
+//   - 带 X-Idempotency-Key 的写请求开始去重
+//   - 请求开始计入限流与并发配额
+//
+// 新增路由时必须注册在 v1 上，不要再从 Engine 新建路由组，否则会绕过上述所有中间件
+// events 是发件箱，Webhook 分发器从这里读取领域事件
+// 返回需要后台运行的任务，由 main 负责启动，并在优雅停机时停止
+func SetupRoutes(v1 *gin.RouterGroup, events outbox.Store) *Background {
 	// 1. 注册 User 资源 (第 08 讲内容)
 	userCtrl := user.NewUserController()
-	RegisterResource(v1, "users", userCtrl)
+	// 批量接口用于大量导入，请求会经过同样的幂等中间件 (POST + X-Idempotency-Key)
+	RegisterResource(v1, "users", userCtrl, WithBatchMethods())
 
 	// --- 第 10 讲新增：LRO 路由 ---
 
@@ -61,10 +126,48 @@ func SetupRoutes(r *gin.Engine) {
 
 	// 3. 注册 Operations 资源 (复用 BaseController 能力)
 	// 这会自动生成 GET /api/v1/operations/:id
-	RegisterResource(v1, "operations", opCtrl)
+	// 同时挂载 GET /api/v1/operations/:id/watch，以 SSE 推送进度，替代轮询
+	RegisterResource(v1, "operations", opCtrl,
+		WithCustomMethod(http.MethodGet, "watch", aiCtrl.WatchOperation),
+	)
+
+	// Operation 完成时写入发件箱，通知订阅方
+	task.Events = events
 
 	// 4. 注册 AI 自定义方法 (LRO 触发)
 	// 对应 Google AIP 风格: POST /images:generate
-	v1.POST("/images/generate", aiCtrl.GenerateImage)
-	v1.POST("/chat/stream", aiCtrl.ChatStream)
+	v1.POST("/images/generate", guard("images", "generate"), aiCtrl.GenerateImage)
+	v1.POST("/chat/stream", guard("chat", "stream"), aiCtrl.ChatStream)
+	// 断线续传：按流 ID 重新连接 (配合 Last-Event-ID)
+	v1.GET("/chat/streams/:id", guard("chat", "stream"), aiCtrl.ResumeChatStream)
+
+	// 5. 注册 Order 资源，取消订单是自定义方法
+	// POST /api/v1/orders/:id/cancel
+	orderCtrl := order.NewOrderController()
+	RegisterResource(v1, "orders", orderCtrl,
+		WithCustomMethod(http.MethodPost, "cancel", orderCtrl.Cancel),
+	)
+
+	// 6. 注册 Webhook 订阅资源
+	// 资源变更 (标准方法、cancel、Operation 完成) 以签名的 HTTP 回调推送给订阅方
+	//   GET  /api/v1/webhooks/:id/deadletters 查看重试耗尽的投递
+	//   POST /api/v1/webhooks/:id/replay       重放死信
+	webhookCtrl := subscription.NewSubscriptionController(events)
+	RegisterResource(v1, "webhooks", webhookCtrl,
+		WithCustomMethod(http.MethodGet, "deadletters", webhookCtrl.DeadLetters),
+		WithCustomMethod(http.MethodPost, "replay", webhookCtrl.Replay),
+	)
+
+	return &Background{
+		// 开启了软删除的资源，需要定期永久清除过了保留期的数据
+		Purgers:        []controller.Purger{userCtrl},
+		StopDispatcher: webhookCtrl.Dispatcher().Start(time.Second),
+	}
+}
+
+// Background 是路由注册过程中创建的后台任务
+type Background struct {
+	Purgers []controller.Purger
+	// StopDispatcher 停止 Webhook 分发循环，退出前调用
+	StopDispatcher func()
 }
@@ -0,0 +1,312 @@
+package subscription
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rod-demo/internal/controller"
+	"rod-demo/internal/domain"
+	"rod-demo/internal/dto"
+	"rod-demo/internal/middleware"
+	"rod-demo/pkg/errs"
+	"rod-demo/pkg/outbox"
+	"rod-demo/pkg/pagination"
+	"rod-demo/pkg/webhook"
+)
+
+// =============================================================================
+// 1. 业务服务 (SubscriptionService)
+//    实现 controller.CRUDService，同时实现 webhook.EndpointSource 供分发器查询订阅
+//    订阅按创建者隔离：调用方只能看到和修改自己的订阅，其他调用方的订阅一律视为不存在 (404)
+// =============================================================================
+
+type SubscriptionService struct {
+	mu    sync.RWMutex
+	store map[string]domain.WebhookSubscription
+
+	// validateURL 校验回调地址，测试中可替换以避免真实的 DNS 查询
+	validateURL func(ctx context.Context, raw string) error
+}
+
+func NewSubscriptionService() *SubscriptionService {
+	return &SubscriptionService{
+		store:       make(map[string]domain.WebhookSubscription),
+		validateURL: webhook.ValidateURL,
+	}
+}
+
+// checkURL 校验回调地址，不合法时返回 400
+func (s *SubscriptionService) checkURL(ctx context.Context, raw string) error {
+	if err := s.validateURL(ctx, raw); err != nil {
+		return errs.Wrap(errs.ErrInvalidArgument, "url must be a public https endpoint", err).
+			WithDetails(map[string]interface{}{"field": "url"})
+	}
+	return nil
+}
+
+// getOwnedLocked 查询属于当前调用方的订阅，调用方必须持有锁
+func (s *SubscriptionService) getOwnedLocked(owner, id string) (domain.WebhookSubscription, error) {
+	sub, exists := s.store[id]
+	if !exists || sub.Owner != owner {
+		return sub, errs.New(errs.ErrNotFound, "webhook not found with id "+id)
+	}
+	return sub, nil
+}
+
+func (s *SubscriptionService) Create(ctx *gin.Context, req *dto.CreateWebhookRequest) (*domain.WebhookSubscription, error) {
+	if err := s.checkURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err != nil {
+			return nil, errs.Wrap(errs.ErrInternalServer, "failed to generate secret", err)
+		}
+		secret = "whsec_" + hex.EncodeToString(b)
+	}
+
+	sub := domain.WebhookSubscription{
+		ID:         uuid.New().String(),
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Active:     true,
+		CreateTime: time.Now().UTC(),
+		Secret:     secret,
+		Owner:      middleware.CurrentOwner(ctx),
+	}
+
+	s.mu.Lock()
+	s.store[sub.ID] = sub
+	s.mu.Unlock()
+
+	// 只有创建时返回 Secret，之后的查询都会隐藏
+	return &sub, nil
+}
+
+func (s *SubscriptionService) Get(ctx *gin.Context, id string) (*domain.WebhookSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, err := s.getOwnedLocked(middleware.CurrentOwner(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	return redact(sub), nil
+}
+
+// List 按创建时间排序，page_token 中保存的是偏移量
+// 只返回当前调用方的订阅
+func (s *SubscriptionService) List(ctx *gin.Context, req controller.ListRequest) (*controller.ListResponse[*domain.WebhookSubscription], error) {
+	offset := 0
+	if req.PageToken != "" {
+		cursor, err := pagination.DecodeQuery(req.PageToken, req.TokenQuery())
+		if err != nil {
+			return nil, err
+		}
+		offset, _ = strconv.Atoi(cursor)
+	}
+
+	owner := middleware.CurrentOwner(ctx)
+	s.mu.RLock()
+	subs := make([]domain.WebhookSubscription, 0, len(s.store))
+	for _, sub := range s.store {
+		if sub.Owner == owner {
+			subs = append(subs, sub)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].CreateTime.Equal(subs[j].CreateTime) {
+			return subs[i].ID < subs[j].ID
+		}
+		return subs[i].CreateTime.Before(subs[j].CreateTime)
+	})
+
+	items := make([]*domain.WebhookSubscription, 0, req.PageSize)
+	for i := offset; i < len(subs) && len(items) < req.PageSize; i++ {
+		items = append(items, redact(subs[i]))
+	}
+
+	next := ""
+	if end := offset + len(items); end < len(subs) {
+		var err error
+		next, err = pagination.EncodeToken(strconv.Itoa(end), req.TokenQuery())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &controller.ListResponse[*domain.WebhookSubscription]{
+		Items:         items,
+		NextPageToken: next,
+		TotalSize:     len(subs),
+	}, nil
+}
+
+func (s *SubscriptionService) Update(ctx *gin.Context, id string, req *dto.UpdateWebhookRequest) (*domain.WebhookSubscription, error) {
+	// DNS 查询较慢，放在锁外执行
+	if req.URL != nil {
+		if err := s.checkURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, err := s.getOwnedLocked(middleware.CurrentOwner(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		sub.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		sub.EventTypes = req.EventTypes
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+
+	s.store[id] = sub
+	return redact(sub), nil
+}
+
+// Delete 删除订阅，不存在 (或属于其他调用方) 时返回 404
+func (s *SubscriptionService) Delete(ctx *gin.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.getOwnedLocked(middleware.CurrentOwner(ctx), id); err != nil {
+		return err
+	}
+	delete(s.store, id)
+	return nil
+}
+
+// --- webhook.EndpointSource 实现 ---
+
+func (s *SubscriptionService) Match(ctx context.Context, owner, eventType string) ([]webhook.Endpoint, error) {
+	if owner == "" {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var endpoints []webhook.Endpoint
+	for _, sub := range s.store {
+		if sub.Owner == owner && sub.Active && webhook.MatchEventType(sub.EventTypes, eventType) {
+			endpoints = append(endpoints, toEndpoint(sub))
+		}
+	}
+	return endpoints, nil
+}
+
+func (s *SubscriptionService) Endpoint(ctx context.Context, id string) (*webhook.Endpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, exists := s.store[id]
+	if !exists {
+		return nil, nil
+	}
+	ep := toEndpoint(sub)
+	return &ep, nil
+}
+
+func toEndpoint(sub domain.WebhookSubscription) webhook.Endpoint {
+	return webhook.Endpoint{ID: sub.ID, URL: sub.URL, Secret: []byte(sub.Secret), Active: sub.Active}
+}
+
+// redact 返回隐藏了 Secret 的副本
+func redact(sub domain.WebhookSubscription) *domain.WebhookSubscription {
+	sub.Secret = ""
+	return &sub
+}
+
+// =============================================================================
+// 2. 控制器 (SubscriptionController)
+//    标准方法来自 BaseController，额外提供死信查询与重放两个自定义方法
+// =============================================================================
+
+type SubscriptionController struct {
+	*controller.BaseController[domain.WebhookSubscription, dto.CreateWebhookRequest, dto.UpdateWebhookRequest]
+
+	service    *SubscriptionService
+	dispatcher *webhook.Dispatcher
+}
+
+// NewSubscriptionController 创建控制器，同时创建从 events 读取事件的分发器
+// 分发器需要调用方通过 Dispatcher().Start 启动
+func NewSubscriptionController(events outbox.Store) *SubscriptionController {
+	svc := NewSubscriptionService()
+	base := controller.NewBaseController[domain.WebhookSubscription, dto.CreateWebhookRequest, dto.UpdateWebhookRequest](svc)
+
+	return &SubscriptionController{
+		BaseController: base,
+		service:        svc,
+		dispatcher:     webhook.NewDispatcher(events, webhook.NewMemoryDeliveryStore(), svc, webhook.DefaultRetryPolicy),
+	}
+}
+
+// Dispatcher 返回该订阅集合对应的分发器
+func (ctrl *SubscriptionController) Dispatcher() *webhook.Dispatcher {
+	return ctrl.dispatcher
+}
+
+// DeadLetters 列出重试耗尽的投递
+// 映射路由: GET /webhooks/:id/deadletters
+func (ctrl *SubscriptionController) DeadLetters(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := ctrl.service.Get(c, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	dead, err := ctrl.dispatcher.DeadLetters(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": dead})
+}
+
+// Replay 把死信重新放回投递队列
+// 映射路由: POST /webhooks/:id/replay
+// 投递是异步的，返回 202 Accepted
+func (ctrl *SubscriptionController) Replay(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := ctrl.service.Get(c, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req dto.ReplayWebhookRequest
+	// 请求体可选
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errs.Wrap(errs.ErrBadRequest, "invalid request parameters", err))
+			return
+		}
+	}
+
+	n, err := ctrl.dispatcher.Replay(c, id, req.DeliveryIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"replayed": n})
+}
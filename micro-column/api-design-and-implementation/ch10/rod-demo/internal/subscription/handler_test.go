@@ -0,0 +1,108 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"rod-demo/internal/controller"
+	"rod-demo/internal/dto"
+	"rod-demo/pkg/auth"
+	"rod-demo/pkg/errs"
+	"rod-demo/pkg/webhook"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestService 创建跳过 DNS 查询的服务，只保留 scheme 检查
+func newTestService() *SubscriptionService {
+	svc := NewSubscriptionService()
+	svc.validateURL = func(ctx context.Context, raw string) error {
+		if !strings.HasPrefix(raw, "https://") {
+			return webhook.ErrUnsafeURL
+		}
+		return nil
+	}
+	return svc
+}
+
+// ctxAs 构造以 owner 身份发起请求的 gin.Context，owner 为空时视为匿名
+func ctxAs(owner string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if owner != "" {
+		c.Set(auth.ContextKey, &auth.Principal{ID: owner, Kind: "api_key"})
+	}
+	return c
+}
+
+func errType(err error) errs.ErrorType {
+	if err == nil {
+		return ""
+	}
+	return errs.From(err).Type
+}
+
+func TestSubscriptionsAreScopedToOwner(t *testing.T) {
+	svc := newTestService()
+	alice, bob := ctxAs("alice"), ctxAs("bob")
+
+	sub, err := svc.Create(alice, &dto.CreateWebhookRequest{URL: "https://a.example/hook", EventTypes: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Get(bob, sub.ID); errType(err) != errs.ErrNotFound {
+		t.Fatalf("bob Get = %v, want not found", err)
+	}
+	url := "https://b.example/hook"
+	if _, err := svc.Update(bob, sub.ID, &dto.UpdateWebhookRequest{URL: &url}); errType(err) != errs.ErrNotFound {
+		t.Fatalf("bob Update = %v, want not found", err)
+	}
+	if err := svc.Delete(bob, sub.ID); errType(err) != errs.ErrNotFound {
+		t.Fatalf("bob Delete = %v, want not found", err)
+	}
+	list, err := svc.List(bob, controller.ListRequest{PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 0 {
+		t.Fatalf("bob sees %d subscriptions, want 0", len(list.Items))
+	}
+
+	// 事件只投递给同一调用方的订阅，匿名事件不投递
+	if eps, _ := svc.Match(context.Background(), "api_key:bob", "order.created"); len(eps) != 0 {
+		t.Fatalf("bob's event matched %d endpoints", len(eps))
+	}
+	if eps, _ := svc.Match(context.Background(), "", "order.created"); len(eps) != 0 {
+		t.Fatalf("anonymous event matched %d endpoints", len(eps))
+	}
+	if eps, _ := svc.Match(context.Background(), "api_key:alice", "order.created"); len(eps) != 1 {
+		t.Fatalf("alice's event matched %d endpoints, want 1", len(eps))
+	}
+
+	if err := svc.Delete(alice, sub.ID); err != nil {
+		t.Fatalf("alice Delete = %v", err)
+	}
+}
+
+func TestDeleteMissingSubscriptionReturnsNotFound(t *testing.T) {
+	svc := newTestService()
+	if err := svc.Delete(ctxAs("alice"), "missing"); errType(err) != errs.ErrNotFound {
+		t.Fatalf("Delete = %v, want not found", err)
+	}
+}
+
+func TestCreateRejectsUnsafeURL(t *testing.T) {
+	svc := newTestService()
+	_, err := svc.Create(ctxAs("alice"), &dto.CreateWebhookRequest{URL: "http://a.example/hook", EventTypes: []string{"*"}})
+	if errType(err) != errs.ErrInvalidArgument {
+		t.Fatalf("Create = %v, want invalid argument", err)
+	}
+}
@@ -1,8 +1,11 @@
 package task
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"rod-demo/internal/domain"
+	"rod-demo/pkg/outbox"
 	"sync"
 	"time"
 )
@@ -11,6 +14,13 @@ import (
 var (
 	OpStore = make(map[string]*domain.Operation)
 	mu      sync.RWMutex
+
+	// watchers 记录每个 Operation 的监听者，状态变化时主动推送 (生产环境可用 Redis Pub/Sub)
+	watchers = make(map[string]map[chan domain.Operation]struct{})
+
+	// Events 接收 Operation 完成事件的发件箱，启动时注入；为 nil 时不发事件
+	// 后台任务不经过 HTTP 请求，因此直接写入发件箱
+	Events outbox.Store
 )
 
 // GetOperation 安全地获取操作状态
@@ -25,13 +35,57 @@ func GetOperation(id string) *domain.Operation {
 	return nil
 }
 
+// Watch 订阅 Operation 的状态变化
+// 返回的通道会先收到一次当前快照，之后每次更新都会收到最新状态；
+// 通道容量为 1 且只保留最新值，慢消费者不会阻塞任务本身
+// 如果 Operation 不存在，返回 ok=false
+func Watch(id string) (updates <-chan domain.Operation, cancel func(), ok bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	op, exists := OpStore[id]
+	if !exists {
+		return nil, nil, false
+	}
+
+	ch := make(chan domain.Operation, 1)
+	ch <- *op
+	if watchers[id] == nil {
+		watchers[id] = make(map[chan domain.Operation]struct{})
+	}
+	watchers[id][ch] = struct{}{}
+
+	return ch, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(watchers[id], ch)
+		if len(watchers[id]) == 0 {
+			delete(watchers, id)
+		}
+	}, true
+}
+
+// notifyLocked 向所有监听者推送最新状态，调用方必须持有 mu 写锁
+func notifyLocked(op *domain.Operation) {
+	for ch := range watchers[op.ID] {
+		// 丢弃尚未被消费的旧状态，只保留最新值
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- *op
+	}
+}
+
 // StartImageGeneration 启动异步任务
-func StartImageGeneration(opID string, prompt string) {
+// owner 为发起调用方的标识，用于限定完成事件的接收方
+func StartImageGeneration(opID, owner, prompt string) {
 	// 1. 初始化任务状态
 	mu.Lock()
 	OpStore[opID] = &domain.Operation{
-		ID:   opID,
-		Done: false,
+		ID:    opID,
+		Owner: owner,
+		Done:  false,
 		Metadata: domain.OperationMetadata{
 			Status:   "Queued",
 			Progress: 0,
@@ -50,11 +104,13 @@ func StartImageGeneration(opID string, prompt string) {
 					Status:   "Processing",
 					Progress: i * 20,
 				}
+				notifyLocked(op)
 			}
 			mu.Unlock()
 		}
 
 		// 3. 任务完成
+		var done *domain.Operation
 		mu.Lock()
 		if op, ok := OpStore[opID]; ok {
 			op.Done = true
@@ -63,7 +119,30 @@ func StartImageGeneration(opID string, prompt string) {
 			op.Response = map[string]string{
 				"image_url": fmt.Sprintf("https://cdn.example.com/images/%s.png", opID),
 			}
+			notifyLocked(op)
+			val := *op
+			done = &val
 		}
 		mu.Unlock()
+
+		// 4. 通知 Webhook 订阅方
+		if done != nil {
+			emitCompleted(done)
+		}
 	}()
 }
+
+// emitCompleted 写入 "operation.completed" 事件
+func emitCompleted(op *domain.Operation) {
+	if Events == nil {
+		return
+	}
+	evt, err := outbox.NewEvent("operation.completed", op.ID, op)
+	if err == nil {
+		evt.Owner = op.Owner
+		err = Events.Append(context.Background(), evt)
+	}
+	if err != nil {
+		log.Printf("emit operation.completed for %s failed: %v", op.ID, err)
+	}
+}
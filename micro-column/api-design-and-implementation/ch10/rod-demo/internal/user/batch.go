@@ -0,0 +1,120 @@
+package user
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"rod-demo/internal/controller"
+	"rod-demo/internal/domain"
+	"rod-demo/internal/dto"
+	"rod-demo/pkg/errs"
+)
+
+// UserService 实现 controller.BatchService，批量写操作在同一把写锁内完成，相当于一个事务：
+// 先在暂存区里校验并计算所有条目的新状态，全部通过后才一次性写回，任意一条失败都不会留下部分修改
+var _ controller.BatchService[domain.User, dto.CreateUserRequest, dto.UpdateUserRequest] = (*UserService)(nil)
+
+// BatchGet 批量查询，任意一个 ID 不存在时整体返回 404
+func (s *UserService) BatchGet(ctx *gin.Context, ids []string) ([]*domain.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]*domain.User, len(ids))
+	for i, id := range ids {
+		user, exists := s.store[id]
+		if !exists {
+			return nil, controller.WithIndex(errs.New(errs.ErrNotFound, "user not found with id "+id), i)
+		}
+		users[i] = &user
+	}
+	return users, nil
+}
+
+// BatchCreate 批量创建
+func (s *UserService) BatchCreate(ctx *gin.Context, reqs []*dto.CreateUserRequest) ([]*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]*domain.User, len(reqs))
+	for i, req := range reqs {
+		user, err := newUser(req)
+		if err != nil {
+			return nil, controller.WithIndex(err, i)
+		}
+		users[i] = &user
+	}
+
+	for _, user := range users {
+		s.insertLocked(*user)
+	}
+	return users, nil
+}
+
+// BatchUpdate 批量更新，同一个 ID 出现多次时按顺序叠加
+func (s *UserService) BatchUpdate(ctx *gin.Context, reqs []controller.BatchUpdateItem[dto.UpdateUserRequest]) ([]*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	staged := make(map[string]domain.User, len(reqs))
+	users := make([]*domain.User, len(reqs))
+	for i, it := range reqs {
+		user, err := s.stagedLocked(staged, it.ID)
+		if err != nil {
+			return nil, controller.WithIndex(err, i)
+		}
+		user = applyUpdate(user, it.Update)
+		staged[it.ID] = user
+		users[i] = &user
+	}
+
+	for id, user := range staged {
+		s.store[id] = user
+	}
+	return users, nil
+}
+
+// BatchDelete 批量软删除，任意一个 ID 不存在或已被删除时整体返回 404，不会删除任何用户
+func (s *UserService) BatchDelete(ctx *gin.Context, ids []string) ([]*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	staged := make(map[string]domain.User, len(ids))
+	users := make([]*domain.User, len(ids))
+	for i, id := range ids {
+		// 同一批次中重复的 ID 在第二次出现时已处于删除状态，同样返回 404
+		user, err := s.stagedLocked(staged, id)
+		if err != nil {
+			if errs.From(err).Type == errs.ErrFailedPrecondition {
+				err = errs.New(errs.ErrNotFound, "user not found with id "+id)
+			}
+			return nil, controller.WithIndex(err, i)
+		}
+		user.MarkDeleted(now, controller.SoftDeleteRetention)
+		staged[id] = user
+		users[i] = &user
+	}
+
+	for id, user := range staged {
+		s.store[id] = user
+	}
+	return users, nil
+}
+
+// stagedLocked 读取用户在本批次中的最新状态 (优先暂存区)，不存在或已删除时返回错误
+// 调用方必须持有写锁
+func (s *UserService) stagedLocked(staged map[string]domain.User, id string) (domain.User, error) {
+	user, exists := staged[id]
+	if !exists {
+		user, exists = s.store[id]
+	}
+	if !exists {
+		return user, errs.New(errs.ErrNotFound, "user not found with id "+id)
+	}
+	// 与单条 Update 一致：已软删除的资源需先 undelete
+	if user.IsDeleted() {
+		return user, errs.New(errs.ErrFailedPrecondition, "user "+id+" is deleted")
+	}
+	return user, nil
+}
@@ -0,0 +1,146 @@
+package user
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"rod-demo/internal/controller"
+	"rod-demo/internal/dto"
+	"rod-demo/pkg/errs"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// assertIndexError 检查批量失败时返回的错误类型与出错条目
+func assertIndexError(t *testing.T, err error, want errs.ErrorType, index int) {
+	t.Helper()
+	appErr := errs.From(err)
+	if appErr.Type != want {
+		t.Fatalf("type = %s, want %s (%v)", appErr.Type, want, err)
+	}
+	if appErr.Details["index"] != index {
+		t.Fatalf("index = %v, want %d", appErr.Details["index"], index)
+	}
+}
+
+func TestBatchDeleteIsAtomic(t *testing.T) {
+	s := NewUserService()
+	if _, err := s.SoftDelete(nil, "10002", controller.SoftDeleteRetention); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二条已被软删除，整个批次失败，第一条不能被删除
+	_, err := s.BatchDelete(nil, []string{"10001", "10002"})
+	assertIndexError(t, err, errs.ErrNotFound, 1)
+	if u, _ := s.Get(nil, "10001"); u.IsDeleted() {
+		t.Fatal("10001 was deleted although the batch failed")
+	}
+
+	// 同一批次中重复的 ID
+	_, err = s.BatchDelete(nil, []string{"10003", "10003"})
+	assertIndexError(t, err, errs.ErrNotFound, 1)
+	if u, _ := s.Get(nil, "10003"); u.IsDeleted() {
+		t.Fatal("10003 was deleted although the batch failed")
+	}
+
+	users, err := s.BatchDelete(nil, []string{"10001", "10003"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range users {
+		if !u.IsDeleted() {
+			t.Fatalf("user %s not marked deleted", u.ID)
+		}
+	}
+}
+
+func TestBatchUpdateIsAtomic(t *testing.T) {
+	s := NewUserService()
+
+	_, err := s.BatchUpdate(nil, []controller.BatchUpdateItem[dto.UpdateUserRequest]{
+		{ID: "10001", Update: &dto.UpdateUserRequest{Name: ptr("changed")}},
+		{ID: "missing", Update: &dto.UpdateUserRequest{Name: ptr("changed")}},
+	})
+	assertIndexError(t, err, errs.ErrNotFound, 1)
+	if u, _ := s.Get(nil, "10001"); u.Name != "User 10001" {
+		t.Fatalf("name = %q, the failed batch must not be applied", u.Name)
+	}
+
+	users, err := s.BatchUpdate(nil, []controller.BatchUpdateItem[dto.UpdateUserRequest]{
+		{ID: "10001", Update: &dto.UpdateUserRequest{Name: ptr("first")}},
+		{ID: "10001", Update: &dto.UpdateUserRequest{Age: ptr(0)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := users[1]; got.Name != "first" || got.Age != 0 {
+		t.Fatalf("updates on the same id should stack, got %+v", got)
+	}
+}
+
+func TestBatchCreateIsAtomic(t *testing.T) {
+	s := NewUserService()
+	before := len(s.store)
+
+	_, err := s.BatchCreate(nil, []*dto.CreateUserRequest{
+		{Name: "ok", Age: 20},
+		{Name: "bad", Age: 200},
+	})
+	assertIndexError(t, err, errs.ErrBadRequest, 1)
+	if len(s.store) != before {
+		t.Fatalf("store size = %d, want %d", len(s.store), before)
+	}
+}
+
+// listAll 翻完所有页，返回用户 ID 与最后一页的 TotalSize
+func listAll(t *testing.T, s *UserService) ([]string, int) {
+	t.Helper()
+	var ids []string
+	req := controller.ListRequest{PageSize: 50}
+	for {
+		resp, err := s.List(nil, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range resp.Items {
+			ids = append(ids, u.ID)
+		}
+		if resp.NextPageToken == "" {
+			return ids, resp.TotalSize
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+func TestCreatedUsersAreListed(t *testing.T) {
+	s := NewUserService()
+	before := len(s.store)
+
+	created, err := s.Create(nil, &dto.CreateUserRequest{Name: "single", Age: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.BatchCreate(nil, []*dto.CreateUserRequest{{Name: "a", Age: 20}, {Name: "b", Age: 30}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SoftDelete(nil, "10000", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, total := listAll(t, s)
+	if !slices.IsSortedFunc(ids, func(a, b string) int { return strings.Compare(b, a) }) {
+		t.Fatal("list is not in descending id order")
+	}
+	for _, u := range append(batch, created) {
+		if !slices.Contains(ids, u.ID) {
+			t.Fatalf("created user %s is missing from List", u.ID)
+		}
+	}
+	// 3 个新用户，1 个已软删除的用户不计入
+	if want := before + 3 - 1; len(ids) != want || total != want {
+		t.Fatalf("listed %d users (total_size %d), want %d", len(ids), total, want)
+	}
+}
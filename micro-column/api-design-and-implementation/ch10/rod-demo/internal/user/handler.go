@@ -1,9 +1,12 @@
 package user
 
 import (
+	"context"
+	"slices"
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -60,6 +63,18 @@ func (s *UserService) Create(ctx *gin.Context, req *dto.CreateUserRequest) (*dom
 	defer s.mu.Unlock()
 
 	// [修改点] 模拟复杂的业务校验
+	user, err := newUser(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 存入模拟数据库
+	s.insertLocked(user)
+	return &user, nil
+}
+
+// newUser 校验创建请求并构造新用户 (Create 与 BatchCreate 共用)
+func newUser(req *dto.CreateUserRequest) (domain.User, error) {
 	if req.Age < 0 || req.Age > 150 {
 		// 构建结构化的错误详情 (Google AIP 风格)
 		details := map[string]interface{}{
@@ -70,24 +85,18 @@ func (s *UserService) Create(ctx *gin.Context, req *dto.CreateUserRequest) (*dom
 		}
 
 		// 返回带详情的错误
-		return nil, errs.New(errs.ErrBadRequest, "invalid parameters").
+		return domain.User{}, errs.New(errs.ErrBadRequest, "invalid parameters").
 			WithDetails(details)
 	}
 
 	// 模拟生成 ID
-	id := uuid.New().String()
-
-	user := domain.User{
-		ID:       id,
+	return domain.User{
+		ID:       uuid.New().String(),
 		Name:     req.Name,
 		Age:      req.Age,
 		Bio:      "Default Bio",
 		IsActive: true,
-	}
-
-	// 存入模拟数据库
-	s.store[id] = user
-	return &user, nil
+	}, nil
 }
 
 // Get 实现具体的查询逻辑
@@ -114,9 +123,10 @@ func (s *UserService) List(ctx *gin.Context, req controller.ListRequest) (*contr
 
 	// 1. 解码 Token 获取游标 (Cursor)
 	// 如果 Token 为空，cursor 为空字符串，表示第一页
-	cursor, err := pagination.Decode(req.PageToken)
+	// Token 经过签名且绑定了查询参数，伪造、过期或更换 filter 都会得到 400 INVALID_ARGUMENT
+	cursor, err := pagination.DecodeQuery(req.PageToken, req.TokenQuery())
 	if err != nil {
-		return nil, err // 实际应返回 Invalid Argument 错误
+		return nil, err
 	}
 
 	var result []*domain.User
@@ -143,34 +153,43 @@ func (s *UserService) List(ctx *gin.Context, req controller.ListRequest) (*contr
 	}
 
 	// 3. 截取数据 (LIMIT page_size)
-	count := 0
-	for i := startIndex; i < len(s.sortedIDs); i++ {
-		if count >= req.PageSize {
-			break
-		}
+	// 已软删除的用户默认不出现在列表中 (AIP-164)，相当于 WHERE delete_time IS NULL
+	i := startIndex
+	for ; i < len(s.sortedIDs) && len(result) < req.PageSize; i++ {
 		id := s.sortedIDs[i]
-		if user, ok := s.store[id]; ok {
-			// 注意：必须拷贝副本，避免指针指向循环变量
-			temp := user
-			result = append(result, &temp)
-			// 记录当前最后一条数据的 ID，作为下一次的游标
-			nextCursor = id
-			count++
+		user, ok := s.store[id]
+		if !ok || (user.IsDeleted() && !req.ShowDeleted) {
+			continue
 		}
+		// 注意：必须拷贝副本，避免指针指向循环变量
+		temp := user
+		result = append(result, &temp)
+		// 记录当前最后一条数据的 ID，作为下一次的游标
+		nextCursor = id
 	}
 
 	// 4. 生成 NextPageToken
 	// 如果取出的数据量少于 PageSize，或者已经到了数组末尾，说明没有下一页了
 	encodedToken := ""
-	if len(result) == req.PageSize && startIndex+len(result) < len(s.sortedIDs) {
-		encodedToken = pagination.Encode(nextCursor)
+	if len(result) == req.PageSize && i < len(s.sortedIDs) {
+		encodedToken, err = pagination.EncodeToken(nextCursor, req.TokenQuery())
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// 5. 构造标准响应
+	// TotalSize 与列表的过滤条件一致：默认不计入已软删除的用户
+	total := 0
+	for _, user := range s.store {
+		if !user.IsDeleted() || req.ShowDeleted {
+			total++
+		}
+	}
 	return &controller.ListResponse[*domain.User]{
 		Items:         result,
 		NextPageToken: encodedToken,
-		TotalSize:     len(s.sortedIDs), // 可选
+		TotalSize:     total, // 可选
 	}, nil
 }
 
@@ -185,9 +204,22 @@ func (s *UserService) Update(ctx *gin.Context, id string, req *dto.UpdateUserReq
 	if !exists {
 		return nil, nil // 触发 404
 	}
+	// 已软删除的资源不允许修改，需先 undelete
+	if user.IsDeleted() {
+		return nil, errs.New(errs.ErrFailedPrecondition, "user "+id+" is deleted")
+	}
 
 	// 2. 核心逻辑：零值更新处理
-	// 只有当 DTO 中的指针不为 nil 时，才更新对应的字段
+	user = applyUpdate(user, req)
+
+	// 3. 保存回数据库
+	s.store[id] = user
+
+	return &user, nil
+}
+
+// applyUpdate 只更新 DTO 中指针不为 nil 的字段 (Update 与 BatchUpdate 共用)
+func applyUpdate(user domain.User, req *dto.UpdateUserRequest) domain.User {
 	if req.Name != nil {
 		user.Name = *req.Name
 	}
@@ -201,21 +233,87 @@ func (s *UserService) Update(ctx *gin.Context, id string, req *dto.UpdateUserReq
 	if req.IsActive != nil {
 		user.IsActive = *req.IsActive
 	}
+	return user
+}
 
-	// 3. 保存回数据库
+// Delete 实现具体的删除逻辑 (物理删除)
+// User 开启了软删除，BaseController 会改为调用 SoftDelete，这里保留给内部清理使用
+func (s *UserService) Delete(ctx *gin.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 即使 ID 不存在，Delete 通常也视为成功（幂等性）
+	s.purgeLocked(id)
+	return nil
+}
+
+// SoftDelete 标记删除 (AIP-164)
+// 与物理删除不同，这里对不存在或已删除的资源返回 404，方便客户端判断
+func (s *UserService) SoftDelete(ctx *gin.Context, id string, retention time.Duration) (*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.store[id]
+	if !exists || user.IsDeleted() {
+		return nil, errs.New(errs.ErrNotFound, "user not found with id "+id)
+	}
+
+	user.MarkDeleted(time.Now(), retention)
 	s.store[id] = user
+	return &user, nil
+}
+
+// Undelete 恢复已删除的用户
+func (s *UserService) Undelete(ctx *gin.Context, id string) (*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.store[id]
+	if !exists {
+		return nil, errs.New(errs.ErrNotFound, "user not found with id "+id)
+	}
+	if !user.IsDeleted() {
+		return nil, errs.New(errs.ErrFailedPrecondition, "user "+id+" is not deleted")
+	}
 
+	user.Restore()
+	s.store[id] = user
 	return &user, nil
 }
 
-// Delete 实现具体的删除逻辑
-func (s *UserService) Delete(ctx *gin.Context, id string) error {
+// Purge 永久清除已过保留期的用户
+func (s *UserService) Purge(ctx context.Context, now time.Time) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// 即使 ID 不存在，Delete 通常也视为成功（幂等性）
+	purged := 0
+	for id, user := range s.store {
+		if user.Expired(now) {
+			s.purgeLocked(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// insertLocked 写入存储，并按倒序把 ID 插入索引，调用方必须持有写锁
+func (s *UserService) insertLocked(user domain.User) {
+	s.store[user.ID] = user
+	idx := sort.Search(len(s.sortedIDs), func(i int) bool {
+		return s.sortedIDs[i] <= user.ID
+	})
+	s.sortedIDs = slices.Insert(s.sortedIDs, idx, user.ID)
+}
+
+// purgeLocked 从存储和索引中移除用户，调用方必须持有写锁
+func (s *UserService) purgeLocked(id string) {
 	delete(s.store, id)
-	return nil
+	for i, sid := range s.sortedIDs {
+		if sid == id {
+			s.sortedIDs = append(s.sortedIDs[:i], s.sortedIDs[i+1:]...)
+			break
+		}
+	}
 }
 
 // =============================================================================
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// APIKeyStore 按 API Key 的哈希值查询调用方
+// 存储层永远只保存哈希，即使数据库泄露也无法还原出原始 Key
+// 生产环境可以基于 Redis / 数据库实现
+type APIKeyStore interface {
+	Lookup(ctx context.Context, hash string) (*Principal, error)
+}
+
+// HashAPIKey 计算 API Key 的存储哈希 (SHA-256 十六进制)
+// API Key 本身是高熵随机串，无需 bcrypt 这类慢哈希
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryAPIKeyStore 基于内存的 APIKeyStore，用于演示和测试
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]Principal
+}
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{keys: make(map[string]Principal)}
+}
+
+// Add 登记一个 API Key 的哈希及其对应的调用方
+func (s *MemoryAPIKeyStore) Add(hash string, p Principal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.Kind = KindAPIKey
+	s.keys[hash] = p
+}
+
+// Revoke 吊销一个 API Key
+func (s *MemoryAPIKeyStore) Revoke(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, hash)
+}
+
+func (s *MemoryAPIKeyStore) Lookup(ctx context.Context, hash string) (*Principal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.keys[hash]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return &p, nil
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// JWTOptions 定义 JWT 的校验规则
+type JWTOptions struct {
+	HMACSecret []byte                    // HS256 共享密钥，为空则不接受 HS256
+	RSAKeys    map[string]*rsa.PublicKey // RS256 公钥，按 kid 索引 (通常来自 JWKS)，为空则不接受 RS256
+	Issuer     string                    // 期望的 iss，为空则不校验
+	Audience   string                    // 期望的 aud，为空则不校验
+	Leeway     time.Duration             // 允许的时钟偏差
+}
+
+// JWTVerifier 校验 Bearer Token 并提取调用方身份
+// 只支持 HS256 和 RS256，显式拒绝 "none" 等其它算法，防止算法混淆攻击
+type JWTVerifier struct {
+	opts JWTOptions
+	now  func() time.Time
+}
+
+func NewJWTVerifier(opts JWTOptions) *JWTVerifier {
+	return &JWTVerifier{opts: opts, now: time.Now}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"` // 可能是字符串，也可能是数组
+	ExpiresAt int64           `json:"exp"`
+	NotBefore int64           `json:"nbf"`
+	Scope     string          `json:"scope"` // OAuth2 风格：空格分隔
+	Scp       []string        `json:"scp"`   // 部分 IdP 使用数组
+	Plan      string          `json:"plan"`  // 自定义声明：配额套餐
+}
+
+// Verify 校验 Token 签名与声明，成功时返回 Principal
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed jwt", ErrInvalidCredentials)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+	if err := v.verifySignature(header, signingInput, sig); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	scopes := claims.Scp
+	if claims.Scope != "" {
+		scopes = append(scopes, strings.Fields(claims.Scope)...)
+	}
+	return &Principal{ID: claims.Subject, Kind: KindJWT, Scopes: scopes, Plan: claims.Plan}, nil
+}
+
+func (v *JWTVerifier) verifySignature(h jwtHeader, input string, sig []byte) error {
+	switch h.Alg {
+	case "HS256":
+		if len(v.opts.HMACSecret) == 0 {
+			break
+		}
+		mac := hmac.New(sha256.New, v.opts.HMACSecret)
+		mac.Write([]byte(input))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidCredentials)
+		}
+		return nil
+
+	case "RS256":
+		if h.Kid == "" {
+			return fmt.Errorf("%w: missing kid", ErrInvalidCredentials)
+		}
+		key, ok := v.opts.RSAKeys[h.Kid]
+		if !ok {
+			return fmt.Errorf("%w: unknown kid %q", ErrInvalidCredentials, h.Kid)
+		}
+		digest := sha256.Sum256([]byte(input))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidCredentials)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported alg %q", ErrInvalidCredentials, h.Alg)
+}
+
+func (v *JWTVerifier) validateClaims(c jwtClaims) error {
+	now := v.now()
+	if c.Subject == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidCredentials)
+	}
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(v.opts.Leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	}
+	if c.NotBefore != 0 && now.Add(v.opts.Leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidCredentials)
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return fmt.Errorf("%w: unexpected iss", ErrInvalidCredentials)
+	}
+	if v.opts.Audience != "" && !audienceContains(c.Audience, v.opts.Audience) {
+		return fmt.Errorf("%w: unexpected aud", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+func audienceContains(raw json.RawMessage, want string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == want
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, aud := range list {
+			if aud == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidCredentials)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// JWKS
+// -----------------------------------------------------------------------------
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// LoadJWKS 从本地文件加载 JWKS (RFC 7517)，返回按 kid 索引的 RSA 公钥
+// 只保留 kty=RSA、用途为签名 (use 为空或 "sig") 且算法为 RS256 (alg 为空或 "RS256") 的密钥：
+// Verifier 对这些公钥一律按 RS256 验签，声明了其它 alg (如 RS512 / PS256) 或 use=enc 的密钥
+// 如果也被加载，同一把公钥就会被用在发行方并未授权的算法或用途上
+// 没有 kid 的密钥无法被 Token 的 kid 选中，同样跳过；kid 重复视为配置错误
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwks
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("auth: parse jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || k.Kid == "" {
+			continue
+		}
+		if (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+		if _, dup := keys[k.Kid]; dup {
+			return nil, fmt.Errorf("auth: jwk %q: duplicate kid", k.Kid)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("auth: jwk %q: bad modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("auth: jwk %q: bad exponent: %w", k.Kid, err)
+		}
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if key.N.BitLen() < 2048 || key.E < 3 || len(e) > 4 {
+			return nil, fmt.Errorf("auth: jwk %q: weak or invalid rsa key", k.Kid)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
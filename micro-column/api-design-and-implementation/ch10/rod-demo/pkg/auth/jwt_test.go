@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testNow    = time.Unix(1_700_000_000, 0)
+	hmacSecret = []byte("test-hs256-secret")
+)
+
+func mustRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func b64JSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signHS256 用给定密钥按 HS256 签名
+func signHS256(t *testing.T, header, claims map[string]interface{}, secret []byte) string {
+	t.Helper()
+	input := b64JSON(t, header) + "." + b64JSON(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRS256 用给定私钥按 RS256 签名
+func signRS256(t *testing.T, header, claims map[string]interface{}, key *rsa.PrivateKey) string {
+	t.Helper()
+	input := b64JSON(t, header) + "." + b64JSON(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "alice",
+		"iss":   "https://issuer.example",
+		"aud":   []string{"other", "rod-demo"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"scope": "users.read orders.read",
+		"plan":  "pro",
+	}
+}
+
+func withClaim(key string, value interface{}) map[string]interface{} {
+	c := validClaims()
+	if value == nil {
+		delete(c, key)
+	} else {
+		c[key] = value
+	}
+	return c
+}
+
+func TestJWTVerifier(t *testing.T) {
+	rsaKey := mustRSAKey(t)
+	otherKey := mustRSAKey(t)
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hs := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	rs := map[string]interface{}{"alg": "RS256", "kid": "k1"}
+
+	v := NewJWTVerifier(JWTOptions{
+		HMACSecret: hmacSecret,
+		RSAKeys:    map[string]*rsa.PublicKey{"k1": &rsaKey.PublicKey},
+		Issuer:     "https://issuer.example",
+		Audience:   "rod-demo",
+		Leeway:     30 * time.Second,
+	})
+	v.now = func() time.Time { return testNow }
+
+	// 只配置了 RS256 的验证器，用于检查算法混淆
+	rsOnly := NewJWTVerifier(JWTOptions{RSAKeys: map[string]*rsa.PublicKey{"k1": &rsaKey.PublicKey}})
+	rsOnly.now = func() time.Time { return testNow }
+
+	tests := []struct {
+		name     string
+		verifier *JWTVerifier
+		token    string
+		wantErr  bool
+	}{
+		{"hs256 valid", v, signHS256(t, hs, validClaims(), hmacSecret), false},
+		{"rs256 valid", v, signRS256(t, rs, validClaims(), rsaKey), false},
+		{"expired within leeway", v, signHS256(t, hs, withClaim("exp", testNow.Add(-10*time.Second).Unix()), hmacSecret), false},
+
+		// 算法混淆
+		{"alg none", v, b64JSON(t, map[string]string{"alg": "none"}) + "." + b64JSON(t, validClaims()) + ".", true},
+		{"alg lowercase", v, signHS256(t, map[string]interface{}{"alg": "hs256"}, validClaims(), hmacSecret), true},
+		{"hs256 signed with rsa public key", rsOnly, signHS256(t, hs, validClaims(), pubDER), true},
+		{"hs256 signed with rsa modulus", rsOnly, signHS256(t, hs, validClaims(), rsaKey.PublicKey.N.Bytes()), true},
+		{"rs256 header with hmac signature", v, signHS256(t, rs, validClaims(), hmacSecret), true},
+
+		// kid
+		{"rs256 missing kid", v, signRS256(t, map[string]interface{}{"alg": "RS256"}, validClaims(), rsaKey), true},
+		{"rs256 unknown kid", v, signRS256(t, map[string]interface{}{"alg": "RS256", "kid": "k2"}, validClaims(), rsaKey), true},
+
+		// 签名错误
+		{"hs256 wrong secret", v, signHS256(t, hs, validClaims(), []byte("other-secret")), true},
+		{"rs256 wrong key", v, signRS256(t, rs, validClaims(), otherKey), true},
+		{"claims changed after signing", v, func() string {
+			// 保留原签名，替换 payload 以提升权限
+			tok := signHS256(t, hs, validClaims(), hmacSecret)
+			forged := signHS256(t, hs, withClaim("scope", "users.write"), []byte("unknown"))
+			return forged[:strings.LastIndex(forged, ".")] + tok[strings.LastIndex(tok, "."):]
+		}(), true},
+		{"malformed", v, "not-a-jwt", true},
+
+		// 声明
+		{"expired", v, signHS256(t, hs, withClaim("exp", testNow.Add(-time.Minute).Unix()), hmacSecret), true},
+		{"missing exp", v, signHS256(t, hs, withClaim("exp", nil), hmacSecret), true},
+		{"not yet valid", v, signHS256(t, hs, withClaim("nbf", testNow.Add(time.Minute).Unix()), hmacSecret), true},
+		{"missing sub", v, signHS256(t, hs, withClaim("sub", nil), hmacSecret), true},
+		{"wrong issuer", v, signHS256(t, hs, withClaim("iss", "https://evil.example"), hmacSecret), true},
+		{"wrong audience", v, signHS256(t, hs, withClaim("aud", "other"), hmacSecret), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.verifier.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.ID != "alice" || p.Kind != KindJWT || p.Plan != "pro" || !p.HasAnyScope("orders.read") {
+				t.Fatalf("unexpected principal %+v", p)
+			}
+		})
+	}
+}
+
+func jwk(kid, use, alg string, pub *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": use,
+		"alg": alg,
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func writeJWKS(t *testing.T, keys ...map[string]string) string {
+	t.Helper()
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadJWKS(t *testing.T) {
+	pub := &mustRSAKey(t).PublicKey
+
+	keys, err := LoadJWKS(writeJWKS(t,
+		jwk("sig-rs256", "sig", "RS256", pub),
+		jwk("no-use-no-alg", "", "", pub),
+		jwk("enc", "enc", "RS256", pub),
+		jwk("rs512", "sig", "RS512", pub),
+		jwk("ps256", "", "PS256", pub),
+		jwk("", "sig", "RS256", pub),
+		map[string]string{"kty": "EC", "kid": "ec", "crv": "P-256"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"sig-rs256": true, "no-use-no-alg": true}
+	if len(keys) != len(want) {
+		t.Fatalf("loaded %d keys, want %d: %v", len(keys), len(want), keys)
+	}
+	for kid := range want {
+		if keys[kid] == nil {
+			t.Fatalf("key %q not loaded", kid)
+		}
+	}
+}
+
+func TestLoadJWKSRejects(t *testing.T) {
+	pub := &mustRSAKey(t).PublicKey
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		keys []map[string]string
+	}{
+		{"duplicate kid", []map[string]string{jwk("k1", "sig", "RS256", pub), jwk("k1", "", "", pub)}},
+		{"weak modulus", []map[string]string{jwk("k1", "sig", "RS256", &weak.PublicKey)}},
+		{"bad modulus", []map[string]string{{"kty": "RSA", "kid": "k1", "n": "!!", "e": "AQAB"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadJWKS(writeJWKS(t, tt.keys...)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
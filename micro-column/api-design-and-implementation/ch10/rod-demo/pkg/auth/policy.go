@@ -0,0 +1,22 @@
+package auth
+
+// Policy 是声明式的权限表
+// key 为权限名，格式为 "{resource}.{method}"，如 "users.list"、"orders.cancel"
+// value 为允许访问的 scope 列表，调用方拥有其中任意一个即可
+//
+// 未在表中声明的权限一律拒绝 (默认拒绝)，避免新增接口时忘记配置而意外暴露
+type Policy map[string][]string
+
+// Permission 拼接权限名
+func Permission(resource, method string) string {
+	return resource + "." + method
+}
+
+// Allows 判断调用方是否具备某项权限
+func (p Policy) Allows(principal *Principal, permission string) bool {
+	scopes, ok := p[permission]
+	if !ok || principal == nil {
+		return false
+	}
+	return principal.HasAnyScope(scopes...)
+}
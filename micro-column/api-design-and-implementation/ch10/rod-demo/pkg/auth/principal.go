@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ContextKey 是 Principal 在 gin.Context 中的键
+const ContextKey = "auth.principal"
+
+// 认证方式
+const (
+	KindAPIKey = "api_key"
+	KindJWT    = "jwt"
+)
+
+// ErrInvalidCredentials 表示携带了凭证但校验失败 (格式错误、签名错误、过期、未知的 Key 等)
+// 具体原因只用于内部日志，不透传给客户端，避免帮助攻击者探测
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal 代表一个已认证的调用方
+type Principal struct {
+	ID     string   `json:"id"`     // API Key 的所有者或 JWT 的 sub
+	Kind   string   `json:"kind"`   // 认证方式：api_key / jwt
+	Scopes []string `json:"scopes"` // 授予的权限范围
+	Plan   string   `json:"plan"`   // 配额套餐 (如 free / pro)，为空时使用默认套餐
+}
+
+// HasAnyScope 判断调用方是否拥有任意一个给定的 scope
+func (p *Principal) HasAnyScope(scopes ...string) bool {
+	for _, want := range scopes {
+		for _, have := range p.Scopes {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authenticator 组合多种认证方式，按以下顺序尝试：
+// 1. X-API-Key Header
+// 2. Authorization: Bearer <JWT>
+// 两者都没有时视为匿名请求，返回 nil, nil，由授权阶段决定是否放行
+type Authenticator struct {
+	APIKeys APIKeyStore  // 为 nil 时不支持 API Key
+	JWT     *JWTVerifier // 为 nil 时不支持 JWT
+}
+
+// Authenticate 从请求中解析调用方身份
+func (a *Authenticator) Authenticate(ctx context.Context, r *http.Request) (*Principal, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		if a.APIKeys == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return a.APIKeys.Lookup(ctx, HashAPIKey(key))
+	}
+
+	if authz := r.Header.Get("Authorization"); authz != "" {
+		token, ok := strings.CutPrefix(authz, "Bearer ")
+		if !ok || a.JWT == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return a.JWT.Verify(strings.TrimSpace(token))
+	}
+
+	return nil, nil
+}
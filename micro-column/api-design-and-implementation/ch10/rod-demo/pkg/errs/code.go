@@ -12,19 +12,39 @@ const (
 	ErrNotFound       ErrorType = "NOT_FOUND"
 	ErrUnauthorized   ErrorType = "UNAUTHORIZED"
 
+	// 对齐 Google AIP-193 的错误码
+	// ErrInvalidArgument 与 ErrBadRequest 区分：请求格式正确，但某个参数的值不合法 (如伪造/过期的 page_token)
+	ErrInvalidArgument ErrorType = "INVALID_ARGUMENT"
+	// ErrPermissionDenied 已认证但权限不足 (403)，与未认证 (401) 区分
+	ErrPermissionDenied ErrorType = "PERMISSION_DENIED"
+	// ErrFailedPrecondition 资源当前状态不允许该操作 (如恢复一个未删除的资源)
+	ErrFailedPrecondition ErrorType = "FAILED_PRECONDITION"
+	ErrConflict           ErrorType = "CONFLICT"
+	ErrRequestTooLarge    ErrorType = "REQUEST_TOO_LARGE"
+	ErrUnavailable        ErrorType = "UNAVAILABLE"
+
 	// 业务特定错误 (Example)
 	ErrUserFrozen    ErrorType = "USER_FROZEN"
 	ErrQuotaExceeded ErrorType = "QUOTA_EXCEEDED"
+	// ErrIdempotencyKeyReused 同一个幂等键被用于不同的请求内容
+	ErrIdempotencyKeyReused ErrorType = "IDEMPOTENCY_KEY_REUSED"
 )
 
 // Map ErrorType to HTTP Status Code
 var statusMap = map[ErrorType]int{
-	ErrInternalServer: http.StatusInternalServerError,
-	ErrBadRequest:     http.StatusBadRequest,
-	ErrNotFound:       http.StatusNotFound,
-	ErrUnauthorized:   http.StatusUnauthorized,
-	ErrUserFrozen:     http.StatusForbidden,
-	ErrQuotaExceeded:  http.StatusTooManyRequests,
+	ErrInternalServer:       http.StatusInternalServerError,
+	ErrBadRequest:           http.StatusBadRequest,
+	ErrNotFound:             http.StatusNotFound,
+	ErrUnauthorized:         http.StatusUnauthorized,
+	ErrInvalidArgument:      http.StatusBadRequest,
+	ErrPermissionDenied:     http.StatusForbidden,
+	ErrFailedPrecondition:   http.StatusBadRequest,
+	ErrConflict:             http.StatusConflict,
+	ErrRequestTooLarge:      http.StatusRequestEntityTooLarge,
+	ErrUnavailable:          http.StatusServiceUnavailable,
+	ErrUserFrozen:           http.StatusForbidden,
+	ErrQuotaExceeded:        http.StatusTooManyRequests,
+	ErrIdempotencyKeyReused: http.StatusUnprocessableEntity,
 }
 
 func (t ErrorType) HTTPStatus() int {
@@ -17,6 +17,11 @@ func (e *AppError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Type, e.Message)
 }
 
+// Unwrap 暴露原始错误，使 errors.Is / errors.As 可以穿透 AppError
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
 // 工厂方法：快速创建错误
 func New(t ErrorType, msg string) *AppError {
 	return &AppError{Type: t, Message: msg}
@@ -1,5 +1,7 @@
 package errs
 
+import "errors"
+
 // ProblemDetails 符合 RFC 7807 的 JSON 结构
 type ProblemDetails struct {
 	Type     string                 `json:"type"`               // 错误类型的 URI 标识
@@ -9,3 +11,26 @@ type ProblemDetails struct {
 	Instance string                 `json:"instance,omitempty"` // 请求路径
 	Details  map[string]interface{} `json:"details,omitempty"`  // 扩展字段 (Google AIP 风格)
 }
+
+// From 将任意 error 转换为 AppError
+// 未知错误 (如 panic 或第三方库错误) 统一包装为 Internal Server Error
+func From(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	// 实际生产中这里应该打印堆栈日志
+	return Wrap(ErrInternalServer, "something went wrong", err)
+}
+
+// NewProblem 根据 AppError 构造 RFC 7807 响应体
+func NewProblem(e *AppError, instance string) ProblemDetails {
+	return ProblemDetails{
+		Type:     "https://example.com/probs/" + string(e.Type), // 示例 URI
+		Title:    string(e.Type),
+		Status:   e.Type.HTTPStatus(),
+		Detail:   e.Message,
+		Instance: instance,
+		Details:  e.Details,
+	}
+}
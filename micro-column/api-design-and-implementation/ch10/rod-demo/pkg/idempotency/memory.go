@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore 基于内存的 Store，适用于单元测试和单实例部署
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	record   Record
+	expireAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.getLocked(key); ok {
+		rec := e.record
+		return &rec, false, nil
+	}
+
+	s.records[key] = memoryEntry{
+		record:   Record{State: StateProcessing, Fingerprint: fingerprint},
+		expireAt: s.now().Add(ttl),
+	}
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.getLocked(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	rec := e.record
+	return &rec, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = memoryEntry{record: *rec, expireAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// getLocked 读取未过期的记录，顺带惰性清理过期记录
+func (s *MemoryStore) getLocked(key string) (memoryEntry, bool) {
+	e, ok := s.records[key]
+	if !ok {
+		return e, false
+	}
+	if !s.now().Before(e.expireAt) {
+		delete(s.records, key)
+		return e, false
+	}
+	return e, true
+}
@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix Redis 中幂等记录的键前缀
+const KeyPrefix = "idemp:"
+
+// RedisStore 基于 Redis 的 Store，适用于多实例部署
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func (s *RedisStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	b, err := json.Marshal(Record{State: StateProcessing, Fingerprint: fingerprint})
+	if err != nil {
+		return nil, false, err
+	}
+
+	// SETNX: Set if Not Exists，保证只有一个请求能抢到占位
+	// 极端情况下 SETNX 失败后记录恰好过期或被释放，重试一次即可
+	for attempt := 0; attempt < 2; attempt++ {
+		ok, err := s.rdb.SetNX(ctx, KeyPrefix+key, b, ttl).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return nil, true, nil
+		}
+
+		rec, err := s.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return rec, false, err
+	}
+	return nil, false, errors.New("idempotency: failed to acquire key")
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (*Record, error) {
+	val, err := s.rdb.Get(ctx, KeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rec Record
+	if err := json.Unmarshal(val, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, KeyPrefix+key, b, ttl).Err()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, KeyPrefix+key).Err()
+}
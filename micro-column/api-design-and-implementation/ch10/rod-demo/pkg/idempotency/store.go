@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// 记录状态
+const (
+	StateProcessing = "PROCESSING" // 第一个请求正在处理中
+	StateCompleted  = "COMPLETED"  // 已处理完毕，响应已缓存
+)
+
+// ErrNotFound 记录不存在 (已过期或被释放)
+var ErrNotFound = errors.New("idempotency: record not found")
+
+// Response 缓存的响应
+type Response struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    []byte              `json:"body"`
+}
+
+// Record 是一个幂等键对应的记录
+type Record struct {
+	State       string    `json:"state"`
+	Fingerprint string    `json:"fingerprint"` // 请求指纹，用于识别"同一个 Key 换了请求内容"
+	Response    *Response `json:"response,omitempty"`
+}
+
+// Store 抽象幂等记录的存储，便于在 Redis 与内存 (单测/单机) 之间切换
+type Store interface {
+	// Begin 原子地占位 (SETNX 语义)
+	// 占位成功返回 acquired=true；已存在时返回已有记录，acquired=false
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (existing *Record, acquired bool, err error)
+	// Get 查询记录，不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) (*Record, error)
+	// Complete 保存最终响应，将状态置为 COMPLETED
+	Complete(ctx context.Context, key string, rec *Record, ttl time.Duration) error
+	// Release 删除记录，允许客户端用同一个 Key 重试 (用于 5xx 等可重试失败)
+	Release(ctx context.Context, key string) error
+}
+
+// Fingerprint 计算请求指纹：method + path + 调用方 + 请求体
+// 同一个幂等键只能对应同一个请求，否则说明客户端错误地复用了 Key
+func Fingerprint(method, path, principal string, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{method, path, principal} {
+		h.Write([]byte(part))
+		h.Write([]byte{0}) // 分隔符，避免 "a"+"bc" 与 "ab"+"c" 碰撞
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,98 @@
+package limiter
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// concurrencyKeyTTL 并发计数器的兜底过期时间
+// 进程崩溃时来不及 DECR，计数器最迟在该时间后自动清零
+const concurrencyKeyTTL = 10 * time.Minute
+
+// ConcurrencyLimiter 限制同一个 key 同时在途的请求数
+// 与速率限制不同，它关心的是"正在占用多少资源"，适合 AI 推理这类耗时且昂贵的接口
+type ConcurrencyLimiter struct {
+	rdb *redis.Client
+
+	// Redis 不可用时降级为进程内计数
+	mu    sync.Mutex
+	local map[string]int
+
+	// 与 Limiter 相同的降级策略：降级期间每隔 redisProbeInterval 才探测一次 Redis，
+	// 其余请求直接使用本地计数，不再逐个等待 Redis 超时
+	degraded atomic.Bool
+	probeAt  atomic.Int64
+}
+
+func NewConcurrencyLimiter(rdb *redis.Client) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		rdb:   rdb,
+		local: make(map[string]int),
+	}
+}
+
+// Acquire 尝试占用一个并发名额
+// 成功时返回 release，调用方必须在请求结束后调用它归还名额
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, key string, max int) (release func(), ok bool) {
+	redisKey := "concurrency:" + key
+
+	if l.degraded.Load() && time.Now().UnixNano() < l.probeAt.Load() {
+		return l.acquireLocal(key, max)
+	}
+
+	n, err := l.rdb.Incr(ctx, redisKey).Result()
+	if err != nil {
+		l.probeAt.Store(time.Now().Add(redisProbeInterval).UnixNano())
+		// 只在状态切换时打印日志，避免 Redis 故障期间日志刷屏
+		if !l.degraded.Swap(true) {
+			log.Printf("concurrency limiter: redis unavailable, falling back to local counter: %v", err)
+		}
+		return l.acquireLocal(key, max)
+	}
+	if l.degraded.Swap(false) {
+		log.Printf("concurrency limiter: redis recovered")
+	}
+	l.rdb.Expire(ctx, redisKey, concurrencyKeyTTL)
+
+	decr := func() {
+		// 请求可能已被取消，归还名额不能依赖请求的 ctx
+		l.rdb.Decr(context.WithoutCancel(ctx), redisKey)
+	}
+	if n > int64(max) {
+		decr()
+		return nil, false
+	}
+	return decr, true
+}
+
+// Degraded 返回当前是否处于降级 (本地计数) 状态
+func (l *ConcurrencyLimiter) Degraded() bool {
+	return l.degraded.Load()
+}
+
+func (l *ConcurrencyLimiter) acquireLocal(key string, max int) (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.local[key] >= max {
+		return nil, false
+	}
+	l.local[key]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.local[key]--
+			if l.local[key] <= 0 {
+				delete(l.local, key)
+			}
+		})
+	}, true
+}
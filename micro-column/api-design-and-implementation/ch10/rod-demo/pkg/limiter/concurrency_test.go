@@ -0,0 +1,84 @@
+package limiter
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// countingHook 统计实际发往 Redis 的命令数
+type countingHook struct{ calls atomic.Int32 }
+
+func (h *countingHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *countingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.calls.Add(1)
+		return next(ctx, cmd)
+	}
+}
+
+func (h *countingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// unavailableRedis 返回一个连不上的 Redis 客户端
+func unavailableRedis(t *testing.T) (*redis.Client, *countingHook) {
+	t.Helper()
+	// 占用一个端口后立即关闭，保证连接会被拒绝
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { rdb.Close() })
+	hook := &countingHook{}
+	rdb.AddHook(hook)
+	return rdb, hook
+}
+
+func TestConcurrencyLimiterFailsOverWithoutWaitingOnRedis(t *testing.T) {
+	rdb, hook := unavailableRedis(t)
+	l := NewConcurrencyLimiter(rdb)
+	ctx := context.Background()
+
+	release, ok := l.Acquire(ctx, "user-1", 2)
+	if !ok {
+		t.Fatal("first acquire should fall back to the local counter")
+	}
+	if !l.Degraded() {
+		t.Fatal("limiter should be degraded after a redis error")
+	}
+	if got := hook.calls.Load(); got != 1 {
+		t.Fatalf("redis calls = %d, want 1", got)
+	}
+
+	// 降级期间不再访问 Redis，本地计数仍然生效
+	if _, ok := l.Acquire(ctx, "user-1", 2); !ok {
+		t.Fatal("second acquire should succeed")
+	}
+	if _, ok := l.Acquire(ctx, "user-1", 2); ok {
+		t.Fatal("third acquire should exceed the local limit")
+	}
+	release()
+	if _, ok := l.Acquire(ctx, "user-1", 2); !ok {
+		t.Fatal("acquire after release should succeed")
+	}
+	if got := hook.calls.Load(); got != 1 {
+		t.Fatalf("redis calls while degraded = %d, want 1", got)
+	}
+
+	// 到达探测时间后重新尝试 Redis
+	l.probeAt.Store(time.Now().Add(-time.Second).UnixNano())
+	l.Acquire(ctx, "user-2", 2)
+	if got := hook.calls.Load(); got != 2 {
+		t.Fatalf("redis calls after probe interval = %d, want 2", got)
+	}
+}
@@ -0,0 +1,84 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis_rate/v10"
+)
+
+// localGCRA 是进程内的 GCRA (Generic Cell Rate Algorithm) 限流器
+// 算法与 redis_rate 的 Lua 脚本一致，用于 Redis 不可用时降级
+// 注意：降级期间每个实例独立计数，集群整体的实际上限约为 单实例上限 × 实例数
+type localGCRA struct {
+	mu   sync.Mutex
+	tats map[string]time.Time // 每个 key 的理论到达时间 (Theoretical Arrival Time)
+	now  func() time.Time
+}
+
+func newLocalGCRA() *localGCRA {
+	return &localGCRA{
+		tats: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+// maxLocalKeys 超过该数量时清理已恢复初始状态的 key，防止内存无限增长
+const maxLocalKeys = 10000
+
+func (g *localGCRA) Allow(key string, limit LimitDefinition) *redis_rate.Result {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	res := &redis_rate.Result{
+		Limit: redis_rate.Limit{Rate: limit.Rate, Period: limit.Period, Burst: limit.Burst},
+	}
+	// 无效的规则 (Rate 或 Period 不为正) 一律拒绝，同时避免下面除以零
+	if limit.Validate() != nil {
+		res.Allowed = 0
+		res.Remaining = 0
+		res.RetryAfter = limit.Period
+		res.ResetAfter = limit.Period
+		return res
+	}
+
+	now := g.now()
+	emission := limit.Period / time.Duration(limit.Rate)
+	burstOffset := emission * time.Duration(limit.Burst)
+
+	tat, ok := g.tats[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(emission)
+	allowAt := newTat.Add(-burstOffset)
+	diff := now.Sub(allowAt)
+
+	if diff < 0 {
+		res.Allowed = 0
+		res.Remaining = 0
+		res.RetryAfter = -diff
+		res.ResetAfter = tat.Sub(now)
+		return res
+	}
+
+	if len(g.tats) >= maxLocalKeys {
+		g.sweepLocked(now)
+	}
+	g.tats[key] = newTat
+
+	res.Allowed = 1
+	res.Remaining = int(diff / emission)
+	res.RetryAfter = -1
+	res.ResetAfter = newTat.Sub(now)
+	return res
+}
+
+func (g *localGCRA) sweepLocked(now time.Time) {
+	for k, tat := range g.tats {
+		if !tat.After(now) {
+			delete(g.tats, k)
+		}
+	}
+}
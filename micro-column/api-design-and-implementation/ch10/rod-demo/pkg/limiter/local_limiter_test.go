@@ -0,0 +1,42 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalGCRADeniesInvalidLimit(t *testing.T) {
+	g := newLocalGCRA()
+	for _, limit := range []LimitDefinition{
+		{Rate: 0, Period: time.Minute, Burst: 10},
+		{Rate: -1, Period: time.Minute, Burst: 10},
+		{Rate: 10, Period: 0, Burst: 10},
+	} {
+		// 无效的规则不能 panic (除以零)，也不能放行
+		if res := g.Allow("k", limit); res.Allowed != 0 {
+			t.Fatalf("Allow(%+v).Allowed = %d, want 0", limit, res.Allowed)
+		}
+	}
+}
+
+func TestPlanValidate(t *testing.T) {
+	valid := LimitDefinition{Rate: 10, Period: time.Minute, Burst: 10}
+	tests := []struct {
+		name    string
+		plan    Plan
+		wantErr bool
+	}{
+		{name: "valid", plan: Plan{Name: "free", Default: valid, Routes: map[string]LimitDefinition{"/api/v1/users": valid}, Concurrency: map[string]int{"/api/v1/images": 1}}},
+		{name: "zero default rate", plan: Plan{Name: "free", Default: LimitDefinition{Period: time.Minute, Burst: 10}}, wantErr: true},
+		{name: "zero route rate", plan: Plan{Name: "free", Default: valid, Routes: map[string]LimitDefinition{"/api/v1/users": {Period: time.Minute}}}, wantErr: true},
+		{name: "zero period", plan: Plan{Name: "free", Default: LimitDefinition{Rate: 10, Burst: 10}}, wantErr: true},
+		{name: "zero concurrency", plan: Plan{Name: "free", Default: valid, Concurrency: map[string]int{"/api/v1/images": 0}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.plan.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
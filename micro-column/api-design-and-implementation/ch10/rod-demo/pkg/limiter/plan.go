@@ -0,0 +1,100 @@
+package limiter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Plan 是一个具名的配额套餐 (如 free / pro)
+//
+// Routes 与 Concurrency 的 key 是路由选择器，支持两种写法：
+//   - "/api/v1/users"            路由组前缀，匹配该前缀下的所有方法
+//   - "POST /api/v1/users"       指定 HTTP 方法
+//
+// 匹配规则：前缀最长者优先；前缀相同时，指定了方法的规则优先；都不匹配时使用 Default
+type Plan struct {
+	Name    string
+	Default LimitDefinition
+	Routes  map[string]LimitDefinition
+	// Concurrency 限制同一调用方同时在途的请求数，用于 /images/generate 这类昂贵接口
+	Concurrency map[string]int
+}
+
+// Validate 检查套餐中的每条规则，Rate 与 Period 必须为正数
+// 套餐在启动时加载，配置错误应当立即暴露，而不是等到请求命中时才出问题
+func (p Plan) Validate() error {
+	if err := p.Default.Validate(); err != nil {
+		return fmt.Errorf("plan %s: default: %w", p.Name, err)
+	}
+	for selector, limit := range p.Routes {
+		if err := limit.Validate(); err != nil {
+			return fmt.Errorf("plan %s: route %q: %w", p.Name, selector, err)
+		}
+	}
+	for selector, max := range p.Concurrency {
+		if max <= 0 {
+			return fmt.Errorf("plan %s: concurrency %q: max must be positive, got %d", p.Name, selector, max)
+		}
+	}
+	return nil
+}
+
+// Validate 检查限流规则是否有效
+func (l LimitDefinition) Validate() error {
+	if l.Rate <= 0 {
+		return fmt.Errorf("rate must be positive, got %d", l.Rate)
+	}
+	if l.Period <= 0 {
+		return fmt.Errorf("period must be positive, got %s", l.Period)
+	}
+	return nil
+}
+
+// Rule 是对某个请求生效的限流规则
+type Rule struct {
+	Name  string // 规则名，同时用作限流 key 的一部分与 RateLimit-Policy 的策略名
+	Limit LimitDefinition
+}
+
+// RuleFor 返回请求 (method + 路由模板) 命中的限流规则
+func (p Plan) RuleFor(method, path string) Rule {
+	if selector, ok := match(p.Routes, method, path); ok {
+		return Rule{Name: p.Name + ":" + selector, Limit: p.Routes[selector]}
+	}
+	return Rule{Name: p.Name + ":default", Limit: p.Default}
+}
+
+// ConcurrencyFor 返回请求命中的并发上限，未配置时 ok=false
+func (p Plan) ConcurrencyFor(method, path string) (selector string, max int, ok bool) {
+	selector, ok = match(p.Concurrency, method, path)
+	if !ok {
+		return "", 0, false
+	}
+	return p.Name + ":" + selector, p.Concurrency[selector], true
+}
+
+// match 在规则表中找到最具体的选择器
+func match[V any](rules map[string]V, method, path string) (string, bool) {
+	best, bestScore := "", -1
+	for selector := range rules {
+		m, prefix, hasMethod := strings.Cut(selector, " ")
+		if !hasMethod {
+			m, prefix = "", selector
+		}
+		if m != "" && !strings.EqualFold(m, method) {
+			continue
+		}
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		// 前缀越长越具体；指定方法的规则额外加一分
+		score := len(prefix) * 2
+		if m != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = selector, score
+		}
+	}
+	return best, bestScore >= 0
+}
@@ -2,6 +2,8 @@ package limiter
 
 import (
 	"context"
+	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis_rate/v10"
@@ -17,17 +19,29 @@ type LimitDefinition struct {
 
 type Limiter struct {
 	core *redis_rate.Limiter
+
+	// local 在 Redis 不可用时接管，保证限流不会完全失效
+	local    *localGCRA
+	degraded atomic.Bool
+	// probeAt 降级期间下一次尝试 Redis 的时间 (UnixNano)
+	// 避免每个请求都等待 Redis 连接超时
+	probeAt atomic.Int64
 }
 
+// redisProbeInterval 降级后每隔多久重新探测一次 Redis
+const redisProbeInterval = 5 * time.Second
+
 func NewLimiter(rdb *redis.Client) *Limiter {
 	return &Limiter{
-		core: redis_rate.NewLimiter(rdb),
+		core:  redis_rate.NewLimiter(rdb),
+		local: newLocalGCRA(),
 	}
 }
 
 // Allow 检查是否允许通过
 // key: 限流的标识（如 IP、UserID）
 // limit: 限流规则
+// Redis 出错时降级为进程内 GCRA 限流，而不是直接放行 (Fail Open) 或拒绝 (Fail Closed)
 func (l *Limiter) Allow(ctx context.Context, key string, limit LimitDefinition) (*redis_rate.Result, error) {
 	// redis_rate 的 Limit 结构体转换
 	rrLimit := redis_rate.Limit{
@@ -36,5 +50,27 @@ func (l *Limiter) Allow(ctx context.Context, key string, limit LimitDefinition)
 		Burst:  limit.Burst,
 	}
 
-	return l.core.Allow(ctx, key, rrLimit)
+	if l.degraded.Load() && time.Now().UnixNano() < l.probeAt.Load() {
+		return l.local.Allow(key, limit), nil
+	}
+
+	res, err := l.core.Allow(ctx, key, rrLimit)
+	if err != nil {
+		l.probeAt.Store(time.Now().Add(redisProbeInterval).UnixNano())
+		// 只在状态切换时打印日志，避免 Redis 故障期间日志刷屏
+		if !l.degraded.Swap(true) {
+			log.Printf("rate limiter: redis unavailable, falling back to local limiter: %v", err)
+		}
+		return l.local.Allow(key, limit), nil
+	}
+
+	if l.degraded.Swap(false) {
+		log.Printf("rate limiter: redis recovered")
+	}
+	return res, nil
+}
+
+// Degraded 返回当前是否处于降级 (本地限流) 状态
+func (l *Limiter) Degraded() bool {
+	return l.degraded.Load()
 }
@@ -0,0 +1,38 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event 是写入发件箱的领域事件
+// 字段命名参考 CloudEvents：type 形如 "order.cancelled"，subject 为资源 ID
+type Event struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Subject string          `json:"subject"`
+	Time    time.Time       `json:"time"`
+	Data    json.RawMessage `json:"data"`
+
+	// Owner 产生该事件的调用方 ("{kind}:{id}")，分发器只把事件投递给同一调用方的订阅
+	// 为空的事件不属于任何调用方，不会投递给任何订阅
+	Owner string `json:"owner,omitempty"`
+}
+
+// NewEvent 创建一个事件，data 会被序列化为 JSON 快照
+// 必须在写操作完成时就序列化，而不是等到投递时再读取资源，否则订阅方看到的可能是之后的状态
+func NewEvent(typ, subject string, data any) (Event, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:      uuid.New().String(),
+		Type:    typ,
+		Subject: subject,
+		Time:    time.Now().UTC(),
+		Data:    b,
+	}, nil
+}
@@ -0,0 +1,22 @@
+package outbox
+
+import "github.com/gin-gonic/gin"
+
+// stagedKey 是暂存事件在 gin.Context 中的 Key
+const stagedKey = "outbox.staged"
+
+// Record 在当前请求中暂存一个事件
+// 事件不会立即写入 Store，而是在请求成功结束后由中间件统一提交；
+// 请求失败 (如批量操作回滚) 时暂存的事件随之丢弃
+func Record(c *gin.Context, evt Event) {
+	staged, _ := c.Get(stagedKey)
+	events, _ := staged.([]Event)
+	c.Set(stagedKey, append(events, evt))
+}
+
+// Staged 返回当前请求暂存的事件
+func Staged(c *gin.Context) []Event {
+	staged, _ := c.Get(stagedKey)
+	events, _ := staged.([]Event)
+	return events
+}
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+)
+
+// Store 是事务性发件箱 (Transactional Outbox) 的存储
+//
+// 生产环境中，outbox 表与业务表位于同一个数据库，事件与业务数据在同一个事务中写入，
+// 由后台分发器异步读取并投递，从而保证"数据改了，事件一定发出"
+type Store interface {
+	// Append 写入事件
+	Append(ctx context.Context, events ...Event) error
+	// Pending 按写入顺序返回尚未分发的事件
+	Pending(ctx context.Context, limit int) ([]Event, error)
+	// MarkDispatched 标记事件已分发，之后不再出现在 Pending 中
+	MarkDispatched(ctx context.Context, ids ...string) error
+}
+
+// MemoryStore 基于内存的发件箱，仅适用于单实例演示
+type MemoryStore struct {
+	mu      sync.Mutex
+	pending []Event
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Append(ctx context.Context, events ...Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, events...)
+	return nil
+}
+
+func (s *MemoryStore) Pending(ctx context.Context, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit <= 0 || limit > len(s.pending) {
+		limit = len(s.pending)
+	}
+	out := make([]Event, limit)
+	copy(out, s.pending)
+	return out, nil
+}
+
+func (s *MemoryStore) MarkDispatched(ctx context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	done := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		done[id] = struct{}{}
+	}
+	kept := s.pending[:0]
+	for _, evt := range s.pending {
+		if _, ok := done[evt.ID]; !ok {
+			kept = append(kept, evt)
+		}
+	}
+	s.pending = kept
+	return nil
+}
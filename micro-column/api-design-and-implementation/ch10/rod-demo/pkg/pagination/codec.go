@@ -0,0 +1,223 @@
+package pagination
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Codec 负责 PageToken 与字符串之间的互转，并保证完整性 (防篡改)
+// 过期与查询参数校验由 Manager 统一完成，Codec 只关心密码学部分
+type Codec interface {
+	Encode(t PageToken) (string, error)
+	Decode(s string) (PageToken, error)
+}
+
+// Keyring 保存多把密钥，按 Key ID 索引，用于密钥轮换：
+// - 新 Token 始终使用 ActiveID 对应的密钥签发
+// - 旧 Token 只要其 Key ID 仍在 Keys 中，就可以继续校验通过
+// 轮换流程：加入新密钥 -> 切换 ActiveID -> 等旧 Token 全部过期后移除旧密钥
+type Keyring struct {
+	ActiveID string
+	Keys     map[string][]byte
+}
+
+// MinKeyBytes 密钥的最小长度，HMAC-SHA256 的密钥短于摘要长度时强度不足
+const MinKeyBytes = 32
+
+// NewKeyring 创建密钥环，ActiveID 必须存在于 keys 中，每把密钥至少 MinKeyBytes 字节
+func NewKeyring(activeID string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("pagination: active key %q not found in keyring", activeID)
+	}
+	for kid, key := range keys {
+		// Key ID 会拼进 Token 作为分隔段，不能包含分隔符
+		if kid == "" || strings.Contains(kid, ".") {
+			return nil, fmt.Errorf("pagination: invalid key id %q", kid)
+		}
+		if len(key) < MinKeyBytes {
+			return nil, fmt.Errorf("pagination: key %q is %d bytes, want at least %d", kid, len(key), MinKeyBytes)
+		}
+	}
+	return &Keyring{ActiveID: activeID, Keys: keys}, nil
+}
+
+// ParseKeyring 从配置字符串解析密钥环，格式为 "kid1:secret1,kid2:secret2"
+// activeID 为空时使用第一把密钥签发新 Token，其余密钥只用于校验旧 Token
+func ParseKeyring(spec, activeID string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	first := ""
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, secret, ok := strings.Cut(entry, ":")
+		if !ok || secret == "" {
+			return nil, fmt.Errorf("pagination: invalid keyring entry for key %q, expected kid:secret", kid)
+		}
+		if _, dup := keys[kid]; dup {
+			return nil, fmt.Errorf("pagination: duplicate key id %q", kid)
+		}
+		keys[kid] = []byte(secret)
+		if first == "" {
+			first = kid
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("pagination: keyring is empty")
+	}
+	if activeID == "" {
+		activeID = first
+	}
+	return NewKeyring(activeID, keys)
+}
+
+// splitToken 拆分 "{kid}.{body}" 结构
+func splitToken(s string) (kid, body string, err error) {
+	kid, body, ok := strings.Cut(s, ".")
+	if !ok || kid == "" || body == "" {
+		return "", "", ErrMalformedToken
+	}
+	return kid, body, nil
+}
+
+// -----------------------------------------------------------------------------
+// HMACCodec：明文 + 签名
+// 格式: {kid}.{base64(payload)}.{base64(hmac_sha256(kid.payload))}
+// 客户端能看到 Token 内容，但无法伪造
+// -----------------------------------------------------------------------------
+
+type HMACCodec struct {
+	keys *Keyring
+}
+
+func NewHMACCodec(keys *Keyring) *HMACCodec {
+	return &HMACCodec{keys: keys}
+}
+
+func (c *HMACCodec) Encode(t PageToken) (string, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	kid := c.keys.ActiveID
+	signed := kid + "." + base64.RawURLEncoding.EncodeToString(b)
+	return signed + "." + c.sign(c.keys.Keys[kid], signed), nil
+}
+
+func (c *HMACCodec) Decode(s string) (PageToken, error) {
+	var t PageToken
+
+	kid, rest, err := splitToken(s)
+	if err != nil {
+		return t, err
+	}
+	payload, sig, ok := strings.Cut(rest, ".")
+	if !ok {
+		return t, ErrMalformedToken
+	}
+	key, ok := c.keys.Keys[kid]
+	if !ok {
+		return t, ErrUnknownKey
+	}
+
+	// 使用常量时间比较，避免时序攻击
+	expected := c.sign(key, kid+"."+payload)
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return t, ErrInvalidSignature
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return t, ErrMalformedToken
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, ErrMalformedToken
+	}
+	return t, nil
+}
+
+func (c *HMACCodec) sign(key []byte, data string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// -----------------------------------------------------------------------------
+// AEADCodec：AES-GCM 加密
+// 格式: {kid}.{base64(nonce + ciphertext)}，Key ID 作为附加认证数据 (AAD)
+// 客户端既无法伪造，也看不到游标内容 (适合游标里带有内部主键等敏感信息的场景)
+// -----------------------------------------------------------------------------
+
+type AEADCodec struct {
+	keys  *Keyring
+	aeads map[string]cipher.AEAD
+}
+
+// NewAEADCodec 创建加密 Codec，每把密钥必须是 32 字节 (AES-256，Keyring 不接受更短的密钥)
+func NewAEADCodec(keys *Keyring) (*AEADCodec, error) {
+	aeads := make(map[string]cipher.AEAD, len(keys.Keys))
+	for kid, key := range keys.Keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("pagination: key %q: %w", kid, err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("pagination: key %q: %w", kid, err)
+		}
+		aeads[kid] = gcm
+	}
+	return &AEADCodec{keys: keys, aeads: aeads}, nil
+}
+
+func (c *AEADCodec) Encode(t PageToken) (string, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	kid := c.keys.ActiveID
+	aead := c.aeads[kid]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, b, []byte(kid))
+	return kid + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (c *AEADCodec) Decode(s string) (PageToken, error) {
+	var t PageToken
+
+	kid, body, err := splitToken(s)
+	if err != nil {
+		return t, err
+	}
+	aead, ok := c.aeads[kid]
+	if !ok {
+		return t, ErrUnknownKey
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return t, ErrMalformedToken
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	b, err := aead.Open(nil, nonce, ciphertext, []byte(kid))
+	if err != nil {
+		// 密文被篡改或密钥不匹配
+		return t, ErrInvalidSignature
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, ErrMalformedToken
+	}
+	return t, nil
+}
@@ -1,41 +1,185 @@
 package pagination
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"rod-demo/pkg/errs"
 )
 
 // PageToken 是我们在 Token 字符串中隐藏的结构
-// 实际生产中，你可以加入 Salt 签名或加密，防止客户端伪造
+// 它由 Codec 签名或加密，客户端无法伪造
 type PageToken struct {
-	Offset string `json:"o"` // 这里的 Offset 不是 SQL offset，而是"偏移的锚点值"(Cursor)
-	// 可以扩展：Time int64 `json:"t"` // 如果按时间排序
+	Offset    string `json:"o"`           // 这里的 Offset 不是 SQL offset，而是"偏移的锚点值"(Cursor)
+	ExpireAt  int64  `json:"e,omitempty"` // 过期时间 (Unix 秒)，防止 Token 被长期保存后重放
+	QueryHash string `json:"q,omitempty"` // 生成 Token 时查询参数的摘要，防止换了 filter 后继续翻页
 }
 
-// Encode 生成 next_page_token
-func Encode(cursor string) string {
-	if cursor == "" {
+// Decode 返回的错误原因，均被包装为 errs.ErrInvalidArgument (400)
+// 调用方可以用 errors.Is 区分具体原因
+var (
+	ErrMalformedToken   = errors.New("page_token is malformed")
+	ErrUnknownKey       = errors.New("page_token was signed with an unknown key")
+	ErrInvalidSignature = errors.New("page_token signature is invalid")
+	ErrTokenExpired     = errors.New("page_token has expired")
+	ErrQueryMismatch    = errors.New("page_token does not match the current query parameters")
+)
+
+// Query 是参与摘要计算的查询参数 (如 filter、order_by、page_size)
+// 按 AIP-158 的要求，除 page_token 外的参数在翻页过程中必须保持一致
+type Query map[string]string
+
+// Hash 计算查询参数的稳定摘要 (与 key 的顺序无关)
+func (q Query) Hash() string {
+	if len(q) == 0 {
 		return ""
 	}
-	t := PageToken{Offset: cursor}
-	b, _ := json.Marshal(t)
-	// 使用 URL 安全的 Base64 编码
-	return base64.URLEncoding.EncodeToString(b)
+	// 按 URL 编码后拼接，值中的 '=' '&' 会被转义，不同的参数组合不会得到相同的输入
+	// url.Values.Encode 按 key 排序
+	v := make(url.Values, len(q))
+	for k, val := range q {
+		v.Set(k, val)
+	}
+	sum := sha256.Sum256([]byte(v.Encode()))
+	// 截取前 16 字节即可，Token 不宜过长
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
 }
 
-// Decode 解析 page_token
-func Decode(tokenStr string) (string, error) {
+// Manager 在 Codec 之上负责过期时间与查询参数的校验
+type Manager struct {
+	codec Codec
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewManager 创建 Token 管理器，ttl <= 0 表示永不过期
+func NewManager(codec Codec, ttl time.Duration) *Manager {
+	return &Manager{codec: codec, ttl: ttl, now: time.Now}
+}
+
+// EncodeQuery 生成绑定了查询参数的 next_page_token
+// 编码失败 (如随机数源不可用) 是服务端问题，包装为 500，绝不能返回空 Token 让客户端误以为没有下一页
+func (m *Manager) EncodeQuery(cursor string, q Query) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	t := PageToken{Offset: cursor, QueryHash: q.Hash()}
+	if m.ttl > 0 {
+		t.ExpireAt = m.now().Add(m.ttl).Unix()
+	}
+	s, err := m.codec.Encode(t)
+	if err != nil {
+		return "", errs.Wrap(errs.ErrInternalServer, "failed to encode page_token", err)
+	}
+	return s, nil
+}
+
+// DecodeQuery 校验并解析 page_token，返回游标
+func (m *Manager) DecodeQuery(tokenStr string, q Query) (string, error) {
 	if tokenStr == "" {
 		return "", nil
 	}
-	b, err := base64.URLEncoding.DecodeString(tokenStr)
+
+	t, err := m.codec.Decode(tokenStr)
 	if err != nil {
-		return "", errors.New("invalid page_token format")
+		return "", invalid(err)
 	}
-	var t PageToken
-	if err := json.Unmarshal(b, &t); err != nil {
-		return "", errors.New("invalid page_token payload")
+	if t.ExpireAt > 0 && m.now().Unix() > t.ExpireAt {
+		return "", invalid(ErrTokenExpired)
+	}
+	if t.QueryHash != q.Hash() {
+		return "", invalid(ErrQueryMismatch)
 	}
 	return t.Offset, nil
 }
+
+// invalid 将解析失败的原因统一包装为 400 INVALID_ARGUMENT
+func invalid(cause error) error {
+	msg := cause.Error()
+	if !isKnownReason(cause) {
+		// 未知错误不向客户端暴露细节
+		msg = ErrMalformedToken.Error()
+	}
+	return errs.Wrap(errs.ErrInvalidArgument, msg, cause).
+		WithDetails(map[string]interface{}{"field": "page_token"})
+}
+
+func isKnownReason(err error) bool {
+	for _, known := range []error{ErrMalformedToken, ErrUnknownKey, ErrInvalidSignature, ErrTokenExpired, ErrQueryMismatch} {
+		if errors.Is(err, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// -----------------------------------------------------------------------------
+// 包级默认 Manager
+// 兼容已有的 Encode / Decode 调用方，通过 SetDefault 替换 Codec 与有效期
+// 需要感知编码失败的调用方使用 EncodeToken
+// -----------------------------------------------------------------------------
+
+// DefaultTTL 默认 Token 有效期
+const DefaultTTL = 24 * time.Hour
+
+var (
+	defaultMu      sync.RWMutex
+	defaultManager = NewManager(NewHMACCodec(randomKeyring()), DefaultTTL)
+)
+
+// SetDefault 替换包级默认 Manager (通常在 main 中根据配置调用一次)
+func SetDefault(m *Manager) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultManager = m
+}
+
+func std() *Manager {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultManager
+}
+
+// Encode 生成 next_page_token
+// 编码失败时返回空串，与下一页不存在无法区分，新代码请使用 EncodeToken
+func Encode(cursor string) string {
+	s, _ := std().EncodeQuery(cursor, nil)
+	return s
+}
+
+// Decode 解析 page_token
+func Decode(tokenStr string) (string, error) {
+	return std().DecodeQuery(tokenStr, nil)
+}
+
+// EncodeQuery 使用默认 Manager 生成绑定查询参数的 Token
+// 编码失败时返回空串，新代码请使用 EncodeToken
+func EncodeQuery(cursor string, q Query) string {
+	s, _ := std().EncodeQuery(cursor, q)
+	return s
+}
+
+// EncodeToken 使用默认 Manager 生成绑定查询参数的 Token，编码失败时返回 500 错误
+func EncodeToken(cursor string, q Query) (string, error) {
+	return std().EncodeQuery(cursor, q)
+}
+
+// DecodeQuery 使用默认 Manager 校验并解析绑定查询参数的 Token
+func DecodeQuery(tokenStr string, q Query) (string, error) {
+	return std().DecodeQuery(tokenStr, q)
+}
+
+// randomKeyring 生成进程内随机密钥
+// 未显式配置时使用：Token 仍然防伪，但进程重启或多实例部署时会失效
+func randomKeyring() *Keyring {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("pagination: failed to generate key: " + err.Error())
+	}
+	return &Keyring{ActiveID: "local", Keys: map[string][]byte{"local": key}}
+}
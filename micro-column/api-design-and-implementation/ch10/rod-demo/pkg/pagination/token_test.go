@@ -0,0 +1,202 @@
+package pagination
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"rod-demo/pkg/errs"
+)
+
+func testKeyring(t *testing.T, activeID string, kids ...string) *Keyring {
+	t.Helper()
+	keys := make(map[string][]byte, len(kids))
+	for _, kid := range kids {
+		// AES-256 要求 32 字节密钥
+		keys[kid] = []byte(strings.Repeat(kid, 32)[:32])
+	}
+	k, err := NewKeyring(activeID, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func codecs(t *testing.T, keys *Keyring) map[string]Codec {
+	t.Helper()
+	aead, err := NewAEADCodec(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Codec{"hmac": NewHMACCodec(keys), "aead": aead}
+}
+
+// tamper 修改 Token 中间的一个字符
+func tamper(s string) string {
+	i := len(s) / 2
+	c := byte('A')
+	if s[i] == 'A' {
+		c = 'B'
+	}
+	return s[:i] + string(c) + s[i+1:]
+}
+
+func TestManagerRoundTrip(t *testing.T) {
+	q := Query{"filter": "age>18", "page_size": "10"}
+	for name, codec := range codecs(t, testKeyring(t, "v1", "v1")) {
+		t.Run(name, func(t *testing.T) {
+			m := NewManager(codec, time.Hour)
+			token, err := m.EncodeQuery("cursor-42", q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 查询参数的顺序不影响摘要
+			got, err := m.DecodeQuery(token, Query{"page_size": "10", "filter": "age>18"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "cursor-42" {
+				t.Fatalf("cursor = %q, want cursor-42", got)
+			}
+		})
+	}
+}
+
+func TestManagerRejects(t *testing.T) {
+	q := Query{"filter": "age>18"}
+	now := time.Unix(1_700_000_000, 0)
+
+	for name, codec := range codecs(t, testKeyring(t, "v1", "v1")) {
+		m := NewManager(codec, time.Hour)
+		m.now = func() time.Time { return now }
+		token, err := m.EncodeQuery("cursor-42", q)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name  string
+			token string
+			query Query
+			at    time.Time
+			want  error
+		}{
+			{"tampered", tamper(token), q, now, ErrInvalidSignature},
+			{"unknown key", "v9" + token[2:], q, now, ErrUnknownKey},
+			{"malformed", "garbage", q, now, ErrMalformedToken},
+			{"expired", token, q, now.Add(2 * time.Hour), ErrTokenExpired},
+			{"query mismatch", token, Query{"filter": "age>30"}, now, ErrQueryMismatch},
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				m.now = func() time.Time { return tt.at }
+				_, err := m.DecodeQuery(tt.token, tt.query)
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("err = %v, want %v", err, tt.want)
+				}
+				if appErr := errs.From(err); appErr.Type != errs.ErrInvalidArgument {
+					t.Fatalf("type = %s, want %s", appErr.Type, errs.ErrInvalidArgument)
+				}
+			})
+		}
+	}
+}
+
+func TestManagerDecodeAfterRotation(t *testing.T) {
+	q := Query{"filter": "age>18"}
+	before := testKeyring(t, "v1", "v1")
+	// 轮换：加入 v2 并切换为签发密钥，v1 保留用于校验
+	after := testKeyring(t, "v2", "v1", "v2")
+
+	for name := range codecs(t, before) {
+		t.Run(name, func(t *testing.T) {
+			oldM := NewManager(codecs(t, before)[name], time.Hour)
+			newM := NewManager(codecs(t, after)[name], time.Hour)
+
+			oldToken, err := oldM.EncodeQuery("cursor-1", q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := newM.DecodeQuery(oldToken, q); err != nil || got != "cursor-1" {
+				t.Fatalf("decode old token after rotation = %q, %v", got, err)
+			}
+
+			newToken, err := newM.EncodeQuery("cursor-2", q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(newToken, "v2.") {
+				t.Fatalf("new token %q not signed with the active key", newToken)
+			}
+			// 旧实例不认识 v2
+			if _, err := oldM.DecodeQuery(newToken, q); !errors.Is(err, ErrUnknownKey) {
+				t.Fatalf("err = %v, want %v", err, ErrUnknownKey)
+			}
+		})
+	}
+}
+
+type failingCodec struct{}
+
+func (failingCodec) Encode(PageToken) (string, error) { return "", errors.New("entropy exhausted") }
+func (failingCodec) Decode(string) (PageToken, error) { return PageToken{}, ErrMalformedToken }
+
+func TestManagerEncodeError(t *testing.T) {
+	m := NewManager(failingCodec{}, time.Hour)
+	token, err := m.EncodeQuery("cursor-1", nil)
+	if err == nil || token != "" {
+		t.Fatalf("EncodeQuery = %q, %v, want error", token, err)
+	}
+	if appErr := errs.From(err); appErr.Type != errs.ErrInternalServer {
+		t.Fatalf("type = %s, want %s", appErr.Type, errs.ErrInternalServer)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	secret := strings.Repeat("s", MinKeyBytes)
+	tests := []struct {
+		name, spec, active string
+		wantActive         string
+		wantErr            bool
+	}{
+		{name: "first key is active", spec: "v2:" + secret + ",v1:" + secret, wantActive: "v2"},
+		{name: "explicit active key", spec: "v2:" + secret + ", v1:" + secret, active: "v1", wantActive: "v1"},
+		{name: "colon in secret", spec: "v1:sec:" + secret, wantActive: "v1"},
+		{name: "unknown active key", spec: "v1:" + secret, active: "v2", wantErr: true},
+		{name: "missing secret", spec: "v1", wantErr: true},
+		{name: "duplicate key id", spec: "v1:" + secret + ",v1:" + secret, wantErr: true},
+		{name: "dot in key id", spec: "v.1:" + secret, wantErr: true},
+		{name: "short secret", spec: "v1:" + secret[1:], wantErr: true},
+		{name: "empty", spec: " , ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := ParseKeyring(tt.spec, tt.active)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if k.ActiveID != tt.wantActive {
+				t.Fatalf("active = %q, want %q", k.ActiveID, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestQueryHashUnambiguous(t *testing.T) {
+	// 值中带有分隔符时，不同的参数组合不能得到相同的摘要
+	a := Query{"filter": "a&order_by=b"}
+	b := Query{"filter": "a", "order_by": "b"}
+	if a.Hash() == b.Hash() {
+		t.Fatalf("Hash(%v) == Hash(%v)", a, b)
+	}
+	if got, want := (Query{"b": "2", "a": "1"}).Hash(), (Query{"a": "1", "b": "2"}).Hash(); got != want {
+		t.Fatalf("Hash depends on key order: %q != %q", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderLastEventID 是 SSE 规范中客户端重连时携带的 Header
+const HeaderLastEventID = "Last-Event-ID"
+
+// HeaderStreamID 告诉客户端当前流的 ID，便于通过 GET 接口显式续传
+const HeaderStreamID = "X-Stream-ID"
+
+// LastEventID 读取客户端上报的最后事件 ID
+// 原生 EventSource 使用 Header；部分客户端无法设置 Header，兼容 ?last_event_id= 查询参数
+func LastEventID(c *gin.Context) string {
+	if id := c.GetHeader(HeaderLastEventID); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
+
+// Serve 将流推送给当前 HTTP 连接，直到流结束或客户端断开
+// 1. 先重放 Last-Event-ID 之后缓冲区内的事件
+// 2. 再持续推送新事件；一次唤醒内的多条事件合并为一次 Flush
+// 3. 空闲时按 HeartbeatInterval 发送注释心跳
+func (h *Hub) Serve(c *gin.Context, s *Stream) {
+	var lastSeq uint64
+	if id, seq, ok := parseEventID(LastEventID(c)); ok && id == s.ID {
+		lastSeq = seq
+	}
+
+	// 设置 SSE 专用 Header
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// 告诉 Nginx 不要在缓冲区等待，立即下发
+	c.Header("X-Accel-Buffering", "no")
+	c.Header(HeaderStreamID, s.ID)
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	notify, unsubscribe := s.subscribe()
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(h.opts.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		events, done := s.since(lastSeq)
+		for _, e := range events {
+			if err := sse.Encode(c.Writer, sse.Event{
+				Id:    s.EventID(e),
+				Event: e.Name,
+				Data:  e.Data,
+			}); err != nil {
+				return
+			}
+			lastSeq = e.Seq
+		}
+		if len(events) > 0 {
+			c.Writer.Flush()
+		}
+		if done {
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			// 客户端断开：只退订，不取消生产者，留给重连续传
+			return
+		case <-notify:
+		case <-heartbeat.C:
+			// 以冒号开头的行是 SSE 注释，客户端会忽略，但能让连接保持活跃
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
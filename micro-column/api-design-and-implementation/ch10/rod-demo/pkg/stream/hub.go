@@ -0,0 +1,280 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event 是流中的一条消息
+// Seq 在单个流内单调递增，对外暴露的 SSE id 形如 "{streamID}:{seq}"
+type Event struct {
+	Seq  uint64
+	Name string      // SSE event 字段，如 "message" / "progress" / "done"
+	Data interface{} // SSE data 字段，非字符串会被序列化为 JSON
+}
+
+// Emitter 供生产者推送事件
+// 当流被取消 (所有客户端断开且超过 IdleTimeout) 时返回 ctx.Err()，生产者应立即退出
+type Emitter func(name string, data interface{}) error
+
+// Producer 是真正干活的函数 (如 LLM 逐字生成、LRO 进度监听)
+// 它运行在独立的 goroutine 中，与 HTTP 连接的生命周期解耦，这是断线续传的前提
+type Producer func(ctx context.Context, emit Emitter) error
+
+// Options 定义流的缓冲与保活策略
+type Options struct {
+	BufferSize        int           // 每个流保留的最近事件数 (重放缓冲区大小)
+	HeartbeatInterval time.Duration // 注释心跳间隔，防止代理/LB 因空闲断开连接
+	IdleTimeout       time.Duration // 没有任何客户端连接多久后取消生产者
+	Retention         time.Duration // 流结束后保留多久，供迟到的重连重放
+}
+
+// DefaultOptions 默认配置
+var DefaultOptions = Options{
+	BufferSize:        256,
+	HeartbeatInterval: 15 * time.Second,
+	IdleTimeout:       30 * time.Second,
+	Retention:         5 * time.Minute,
+}
+
+// Hub 管理所有活跃的流，按流 ID 索引
+type Hub struct {
+	opts Options
+
+	mu      sync.Mutex
+	streams map[string]*Stream
+}
+
+// NewHub 创建流管理器，未设置的选项使用 DefaultOptions
+func NewHub(opts Options) *Hub {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultOptions.BufferSize
+	}
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = DefaultOptions.HeartbeatInterval
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultOptions.IdleTimeout
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultOptions.Retention
+	}
+	return &Hub{
+		opts:    opts,
+		streams: make(map[string]*Stream),
+	}
+}
+
+// Open 启动一个新流并在后台运行生产者
+// owner 是流的创建者 (通常为 Principal.ID)，之后只有同一个 owner 才能 Lookup / Resume 这个流；
+// owner 为空表示共享流 (如 Operation 进度)，访问控制完全交给路由上的权限校验
+// 如果同 ID 的流已存在 (例如多个客户端监听同一个 Operation)，直接返回已有的流，不会重复启动生产者；
+// 但因空闲被取消的流不会再产生事件，此时用新流替换它
+func (h *Hub) Open(id, owner string, producer Producer) *Stream {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.streams[id]; ok && !s.isCanceled() {
+		return s
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Stream{
+		ID:     id,
+		hub:    h,
+		owner:  owner,
+		cancel: cancel,
+		subs:   make(map[chan struct{}]struct{}),
+	}
+	h.streams[id] = s
+
+	// 生产者启动后若一直没人连接，同样需要被回收
+	s.idleTimer = time.AfterFunc(h.opts.IdleTimeout, s.abort)
+
+	go func() {
+		err := producer(ctx, func(name string, data interface{}) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			s.publish(name, data)
+			return nil
+		})
+		s.finish(err)
+	}()
+
+	return s
+}
+
+// Lookup 按流 ID 查找流，owner 与创建者不一致时视为不存在，避免泄露他人的流
+func (h *Hub) Lookup(id, owner string) (*Stream, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.streams[id]
+	if !ok || s.owner != owner {
+		return nil, false
+	}
+	return s, true
+}
+
+// Resume 根据客户端上报的 Last-Event-ID 找回对应的流
+// Last-Event-ID 由客户端任意填写，同样必须校验 owner
+func (h *Hub) Resume(lastEventID, owner string) (*Stream, bool) {
+	id, _, ok := parseEventID(lastEventID)
+	if !ok {
+		return nil, false
+	}
+	return h.Lookup(id, owner)
+}
+
+// remove 移除流，仅当索引中仍是同一个流时才删除 (同 ID 的流可能已被 Open 替换)
+func (h *Hub) remove(s *Stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[s.ID] == s {
+		delete(h.streams, s.ID)
+	}
+}
+
+// Stream 是一个可重放的事件流
+// 生产者只管往环形缓冲区里写，从不阻塞在慢客户端上；每个客户端按自己的进度从缓冲区读取
+type Stream struct {
+	ID string
+
+	hub    *Hub
+	owner  string
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	events    []Event // 环形缓冲区，只保留最近 BufferSize 条
+	seq       uint64
+	done      bool
+	canceled  bool // 因无人连接被取消，生产者没有正常结束
+	subs      map[chan struct{}]struct{}
+	idleTimer *time.Timer
+}
+
+// EventID 返回事件对外暴露的 SSE id
+func (s *Stream) EventID(e Event) string {
+	return s.ID + ":" + strconv.FormatUint(e.Seq, 10)
+}
+
+// publish 追加事件并唤醒所有订阅者
+func (s *Stream) publish(name string, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done {
+		return
+	}
+
+	s.seq++
+	s.events = append(s.events, Event{Seq: s.seq, Name: name, Data: data})
+	if len(s.events) > s.hub.opts.BufferSize {
+		s.events = s.events[len(s.events)-s.hub.opts.BufferSize:]
+	}
+	s.notifyLocked()
+}
+
+// abort 由空闲计时器触发，取消生产者
+func (s *Stream) abort() {
+	s.mu.Lock()
+	s.canceled = true
+	s.mu.Unlock()
+	s.cancel()
+}
+
+// isCanceled 返回流是否已因空闲被取消
+func (s *Stream) isCanceled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.canceled
+}
+
+// finish 标记流结束，生产者返回的非取消错误会作为 error 事件下发
+func (s *Stream) finish(err error) {
+	canceled := errors.Is(err, context.Canceled)
+	if err != nil && !canceled {
+		s.publish("error", map[string]string{"message": err.Error()})
+	}
+
+	s.mu.Lock()
+	s.done = true
+	s.canceled = s.canceled || canceled
+	canceled = s.canceled
+	s.idleTimer.Stop()
+	s.notifyLocked()
+	s.mu.Unlock()
+
+	s.cancel()
+
+	// 被取消的流只有半截输出，重放它没有意义，立即移除，后续请求会重新生成
+	if canceled {
+		s.hub.remove(s)
+		return
+	}
+
+	// 保留一段时间，给断线的客户端重放尾部事件的机会
+	time.AfterFunc(s.hub.opts.Retention, func() { s.hub.remove(s) })
+}
+
+// notifyLocked 非阻塞地唤醒订阅者，调用方必须持有 s.mu
+// 通知通道容量为 1，多次写入会自然合并，订阅者醒来后一次性读取所有新事件
+func (s *Stream) notifyLocked() {
+	for ch := range s.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// since 返回 seq 大于 after 的所有缓冲事件，以及流是否已结束
+func (s *Stream) since(after uint64) ([]Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Event
+	for _, e := range s.events {
+		if e.Seq > after {
+			out = append(out, e)
+		}
+	}
+	return out, s.done
+}
+
+// subscribe 注册一个订阅者，并停止空闲回收计时
+func (s *Stream) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.idleTimer.Stop()
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs, ch)
+		// 最后一个客户端离开后开始计时，超时仍无人重连则取消生产者
+		if len(s.subs) == 0 && !s.done {
+			s.idleTimer.Reset(s.hub.opts.IdleTimeout)
+		}
+	}
+}
+
+// parseEventID 解析 "{streamID}:{seq}" 格式的事件 ID
+func parseEventID(id string) (string, uint64, bool) {
+	i := strings.LastIndex(id, ":")
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
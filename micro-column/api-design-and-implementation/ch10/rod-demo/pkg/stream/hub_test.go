@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingProducer 一直运行直到被取消
+func blockingProducer(ctx context.Context, emit Emitter) error {
+	if err := emit("message", "hello"); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHubRemovesIdleCanceledStream(t *testing.T) {
+	h := NewHub(Options{IdleTimeout: 20 * time.Millisecond, Retention: time.Hour})
+
+	h.Open("operations/1", "", blockingProducer)
+	waitFor(t, func() bool {
+		_, ok := h.Lookup("operations/1", "")
+		return !ok
+	})
+}
+
+func TestHubOpenReplacesCanceledStream(t *testing.T) {
+	h := NewHub(Options{IdleTimeout: time.Hour, Retention: time.Hour})
+
+	first := h.Open("operations/1", "", blockingProducer)
+	// 模拟空闲计时器触发，但 finish 尚未执行
+	first.mu.Lock()
+	first.canceled = true
+	first.mu.Unlock()
+
+	second := h.Open("operations/1", "", blockingProducer)
+	if second == first {
+		t.Fatal("Open returned the canceled stream")
+	}
+
+	// 旧流的 finish 不能把新流从索引中删掉
+	first.abort()
+	waitFor(t, func() bool {
+		first.mu.Lock()
+		defer first.mu.Unlock()
+		return first.done
+	})
+	if s, ok := h.Lookup("operations/1", ""); !ok || s != second {
+		t.Fatal("replacement stream was removed by the canceled one")
+	}
+	second.abort()
+}
+
+func TestHubSharesRunningStream(t *testing.T) {
+	h := NewHub(Options{IdleTimeout: time.Hour})
+
+	first := h.Open("operations/1", "", blockingProducer)
+	defer first.abort()
+	if second := h.Open("operations/1", "", blockingProducer); second != first {
+		t.Fatal("Open started a second producer for a running stream")
+	}
+}
+
+func TestHubOwnerCheck(t *testing.T) {
+	h := NewHub(Options{IdleTimeout: time.Hour})
+	s := h.Open("chat-1", "jwt:alice", blockingProducer)
+	defer s.abort()
+
+	tests := []struct {
+		name   string
+		lookup func() (*Stream, bool)
+		want   bool
+	}{
+		{"lookup by owner", func() (*Stream, bool) { return h.Lookup("chat-1", "jwt:alice") }, true},
+		{"lookup by other", func() (*Stream, bool) { return h.Lookup("chat-1", "jwt:bob") }, false},
+		{"lookup anonymous", func() (*Stream, bool) { return h.Lookup("chat-1", "") }, false},
+		{"resume by owner", func() (*Stream, bool) { return h.Resume("chat-1:1", "jwt:alice") }, true},
+		{"resume by other", func() (*Stream, bool) { return h.Resume("chat-1:1", "jwt:bob") }, false},
+		{"resume malformed id", func() (*Stream, bool) { return h.Resume("chat-1", "jwt:alice") }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := tt.lookup(); ok != tt.want {
+				t.Fatalf("got %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestHubRetainsFinishedStream(t *testing.T) {
+	h := NewHub(Options{Retention: time.Hour})
+
+	s := h.Open("chat-1", "jwt:alice", func(ctx context.Context, emit Emitter) error {
+		return emit("done", "[DONE]")
+	})
+	waitFor(t, func() bool {
+		_, done := s.since(0)
+		return done
+	})
+	if _, ok := h.Lookup("chat-1", "jwt:alice"); !ok {
+		t.Fatal("finished stream should be kept for replay")
+	}
+}
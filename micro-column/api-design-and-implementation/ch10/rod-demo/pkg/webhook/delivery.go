@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"context"
+	"rod-demo/pkg/outbox"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeliveryState 投递状态
+type DeliveryState string
+
+const (
+	DeliveryPending   DeliveryState = "PENDING"   // 等待投递或等待重试
+	DeliverySucceeded DeliveryState = "SUCCEEDED" // 对方返回 2xx
+	DeliveryDead      DeliveryState = "DEAD"      // 重试次数耗尽，进入死信列表，可手动重放
+)
+
+// Delivery 是一个事件向一个订阅的投递任务
+type Delivery struct {
+	ID            string        `json:"id"`
+	EndpointID    string        `json:"endpoint_id"`
+	Event         outbox.Event  `json:"event"`
+	State         DeliveryState `json:"state"`
+	Attempts      int           `json:"attempts"`
+	NextAttemptAt time.Time     `json:"next_attempt_time"`
+	LastStatus    int           `json:"last_status,omitempty"` // 最后一次的 HTTP 状态码，网络错误时为 0
+	LastError     string        `json:"last_error,omitempty"`
+	UpdateTime    time.Time     `json:"update_time"`
+}
+
+// DeliveryStore 保存投递任务
+type DeliveryStore interface {
+	Create(ctx context.Context, deliveries ...*Delivery) error
+	// Due 返回到期需要投递的任务 (State=PENDING 且 NextAttemptAt <= now)
+	Due(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+	Save(ctx context.Context, d *Delivery) error
+	// List 返回某个订阅下指定状态的任务，按创建顺序
+	List(ctx context.Context, endpointID string, state DeliveryState) ([]*Delivery, error)
+}
+
+// MemoryDeliveryStore 基于内存的投递任务存储，仅适用于单实例演示
+type MemoryDeliveryStore struct {
+	mu    sync.Mutex
+	seq   int
+	items map[string]*memoryDelivery
+}
+
+type memoryDelivery struct {
+	seq int // 创建顺序
+	d   Delivery
+}
+
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{items: make(map[string]*memoryDelivery)}
+}
+
+func (s *MemoryDeliveryStore) Create(ctx context.Context, deliveries ...*Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range deliveries {
+		s.seq++
+		s.items[d.ID] = &memoryDelivery{seq: s.seq, d: *d}
+	}
+	return nil
+}
+
+func (s *MemoryDeliveryStore) Due(ctx context.Context, now time.Time, limit int) ([]*Delivery, error) {
+	return s.filter(limit, func(d *Delivery) bool {
+		return d.State == DeliveryPending && !d.NextAttemptAt.After(now)
+	}), nil
+}
+
+func (s *MemoryDeliveryStore) Save(ctx context.Context, d *Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if it, ok := s.items[d.ID]; ok {
+		it.d = *d
+	}
+	return nil
+}
+
+func (s *MemoryDeliveryStore) List(ctx context.Context, endpointID string, state DeliveryState) ([]*Delivery, error) {
+	return s.filter(0, func(d *Delivery) bool {
+		return d.EndpointID == endpointID && d.State == state
+	}), nil
+}
+
+// filter 按创建顺序返回满足条件的任务副本，limit <= 0 表示不限制
+func (s *MemoryDeliveryStore) filter(limit int, keep func(*Delivery) bool) []*Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matched := make([]*memoryDelivery, 0)
+	for _, it := range s.items {
+		if keep(&it.d) {
+			matched = append(matched, it)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].seq < matched[j].seq })
+
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	out := make([]*Delivery, len(matched))
+	for i, it := range matched {
+		d := it.d
+		out[i] = &d
+	}
+	return out
+}
@@ -0,0 +1,322 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"rod-demo/pkg/outbox"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Endpoint 是一个 Webhook 订阅的投递目标
+type Endpoint struct {
+	ID     string
+	URL    string
+	Secret []byte
+	// Active 为 false 时订阅已停用，已经排队的投递也不再发送
+	Active bool
+}
+
+// EndpointSource 提供订阅信息，通常由订阅资源的 Service 实现
+type EndpointSource interface {
+	// Match 返回属于 owner、订阅了该事件类型且处于启用状态的订阅
+	// 事件只能投递给产生它的调用方自己的订阅，owner 为空时不匹配任何订阅
+	Match(ctx context.Context, owner, eventType string) ([]Endpoint, error)
+	// Endpoint 按 ID 查询订阅，不存在时返回 nil
+	// 每次投递都重新查询，保证修改 URL 或轮换密钥后立即生效
+	Endpoint(ctx context.Context, id string) (*Endpoint, error)
+}
+
+// MatchEventType 判断事件类型是否匹配订阅的模式
+// 支持精确匹配 "order.cancelled"、前缀通配 "order.*" 与全部 "*"
+func MatchEventType(patterns []string, eventType string) bool {
+	for _, p := range patterns {
+		switch {
+		case p == "*" || p == eventType:
+			return true
+		case strings.HasSuffix(p, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(p, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// RetryPolicy 定义失败重试策略 (指数退避 + 抖动)
+type RetryPolicy struct {
+	MaxAttempts int           // 最多尝试次数，耗尽后进入死信
+	BaseDelay   time.Duration // 第一次重试的间隔，之后每次翻倍
+	MaxDelay    time.Duration // 重试间隔上限
+}
+
+// DefaultRetryPolicy 5s, 10s, 20s ... 最长 1 小时，共 8 次
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 8,
+	BaseDelay:   5 * time.Second,
+	MaxDelay:    time.Hour,
+}
+
+// Backoff 返回第 attempt 次失败后的等待时间
+// 加入 0~10% 的随机抖动，避免大量失败的投递在同一时刻集中重试
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if jitter := int64(d / 10); jitter > 0 {
+		d += time.Duration(rand.Int63n(jitter))
+	}
+	return d
+}
+
+// Dispatcher 从发件箱读取事件，扇出到匹配的订阅，并负责签名投递与重试
+//
+// 投递语义是 At-Least-Once：同一事件可能被投递多次，接收方应按 Webhook-Id 去重
+type Dispatcher struct {
+	events     outbox.Store
+	deliveries DeliveryStore
+	endpoints  EndpointSource
+	policy     RetryPolicy
+	client     *http.Client
+
+	// BatchSize 每轮最多处理的事件数与投递数
+	BatchSize int
+	// Concurrency 每轮并发投递的数量
+	Concurrency int
+
+	now func() time.Time
+}
+
+func NewDispatcher(events outbox.Store, deliveries DeliveryStore, endpoints EndpointSource, policy RetryPolicy) *Dispatcher {
+	return &Dispatcher{
+		events:      events,
+		deliveries:  deliveries,
+		endpoints:   endpoints,
+		policy:      policy,
+		client:      newSafeClient(10 * time.Second),
+		BatchSize:   100,
+		Concurrency: 8,
+		now:         time.Now,
+	}
+}
+
+// Start 启动后台分发循环，每隔 interval 执行一轮
+// 返回的 stop 函数用于优雅停机，会等待正在进行的一轮分发结束
+func (d *Dispatcher) Start(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.Tick(ctx); err != nil {
+					log.Printf("webhook dispatch failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Tick 执行一轮分发：
+// 1. 把发件箱中的新事件扇出为每个订阅的投递任务
+// 2. 投递所有到期的任务
+func (d *Dispatcher) Tick(ctx context.Context) error {
+	if err := d.fanOut(ctx); err != nil {
+		return err
+	}
+	return d.deliverDue(ctx)
+}
+
+// fanOut 为每个 (事件, 订阅) 生成投递任务
+// 先创建任务再标记事件已分发：中途崩溃最多导致重复投递，而不会丢事件
+func (d *Dispatcher) fanOut(ctx context.Context) error {
+	events, err := d.events.Pending(ctx, d.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, evt := range events {
+		endpoints, err := d.endpoints.Match(ctx, evt.Owner, evt.Type)
+		if err != nil {
+			return err
+		}
+
+		now := d.now()
+		deliveries := make([]*Delivery, 0, len(endpoints))
+		for _, ep := range endpoints {
+			deliveries = append(deliveries, &Delivery{
+				ID:            uuid.New().String(),
+				EndpointID:    ep.ID,
+				Event:         evt,
+				State:         DeliveryPending,
+				NextAttemptAt: now,
+				UpdateTime:    now,
+			})
+		}
+		if len(deliveries) > 0 {
+			if err := d.deliveries.Create(ctx, deliveries...); err != nil {
+				return err
+			}
+		}
+		if err := d.events.MarkDispatched(ctx, evt.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) error {
+	due, err := d.deliveries.Due(ctx, d.now(), d.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	// 信号量控制并发，慢的接收方不会拖住整轮分发
+	sem := make(chan struct{}, d.Concurrency)
+	var wg sync.WaitGroup
+	for _, dl := range due {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(dl *Delivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			d.attempt(ctx, dl)
+		}(dl)
+	}
+	wg.Wait()
+	return nil
+}
+
+// attempt 执行一次投递并根据结果更新任务状态
+func (d *Dispatcher) attempt(ctx context.Context, dl *Delivery) {
+	status, err := d.send(ctx, dl)
+
+	now := d.now()
+	dl.Attempts++
+	dl.LastStatus = status
+	dl.UpdateTime = now
+
+	switch {
+	case err == nil:
+		dl.State = DeliverySucceeded
+		dl.LastError = ""
+	case dl.Attempts >= d.policy.MaxAttempts:
+		dl.State = DeliveryDead
+		dl.LastError = err.Error()
+		log.Printf("webhook delivery %s to endpoint %s is dead after %d attempts: %v",
+			dl.ID, dl.EndpointID, dl.Attempts, err)
+	default:
+		dl.LastError = err.Error()
+		dl.NextAttemptAt = now.Add(d.policy.Backoff(dl.Attempts))
+	}
+
+	if err := d.deliveries.Save(ctx, dl); err != nil {
+		log.Printf("webhook: save delivery %s failed: %v", dl.ID, err)
+	}
+}
+
+// send 签名并发送事件，返回 HTTP 状态码
+func (d *Dispatcher) send(ctx context.Context, dl *Delivery) (int, error) {
+	ep, err := d.endpoints.Endpoint(ctx, dl.EndpointID)
+	if err != nil {
+		return 0, err
+	}
+	if ep == nil {
+		return 0, fmt.Errorf("endpoint %s no longer exists", dl.EndpointID)
+	}
+	// 订阅可能在事件排队之后被停用，以投递时的状态为准
+	if !ep.Active {
+		return 0, fmt.Errorf("endpoint %s is inactive", dl.EndpointID)
+	}
+
+	body, err := json.Marshal(dl.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	ts := d.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, dl.Event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(ep.Secret, dl.Event.ID, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// 读完响应体以复用连接，但不关心其内容
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// DeadLetters 返回某个订阅的死信列表
+func (d *Dispatcher) DeadLetters(ctx context.Context, endpointID string) ([]*Delivery, error) {
+	return d.deliveries.List(ctx, endpointID, DeliveryDead)
+}
+
+// Replay 把死信重新放回投递队列，返回重放的数量
+// ids 为空时重放该订阅的全部死信；不属于该订阅或不在死信中的 ID 会被忽略
+func (d *Dispatcher) Replay(ctx context.Context, endpointID string, ids []string) (int, error) {
+	dead, err := d.DeadLetters(ctx, endpointID)
+	if err != nil {
+		return 0, err
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	now := d.now()
+	replayed := 0
+	for _, dl := range dead {
+		if len(ids) > 0 && !wanted[dl.ID] {
+			continue
+		}
+		// 重新获得完整的重试次数
+		dl.State = DeliveryPending
+		dl.Attempts = 0
+		dl.NextAttemptAt = now
+		dl.UpdateTime = now
+		if err := d.deliveries.Save(ctx, dl); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rod-demo/pkg/outbox"
+)
+
+// staticEndpoints 是只有一个订阅的 EndpointSource
+type staticEndpoints struct {
+	ep Endpoint
+}
+
+func (s *staticEndpoints) Match(ctx context.Context, owner, eventType string) ([]Endpoint, error) {
+	return nil, nil
+}
+
+func (s *staticEndpoints) Endpoint(ctx context.Context, id string) (*Endpoint, error) {
+	if id != s.ep.ID {
+		return nil, nil
+	}
+	ep := s.ep
+	return &ep, nil
+}
+
+func TestDispatcherSkipsInactiveEndpoint(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	endpoints := &staticEndpoints{ep: Endpoint{ID: "sub-1", URL: srv.URL, Secret: []byte("secret"), Active: true}}
+	deliveries := NewMemoryDeliveryStore()
+	d := NewDispatcher(outbox.NewMemoryStore(), deliveries, endpoints, DefaultRetryPolicy)
+	// 测试服务器监听在回环地址上，绕过 SSRF 防护
+	d.client = srv.Client()
+
+	ctx := context.Background()
+	queue := func(id string) {
+		t.Helper()
+		dl := &Delivery{ID: id, EndpointID: "sub-1", Event: outbox.Event{ID: "evt-" + id}, State: DeliveryPending, NextAttemptAt: time.Now()}
+		if err := deliveries.Create(ctx, dl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 事件排队之后订阅被停用
+	queue("d1")
+	endpoints.ep.Active = false
+	if err := d.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Fatalf("inactive endpoint received %d requests, want 0", n)
+	}
+	pending, err := deliveries.List(ctx, "sub-1", DeliveryPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || !strings.Contains(pending[0].LastError, "inactive") {
+		t.Fatalf("pending deliveries = %+v, want d1 failed as inactive", pending)
+	}
+
+	// 重新启用后，新的投递照常发送
+	endpoints.ep.Active = true
+	queue("d2")
+	if err := d.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("active endpoint received %d requests, want 1", n)
+	}
+}
@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrUnsafeURL 表示回调地址不满足要求 (非 https 或指向内网)
+// 订阅方可以填写任意 URL，不加限制时服务端会替攻击者请求内网服务 (SSRF)
+var ErrUnsafeURL = errors.New("webhook: unsafe url")
+
+// nonPublicPrefixes 是 netip 未覆盖的保留地址段
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "本网络"
+	netip.MustParsePrefix("100.64.0.0/10"),  // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"),  // 基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),    // 保留，含广播地址
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64，可映射到内网 IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"), // 本地 NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // 文档
+	netip.MustParsePrefix("fec0::/10"),      // 已废弃的站点本地地址
+}
+
+// IsPublicIP 判断地址是否为公网地址
+func IsPublicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateURL 在创建或修改订阅时校验回调地址：
+// - 只允许 https，且不能携带用户名密码
+// - 主机名解析出的所有地址都必须是公网地址
+//
+// 创建时的校验只是尽早给出错误，DNS 结果随时可能变化 (DNS Rebinding)，
+// 真正的防线是投递时的 SafeDialer
+func ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsafeURL, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme must be https", ErrUnsafeURL)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials are not allowed in url", ErrUnsafeURL)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrUnsafeURL)
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !IsPublicIP(ip) {
+			return fmt.Errorf("%w: %s is not a public address", ErrUnsafeURL, host)
+		}
+		return nil
+	}
+	// 解析器可能接受 "0x7f.1" 这类数字形式的主机名，合法域名的顶级域不会是纯数字
+	if labels := strings.Split(strings.TrimSuffix(host, "."), "."); isNumeric(labels[len(labels)-1]) {
+		return fmt.Errorf("%w: numeric host %s", ErrUnsafeURL, host)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve %s", ErrUnsafeURL, host)
+	}
+	for _, ip := range addrs {
+		if !IsPublicIP(ip) {
+			return fmt.Errorf("%w: %s resolves to non-public address %s", ErrUnsafeURL, host, ip)
+		}
+	}
+	return nil
+}
+
+// isNumeric 判断标签是否为十进制、八进制或 0x 开头的十六进制数字
+func isNumeric(label string) bool {
+	digits := "0123456789"
+	if rest, ok := strings.CutPrefix(strings.ToLower(label), "0x"); ok {
+		label, digits = rest, "0123456789abcdef"
+		if label == "" {
+			return true
+		}
+	}
+	if label == "" {
+		return false
+	}
+	for _, r := range label {
+		if !strings.ContainsRune(digits, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// SafeDialer 在建立连接前检查实际要连接的 IP，拒绝非公网地址
+// Control 拿到的是 DNS 解析之后的地址，校验与连接之间不存在 DNS Rebinding 的窗口
+func SafeDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrUnsafeURL, err)
+			}
+			if !IsPublicIP(ap.Addr()) {
+				return fmt.Errorf("%w: refusing to connect to %s", ErrUnsafeURL, ap.Addr())
+			}
+			return nil
+		},
+	}
+}
+
+// newSafeClient 创建投递用的 HTTP 客户端
+// - 只通过 SafeDialer 建立连接，且不走环境变量中的代理 (代理会绕过地址检查)
+// - 不跟随重定向：重定向目标同样由订阅方控制，按投递失败处理
+func newSafeClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         SafeDialer().DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 4,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
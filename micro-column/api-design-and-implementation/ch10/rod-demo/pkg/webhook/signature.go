@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名 Header，遵循 Standard Webhooks 规范 (https://www.standardwebhooks.com)
+const (
+	HeaderID        = "Webhook-Id"        // 事件 ID，重试时保持不变，接收方据此去重
+	HeaderTimestamp = "Webhook-Timestamp" // 本次投递的 Unix 时间戳 (秒)
+	HeaderSignature = "Webhook-Signature" // "v1,<base64(HMAC-SHA256)>"，轮换密钥期间可能有多个，以空格分隔
+)
+
+var (
+	ErrMissingHeaders   = errors.New("webhook: missing signature headers")
+	ErrInvalidTimestamp = errors.New("webhook: timestamp outside tolerance")
+	ErrNoMatchingSig    = errors.New("webhook: no matching signature")
+)
+
+// Sign 计算签名，签名内容为 "{id}.{timestamp}.{body}"
+// 把 id 和 timestamp 纳入签名，防止攻击者重放旧请求或篡改时间戳
+func Sign(secret []byte, id string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id + "." + strconv.FormatInt(ts, 10) + "."))
+	mac.Write(body)
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 供接收方校验签名
+// tolerance 是允许的时间偏差，超出范围的请求视为重放
+func Verify(secret []byte, h http.Header, body []byte, tolerance time.Duration) error {
+	id, tsRaw, sigs := h.Get(HeaderID), h.Get(HeaderTimestamp), h.Get(HeaderSignature)
+	if id == "" || tsRaw == "" || sigs == "" {
+		return ErrMissingHeaders
+	}
+
+	ts, err := strconv.ParseInt(tsRaw, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if d := time.Since(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return ErrInvalidTimestamp
+	}
+
+	expected := Sign(secret, id, ts, body)
+	for _, sig := range strings.Fields(sigs) {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrNoMatchingSig
+}
@@ -5,6 +5,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"errors"
 	"context"
 	"time"
 	"fmt"
@@ -14,10 +17,14 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 	
+	"github.com/bigwhite/shortlink/pkg/analytics"
+	"github.com/bigwhite/shortlink/pkg/client"
+	"github.com/bigwhite/shortlink/pkg/codegen"
 	"github.com/bigwhite/shortlink/pkg/handler"
 	"github.com/bigwhite/shortlink/pkg/repository/postgres"
 	redis_repo "github.com/bigwhite/shortlink/pkg/repository/redis"
 	"github.com/bigwhite/shortlink/pkg/service"
+	"github.com/bigwhite/shortlink/pkg/validator"
 )
 
 func main() {
@@ -67,12 +74,54 @@ func main() {
 	// --- 组装应用 ---
 	linkRepo := postgres.NewPgLinkRepository(dbPool)
 	linkCache := redis_repo.NewRedisLinkCache(redisClient)
-	shortenerSvc := service.NewShortenerService(linkRepo, linkCache)
-	linkHandler := handler.NewLinkHandler(shortenerSvc)
+	visitRepo := postgres.NewPgVisitRepository(dbPool)
+
+	// 访问事件经有界缓冲异步批量落库，GeoIP 数据库可选
+	var geoDB *analytics.GeoDB
+	if geoPath := os.Getenv("GEOIP_DB"); geoPath != "" {
+		if geoDB, err = analytics.LoadGeoDB(geoPath); err != nil {
+			log.Fatalf("无法加载 GeoIP 数据库: %s", err)
+		}
+	}
+	recorder := analytics.NewRecorder(visitRepo, linkCache, analytics.Config{GeoDB: geoDB})
+
+	svcOpts := []service.Option{
+		service.WithVisitRecorder(recorder),
+		service.WithVisitRepository(visitRepo),
+		// 目标 URL 安全策略，域名列表均为逗号分隔
+		service.WithURLPolicy(&validator.Policy{
+			SelfDomains:    splitList(os.Getenv("SHORTLINK_DOMAINS")),
+			BlockedDomains: splitList(os.Getenv("URL_BLOCKLIST")),
+			AllowedDomains: splitList(os.Getenv("URL_ALLOWLIST")),
+		}),
+	}
+	// 短码生成策略：random (默认) / sequence / range，后两者需要 SHORT_CODE_KEY 作为置换密钥
+	if codes := newCodeGenerator(os.Getenv("SHORT_CODE_STRATEGY"), os.Getenv("SHORT_CODE_KEY"), dbPool); codes != nil {
+		svcOpts = append(svcOpts, service.WithCodeGenerator(codes))
+	}
+	// 配置了用户服务时，创建带 owner 的链接需要校验权限
+	if userServiceURL := os.Getenv("USER_SERVICE_BASE_URL"); userServiceURL != "" {
+		// 单次尝试的超时、重试、熔断和权限缓存都由客户端的默认策略处理
+		userClient := &client.UserServiceClient{
+			BaseURL:    userServiceURL,
+			HTTPClient: &http.Client{Timeout: 2 * time.Second},
+		}
+		if token := os.Getenv("USER_SERVICE_TOKEN"); token != "" {
+			userClient.TokenSource = client.StaticToken(token)
+		}
+		svcOpts = append(svcOpts, service.WithUserService(userClient))
+	}
+	shortenerSvc := service.NewShortenerService(linkRepo, linkCache, svcOpts...)
+	// X-User-ID 只采信携带 GATEWAY_TOKEN 的请求；未配置时所有带 X-User-ID 的请求都会被拒绝
+	linkHandler := handler.NewLinkHandler(shortenerSvc, handler.WithGatewayToken(os.Getenv("GATEWAY_TOKEN")))
 
 	// --- 路由与服务器启动 ---
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/links", linkHandler.CreateLink)
+	// 修改和删除需要所有者身份 (由可信网关注入的 X-User-ID)
+	// 带方法和通配符的模式比下面的 "/api/links/" 更具体，会被优先匹配
+	mux.HandleFunc("PATCH /api/links/{code}", linkHandler.UpdateLink)
+	mux.HandleFunc("DELETE /api/links/{code}", linkHandler.DeleteLink)
 
 	// (为其他 handler 添加路由...)
 	// 为统计接口注册一个更具体的路径
@@ -111,8 +160,68 @@ func main() {
 		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 	})
 
-	log.Println("Shortlink Service 启动于 :8080")
-	if err := http.ListenAndServe(":8080", mux); err != nil {
-		log.Fatalf("服务器启动失败: %s", err)
+	// --- 启动与优雅退出 ---
+	// 收到 SIGINT/SIGTERM 后先停止接收请求，再等待 Recorder 把缓冲中的访问写完
+	srv := &http.Server{Addr: ":8080", Handler: mux}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Println("Shortlink Service 启动于 :8080")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("服务器启动失败: %s", err)
+		}
+	}()
+	<-ctx.Done()
+
+	log.Println("正在关闭 Shortlink Service...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("关闭 HTTP 服务失败: %s", err)
+	}
+	if err := recorder.Close(shutdownCtx); err != nil {
+		log.Printf("访问记录未能全部写入: %s", err)
+	}
+	if n := recorder.Dropped(); n > 0 {
+		log.Printf("运行期间因缓冲已满丢弃了 %d 条访问记录", n)
+	}
+}
+
+// newCodeGenerator 按策略创建短码生成器，random 返回 nil 表示使用 service 的默认实现
+func newCodeGenerator(strategy, key string, db *sql.DB) codegen.CodeGenerator {
+	switch strategy {
+	case "", "random":
+		return nil
+	case "sequence", "range":
+	default:
+		log.Fatalf("未知的短码生成策略: %s", strategy)
+	}
+
+	if key == "" {
+		log.Fatal("短码生成策略为 sequence/range 时必须设置 SHORT_CODE_KEY")
+	}
+	// 36 位的 ID 空间对应 7 个字符的短码
+	perm, err := codegen.NewFeistel(36, []byte(key))
+	if err != nil {
+		log.Fatalf("无法创建短码置换: %s", err)
+	}
+
+	seq := postgres.NewPgCodeSequence(db)
+	if strategy == "range" {
+		// 每个实例一次租用 1000 个 ID
+		return codegen.NewSequenceGenerator(codegen.NewRangeAllocator(seq, 1000), perm)
+	}
+	return codegen.NewSequenceGenerator(seq, perm)
+}
+
+// splitList 解析逗号分隔的环境变量，忽略空白项
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -0,0 +1,6 @@
+-- migrations/000002_add_link_options.down.sql
+DROP INDEX IF EXISTS owner_id_idx;
+
+ALTER TABLE IF EXISTS links DROP COLUMN IF EXISTS max_visits;
+ALTER TABLE IF EXISTS links DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE IF EXISTS links DROP COLUMN IF EXISTS owner_id;
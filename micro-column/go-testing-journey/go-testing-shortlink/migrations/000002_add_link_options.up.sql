@@ -0,0 +1,6 @@
+-- migrations/000002_add_link_options.up.sql
+ALTER TABLE links ADD COLUMN IF NOT EXISTS owner_id VARCHAR(64);
+ALTER TABLE links ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS max_visits BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS owner_id_idx ON links (owner_id);
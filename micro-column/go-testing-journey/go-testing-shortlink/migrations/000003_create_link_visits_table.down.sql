@@ -0,0 +1,2 @@
+-- migrations/000003_create_link_visits_table.down.sql
+DROP TABLE IF EXISTS link_visits;
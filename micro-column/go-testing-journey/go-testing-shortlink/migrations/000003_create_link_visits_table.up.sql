@@ -0,0 +1,12 @@
+-- migrations/000003_create_link_visits_table.up.sql
+CREATE TABLE IF NOT EXISTS link_visits (
+    id BIGSERIAL PRIMARY KEY,
+    short_code VARCHAR(255) NOT NULL,
+    visited_at TIMESTAMPTZ NOT NULL,
+    referrer TEXT NOT NULL DEFAULT '',
+    ua_class VARCHAR(16) NOT NULL DEFAULT '',
+    country VARCHAR(2) NOT NULL DEFAULT ''
+);
+
+-- 统计查询总是按短码 + 时间范围过滤
+CREATE INDEX IF NOT EXISTS link_visits_code_time_idx ON link_visits (short_code, visited_at);
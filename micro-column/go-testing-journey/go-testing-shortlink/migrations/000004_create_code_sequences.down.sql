@@ -0,0 +1,2 @@
+-- migrations/000004_create_code_sequences.down.sql
+DROP SEQUENCE IF EXISTS link_code_seq;
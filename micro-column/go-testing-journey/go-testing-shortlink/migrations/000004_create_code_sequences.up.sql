@@ -0,0 +1,3 @@
+-- migrations/000004_create_code_sequences.up.sql
+-- 短码 ID 的序列，逐个分配与按区间租用共用这一个计数器
+CREATE SEQUENCE IF NOT EXISTS link_code_seq START WITH 1;
@@ -0,0 +1,178 @@
+//go:build unit
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bigwhite/shortlink/pkg/repository/fakes"
+)
+
+func TestGeoDB_Country(t *testing.T) {
+	db, err := ParseGeoDB(strings.NewReader(`# start,end,country
+1.0.0.0,1.0.0.255,au
+8.8.8.0,8.8.8.255,US
+2001:db8::,2001:db8::ffff,DE
+`))
+	if err != nil {
+		t.Fatalf("ParseGeoDB() returned an unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		ip   string
+		want string
+	}{
+		{"1.0.0.1", "AU"},
+		{"8.8.8.8", "US"},
+		{"::ffff:8.8.8.8", "US"}, // IPv4-mapped IPv6
+		{"2001:db8::1", "DE"},
+		{"8.8.9.1", ""},    // 落在两个区间之间
+		{"0.0.0.1", ""},    // 小于第一个区间
+		{"2001:db9::", ""}, // IPv6 越界
+		{"not-an-ip", ""},
+	}
+	for _, tc := range testCases {
+		if got := db.Country(tc.ip); got != tc.want {
+			t.Errorf("Country(%q) = %q, want %q", tc.ip, got, tc.want)
+		}
+	}
+
+	var nilDB *GeoDB
+	if got := nilDB.Country("8.8.8.8"); got != "" {
+		t.Errorf("nil GeoDB Country() = %q, want empty", got)
+	}
+
+	if _, err := ParseGeoDB(strings.NewReader("8.8.8.255,8.8.8.0,US\n")); err == nil {
+		t.Error("ParseGeoDB() with reversed range should fail")
+	}
+}
+
+func TestClassifyUserAgent(t *testing.T) {
+	testCases := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0":                  UAClassDesktop,
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148":                       UAClassMobile,
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537": UAClassMobile,
+		"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 Chrome/120.0 Safari/537.36":     UAClassTablet,
+		"Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X)":                                              UAClassTablet,
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)":                   UAClassBot,
+		"curl/8.4.0": UAClassBot,
+		"":           UAClassOther,
+	}
+	for ua, want := range testCases {
+		if got := ClassifyUserAgent(ua); got != want {
+			t.Errorf("ClassifyUserAgent(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestReferrerHost(t *testing.T) {
+	testCases := map[string]string{
+		"https://www.Example.com/path?token=secret": "example.com",
+		"https://t.co/abc":                          "t.co",
+		"":                                          "",
+		"not a url":                                 "",
+	}
+	for ref, want := range testCases {
+		if got := ReferrerHost(ref); got != want {
+			t.Errorf("ReferrerHost(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestRecorder_DrainOnClose(t *testing.T) {
+	ctx := context.Background()
+	visits := fakes.NewFakeVisitRepository()
+	cache := &fakes.FakeLinkCache{}
+	// 批量和时间间隔都足够大，访问只会在 Close 时写入
+	r := NewRecorder(visits, cache, Config{BatchSize: 1000, FlushInterval: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		ip := "192.0.2.1"
+		if i%2 == 1 {
+			ip = "192.0.2.2"
+		}
+		if !r.Record(Hit{ShortCode: "abc", Time: time.Now(), IP: ip, Referrer: "https://t.co/x"}) {
+			t.Fatalf("Record() #%d was dropped", i)
+		}
+	}
+
+	if err := r.Close(ctx); err != nil {
+		t.Fatalf("Close() returned an unexpected error: %v", err)
+	}
+
+	saved := visits.Visits()
+	if len(saved) != 5 {
+		t.Fatalf("got %d saved visits, want 5", len(saved))
+	}
+	if saved[0].Referrer != "t.co" || saved[0].VisitorID == "" {
+		t.Errorf("visit not enriched: %+v", saved[0])
+	}
+	if n, _ := cache.GetVisitCount(ctx, "abc"); n != 5 {
+		t.Errorf("visit count got %d, want 5", n)
+	}
+	if n, _ := cache.CountUniqueVisitors(ctx, "abc"); n != 2 {
+		t.Errorf("unique visitors got %d, want 2", n)
+	}
+
+	// 关闭之后的访问被丢弃，而不是 panic
+	if r.Record(Hit{ShortCode: "abc"}) {
+		t.Error("Record() after Close should return false")
+	}
+	if r.Dropped() != 1 {
+		t.Errorf("Dropped() got %d, want 1", r.Dropped())
+	}
+}
+
+func TestRecorder_CountedHitsOnlyAddVisitors(t *testing.T) {
+	ctx := context.Background()
+	cache := &fakes.FakeLinkCache{}
+	r := NewRecorder(fakes.NewFakeVisitRepository(), cache, Config{BatchSize: 1000, FlushInterval: time.Hour})
+
+	r.Record(Hit{ShortCode: "abc", IP: "192.0.2.1", Counted: true})
+	r.Record(Hit{ShortCode: "abc", IP: "192.0.2.2"})
+	if err := r.Close(ctx); err != nil {
+		t.Fatalf("Close() returned an unexpected error: %v", err)
+	}
+
+	// 已计数的访问不再累加计数，但仍计入独立访客
+	if n, _ := cache.GetVisitCount(ctx, "abc"); n != 1 {
+		t.Errorf("visit count got %d, want 1", n)
+	}
+	if n, _ := cache.CountUniqueVisitors(ctx, "abc"); n != 2 {
+		t.Errorf("unique visitors got %d, want 2", n)
+	}
+}
+
+func TestRecorder_DropWhenFull(t *testing.T) {
+	visits := fakes.NewFakeVisitRepository()
+	// 写库失败不影响计数，也不会阻塞后续批次
+	visits.Err = errors.New("db down")
+	cache := &fakes.FakeLinkCache{}
+	r := &Recorder{
+		cfg:    Config{BufferSize: 2, BatchSize: 10, FlushInterval: time.Hour},
+		visits: visits,
+		cache:  cache,
+		ch:     make(chan Hit, 2),
+		done:   make(chan struct{}),
+	}
+	// 先不启动 flusher，保证缓冲一定会被填满
+
+	for i := 0; i < 3; i++ {
+		r.Record(Hit{ShortCode: "abc", IP: "192.0.2.1"})
+	}
+	if r.Dropped() != 1 {
+		t.Fatalf("Dropped() got %d, want 1", r.Dropped())
+	}
+
+	go r.run()
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close() returned an unexpected error: %v", err)
+	}
+	if n, _ := cache.GetVisitCount(context.Background(), "abc"); n != 2 {
+		t.Errorf("visit count got %d, want 2", n)
+	}
+}
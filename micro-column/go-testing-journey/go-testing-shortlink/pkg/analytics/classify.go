@@ -0,0 +1,71 @@
+// pkg/analytics/classify.go
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// User-Agent 的分类
+const (
+	UAClassDesktop = "desktop"
+	UAClassMobile  = "mobile"
+	UAClassTablet  = "tablet"
+	UAClassBot     = "bot"
+	UAClassOther   = "other"
+)
+
+var botMarkers = []string{"bot", "crawler", "spider", "slurp", "curl", "wget", "python-requests", "go-http-client", "headless"}
+
+// ClassifyUserAgent 把 User-Agent 粗略地归为几类
+// 统计只关心设备类型的分布，不需要完整的 UA 解析库
+func ClassifyUserAgent(ua string) string {
+	s := strings.ToLower(ua)
+	switch {
+	case s == "":
+		return UAClassOther
+	case containsAny(s, botMarkers):
+		return UAClassBot
+	// iPad 与不带 Mobile 的 Android 设备是平板，必须先于 mobile 判断
+	case strings.Contains(s, "ipad"), strings.Contains(s, "tablet"),
+		strings.Contains(s, "android") && !strings.Contains(s, "mobile"):
+		return UAClassTablet
+	case strings.Contains(s, "mobi"), strings.Contains(s, "iphone"), strings.Contains(s, "android"):
+		return UAClassMobile
+	case strings.Contains(s, "windows"), strings.Contains(s, "macintosh"),
+		strings.Contains(s, "x11"), strings.Contains(s, "cros"):
+		return UAClassDesktop
+	}
+	return UAClassOther
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReferrerHost 只保留 Referer 的 host 部分
+// 完整的 URL 可能带有查询参数等隐私信息，而且会让来源排行过于分散
+func ReferrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// VisitorID 由 IP 与 User-Agent 生成访客标识，只用于 UV 估算
+// 取哈希后的前 16 字节，既避免在 Redis 中保存原始 IP，也足以区分访客
+func VisitorID(ip, userAgent string) string {
+	sum := sha256.Sum256([]byte(ip + "|" + userAgent))
+	return hex.EncodeToString(sum[:16])
+}
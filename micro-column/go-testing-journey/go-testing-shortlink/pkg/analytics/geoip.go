@@ -0,0 +1,100 @@
+// pkg/analytics/geoip.go
+package analytics
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ipRange 是 GeoIP 数据库中的一条记录，[start, end] 为闭区间
+type ipRange struct {
+	start, end netip.Addr
+	country    string
+}
+
+// GeoDB 是一个本地的 IP -> 国家查询表
+//
+// 数据文件为 CSV 格式，每行 "起始IP,结束IP,国家代码"，IPv4 与 IPv6 可以混合，
+// 与 DB-IP 等提供的免费 "country lite" CSV 格式兼容
+// 整个文件在启动时载入内存，查询为二分查找，不依赖任何外部服务
+type GeoDB struct {
+	ranges []ipRange // 按 start 升序
+}
+
+// LoadGeoDB 从文件载入 GeoIP 数据库
+func LoadGeoDB(path string) (*GeoDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseGeoDB(f)
+}
+
+// ParseGeoDB 解析 CSV 格式的 GeoIP 数据
+func ParseGeoDB(r io.Reader) (*GeoDB, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.Comment = '#'
+
+	db := &GeoDB{}
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, err := netip.ParseAddr(strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("geoip line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(rec[1]))
+		if err != nil {
+			return nil, fmt.Errorf("geoip line %d: %w", line, err)
+		}
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("geoip line %d: invalid range %s-%s", line, start, end)
+		}
+		db.ranges = append(db.ranges, ipRange{
+			start:   start,
+			end:     end,
+			country: strings.ToUpper(strings.TrimSpace(rec[2])),
+		})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool { return db.ranges[i].start.Less(db.ranges[j].start) })
+	return db, nil
+}
+
+// Country 返回 IP 所属国家的两位代码，找不到或 IP 非法时返回空字符串
+// db 为 nil 时同样返回空字符串，方便在未配置数据库时直接调用
+func (db *GeoDB) Country(ip string) string {
+	if db == nil {
+		return ""
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	// 数据文件中的 IPv4 记录不会以 IPv4-mapped IPv6 的形式出现
+	addr = addr.Unmap()
+
+	// 找到最后一个 start <= addr 的区间
+	i := sort.Search(len(db.ranges), func(i int) bool { return addr.Less(db.ranges[i].start) }) - 1
+	if i < 0 {
+		return ""
+	}
+	r := db.ranges[i]
+	if r.start.Is4() != addr.Is4() || r.end.Less(addr) {
+		return ""
+	}
+	return r.country
+}
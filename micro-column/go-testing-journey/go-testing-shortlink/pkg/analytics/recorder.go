@@ -0,0 +1,198 @@
+// pkg/analytics/recorder.go
+package analytics
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bigwhite/shortlink/pkg/domain"
+	"github.com/bigwhite/shortlink/pkg/repository"
+)
+
+// Hit 是跳转时采集的原始访问信息
+// 归类、GeoIP 查询等较重的处理都放到后台 flusher 中进行，不占用请求的时间
+type Hit struct {
+	ShortCode string
+	Time      time.Time
+	IP        string
+	UserAgent string
+	Referrer  string
+
+	// Counted 为 true 表示跳转时已同步累加过计数 (设置了 max_visits 的链接)
+	// flusher 只记录明细与独立访客，不再重复计数
+	Counted bool
+}
+
+// Config 是 Recorder 的配置，零值字段使用默认值
+type Config struct {
+	BufferSize    int           // 缓冲 channel 的容量，默认 4096
+	BatchSize     int           // 攒够多少条写一次库，默认 256
+	FlushInterval time.Duration // 最长多久写一次库，默认 1s
+	GeoDB         *GeoDB        // 可选，为 nil 时国家为空
+}
+
+func (c *Config) setDefaults() {
+	if c.BufferSize <= 0 {
+		c.BufferSize = 4096
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 256
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+}
+
+// Recorder 异步地记录访问事件
+//
+// 跳转请求只把 Hit 放入有界的缓冲 channel，缓冲满时丢弃并计数，绝不阻塞跳转；
+// 后台 flusher 按批量大小或时间间隔把访问明细写入 Postgres，
+// 并更新 Redis 中的访问计数与独立访客 HyperLogLog
+type Recorder struct {
+	cfg    Config
+	visits repository.VisitRepository
+	cache  repository.LinkCache
+
+	mu     sync.RWMutex // 保护 closed，保证 Close 之后不会再向 ch 发送
+	closed bool
+	ch     chan Hit
+	done   chan struct{}
+
+	dropped atomic.Int64
+}
+
+// NewRecorder 创建 Recorder 并启动后台 flusher
+// 调用方必须在退出前调用 Close，否则缓冲中的访问会丢失
+func NewRecorder(visits repository.VisitRepository, cache repository.LinkCache, cfg Config) *Recorder {
+	cfg.setDefaults()
+	r := &Recorder{
+		cfg:    cfg,
+		visits: visits,
+		cache:  cache,
+		ch:     make(chan Hit, cfg.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Record 非阻塞地提交一次访问，缓冲已满或 Recorder 已关闭时返回 false
+func (r *Recorder) Record(hit Hit) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		r.dropped.Add(1)
+		return false
+	}
+	select {
+	case r.ch <- hit:
+		return true
+	default:
+		r.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped 返回因缓冲满或已关闭而丢弃的访问数
+func (r *Recorder) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// Close 停止接收新的访问，并等待缓冲中的访问全部写完
+// ctx 到期时直接返回 ctx.Err()，剩余的访问由 flusher 继续尽力写入
+func (r *Recorder) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.ch)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Hit, 0, r.cfg.BatchSize)
+	for {
+		select {
+		case hit, ok := <-r.ch:
+			if !ok {
+				// channel 已关闭且已读空：写完最后一批后退出
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, hit)
+			if len(batch) >= r.cfg.BatchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush 写入一批访问
+// 写库失败只记录日志：统计数据允许少量丢失，不值得为此无限重试而拖住后续批次
+func (r *Recorder) flush(batch []Hit) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	visits := make([]domain.Visit, len(batch))
+	uncounted := make(map[string][]string) // 需要累加计数的访客
+	counted := make(map[string][]string)   // 计数已在跳转时累加，只记录访客
+	for i, hit := range batch {
+		visits[i] = r.enrich(hit)
+		if hit.Counted {
+			counted[hit.ShortCode] = append(counted[hit.ShortCode], visits[i].VisitorID)
+		} else {
+			uncounted[hit.ShortCode] = append(uncounted[hit.ShortCode], visits[i].VisitorID)
+		}
+	}
+
+	if err := r.visits.SaveVisits(ctx, visits); err != nil {
+		log.Printf("ERROR: Failed to save %d visits: %v", len(visits), err)
+	}
+	// 计数与 UV 不依赖明细是否落库成功
+	for code, ids := range uncounted {
+		if err := r.cache.RecordVisits(ctx, code, ids); err != nil {
+			log.Printf("ERROR: Failed to record visits for code %s: %v", code, err)
+		}
+	}
+	for code, ids := range counted {
+		if err := r.cache.AddUniqueVisitors(ctx, code, ids); err != nil {
+			log.Printf("ERROR: Failed to record visitors for code %s: %v", code, err)
+		}
+	}
+}
+
+func (r *Recorder) enrich(hit Hit) domain.Visit {
+	return domain.Visit{
+		ShortCode: hit.ShortCode,
+		VisitedAt: hit.Time,
+		Referrer:  ReferrerHost(hit.Referrer),
+		UAClass:   ClassifyUserAgent(hit.UserAgent),
+		Country:   r.cfg.GeoDB.Country(hit.IP),
+		VisitorID: VisitorID(hit.IP, hit.UserAgent),
+	}
+}
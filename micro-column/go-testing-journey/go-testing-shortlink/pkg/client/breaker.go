@@ -0,0 +1,117 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 表示熔断器处于打开状态，请求未被发出
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// 熔断器状态
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// CircuitBreaker 是一个按连续失败次数触发的熔断器
+//
+// closed: 正常放行，连续失败达到 FailureThreshold 后转为 open
+// open: 直接拒绝，经过 OpenTimeout 后转为 half-open
+// half-open: 只放行一个探测请求，成功则恢复 closed，失败则重新 open
+type CircuitBreaker struct {
+	FailureThreshold int           // 默认 5
+	OpenTimeout      time.Duration // 默认 10s
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool // half-open 时是否已有探测请求在进行
+	now      func() time.Time
+}
+
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// Allow 判断是否可以发出请求，放行后调用方必须调用 Success、Failure 或 Release 报告结果
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.stateLocked() {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success 报告一次成功的请求
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure 报告一次失败的请求
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	threshold := b.FailureThreshold
+	if threshold <= 0 {
+		threshold = 5
+	}
+	b.failures++
+	if b.probing || b.failures >= threshold {
+		b.state = StateOpen
+		b.openedAt = b.clock()
+		b.probing = false
+	}
+}
+
+// Release 报告一次没有结论的请求 (如调用方取消了 ctx)
+// 不改变状态与失败计数，只归还 half-open 的探测名额
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State 返回当前状态
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateLocked()
+}
+
+// stateLocked 计算当前状态，open 超时后惰性地转为 half-open
+func (b *CircuitBreaker) stateLocked() string {
+	if b.state == "" {
+		b.state = StateClosed
+	}
+	timeout := b.OpenTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if b.state == StateOpen && b.clock().Sub(b.openedAt) >= timeout {
+		b.state = StateHalfOpen
+		b.probing = false
+	}
+	return b.state
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCachedPermissions 限制缓存的条目数，超过时先清理过期条目，仍然超过则整体清空
+const maxCachedPermissions = 10000
+
+type permissionEntry struct {
+	canCreate bool
+	expireAt  time.Time
+}
+
+// permissionCache 是一个带 TTL 的权限缓存
+// TTL 应该很短：权限被收回后，最多还能在 TTL 内继续创建链接
+type permissionCache struct {
+	mu      sync.Mutex
+	entries map[string]permissionEntry
+}
+
+func (c *permissionCache) get(userID string, now time.Time) (canCreate, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, found := c.entries[userID]
+	if !found || !now.Before(e.expireAt) {
+		return false, false
+	}
+	return e.canCreate, true
+}
+
+func (c *permissionCache) set(userID string, canCreate bool, expireAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]permissionEntry)
+	}
+	if len(c.entries) >= maxCachedPermissions {
+		now := time.Now()
+		for id, e := range c.entries {
+			if !now.Before(e.expireAt) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= maxCachedPermissions {
+			clear(c.entries)
+		}
+	}
+	c.entries[userID] = permissionEntry{canCreate: canCreate, expireAt: expireAt}
+}
@@ -0,0 +1,16 @@
+package client
+
+import "context"
+
+// TokenSource 为每个请求提供调用用户服务的认证令牌
+// 实现可以缓存并定期刷新令牌 (例如 OAuth2 client credentials)
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken 是一个固定的令牌，适合从环境变量读取的服务间密钥
+type StaticToken string
+
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
@@ -3,34 +3,190 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 )
 
+// 弹性策略的默认值，对应字段为零值时生效
+const (
+	defaultCallTimeout = 500 * time.Millisecond
+	defaultMaxAttempts = 3
+	defaultBackoff     = 50 * time.Millisecond
+	defaultCacheTTL    = 30 * time.Second
+)
+
+// UserServiceClient 调用用户服务查询创建权限
+//
+// 只设置 BaseURL 和 HTTPClient 即可使用，其余字段为零值时采用默认策略：
+// 每次尝试 500ms 超时、最多 3 次尝试 (指数退避)、成功结果缓存 30s、
+// 连续 5 次失败后熔断 10s
 type UserServiceClient struct {
 	BaseURL    string
 	HTTPClient *http.Client
+
+	// TokenSource 为 nil 时不发送 Authorization 头
+	TokenSource TokenSource
+	// CallTimeout 是单次尝试的超时，整体耗时同时受 ctx 的截止时间约束
+	CallTimeout time.Duration
+	// MaxAttempts 是包括首次请求在内的最大尝试次数
+	MaxAttempts int
+	// Backoff 是首次重试前的等待时间，之后每次翻倍
+	Backoff time.Duration
+	// CacheTTL 为负数时关闭权限缓存
+	CacheTTL time.Duration
+	// Breaker 为 nil 时使用默认参数的熔断器
+	Breaker *CircuitBreaker
+
+	once    sync.Once
+	breaker *CircuitBreaker
+	cache   permissionCache
 }
 
 type UserPermissionResponse struct {
 	CanCreate bool `json:"can_create"`
 }
 
+// StatusError 表示用户服务返回了非 200 的状态码
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("user service returned status %d", e.StatusCode)
+}
+
+// retryable 判断该状态码是否值得重试：5xx (501 除外) 和 429
+func (e *StatusError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		(e.StatusCode >= 500 && e.StatusCode != http.StatusNotImplemented)
+}
+
+func (c *UserServiceClient) init() {
+	c.once.Do(func() {
+		c.breaker = c.Breaker
+		if c.breaker == nil {
+			c.breaker = &CircuitBreaker{}
+		}
+	})
+}
+
+// CanCreateLink 查询用户是否有创建短链接的权限
+//
+// 查询是幂等的 GET，因此网络错误、超时、5xx 和 429 都会按退避策略重试；
+// 其他 4xx 说明请求本身有问题，直接返回且不计入熔断。
+// 获取令牌失败与调用方取消 ctx 都不是用户服务的问题，同样不重试、不计入熔断
 func (c *UserServiceClient) CanCreateLink(ctx context.Context, userID string) (bool, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/users/%s/permissions", c.BaseURL, userID), nil)
+	c.init()
+
+	ttl := c.CacheTTL
+	if ttl == 0 {
+		ttl = defaultCacheTTL
+	}
+	if ttl > 0 {
+		if canCreate, ok := c.cache.get(userID, time.Now()); ok {
+			return canCreate, nil
+		}
+	}
+
+	// 令牌在发出请求前获取一次，失败时用户服务根本没有被调用
+	token := ""
+	if c.TokenSource != nil {
+		var err error
+		if token, err = c.TokenSource.Token(ctx); err != nil {
+			return false, fmt.Errorf("get user service token: %w", err)
+		}
+	}
+
+	attempts := c.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+	backoff := c.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return false, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			case <-timer.C:
+			}
+			backoff *= 2
+		}
+
+		if err := c.breaker.Allow(); err != nil {
+			if lastErr != nil {
+				return false, fmt.Errorf("%w (last error: %v)", err, lastErr)
+			}
+			return false, err
+		}
+
+		canCreate, err := c.fetch(ctx, userID, token)
+		if err == nil {
+			c.breaker.Success()
+			if ttl > 0 {
+				c.cache.set(userID, canCreate, time.Now().Add(ttl))
+			}
+			return canCreate, nil
+		}
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			// 用户服务正常响应了请求，只是结果不可用
+			c.breaker.Success()
+			return false, err
+		}
+		if ctx.Err() != nil {
+			// 调用方取消或超时导致的失败与用户服务的健康状况无关
+			c.breaker.Release()
+			return false, err
+		}
+		c.breaker.Failure()
+		lastErr = err
+	}
+	return false, lastErr
+}
+
+// fetch 发出一次带超时的请求，token 为空时不发送 Authorization 头
+func (c *UserServiceClient) fetch(ctx context.Context, userID, token string) (bool, error) {
+	timeout := c.CallTimeout
+	if timeout <= 0 {
+		timeout = defaultCallTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/users/%s/permissions", c.BaseURL, url.PathEscape(userID)), nil)
 	if err != nil {
 		return false, err
 	}
-	// 在真实应用中，这里应该有认证头
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
-	resp, err := c.HTTPClient.Do(req)
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return false, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("user service returned status %d", resp.StatusCode)
+		return false, &StatusError{StatusCode: resp.StatusCode}
 	}
 
 	var permResp UserPermissionResponse
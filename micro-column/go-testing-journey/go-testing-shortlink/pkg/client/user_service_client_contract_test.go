@@ -4,6 +4,7 @@ package client_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -55,3 +56,38 @@ func TestUserServiceClient_Contract(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestUserServiceClient_Contract_Unavailable(t *testing.T) {
+	mockProvider, err := consumer.NewV4Pact(consumer.MockHTTPProviderConfig{
+		Consumer: "ShortlinkService",
+		Provider: "UserService",
+		Host:     "127.0.0.1",
+	})
+	assert.NoError(t, err)
+
+	// 用户服务过载时返回 503，客户端应把它识别为可重试的故障
+	err = mockProvider.
+		AddInteraction().
+		Given("user service is overloaded").
+		UponReceiving("a request to check user 456's permission while overloaded").
+		WithRequest(http.MethodGet, "/users/456/permissions").
+		WillRespondWith(http.StatusServiceUnavailable).
+		ExecuteTest(t, func(config consumer.MockServerConfig) error {
+			// Pact 要求每个交互恰好被调用一次，因此关闭重试
+			userClient := &client.UserServiceClient{
+				BaseURL:     fmt.Sprintf("http://%s:%d", config.Host, config.Port),
+				HTTPClient:  &http.Client{},
+				MaxAttempts: 1,
+			}
+
+			_, err := userClient.CanCreateLink(context.Background(), "456")
+
+			var statusErr *client.StatusError
+			assert.True(t, errors.As(err, &statusErr))
+			assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+
+			return nil
+		})
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,325 @@
+//go:build unit
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// faultServer 是一个可以注入故障的用户服务替身
+type faultServer struct {
+	*httptest.Server
+	calls atomic.Int32
+
+	mu      sync.Mutex
+	faults  []string // 按调用顺序消费的故障: "slow"、"500"、"503"、"429"、"404"、"reset"，空字符串表示正常
+	delay   time.Duration
+	lastReq *http.Request
+}
+
+func newFaultServer(t *testing.T, faults ...string) *faultServer {
+	fs := &faultServer{faults: faults, delay: 200 * time.Millisecond}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs.calls.Add(1)
+		fs.mu.Lock()
+		fs.lastReq = r
+		fault := ""
+		if len(fs.faults) > 0 {
+			fault, fs.faults = fs.faults[0], fs.faults[1:]
+		}
+		fs.mu.Unlock()
+
+		switch fault {
+		case "slow":
+			select {
+			case <-time.After(fs.delay):
+			case <-r.Context().Done():
+				return
+			}
+		case "500":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case "503":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case "429":
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case "404":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "reset":
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"can_create": true}`))
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *faultServer) newClient() *UserServiceClient {
+	return &UserServiceClient{
+		BaseURL:     fs.URL,
+		HTTPClient:  fs.Client(),
+		CallTimeout: 50 * time.Millisecond,
+		Backoff:     time.Millisecond,
+		CacheTTL:    -1,
+	}
+}
+
+func TestUserServiceClient_RetriesTransientFailures(t *testing.T) {
+	testCases := []struct {
+		name   string
+		faults []string
+	}{
+		{"5xx", []string{"500", "503"}},
+		{"rate limited", []string{"429"}},
+		{"latency", []string{"slow"}},
+		{"connection reset", []string{"reset", "reset"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := newFaultServer(t, tc.faults...)
+			c := fs.newClient()
+
+			canCreate, err := c.CanCreateLink(context.Background(), "u1")
+			if err != nil {
+				t.Fatalf("CanCreateLink() returned an unexpected error: %v", err)
+			}
+			if !canCreate {
+				t.Error("CanCreateLink() = false, want true")
+			}
+			if got, want := fs.calls.Load(), int32(len(tc.faults)+1); got != want {
+				t.Errorf("server received %d calls, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestUserServiceClient_GivesUpAfterMaxAttempts(t *testing.T) {
+	fs := newFaultServer(t, "503", "503", "503", "503")
+	c := fs.newClient()
+
+	_, err := c.CanCreateLink(context.Background(), "u1")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("CanCreateLink() error = %v, want a 503 StatusError", err)
+	}
+	if got := fs.calls.Load(); got != defaultMaxAttempts {
+		t.Errorf("server received %d calls, want %d", got, defaultMaxAttempts)
+	}
+}
+
+func TestUserServiceClient_DoesNotRetryClientErrors(t *testing.T) {
+	fs := newFaultServer(t, "404")
+	c := fs.newClient()
+
+	_, err := c.CanCreateLink(context.Background(), "u1")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("CanCreateLink() error = %v, want a 404 StatusError", err)
+	}
+	if got := fs.calls.Load(); got != 1 {
+		t.Errorf("server received %d calls, want 1", got)
+	}
+	if got := c.breaker.State(); got != StateClosed {
+		t.Errorf("breaker state = %q, want %q", got, StateClosed)
+	}
+}
+
+func TestUserServiceClient_RespectsContextDeadline(t *testing.T) {
+	fs := newFaultServer(t, "slow", "slow", "slow")
+	c := fs.newClient()
+	c.CallTimeout = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.CanCreateLink(ctx, "u1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CanCreateLink() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("CanCreateLink() took %v, want it bounded by the context deadline", elapsed)
+	}
+}
+
+func TestUserServiceClient_CanceledCallsDoNotTripBreaker(t *testing.T) {
+	fs := newFaultServer(t, "slow", "slow")
+	c := fs.newClient()
+	c.CallTimeout = time.Second
+	c.Breaker = &CircuitBreaker{FailureThreshold: 1, OpenTimeout: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := c.CanCreateLink(ctx, "u1")
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("call %d: error = %v, want context.DeadlineExceeded", i, err)
+		}
+	}
+	// 调用方自己超时，不能让用户服务被熔断
+	if got := c.breaker.State(); got != StateClosed {
+		t.Fatalf("breaker state = %q, want %q", got, StateClosed)
+	}
+	if got := fs.calls.Load(); got != 2 {
+		t.Errorf("server received %d calls, want 2 (no retry after cancellation)", got)
+	}
+}
+
+// failingToken 是总是失败的 TokenSource
+type failingToken struct{ calls atomic.Int32 }
+
+func (f *failingToken) Token(ctx context.Context) (string, error) {
+	f.calls.Add(1)
+	return "", errors.New("token endpoint unavailable")
+}
+
+func TestUserServiceClient_TokenErrorsAreNotRetried(t *testing.T) {
+	fs := newFaultServer(t)
+	c := fs.newClient()
+	tokens := &failingToken{}
+	c.TokenSource = tokens
+	c.Breaker = &CircuitBreaker{FailureThreshold: 1, OpenTimeout: time.Minute}
+
+	if _, err := c.CanCreateLink(context.Background(), "u1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := tokens.calls.Load(); got != 1 {
+		t.Errorf("token requested %d times, want 1", got)
+	}
+	if got := fs.calls.Load(); got != 0 {
+		t.Errorf("server received %d calls, want 0", got)
+	}
+	if got := c.breaker.State(); got != StateClosed {
+		t.Fatalf("breaker state = %q, want %q", got, StateClosed)
+	}
+}
+
+func TestUserServiceClient_CircuitBreaker(t *testing.T) {
+	fs := newFaultServer(t, "500", "500", "500", "500")
+	c := fs.newClient()
+	c.MaxAttempts = 1
+
+	now := time.Unix(1700000000, 0)
+	c.Breaker = &CircuitBreaker{FailureThreshold: 2, OpenTimeout: time.Minute, now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	// 连续两次失败后熔断
+	for i := 0; i < 2; i++ {
+		if _, err := c.CanCreateLink(ctx, "u1"); err == nil {
+			t.Fatalf("call %d: expected an error", i)
+		}
+	}
+	if got := c.breaker.State(); got != StateOpen {
+		t.Fatalf("breaker state = %q, want %q", got, StateOpen)
+	}
+	if _, err := c.CanCreateLink(ctx, "u1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("CanCreateLink() error = %v, want ErrCircuitOpen", err)
+	}
+	if got := fs.calls.Load(); got != 2 {
+		t.Errorf("server received %d calls while open, want 2", got)
+	}
+
+	// 超时后进入 half-open，探测失败则重新熔断
+	now = now.Add(time.Minute)
+	if got := c.breaker.State(); got != StateHalfOpen {
+		t.Fatalf("breaker state = %q, want %q", got, StateHalfOpen)
+	}
+	if _, err := c.CanCreateLink(ctx, "u1"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("probe error = %v, want the upstream failure", err)
+	}
+	if got := c.breaker.State(); got != StateOpen {
+		t.Fatalf("breaker state after failed probe = %q, want %q", got, StateOpen)
+	}
+
+	// 清除剩余的故障，再次超时后探测成功
+	fs.mu.Lock()
+	fs.faults = nil
+	fs.mu.Unlock()
+	now = now.Add(time.Minute)
+	canCreate, err := c.CanCreateLink(ctx, "u1")
+	if err != nil || !canCreate {
+		t.Fatalf("probe = (%v, %v), want (true, nil)", canCreate, err)
+	}
+	if got := c.breaker.State(); got != StateClosed {
+		t.Errorf("breaker state after successful probe = %q, want %q", got, StateClosed)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenAllowsSingleProbe(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := &CircuitBreaker{FailureThreshold: 1, OpenTimeout: time.Second, now: func() time.Time { return now }}
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() on a closed breaker returned %v", err)
+	}
+	b.Failure()
+	now = now.Add(time.Second)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("first half-open Allow() returned %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second half-open Allow() = %v, want ErrCircuitOpen", err)
+	}
+	b.Success()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() after recovery returned %v", err)
+	}
+}
+
+func TestUserServiceClient_CachesPermissions(t *testing.T) {
+	fs := newFaultServer(t)
+	c := fs.newClient()
+	c.CacheTTL = time.Minute
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.CanCreateLink(ctx, "u1"); err != nil {
+			t.Fatalf("CanCreateLink() returned an unexpected error: %v", err)
+		}
+	}
+	if got := fs.calls.Load(); got != 1 {
+		t.Errorf("server received %d calls, want 1", got)
+	}
+
+	// 失败结果不缓存
+	fs.mu.Lock()
+	fs.faults = []string{"404"}
+	fs.mu.Unlock()
+	if _, err := c.CanCreateLink(ctx, "u2"); err == nil {
+		t.Fatal("expected an error for u2")
+	}
+	if _, err := c.CanCreateLink(ctx, "u2"); err != nil {
+		t.Fatalf("CanCreateLink(u2) after recovery returned %v", err)
+	}
+}
+
+func TestUserServiceClient_SendsBearerToken(t *testing.T) {
+	fs := newFaultServer(t)
+	c := fs.newClient()
+	c.TokenSource = StaticToken("s3cret")
+
+	if _, err := c.CanCreateLink(context.Background(), "u1"); err != nil {
+		t.Fatalf("CanCreateLink() returned an unexpected error: %v", err)
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if got := fs.lastReq.Header.Get("Authorization"); got != "Bearer s3cret" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer s3cret")
+	}
+}
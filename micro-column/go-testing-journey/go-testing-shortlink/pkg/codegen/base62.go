@@ -0,0 +1,53 @@
+// pkg/codegen/base62.go
+package codegen
+
+import (
+	"fmt"
+	"strings"
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// EncodeBase62 把 n 编码为 base62，不足 width 位时左侧补 '0'
+// 与 base64 不同，结果只包含字母和数字，放在 URL 里不需要转义
+func EncodeBase62(n uint64, width int) string {
+	var buf [11]byte // 2^64 在 base62 下最多 11 位
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+	s := string(buf[i:])
+	if len(s) < width {
+		s = strings.Repeat("0", width-len(s)) + s
+	}
+	return s
+}
+
+// DecodeBase62 是 EncodeBase62 的逆运算
+func DecodeBase62(s string) (uint64, error) {
+	var n uint64
+	for _, c := range []byte(s) {
+		d := strings.IndexByte(base62Alphabet, c)
+		if d < 0 {
+			return 0, fmt.Errorf("base62: invalid character %q", c)
+		}
+		next := n*62 + uint64(d)
+		if next/62 != n {
+			return 0, fmt.Errorf("base62: %q overflows uint64", s)
+		}
+		n = next
+	}
+	return n, nil
+}
+
+// base62Width 返回 [0, max] 中的数编码后的最大长度
+func base62Width(max uint64) int {
+	w := 1
+	for max >= 62 {
+		max /= 62
+		w++
+	}
+	return w
+}
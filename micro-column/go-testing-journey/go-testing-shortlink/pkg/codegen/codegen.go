@@ -0,0 +1,34 @@
+// pkg/codegen/codegen.go
+package codegen
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrExhausted 表示 ID 空间已经用完，需要扩大 Feistel 的位数
+var ErrExhausted = errors.New("short code space exhausted")
+
+// CodeGenerator 生成候选短码
+//
+// 目前有三种实现：
+//   - RandomGenerator: 随机字节，实现简单，但随着短码增多冲突概率上升，需要重试
+//   - SequenceGenerator + 数据库序列: 每个短码一次数据库往返，全局不重复
+//   - SequenceGenerator + RangeAllocator: 每个实例一次租用一段 ID，绝大多数短码不访问数据库
+//
+// 后两者都用 Feistel 置换打乱 ID，短码不随时间递增，无法被顺序猜测
+type CodeGenerator interface {
+	NextCode(ctx context.Context) (string, error)
+}
+
+// GeneratorFunc 把普通函数适配为 CodeGenerator，主要用于测试
+type GeneratorFunc func(ctx context.Context) (string, error)
+
+func (f GeneratorFunc) NextCode(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// IDSource 提供全局唯一的非负整数 ID
+type IDSource interface {
+	NextID(ctx context.Context) (uint64, error)
+}
@@ -0,0 +1,27 @@
+// pkg/codegen/random.go
+package codegen
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+)
+
+// RandomGenerator 用随机字节生成短码，是最初的实现
+// 不需要任何协调，但不能保证唯一，调用方需要在冲突时重试
+type RandomGenerator struct {
+	length int // 随机字节数，编码后的短码长度约为 length*4/3
+}
+
+func NewRandomGenerator(length int) *RandomGenerator {
+	return &RandomGenerator{length: length}
+}
+
+func (g *RandomGenerator) NextCode(ctx context.Context) (string, error) {
+	bytes := make([]byte, g.length)
+	if _, err := io.ReadFull(rand.Reader, bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
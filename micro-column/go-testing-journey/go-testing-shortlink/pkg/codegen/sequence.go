@@ -0,0 +1,36 @@
+// pkg/codegen/sequence.go
+package codegen
+
+import (
+	"context"
+	"fmt"
+)
+
+// SequenceGenerator 把 IDSource 分配的 ID 经 Feistel 置换后编码为定长的 base62 短码
+// 只要 ID 不重复，短码就不会重复，不需要冲突重试
+type SequenceGenerator struct {
+	ids   IDSource
+	perm  *Feistel
+	width int
+}
+
+// NewSequenceGenerator 创建 SequenceGenerator
+// 短码长度由置换的位数决定，例如 36 位对应 7 个字符，可容纳约 687 亿个短码
+func NewSequenceGenerator(ids IDSource, perm *Feistel) *SequenceGenerator {
+	return &SequenceGenerator{
+		ids:   ids,
+		perm:  perm,
+		width: base62Width(perm.Max()),
+	}
+}
+
+func (g *SequenceGenerator) NextCode(ctx context.Context) (string, error) {
+	id, err := g.ids.NextID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("next id: %w", err)
+	}
+	if id > g.perm.Max() {
+		return "", ErrExhausted
+	}
+	return EncodeBase62(g.perm.Permute(id), g.width), nil
+}
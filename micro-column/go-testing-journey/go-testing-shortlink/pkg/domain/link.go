@@ -9,4 +9,21 @@ type Link struct {
 	OriginalURL string
 	ShortCode   string
 	CreatedAt   time.Time
+
+	// OwnerID 是创建者的用户 ID，匿名创建的链接为空，且不能被修改或删除
+	OwnerID string
+	// ExpiresAt 为 nil 表示永不过期
+	ExpiresAt *time.Time
+	// MaxVisits 为 0 表示不限制访问次数
+	MaxVisits int64
+}
+
+// IsExpired 判断链接在 now 时刻是否已过期
+func (l *Link) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// IsExhausted 判断链接的访问次数是否已达上限
+func (l *Link) IsExhausted(visits int64) bool {
+	return l.MaxVisits > 0 && visits >= l.MaxVisits
 }
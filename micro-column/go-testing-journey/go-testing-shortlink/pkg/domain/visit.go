@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+)
+
+// Visit 是一次跳转产生的访问记录
+// 出于隐私考虑，不保存原始 IP 与 User-Agent，只保留归类后的结果
+type Visit struct {
+	ShortCode string
+	VisitedAt time.Time
+	Referrer  string // 来源站点的 host，直接访问时为空
+	UAClass   string // desktop / mobile / tablet / bot / other
+	Country   string // ISO 3166-1 两位国家代码，未知时为空
+	VisitorID string // IP + User-Agent 的哈希，只用于 UV 估算，不落库
+}
+
+// 统计的时间粒度
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+)
+
+// VisitBucket 是时间序列中的一个点
+type VisitBucket struct {
+	Start  time.Time
+	Visits int64
+}
+
+// ReferrerCount 是某个来源的访问次数
+type ReferrerCount struct {
+	Referrer string
+	Visits   int64
+}
+
+// LinkStats 是一个短链接的访问统计
+type LinkStats struct {
+	Visits         int64
+	UniqueVisitors int64 // HyperLogLog 估算值，标准误差约 0.81%
+	Bucket         string
+	Series         []VisitBucket
+	TopReferrers   []ReferrerCount
+}
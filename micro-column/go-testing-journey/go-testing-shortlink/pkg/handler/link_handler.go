@@ -3,58 +3,211 @@ package handler
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/bigwhite/shortlink/pkg/analytics"
 	"github.com/bigwhite/shortlink/pkg/domain"
+	"github.com/bigwhite/shortlink/pkg/service"
 )
 
+// HeaderUserID 携带调用方的用户 ID
+//
+// 本服务自身不做认证，X-User-ID 必须由可信网关在完成认证后注入：
+// 网关需要删除客户端传入的同名头，并附带 HeaderGatewayToken 证明请求确实经过了网关。
+// 服务可能被直接访问 (绕过网关)，因此只有 token 匹配时才采信 X-User-ID，否则返回 401
+const HeaderUserID = "X-User-ID"
+
+// HeaderGatewayToken 携带网关与本服务之间的共享密钥
+const HeaderGatewayToken = "X-Gateway-Token"
+
 // 定义 service 接口，这是 handler 依赖的契约
 type LinkService interface {
-	CreateLink(ctx context.Context, originalURL string) (*domain.Link, error)
-	Redirect(ctx context.Context, code string) (*domain.Link, error)
-	// 假设 GetStats 是 service 层的一个新方法
-	GetStats(ctx context.Context, code string) (int64, error)
+	CreateLink(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error)
+	UpdateLink(ctx context.Context, code, userID string, upd service.LinkUpdate) (*domain.Link, error)
+	DeleteLink(ctx context.Context, code, userID string) error
+	Redirect(ctx context.Context, code string, hit analytics.Hit) (*domain.Link, error)
+	GetStats(ctx context.Context, code string, q service.StatsQuery) (*domain.LinkStats, error)
 }
 
 // LinkHandler 持有其依赖
 type LinkHandler struct {
-	service LinkService
+	service      LinkService
+	gatewayToken string // 为空表示没有可信网关，一律拒绝 X-User-ID
 }
 
-func NewLinkHandler(svc LinkService) *LinkHandler {
-	return &LinkHandler{service: svc}
+// Option 用于配置 LinkHandler
+type Option func(*LinkHandler)
+
+// WithGatewayToken 设置可信网关的共享密钥，只有携带该密钥的请求才采信 X-User-ID
+func WithGatewayToken(token string) Option {
+	return func(h *LinkHandler) {
+		h.gatewayToken = token
+	}
+}
+
+func NewLinkHandler(svc LinkService, opts ...Option) *LinkHandler {
+	h := &LinkHandler{service: svc}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// userID 返回网关注入的用户 ID，请求没有携带时返回空字符串
+// 携带了 X-User-ID 但不是来自可信网关时 ok 为 false，调用方应返回 401
+func (h *LinkHandler) userID(r *http.Request) (userID string, ok bool) {
+	userID = r.Header.Get(HeaderUserID)
+	if userID == "" {
+		return "", true
+	}
+	token := r.Header.Get(HeaderGatewayToken)
+	if h.gatewayToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.gatewayToken)) != 1 {
+		return "", false
+	}
+	return userID, true
+}
+
+// writeUntrustedUser 响应绕过网关、自行携带 X-User-ID 的请求
+func writeUntrustedUser(w http.ResponseWriter) {
+	writeProblem(w, http.StatusUnauthorized, "untrusted_user", HeaderUserID+" is only accepted from the trusted gateway")
 }
 
 // CreateLinkRequest 定义了创建链接请求的 JSON 结构
 type CreateLinkRequest struct {
-	URL string `json:"url"`
+	URL       string     `json:"url"`
+	Alias     string     `json:"alias,omitempty"`      // 可选，自定义短码
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 可选，RFC 3339 格式
+	MaxVisits int64      `json:"max_visits,omitempty"` // 可选，0 表示不限制
 }
 
 // CreateLinkResponse 定义了成功创建后的响应 JSON 结构
 type CreateLinkResponse struct {
-	ShortCode string `json:"short_code"`
+	ShortCode string     `json:"short_code"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxVisits int64      `json:"max_visits,omitempty"`
+}
+
+// UpdateLinkRequest 定义了修改链接请求的 JSON 结构，字段为 nil 表示不修改
+type UpdateLinkRequest struct {
+	URL         *string    `json:"url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ClearExpiry bool       `json:"clear_expiry,omitempty"` // 为 true 时移除过期时间
+	MaxVisits   *int64     `json:"max_visits,omitempty"`
+}
+
+// LinkResponse 是链接资源的完整表示
+type LinkResponse struct {
+	ShortCode   string     `json:"short_code"`
+	OriginalURL string     `json:"url"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxVisits   int64      `json:"max_visits,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 // CreateLink 是处理创建短链接请求的 http.HandlerFunc
 func (h *LinkHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
 	var req CreateLinkRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeProblem(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
 		return
 	}
 
-	link, err := h.service.CreateLink(r.Context(), req.URL)
+	var opts []service.LinkOption
+	if req.Alias != "" {
+		opts = append(opts, service.WithAlias(req.Alias))
+	}
+	if req.ExpiresAt != nil {
+		opts = append(opts, service.WithExpiresAt(*req.ExpiresAt))
+	}
+	if req.MaxVisits != 0 {
+		opts = append(opts, service.WithMaxVisits(req.MaxVisits))
+	}
+	userID, ok := h.userID(r)
+	if !ok {
+		writeUntrustedUser(w)
+		return
+	}
+	if userID != "" {
+		opts = append(opts, service.WithOwner(userID))
+	}
+
+	link, err := h.service.CreateLink(r.Context(), req.URL, opts...)
 	if err != nil {
-		// 在真实项目中，这里应该根据 error 类型返回更精细的状态码
-		http.Error(w, "Failed to create link", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to create link")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(CreateLinkResponse{ShortCode: link.ShortCode})
+	json.NewEncoder(w).Encode(CreateLinkResponse{
+		ShortCode: link.ShortCode,
+		ExpiresAt: link.ExpiresAt,
+		MaxVisits: link.MaxVisits,
+	})
+}
+
+// UpdateLink 处理 PATCH /api/links/{code}
+func (h *LinkHandler) UpdateLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(r)
+	if !ok {
+		writeUntrustedUser(w)
+		return
+	}
+	if userID == "" {
+		writeProblem(w, http.StatusUnauthorized, "missing_user", "Missing "+HeaderUserID)
+		return
+	}
+
+	var req UpdateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+
+	link, err := h.service.UpdateLink(r.Context(), r.PathValue("code"), userID, service.LinkUpdate{
+		OriginalURL: req.URL,
+		ExpiresAt:   req.ExpiresAt,
+		ClearExpiry: req.ClearExpiry,
+		MaxVisits:   req.MaxVisits,
+	})
+	if err != nil {
+		writeServiceError(w, err, "Failed to update link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(LinkResponse{
+		ShortCode:   link.ShortCode,
+		OriginalURL: link.OriginalURL,
+		ExpiresAt:   link.ExpiresAt,
+		MaxVisits:   link.MaxVisits,
+		CreatedAt:   link.CreatedAt,
+	})
+}
+
+// DeleteLink 处理 DELETE /api/links/{code}
+func (h *LinkHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(r)
+	if !ok {
+		writeUntrustedUser(w)
+		return
+	}
+	if userID == "" {
+		writeProblem(w, http.StatusUnauthorized, "missing_user", "Missing "+HeaderUserID)
+		return
+	}
+
+	if err := h.service.DeleteLink(r.Context(), r.PathValue("code"), userID); err != nil {
+		writeServiceError(w, err, "Failed to delete link")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *LinkHandler) Redirect(w http.ResponseWriter, r *http.Request) {
@@ -66,11 +219,14 @@ func (h *LinkHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	link, err := h.service.Redirect(r.Context(), code)
+	link, err := h.service.Redirect(r.Context(), code, analytics.Hit{
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+		Referrer:  r.Referer(),
+	})
 	if err != nil {
-		// 在真实项目中，这里应该根据 error 类型返回更精细的状态码
-		// 比如，如果是 "link not found"，应该返回 404
-		http.Error(w, "Link not found or internal error", http.StatusNotFound)
+		// 过期或访问次数耗尽的链接返回 410 Gone，告诉客户端不必再重试
+		writeServiceError(w, err, "Failed to resolve link")
 		return
 	}
 
@@ -78,11 +234,40 @@ func (h *LinkHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, link.OriginalURL, http.StatusFound) // 302 Found
 }
 
+// clientIP 返回客户端 IP
+// 这里只使用 RemoteAddr：X-Forwarded-For 可以被客户端伪造，
+// 部署在反向代理之后时，应由可信的中间件先改写 RemoteAddr
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// StatsBucket 是时间序列中的一个点
+type StatsBucket struct {
+	Start  time.Time `json:"start"`
+	Visits int64     `json:"visits"`
+}
+
+// ReferrerStats 是某个来源的访问次数
+type ReferrerStats struct {
+	Referrer string `json:"referrer"`
+	Visits   int64  `json:"visits"`
+}
+
 // GetStatsResponse 定义了统计接口的响应
 type GetStatsResponse struct {
-	Visits int64 `json:"visits"`
+	Visits         int64           `json:"visits"`
+	UniqueVisitors int64           `json:"unique_visitors"` // HyperLogLog 估算值
+	Bucket         string          `json:"bucket"`
+	Series         []StatsBucket   `json:"series"`
+	TopReferrers   []ReferrerStats `json:"top_referrers"`
 }
 
+// GetStats 处理 GET /api/links/{code}/stats
+// 可选的查询参数：bucket=hour|day (默认 day)，since=RFC 3339 时间 (默认最近 24 小时或 7 天)
 func (h *LinkHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	// 同样，从路径中提取 short_code
 	// e.g., /api/links/{short_code}/stats
@@ -93,15 +278,38 @@ func (h *LinkHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	}
 	code := parts[3]
 
-	visits, err := h.service.GetStats(r.Context(), code)
+	q := service.StatsQuery{Bucket: r.URL.Query().Get("bucket")}
+	if since := r.URL.Query().Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_since", "Invalid since, want RFC 3339")
+			return
+		}
+		q.Since = t
+	}
+
+	stats, err := h.service.GetStats(r.Context(), code, q)
 	if err != nil {
-		http.Error(w, "Failed to get stats", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to get stats")
 		return
 	}
 
+	// 空切片而不是 null，方便客户端直接遍历
+	resp := GetStatsResponse{
+		Visits:         stats.Visits,
+		UniqueVisitors: stats.UniqueVisitors,
+		Bucket:         stats.Bucket,
+		Series:         make([]StatsBucket, 0, len(stats.Series)),
+		TopReferrers:   make([]ReferrerStats, 0, len(stats.TopReferrers)),
+	}
+	for _, b := range stats.Series {
+		resp.Series = append(resp.Series, StatsBucket{Start: b.Start, Visits: b.Visits})
+	}
+	for _, rc := range stats.TopReferrers {
+		resp.TopReferrers = append(resp.TopReferrers, ReferrerStats{Referrer: rc.Referrer, Visits: rc.Visits})
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(GetStatsResponse{Visits: visits})
+	json.NewEncoder(w).Encode(resp)
 }
-
-
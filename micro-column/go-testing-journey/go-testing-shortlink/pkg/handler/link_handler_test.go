@@ -6,42 +6,62 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/bigwhite/shortlink/pkg/analytics"
 	"github.com/bigwhite/shortlink/pkg/domain"
 	"github.com/bigwhite/shortlink/pkg/handler"
+	"github.com/bigwhite/shortlink/pkg/service"
+	"github.com/bigwhite/shortlink/pkg/validator"
 )
 
 // 1. 创建 Stub Object
 // StubLinkService 是 LinkService 接口的一个测试替身
 type StubLinkService struct {
-	CreateLinkFunc func(ctx context.Context, originalURL string) (*domain.Link, error)
-	RedirectFunc   func(ctx context.Context, code string) (*domain.Link, error)
-	GetStatsFunc   func(ctx context.Context, code string) (int64, error)
+	CreateLinkFunc func(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error)
+	UpdateLinkFunc func(ctx context.Context, code, userID string, upd service.LinkUpdate) (*domain.Link, error)
+	DeleteLinkFunc func(ctx context.Context, code, userID string) error
+	RedirectFunc   func(ctx context.Context, code string, hit analytics.Hit) (*domain.Link, error)
+	GetStatsFunc   func(ctx context.Context, code string, q service.StatsQuery) (*domain.LinkStats, error)
 }
 
-func (s *StubLinkService) Redirect(ctx context.Context, code string) (*domain.Link, error) {
+func (s *StubLinkService) Redirect(ctx context.Context, code string, hit analytics.Hit) (*domain.Link, error) {
 	if s.RedirectFunc != nil {
-		return s.RedirectFunc(ctx, code)
+		return s.RedirectFunc(ctx, code, hit)
 	}
 	return nil, errors.New("RedirectFunc not implemented")
 }
 
-func (s *StubLinkService) CreateLink(ctx context.Context, originalURL string) (*domain.Link, error) {
+func (s *StubLinkService) CreateLink(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error) {
 	if s.CreateLinkFunc != nil {
-		return s.CreateLinkFunc(ctx, originalURL)
+		return s.CreateLinkFunc(ctx, originalURL, opts...)
 	}
 	return nil, errors.New("CreateLinkFunc not implemented")
 }
 
-func (s *StubLinkService) GetStats(ctx context.Context, code string) (int64, error) {
+func (s *StubLinkService) UpdateLink(ctx context.Context, code, userID string, upd service.LinkUpdate) (*domain.Link, error) {
+	if s.UpdateLinkFunc != nil {
+		return s.UpdateLinkFunc(ctx, code, userID, upd)
+	}
+	return nil, errors.New("UpdateLinkFunc not implemented")
+}
+
+func (s *StubLinkService) DeleteLink(ctx context.Context, code, userID string) error {
+	if s.DeleteLinkFunc != nil {
+		return s.DeleteLinkFunc(ctx, code, userID)
+	}
+	return errors.New("DeleteLinkFunc not implemented")
+}
+
+func (s *StubLinkService) GetStats(ctx context.Context, code string, q service.StatsQuery) (*domain.LinkStats, error) {
 	if s.GetStatsFunc != nil {
-		return s.GetStatsFunc(ctx, code)
+		return s.GetStatsFunc(ctx, code, q)
 	}
-	return 0, errors.New("GetStatsFunc not implemented")
+	return nil, errors.New("GetStatsFunc not implemented")
 }
 
 func TestLinkHandler_CreateLink(t *testing.T) {
@@ -57,7 +77,7 @@ func TestLinkHandler_CreateLink(t *testing.T) {
 			name:    "成功创建",
 			reqBody: `{"url": "https://example.com"}`,
 			stub: &StubLinkService{
-				CreateLinkFunc: func(ctx context.Context, originalURL string) (*domain.Link, error) {
+				CreateLinkFunc: func(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error) {
 					return &domain.Link{ShortCode: "success"}, nil
 				},
 			},
@@ -75,13 +95,35 @@ func TestLinkHandler_CreateLink(t *testing.T) {
 			name:    "Service 返回错误",
 			reqBody: `{"url": "https://example.com"}`,
 			stub: &StubLinkService{
-				CreateLinkFunc: func(ctx context.Context, originalURL string) (*domain.Link, error) {
+				CreateLinkFunc: func(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error) {
 					return nil, errors.New("internal error")
 				},
 			},
 			wantStatusCode: http.StatusInternalServerError,
 			wantRespBody:   `Failed to create link`,
 		},
+		{
+			name:    "别名已被占用",
+			reqBody: `{"url": "https://example.com", "alias": "golang"}`,
+			stub: &StubLinkService{
+				CreateLinkFunc: func(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error) {
+					return nil, service.ErrAliasTaken
+				},
+			},
+			wantStatusCode: http.StatusConflict,
+			wantRespBody:   `alias already taken`,
+		},
+		{
+			name:    "URL 违反安全策略",
+			reqBody: `{"url": "http://127.0.0.1/admin"}`,
+			stub: &StubLinkService{
+				CreateLinkFunc: func(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error) {
+					return nil, fmt.Errorf("%w: %w", service.ErrUnsafeURL, &validator.Violation{Host: "127.0.0.1", Reason: validator.ErrPrivateAddress})
+				},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantRespBody:   `unsafe URL: url rejected (host "127.0.0.1"): URL points to a private, loopback or link-local address`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -119,10 +161,309 @@ func TestLinkHandler_CreateLink(t *testing.T) {
 					t.Errorf("response body got %v, want %v", got, want)
 				}
 			} else {
-				if trimmedBody != tc.wantRespBody {
-					t.Errorf("response body got %q, want %q", trimmedBody, tc.wantRespBody)
+				// 错误响应是 application/problem+json，比较其中的 detail
+				assertProblem(t, rr, tc.wantStatusCode, "", tc.wantRespBody)
+			}
+		})
+	}
+}
+
+// testGatewayToken 是测试中网关与服务的共享密钥
+const testGatewayToken = "gateway-secret"
+
+// TestLinkHandler_UserHeaderRequiresGateway 验证 X-User-ID 只在携带网关密钥时被采信
+func TestLinkHandler_UserHeaderRequiresGateway(t *testing.T) {
+	testCases := []struct {
+		name         string
+		handlerToken string // 服务配置的网关密钥
+		reqToken     string // 请求携带的网关密钥
+		wantStatus   int
+	}{
+		{"未配置网关时拒绝用户身份", "", "", http.StatusUnauthorized},
+		{"网关密钥错误", testGatewayToken, "guess", http.StatusUnauthorized},
+		{"缺少网关密钥", testGatewayToken, "", http.StatusUnauthorized},
+		{"来自可信网关", testGatewayToken, testGatewayToken, http.StatusCreated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &StubLinkService{
+				CreateLinkFunc: func(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error) {
+					return &domain.Link{ShortCode: "abc123"}, nil
+				},
+			}
+			req := httptest.NewRequest("POST", "/api/links", strings.NewReader(`{"url": "https://example.com"}`))
+			req.Header.Set(handler.HeaderUserID, "alice")
+			if tc.reqToken != "" {
+				req.Header.Set(handler.HeaderGatewayToken, tc.reqToken)
+			}
+			rr := httptest.NewRecorder()
+
+			linkHandler := handler.NewLinkHandler(stub, handler.WithGatewayToken(tc.handlerToken))
+			http.HandlerFunc(linkHandler.CreateLink).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status code got %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusUnauthorized {
+				assertProblem(t, rr, http.StatusUnauthorized, "untrusted_user", "")
+			}
+		})
+	}
+}
+
+func TestLinkHandler_DeleteLink(t *testing.T) {
+	testCases := []struct {
+		name           string
+		userID         string
+		bypassGateway  bool // 为 true 时不携带网关密钥
+		stub           *StubLinkService
+		wantStatusCode int
+	}{
+		{
+			name:   "所有者删除成功",
+			userID: "alice",
+			stub: &StubLinkService{
+				DeleteLinkFunc: func(ctx context.Context, code, userID string) error {
+					if code != "abc123" || userID != "alice" {
+						return errors.New("unexpected arguments")
+					}
+					return nil
+				},
+			},
+			wantStatusCode: http.StatusNoContent,
+		},
+		{
+			name:           "缺少用户身份",
+			userID:         "",
+			stub:           &StubLinkService{}, // 不会被调用
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "绕过网关伪造用户身份",
+			userID:         "alice",
+			bypassGateway:  true,
+			stub:           &StubLinkService{}, // 不会被调用
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "不是所有者",
+			userID: "bob",
+			stub: &StubLinkService{
+				DeleteLinkFunc: func(ctx context.Context, code, userID string) error {
+					return service.ErrPermissionDenied
+				},
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:   "链接不存在",
+			userID: "alice",
+			stub: &StubLinkService{
+				DeleteLinkFunc: func(ctx context.Context, code, userID string) error {
+					return service.ErrLinkNotFound
+				},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", "/api/links/abc123", nil)
+			// 直接调用 Handler 时没有经过 ServeMux，需要手动设置路径参数
+			req.SetPathValue("code", "abc123")
+			if tc.userID != "" {
+				req.Header.Set(handler.HeaderUserID, tc.userID)
+			}
+			if !tc.bypassGateway {
+				req.Header.Set(handler.HeaderGatewayToken, testGatewayToken)
+			}
+			rr := httptest.NewRecorder()
+
+			linkHandler := handler.NewLinkHandler(tc.stub, handler.WithGatewayToken(testGatewayToken))
+			http.HandlerFunc(linkHandler.DeleteLink).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatusCode {
+				t.Errorf("status code got %d, want %d", rr.Code, tc.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestLinkHandler_GetStats(t *testing.T) {
+	testCases := []struct {
+		name           string
+		target         string
+		stub           *StubLinkService
+		wantStatusCode int
+		wantRespBody   string
+	}{
+		{
+			name:   "按小时查询",
+			target: "/api/links/abc123/stats?bucket=hour",
+			stub: &StubLinkService{
+				GetStatsFunc: func(ctx context.Context, code string, q service.StatsQuery) (*domain.LinkStats, error) {
+					if code != "abc123" || q.Bucket != domain.BucketHour {
+						return nil, errors.New("unexpected arguments")
+					}
+					return &domain.LinkStats{Visits: 3, UniqueVisitors: 2, Bucket: domain.BucketHour}, nil
+				},
+			},
+			wantStatusCode: http.StatusOK,
+			wantRespBody:   `{"visits":3,"unique_visitors":2,"bucket":"hour","series":[],"top_referrers":[]}`,
+		},
+		{
+			name:           "since 格式错误",
+			target:         "/api/links/abc123/stats?since=yesterday",
+			stub:           &StubLinkService{}, // 不会被调用
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "bucket 不支持",
+			target: "/api/links/abc123/stats?bucket=week",
+			stub: &StubLinkService{
+				GetStatsFunc: func(ctx context.Context, code string, q service.StatsQuery) (*domain.LinkStats, error) {
+					return nil, service.ErrInvalidStatsRange
+				},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "链接不存在",
+			target: "/api/links/abc123/stats",
+			stub: &StubLinkService{
+				GetStatsFunc: func(ctx context.Context, code string, q service.StatsQuery) (*domain.LinkStats, error) {
+					return nil, service.ErrLinkNotFound
+				},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.target, nil)
+			rr := httptest.NewRecorder()
+
+			linkHandler := handler.NewLinkHandler(tc.stub)
+			http.HandlerFunc(linkHandler.GetStats).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatusCode {
+				t.Errorf("status code got %d, want %d", rr.Code, tc.wantStatusCode)
+			}
+			if tc.wantRespBody != "" {
+				if got := strings.TrimSpace(rr.Body.String()); got != tc.wantRespBody {
+					t.Errorf("response body got %s, want %s", got, tc.wantRespBody)
+				}
+			}
+		})
+	}
+}
+
+// assertProblem 断言响应是 application/problem+json，code 或 detail 为空时不比较
+func assertProblem(t *testing.T, rr *httptest.ResponseRecorder, wantStatus int, wantCode, wantDetail string) {
+	t.Helper()
+	if ct := rr.Header().Get("Content-Type"); ct != handler.ContentTypeProblem {
+		t.Fatalf("Content-Type got %q, want %q", ct, handler.ContentTypeProblem)
+	}
+	var p handler.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal problem: %v", err)
+	}
+	if p.Status != wantStatus || p.Title != http.StatusText(wantStatus) {
+		t.Errorf("problem status got %d %q, want %d", p.Status, p.Title, wantStatus)
+	}
+	if wantCode != "" && p.Code != wantCode {
+		t.Errorf("problem code got %q, want %q", p.Code, wantCode)
+	}
+	if wantDetail != "" && p.Detail != wantDetail {
+		t.Errorf("problem detail got %q, want %q", p.Detail, wantDetail)
+	}
+}
+
+// TestLinkHandler_ErrorMapping 覆盖 service 错误到 HTTP 状态码与错误码的每一种映射
+func TestLinkHandler_ErrorMapping(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"无效输入", service.ErrInvalidURL, http.StatusBadRequest, "invalid_url", "invalid URL"},
+		{"包装了校验原因的无效输入", fmt.Errorf("%w: %w", service.ErrInvalidAlias, validator.ErrAliasReserved), http.StatusBadRequest, "invalid_alias", "invalid alias: alias is reserved"},
+		// 包装的原因不是校验结果时，detail 中只有业务错误的 Message
+		{"包装了未知原因的无效输入", fmt.Errorf("%w: too short", service.ErrInvalidAlias), http.StatusBadRequest, "invalid_alias", "invalid alias"},
+		{"违反策略", service.ErrUnsafeURL, http.StatusUnprocessableEntity, "unsafe_url", "unsafe URL"},
+		{"无权限", service.ErrPermissionDenied, http.StatusForbidden, "permission_denied", "permission denied"},
+		{"不存在", service.ErrLinkNotFound, http.StatusNotFound, "link_not_found", "link not found"},
+		{"冲突", service.ErrAliasTaken, http.StatusConflict, "alias_taken", "alias already taken"},
+		{"已过期", service.ErrLinkExpired, http.StatusGone, "link_expired", "link expired"},
+		// 上游的网络错误只写日志，不能出现在响应中
+		{"上游不可用", fmt.Errorf("%w: %w", service.ErrUserServiceDown, errors.New("dial tcp 10.0.3.7:8090: i/o timeout")), http.StatusServiceUnavailable, "user_service_unavailable", "user service unavailable"},
+		{"短码重试耗尽", service.ErrCodeSpaceBusy, http.StatusServiceUnavailable, "short_code_unavailable", ""},
+		// 未分类的错误不能把内部细节带到响应中
+		{"内部错误", errors.New("pq: connection refused"), http.StatusInternalServerError, "internal", "Failed to create link"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &StubLinkService{
+				CreateLinkFunc: func(ctx context.Context, originalURL string, opts ...service.LinkOption) (*domain.Link, error) {
+					return nil, tc.err
+				},
+			}
+			req := httptest.NewRequest("POST", "/api/links", strings.NewReader(`{"url": "https://example.com"}`))
+			rr := httptest.NewRecorder()
+
+			http.HandlerFunc(handler.NewLinkHandler(stub).CreateLink).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status code got %d, want %d", rr.Code, tc.wantStatus)
+			}
+			assertProblem(t, rr, tc.wantStatus, tc.wantCode, tc.wantDetail)
+		})
+	}
+}
+
+func TestLinkHandler_Redirect(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"成功跳转", nil, http.StatusFound},
+		{"不存在", service.ErrLinkNotFound, http.StatusNotFound},
+		{"已过期", service.ErrLinkExpired, http.StatusGone},
+		{"数据库故障", errors.New("pq: connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &StubLinkService{
+				RedirectFunc: func(ctx context.Context, code string, hit analytics.Hit) (*domain.Link, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &domain.Link{ShortCode: code, OriginalURL: "https://go.dev"}, nil
+				},
+			}
+			req := httptest.NewRequest("GET", "/abc123", nil)
+			rr := httptest.NewRecorder()
+
+			http.HandlerFunc(handler.NewLinkHandler(stub).Redirect).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status code got %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if tc.err == nil {
+				if loc := rr.Header().Get("Location"); loc != "https://go.dev" {
+					t.Errorf("Location got %q, want %q", loc, "https://go.dev")
 				}
+				return
 			}
+			assertProblem(t, rr, tc.wantStatus, "", "")
 		})
 	}
 }
@@ -0,0 +1,95 @@
+// pkg/handler/problem.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/bigwhite/shortlink/pkg/service"
+	"github.com/bigwhite/shortlink/pkg/validator"
+)
+
+// ContentTypeProblem 是 RFC 9457 定义的错误响应类型
+const ContentTypeProblem = "application/problem+json"
+
+// Problem 是 application/problem+json 响应体
+// Type 固定为 "about:blank"，此时 Title 即状态码的标准描述；Code 是扩展字段，供客户端按错误码分支
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+// statusByKind 是 service 错误类别到 HTTP 状态码的映射
+var statusByKind = []struct {
+	kind   error
+	status int
+}{
+	{service.ErrInvalidInput, http.StatusBadRequest},
+	{service.ErrRejected, http.StatusUnprocessableEntity},
+	{service.ErrForbidden, http.StatusForbidden},
+	{service.ErrNotFound, http.StatusNotFound},
+	{service.ErrConflict, http.StatusConflict},
+	{service.ErrExpired, http.StatusGone},
+	{service.ErrUnavailable, http.StatusServiceUnavailable},
+}
+
+// writeProblem 写出一个错误响应
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	})
+}
+
+// writeServiceError 是 service 错误到 HTTP 响应的集中映射
+// 业务错误按类别返回 4xx/503，detail 只包含业务错误自身的 Message 与 publicDetail；
+// 其他错误 (如数据库故障) 一律 500，响应中只有 fallback。原始错误只写日志，避免泄露内部细节
+func writeServiceError(w http.ResponseWriter, err error, fallback string) {
+	var svcErr *service.Error
+	if errors.As(err, &svcErr) {
+		for _, m := range statusByKind {
+			if errors.Is(svcErr.Kind, m.kind) {
+				// 包装了底层原因的错误 (如用户服务的网络错误) 在日志中保留完整信息
+				if err != error(svcErr) {
+					log.Printf("WARN: %s: %v", fallback, err)
+				}
+				detail := svcErr.Message
+				if d := publicDetail(err); d != "" {
+					detail += ": " + d
+				}
+				writeProblem(w, m.status, svcErr.Code, detail)
+				return
+			}
+		}
+	}
+	log.Printf("ERROR: %s: %v", fallback, err)
+	writeProblem(w, http.StatusInternalServerError, "internal", fallback)
+}
+
+// publicAliasErrors 是别名校验的具体原因，都只描述用户的输入，可以返回给客户端
+var publicAliasErrors = []error{validator.ErrAliasLength, validator.ErrAliasCharset, validator.ErrAliasReserved}
+
+// publicDetail 从错误链中取出可以返回给客户端的细节
+// 只有校验用户输入得到的原因是安全的，其余原因 (网络错误、数据库错误等) 返回空字符串
+func publicDetail(err error) string {
+	var v *validator.Violation
+	if errors.As(err, &v) {
+		return v.Error()
+	}
+	for _, e := range publicAliasErrors {
+		if errors.Is(err, e) {
+			return e.Error()
+		}
+	}
+	return ""
+}
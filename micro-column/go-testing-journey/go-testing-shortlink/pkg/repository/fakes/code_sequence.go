@@ -0,0 +1,34 @@
+// pkg/repository/fakes/code_sequence.go
+package fakes
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeCodeSequence 是 PgCodeSequence 的一个内存实现，用于测试
+// 与数据库实现一样，ID 从 1 开始，NextID 与 LeaseRange 共用同一个计数器
+type FakeCodeSequence struct {
+	mu   sync.Mutex
+	last uint64 // 最后一个分配出去的 ID
+	// Leases 记录 LeaseRange 的调用次数
+	Leases int
+}
+
+func (f *FakeCodeSequence) NextID(ctx context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.last++
+	return f.last, nil
+}
+
+func (f *FakeCodeSequence) LeaseRange(ctx context.Context, size uint64) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	start := f.last + 1
+	f.last += size
+	f.Leases++
+	return start, nil
+}
@@ -4,12 +4,26 @@ package fakes
 import (
 	"context"
 	"sync"
+	"time"
+
+	"github.com/bigwhite/shortlink/pkg/domain"
 )
 
+// cachedLink 是一条缓存的链接，link 为 nil 表示负缓存
+type cachedLink struct {
+	link     *domain.Link
+	expireAt time.Time
+}
+
 // FakeLinkCache 是 LinkCache 的一个内存实现，用于测试
 type FakeLinkCache struct {
-	mu     sync.RWMutex
-	counts map[string]int64
+	mu       sync.RWMutex
+	counts   map[string]int64
+	visitors map[string]map[string]struct{} // 用精确集合代替 HyperLogLog
+	links    map[string]cachedLink
+
+	// LinkHits 与 LinkMisses 记录 GetLink 的命中情况，方便测试断言
+	LinkHits, LinkMisses int
 }
 
 func (f *FakeLinkCache) IncrementVisitCount(ctx context.Context, code string) error {
@@ -29,3 +43,110 @@ func (f *FakeLinkCache) GetVisitCount(ctx context.Context, code string) (int64,
 
 	return f.counts[code], nil // 如果 key 不存在，返回 0，符合 Redis 行为
 }
+
+func (f *FakeLinkCache) IncrementVisitCountBelow(ctx context.Context, code string, limit int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.counts[code] >= limit {
+		return false, nil
+	}
+	if f.counts == nil {
+		f.counts = make(map[string]int64)
+	}
+	f.counts[code]++
+	return true, nil
+}
+
+func (f *FakeLinkCache) RecordVisits(ctx context.Context, code string, visitorIDs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.counts == nil {
+		f.counts = make(map[string]int64)
+	}
+	f.counts[code] += int64(len(visitorIDs))
+	f.addVisitorsLocked(code, visitorIDs)
+	return nil
+}
+
+func (f *FakeLinkCache) AddUniqueVisitors(ctx context.Context, code string, visitorIDs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.addVisitorsLocked(code, visitorIDs)
+	return nil
+}
+
+// addVisitorsLocked 把访客加入集合，调用方必须持有写锁
+func (f *FakeLinkCache) addVisitorsLocked(code string, visitorIDs []string) {
+	if f.visitors == nil {
+		f.visitors = make(map[string]map[string]struct{})
+	}
+	set, ok := f.visitors[code]
+	if !ok {
+		set = make(map[string]struct{})
+		f.visitors[code] = set
+	}
+	for _, id := range visitorIDs {
+		set[id] = struct{}{}
+	}
+}
+
+func (f *FakeLinkCache) CountUniqueVisitors(ctx context.Context, code string) (int64, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return int64(len(f.visitors[code])), nil
+}
+
+func (f *FakeLinkCache) GetLink(ctx context.Context, code string) (*domain.Link, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry, ok := f.links[code]
+	if !ok || !time.Now().Before(entry.expireAt) {
+		f.LinkMisses++
+		return nil, false, nil
+	}
+	f.LinkHits++
+	if entry.link == nil {
+		return nil, true, nil
+	}
+	// 与 Redis 实现一样返回副本，调用方修改不会影响缓存
+	linkCopy := *entry.link
+	return &linkCopy, true, nil
+}
+
+func (f *FakeLinkCache) SetLink(ctx context.Context, code string, link *domain.Link, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.links == nil {
+		f.links = make(map[string]cachedLink)
+	}
+	entry := cachedLink{expireAt: time.Now().Add(ttl)}
+	if link != nil {
+		linkCopy := *link
+		entry.link = &linkCopy
+	}
+	f.links[code] = entry
+	return nil
+}
+
+func (f *FakeLinkCache) DeleteLink(ctx context.Context, code string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.links, code)
+	return nil
+}
+
+func (f *FakeLinkCache) DeleteVisits(ctx context.Context, code string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.counts, code)
+	delete(f.visitors, code)
+	return nil
+}
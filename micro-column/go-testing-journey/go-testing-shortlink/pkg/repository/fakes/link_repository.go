@@ -51,3 +51,31 @@ func (f *FakeLinkRepository) Save(ctx context.Context, link *domain.Link) error
 	f.links[link.ShortCode] = link
 	return nil
 }
+
+func (f *FakeLinkRepository) Update(ctx context.Context, link *domain.Link) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, ok := f.links[link.ShortCode]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	// 与 SQL 实现保持一致：只更新可变字段
+	updated := *existing
+	updated.OriginalURL = link.OriginalURL
+	updated.ExpiresAt = link.ExpiresAt
+	updated.MaxVisits = link.MaxVisits
+	f.links[link.ShortCode] = &updated
+	return nil
+}
+
+func (f *FakeLinkRepository) Delete(ctx context.Context, code string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.links[code]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(f.links, code)
+	return nil
+}
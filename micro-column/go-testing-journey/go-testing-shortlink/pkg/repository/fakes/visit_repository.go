@@ -0,0 +1,101 @@
+// pkg/repository/fakes/visit_repository.go
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bigwhite/shortlink/pkg/domain"
+)
+
+// FakeVisitRepository 是 VisitRepository 的一个内存实现，用于测试
+type FakeVisitRepository struct {
+	mu     sync.RWMutex
+	visits []domain.Visit
+	// Err 不为 nil 时，SaveVisits 返回该错误，用于模拟数据库故障
+	Err error
+}
+
+func NewFakeVisitRepository() *FakeVisitRepository {
+	return &FakeVisitRepository{}
+}
+
+func (f *FakeVisitRepository) SaveVisits(ctx context.Context, visits []domain.Visit) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.visits = append(f.visits, visits...)
+	return nil
+}
+
+// Visits 返回已保存的全部访问记录的副本
+func (f *FakeVisitRepository) Visits() []domain.Visit {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return append([]domain.Visit(nil), f.visits...)
+}
+
+func (f *FakeVisitRepository) VisitSeries(ctx context.Context, code string, since time.Time, bucket string) ([]domain.VisitBucket, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	counts := make(map[time.Time]int64)
+	for _, v := range f.visits {
+		if v.ShortCode != code || v.VisitedAt.Before(since) {
+			continue
+		}
+		t := v.VisitedAt.UTC()
+		switch bucket {
+		case domain.BucketHour:
+			t = t.Truncate(time.Hour)
+		case domain.BucketDay:
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		default:
+			return nil, fmt.Errorf("unsupported bucket %q", bucket)
+		}
+		counts[t]++
+	}
+
+	series := make([]domain.VisitBucket, 0, len(counts))
+	for t, n := range counts {
+		series = append(series, domain.VisitBucket{Start: t, Visits: n})
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Start.Before(series[j].Start) })
+	return series, nil
+}
+
+func (f *FakeVisitRepository) TopReferrers(ctx context.Context, code string, since time.Time, limit int) ([]domain.ReferrerCount, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, v := range f.visits {
+		if v.ShortCode != code || v.VisitedAt.Before(since) || v.Referrer == "" {
+			continue
+		}
+		counts[v.Referrer]++
+	}
+
+	top := make([]domain.ReferrerCount, 0, len(counts))
+	for ref, n := range counts {
+		top = append(top, domain.ReferrerCount{Referrer: ref, Visits: n})
+	}
+	// 与 SQL 实现保持一致：按访问量降序，相同时按来源排序
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Visits != top[j].Visits {
+			return top[i].Visits > top[j].Visits
+		}
+		return top[i].Referrer < top[j].Referrer
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
@@ -5,25 +5,64 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/bigwhite/shortlink/pkg/domain"
 )
 
 var ErrCodeConflict = errors.New("short code conflicts")
 
+// ErrNotFound 表示要修改或删除的链接不存在
+// 注意：FindByCode 找不到时仍然返回 (nil, nil)
+var ErrNotFound = errors.New("link not found")
+
 // DBTX 是一个接口，抽象了 *sql.DB 和 *sql.Tx 的共同方法
 // 它只包含我们 Repository 需要用到的方法
 type DBTX interface {
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	// QueryContext 用于返回多行的统计查询
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 }
 
 type LinkRepository interface {
 	FindByCode(ctx context.Context, code string) (*domain.Link, error)
 	Save(ctx context.Context, link *domain.Link) error
+	// Update 按 ShortCode 更新链接的目标地址、过期时间和访问上限
+	Update(ctx context.Context, link *domain.Link) error
+	Delete(ctx context.Context, code string) error
 }
 
-// LinkCache 定义了链接统计的缓存接口
+// LinkCache 定义了链接统计与链接查询的缓存接口
 type LinkCache interface {
 	IncrementVisitCount(ctx context.Context, code string) error
 	GetVisitCount(ctx context.Context, code string) (int64, error)
+	// IncrementVisitCountBelow 在计数小于 limit 时原子地加一，ok 为 false 表示已达上限且计数未变
+	// 用于 max_visits：检查与累加必须是同一个原子操作，否则并发访问会同时通过检查
+	IncrementVisitCountBelow(ctx context.Context, code string, limit int64) (ok bool, err error)
+	// RecordVisits 批量记录一个短码的访问：计数增加 len(visitorIDs)，并把访客加入 HyperLogLog
+	RecordVisits(ctx context.Context, code string, visitorIDs []string) error
+	// AddUniqueVisitors 只把访客加入 HyperLogLog，用于计数已经同步累加过的访问
+	AddUniqueVisitors(ctx context.Context, code string, visitorIDs []string) error
+	// CountUniqueVisitors 返回独立访客数的估算值
+	CountUniqueVisitors(ctx context.Context, code string) (int64, error)
+
+	// GetLink 读取缓存的链接，found 为 false 表示未命中
+	// 命中负缓存 (短码不存在) 时返回 (nil, true, nil)
+	GetLink(ctx context.Context, code string) (link *domain.Link, found bool, err error)
+	// SetLink 缓存链接，link 为 nil 时写入负缓存
+	SetLink(ctx context.Context, code string, link *domain.Link, ttl time.Duration) error
+	// DeleteLink 删除缓存的链接 (包括负缓存)，不影响访问计数
+	DeleteLink(ctx context.Context, code string) error
+	// DeleteVisits 清空访问计数与独立访客，链接被删除后重新创建同名短码时从零开始
+	DeleteVisits(ctx context.Context, code string) error
+}
+
+// VisitRepository 定义了访问明细的持久化接口
+type VisitRepository interface {
+	SaveVisits(ctx context.Context, visits []domain.Visit) error
+	// VisitSeries 按 bucket (domain.BucketHour / domain.BucketDay) 聚合 since 之后的访问量
+	// 只返回有数据的时间段，按时间升序
+	VisitSeries(ctx context.Context, code string, since time.Time, bucket string) ([]domain.VisitBucket, error)
+	// TopReferrers 返回 since 之后访问量最高的来源，直接访问不计入
+	TopReferrers(ctx context.Context, code string, since time.Time, limit int) ([]domain.ReferrerCount, error)
 }
@@ -0,0 +1,47 @@
+// pkg/repository/postgres/code_sequence.go
+package postgres
+
+import (
+	"context"
+
+	"github.com/bigwhite/shortlink/pkg/repository"
+)
+
+// codeSeqLockKey 是保护 link_code_seq 的事务级 advisory lock 的键
+const codeSeqLockKey = 0x6c696e6b // "link"
+
+// PgCodeSequence 基于数据库序列 link_code_seq 为短码分配 ID
+// 它同时实现了 codegen.IDSource (逐个分配) 与 codegen.RangeLeaser (按区间租用)
+// 两种方式共用同一个序列，切换策略或混用都不会分配出重复的 ID
+type PgCodeSequence struct {
+	db repository.DBTX
+}
+
+func NewPgCodeSequence(db repository.DBTX) *PgCodeSequence {
+	return &PgCodeSequence{db: db}
+}
+
+// NextID 先取共享锁再 nextval：逐个分配之间互不阻塞，但不会插进 LeaseRange 的两步之间
+func (p *PgCodeSequence) NextID(ctx context.Context) (uint64, error) {
+	query := `WITH l AS MATERIALIZED (SELECT pg_advisory_xact_lock_shared($1))
+		SELECT nextval('link_code_seq') FROM l`
+
+	var id int64
+	if err := p.db.QueryRowContext(ctx, query, codeSeqLockKey).Scan(&id); err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+// LeaseRange 用 nextval 取得区间起点，再用 setval 把序列推进 size-1 步
+// 两步在排他锁下执行，区间内的 ID 不会被并发的 NextID 或 LeaseRange 分配出去
+func (p *PgCodeSequence) LeaseRange(ctx context.Context, size uint64) (uint64, error) {
+	query := `WITH l AS MATERIALIZED (SELECT pg_advisory_xact_lock($1))
+		SELECT setval('link_code_seq', nextval('link_code_seq') + $2 - 1) - $2 + 1 FROM l`
+
+	var start int64
+	if err := p.db.QueryRowContext(ctx, query, codeSeqLockKey, int64(size)).Scan(&start); err != nil {
+		return 0, err
+	}
+	return uint64(start), nil
+}
@@ -0,0 +1,77 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/bigwhite/shortlink/pkg/repository/postgres"
+)
+
+// 容器与迁移由 link_repository_int_test.go 中的 TestMain 准备
+// 序列的修改不受事务回滚影响，这里直接使用连接池，并发的调用分布在不同的连接上
+
+func TestCodeSequence(t *testing.T) {
+	ctx := context.Background()
+	seq := postgres.NewPgCodeSequence(dbPool)
+
+	t.Run("LeaseRange 推进共享序列", func(t *testing.T) {
+		start, err := seq.LeaseRange(ctx, 100)
+		if err != nil {
+			t.Fatalf("LeaseRange() returned an unexpected error: %v", err)
+		}
+		next, err := seq.NextID(ctx)
+		if err != nil {
+			t.Fatalf("NextID() returned an unexpected error: %v", err)
+		}
+		if next != start+100 {
+			t.Fatalf("NextID() after LeaseRange(100) at %d = %d, want %d", start, next, start+100)
+		}
+	})
+
+	t.Run("并发租用与逐个分配互不重叠", func(t *testing.T) {
+		const workers, rounds, size = 8, 20, 50
+
+		var mu sync.Mutex
+		owner := make(map[uint64]int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for r := 0; r < rounds; r++ {
+					var ids []uint64
+					if (w+r)%2 == 0 {
+						start, err := seq.LeaseRange(ctx, size)
+						if err != nil {
+							t.Errorf("LeaseRange() returned an unexpected error: %v", err)
+							return
+						}
+						for id := start; id < start+size; id++ {
+							ids = append(ids, id)
+						}
+					} else {
+						id, err := seq.NextID(ctx)
+						if err != nil {
+							t.Errorf("NextID() returned an unexpected error: %v", err)
+							return
+						}
+						ids = append(ids, id)
+					}
+
+					mu.Lock()
+					for _, id := range ids {
+						if prev, dup := owner[id]; dup {
+							t.Errorf("id %d allocated to worker %d and %d", id, prev, w)
+						}
+						owner[id] = w
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
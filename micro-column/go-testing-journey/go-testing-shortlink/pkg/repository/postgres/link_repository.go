@@ -21,11 +21,14 @@ func NewPgLinkRepository(db repository.DBTX) *PgLinkRepository {
 
 func (p *PgLinkRepository) FindByCode(ctx context.Context, code string) (*domain.Link, error) {
 	var link domain.Link
-	query := "SELECT id, original_url, short_code, created_at FROM links WHERE short_code=$1"
+	query := `SELECT id, original_url, short_code, created_at, COALESCE(owner_id, ''), expires_at, max_visits
+		FROM links WHERE short_code=$1`
 
 	// 使用 QueryRowContext，然后手动 Scan
+	// expires_at 可能为 NULL，Scan 到 *time.Time 时会得到 nil
 	row := p.db.QueryRowContext(ctx, query, code)
-	err := row.Scan(&link.ID, &link.OriginalURL, &link.ShortCode, &link.CreatedAt) // <-- 关键变更：手动按顺序 Scan
+	err := row.Scan(&link.ID, &link.OriginalURL, &link.ShortCode, &link.CreatedAt,
+		&link.OwnerID, &link.ExpiresAt, &link.MaxVisits) // <-- 关键变更：手动按顺序 Scan
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -37,9 +40,10 @@ func (p *PgLinkRepository) FindByCode(ctx context.Context, code string) (*domain
 }
 
 func (p *PgLinkRepository) Save(ctx context.Context, link *domain.Link) error {
-	query := "INSERT INTO links (original_url, short_code) VALUES ($1, $2) RETURNING id, created_at"
+	query := `INSERT INTO links (original_url, short_code, owner_id, expires_at, max_visits)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5) RETURNING id, created_at`
 
-	row := p.db.QueryRowContext(ctx, query, link.OriginalURL, link.ShortCode)
+	row := p.db.QueryRowContext(ctx, query, link.OriginalURL, link.ShortCode, link.OwnerID, link.ExpiresAt, link.MaxVisits)
 	err := row.Scan(&link.ID, &link.CreatedAt) // <-- 关键变更：手动 Scan 返回的字段
 
 	if err != nil {
@@ -50,3 +54,26 @@ func (p *PgLinkRepository) Save(ctx context.Context, link *domain.Link) error {
 	}
 	return nil
 }
+
+// Update 和 Delete 同样只依赖 QueryRowContext：借助 RETURNING 判断是否命中了行，
+// 这样 DBTX 接口无需扩展，*sql.DB 和 *sql.Tx 都能继续使用
+func (p *PgLinkRepository) Update(ctx context.Context, link *domain.Link) error {
+	query := `UPDATE links SET original_url=$2, expires_at=$3, max_visits=$4
+		WHERE short_code=$1 RETURNING id`
+
+	var id int64
+	err := p.db.QueryRowContext(ctx, query, link.ShortCode, link.OriginalURL, link.ExpiresAt, link.MaxVisits).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+func (p *PgLinkRepository) Delete(ctx context.Context, code string) error {
+	var id int64
+	err := p.db.QueryRowContext(ctx, "DELETE FROM links WHERE short_code=$1 RETURNING id", code).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	return err
+}
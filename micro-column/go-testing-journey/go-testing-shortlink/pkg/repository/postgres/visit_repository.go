@@ -0,0 +1,111 @@
+// pkg/repository/postgres/visit_repository.go
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bigwhite/shortlink/pkg/domain"
+	"github.com/bigwhite/shortlink/pkg/repository"
+	"github.com/lib/pq"
+)
+
+type PgVisitRepository struct {
+	db repository.DBTX
+}
+
+func NewPgVisitRepository(db repository.DBTX) *PgVisitRepository {
+	return &PgVisitRepository{db: db}
+}
+
+// SaveVisits 用一条 INSERT ... SELECT unnest(...) 写入整批访问记录
+// 每批只有一次数据库往返，且不需要拼接 SQL
+func (p *PgVisitRepository) SaveVisits(ctx context.Context, visits []domain.Visit) error {
+	if len(visits) == 0 {
+		return nil
+	}
+
+	codes := make([]string, len(visits))
+	times := make([]string, len(visits))
+	referrers := make([]string, len(visits))
+	uaClasses := make([]string, len(visits))
+	countries := make([]string, len(visits))
+	for i, v := range visits {
+		codes[i] = v.ShortCode
+		// pq.Array 不支持 []time.Time，以 RFC 3339 文本传入再由数据库转换
+		times[i] = v.VisitedAt.UTC().Format(time.RFC3339Nano)
+		referrers[i] = v.Referrer
+		uaClasses[i] = v.UAClass
+		countries[i] = v.Country
+	}
+
+	query := `WITH ins AS (
+		INSERT INTO link_visits (short_code, visited_at, referrer, ua_class, country)
+		SELECT * FROM unnest($1::text[], $2::timestamptz[], $3::text[], $4::text[], $5::text[])
+		RETURNING 1
+	) SELECT count(*) FROM ins`
+
+	var n int
+	err := p.db.QueryRowContext(ctx, query, pq.Array(codes), pq.Array(times),
+		pq.Array(referrers), pq.Array(uaClasses), pq.Array(countries)).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if n != len(visits) {
+		return fmt.Errorf("saved %d of %d visits", n, len(visits))
+	}
+	return nil
+}
+
+func (p *PgVisitRepository) VisitSeries(ctx context.Context, code string, since time.Time, bucket string) ([]domain.VisitBucket, error) {
+	if bucket != domain.BucketHour && bucket != domain.BucketDay {
+		return nil, fmt.Errorf("unsupported bucket %q", bucket)
+	}
+
+	// 按 UTC 截断，保证与 service 层补齐空桶时的边界一致
+	query := `SELECT date_trunc($3, visited_at AT TIME ZONE 'UTC') AS bucket, count(*)
+		FROM link_visits WHERE short_code=$1 AND visited_at >= $2
+		GROUP BY bucket ORDER BY bucket`
+
+	rows, err := p.db.QueryContext(ctx, query, code, since, bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []domain.VisitBucket
+	for rows.Next() {
+		var b domain.VisitBucket
+		if err := rows.Scan(&b.Start, &b.Visits); err != nil {
+			return nil, err
+		}
+		// AT TIME ZONE 得到的是 timestamp (无时区)，这里补回 UTC
+		b.Start = time.Date(b.Start.Year(), b.Start.Month(), b.Start.Day(),
+			b.Start.Hour(), 0, 0, 0, time.UTC)
+		series = append(series, b)
+	}
+	return series, rows.Err()
+}
+
+func (p *PgVisitRepository) TopReferrers(ctx context.Context, code string, since time.Time, limit int) ([]domain.ReferrerCount, error) {
+	query := `SELECT referrer, count(*) AS visits
+		FROM link_visits WHERE short_code=$1 AND visited_at >= $2 AND referrer <> ''
+		GROUP BY referrer ORDER BY visits DESC, referrer LIMIT $3`
+
+	rows, err := p.db.QueryContext(ctx, query, code, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var top []domain.ReferrerCount
+	for rows.Next() {
+		var rc domain.ReferrerCount
+		if err := rows.Scan(&rc.Referrer, &rc.Visits); err != nil {
+			return nil, err
+		}
+		top = append(top, rc)
+	}
+	return top, rows.Err()
+}
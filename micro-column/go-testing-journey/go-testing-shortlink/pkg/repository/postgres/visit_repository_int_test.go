@@ -0,0 +1,108 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bigwhite/shortlink/pkg/domain"
+	"github.com/bigwhite/shortlink/pkg/repository/postgres"
+)
+
+// 容器与迁移由 link_repository_int_test.go 中的 TestMain 准备
+
+func TestVisitRepository(t *testing.T) {
+	ctx := context.Background()
+	tx, err := dbPool.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("无法开始事务: %v", err)
+	}
+	defer tx.Rollback()
+
+	// 会话时区不是 UTC 时，按天截断的边界仍必须是 UTC 零点
+	if _, err := tx.ExecContext(ctx, "SET LOCAL TIME ZONE 'Asia/Shanghai'"); err != nil {
+		t.Fatalf("无法设置时区: %v", err)
+	}
+	repo := postgres.NewPgVisitRepository(tx)
+
+	day := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	visits := []domain.Visit{
+		{ShortCode: "visits", VisitedAt: day.Add(-time.Minute), Referrer: "t.co", UAClass: "mobile", Country: "US"}, // 前一天 23:59
+		{ShortCode: "visits", VisitedAt: day.Add(1 * time.Hour), Referrer: "t.co", UAClass: "desktop"},
+		{ShortCode: "visits", VisitedAt: day.Add(1*time.Hour + 30*time.Minute), Referrer: "news.ycombinator.com"},
+		{ShortCode: "visits", VisitedAt: day.Add(3 * time.Hour)}, // 直接访问
+		{ShortCode: "other", VisitedAt: day.Add(time.Hour), Referrer: "t.co"},
+	}
+
+	t.Run("SaveVisits 一次写入整批", func(t *testing.T) {
+		if err := repo.SaveVisits(ctx, visits); err != nil {
+			t.Fatalf("SaveVisits() returned an unexpected error: %v", err)
+		}
+		if err := repo.SaveVisits(ctx, nil); err != nil {
+			t.Fatalf("SaveVisits(nil) returned an unexpected error: %v", err)
+		}
+
+		var n int
+		var country string
+		if err := tx.QueryRowContext(ctx,
+			"SELECT count(*), max(country) FROM link_visits WHERE short_code='visits'").Scan(&n, &country); err != nil {
+			t.Fatal(err)
+		}
+		if n != 4 || country != "US" {
+			t.Fatalf("saved %d visits (country %q), want 4 (US)", n, country)
+		}
+	})
+
+	t.Run("VisitSeries 按 UTC 截断", func(t *testing.T) {
+		tests := []struct {
+			bucket string
+			since  time.Time
+			want   []domain.VisitBucket
+		}{
+			{domain.BucketDay, day.AddDate(0, 0, -1), []domain.VisitBucket{
+				{Start: day.AddDate(0, 0, -1), Visits: 1},
+				{Start: day, Visits: 3},
+			}},
+			{domain.BucketHour, day, []domain.VisitBucket{
+				{Start: day.Add(1 * time.Hour), Visits: 2},
+				{Start: day.Add(3 * time.Hour), Visits: 1},
+			}},
+		}
+		for _, tc := range tests {
+			got, err := repo.VisitSeries(ctx, "visits", tc.since, tc.bucket)
+			if err != nil {
+				t.Fatalf("VisitSeries(%s) returned an unexpected error: %v", tc.bucket, err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("VisitSeries(%s) = %v, want %v", tc.bucket, got, tc.want)
+			}
+			for i := range got {
+				if !got[i].Start.Equal(tc.want[i].Start) || got[i].Start.Location() != time.UTC || got[i].Visits != tc.want[i].Visits {
+					t.Errorf("VisitSeries(%s)[%d] = %v, want %v", tc.bucket, i, got[i], tc.want[i])
+				}
+			}
+		}
+
+		if _, err := repo.VisitSeries(ctx, "visits", day, "week"); err == nil {
+			t.Error("VisitSeries(week) should return an error")
+		}
+	})
+
+	t.Run("TopReferrers 不计直接访问", func(t *testing.T) {
+		got, err := repo.TopReferrers(ctx, "visits", day.AddDate(0, 0, -1), 10)
+		if err != nil {
+			t.Fatalf("TopReferrers() returned an unexpected error: %v", err)
+		}
+		want := []domain.ReferrerCount{{Referrer: "t.co", Visits: 2}, {Referrer: "news.ycombinator.com", Visits: 1}}
+		if len(got) != len(want) {
+			t.Fatalf("TopReferrers() = %v, want %v", got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("TopReferrers()[%d] = %v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+}
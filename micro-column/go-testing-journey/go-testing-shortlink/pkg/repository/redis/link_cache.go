@@ -2,11 +2,28 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/bigwhite/shortlink/pkg/domain"
 	"github.com/redis/go-redis/v9"
 )
 
+// incrBelowScript 在计数小于上限时加一，返回 1；已达上限时不修改，返回 0
+var incrBelowScript = redis.NewScript(`
+local n = tonumber(redis.call('GET', KEYS[1]) or '0')
+if n >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('INCR', KEYS[1])
+return 1
+`)
+
+// negativeValue 是负缓存的占位值，合法的链接 JSON 不可能是这个值
+const negativeValue = "-"
+
 type RedisLinkCache struct {
 	client *redis.Client
 }
@@ -19,6 +36,16 @@ func (r *RedisLinkCache) key(code string) string {
 	return fmt.Sprintf("link:visits:%s", code)
 }
 
+// visitorsKey 是独立访客 HyperLogLog 的 key，每个短码固定占用约 12KB
+func (r *RedisLinkCache) visitorsKey(code string) string {
+	return fmt.Sprintf("link:visitors:%s", code)
+}
+
+// linkKey 是链接数据的 key，与计数分开存放，失效链接时不会清掉计数
+func (r *RedisLinkCache) linkKey(code string) string {
+	return fmt.Sprintf("link:data:%s", code)
+}
+
 func (r *RedisLinkCache) IncrementVisitCount(ctx context.Context, code string) error {
 	return r.client.Incr(ctx, r.key(code)).Err()
 }
@@ -30,3 +57,85 @@ func (r *RedisLinkCache) GetVisitCount(ctx context.Context, code string) (int64,
 	}
 	return count, err
 }
+
+// IncrementVisitCountBelow 用 Lua 脚本在 Redis 中原子地完成比较与 INCR
+func (r *RedisLinkCache) IncrementVisitCountBelow(ctx context.Context, code string, limit int64) (bool, error) {
+	n, err := incrBelowScript.Run(ctx, r.client, []string{r.key(code)}, limit).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// RecordVisits 在一个 pipeline 中完成 INCRBY 与 PFADD
+func (r *RedisLinkCache) RecordVisits(ctx context.Context, code string, visitorIDs []string) error {
+	if len(visitorIDs) == 0 {
+		return nil
+	}
+	members := make([]any, len(visitorIDs))
+	for i, id := range visitorIDs {
+		members[i] = id
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.IncrBy(ctx, r.key(code), int64(len(visitorIDs)))
+		pipe.PFAdd(ctx, r.visitorsKey(code), members...)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisLinkCache) AddUniqueVisitors(ctx context.Context, code string, visitorIDs []string) error {
+	if len(visitorIDs) == 0 {
+		return nil
+	}
+	members := make([]any, len(visitorIDs))
+	for i, id := range visitorIDs {
+		members[i] = id
+	}
+	return r.client.PFAdd(ctx, r.visitorsKey(code), members...).Err()
+}
+
+func (r *RedisLinkCache) CountUniqueVisitors(ctx context.Context, code string) (int64, error) {
+	return r.client.PFCount(ctx, r.visitorsKey(code)).Result()
+}
+
+func (r *RedisLinkCache) GetLink(ctx context.Context, code string) (*domain.Link, bool, error) {
+	val, err := r.client.Get(ctx, r.linkKey(code)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if val == negativeValue {
+		return nil, true, nil
+	}
+
+	var link domain.Link
+	if err := json.Unmarshal([]byte(val), &link); err != nil {
+		// 数据损坏时视为未命中，由调用方回源并覆盖
+		return nil, false, nil
+	}
+	return &link, true, nil
+}
+
+func (r *RedisLinkCache) SetLink(ctx context.Context, code string, link *domain.Link, ttl time.Duration) error {
+	val := negativeValue
+	if link != nil {
+		b, err := json.Marshal(link)
+		if err != nil {
+			return err
+		}
+		val = string(b)
+	}
+	return r.client.Set(ctx, r.linkKey(code), val, ttl).Err()
+}
+
+func (r *RedisLinkCache) DeleteLink(ctx context.Context, code string) error {
+	return r.client.Del(ctx, r.linkKey(code)).Err()
+}
+
+func (r *RedisLinkCache) DeleteVisits(ctx context.Context, code string) error {
+	return r.client.Del(ctx, r.key(code), r.visitorsKey(code)).Err()
+}
@@ -0,0 +1,61 @@
+// pkg/service/errors.go
+package service
+
+import "errors"
+
+// 错误类别。service 返回的业务错误都属于其中之一，handler 据此选择 HTTP 状态码
+// 用 errors.Is(err, ErrNotFound) 判断类别，用 errors.Is(err, ErrLinkNotFound) 判断具体错误
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("not found")
+	ErrExpired      = errors.New("expired")
+	ErrForbidden    = errors.New("forbidden")
+	ErrConflict     = errors.New("conflict")
+	ErrRejected     = errors.New("rejected by policy") // 输入格式正确，但违反了策略
+	ErrUnavailable  = errors.New("upstream unavailable")
+)
+
+// Error 是带类别与错误码的业务错误
+type Error struct {
+	Kind    error  // 上面的某个错误类别
+	Code    string // 机器可读的错误码，例如 "alias_taken"，会出现在响应中
+	Message string
+	Err     error // 可选，底层原因
+}
+
+func newError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 让 errors.Is 可以按类别匹配
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// 具体的业务错误。需要附带细节时用 fmt.Errorf("%w: ...", ErrXxx) 包装，类别与错误码保持不变
+var (
+	ErrInvalidURL        = newError(ErrInvalidInput, "invalid_url", "invalid URL")
+	ErrInvalidAlias      = newError(ErrInvalidInput, "invalid_alias", "invalid alias")
+	ErrInvalidExpiry     = newError(ErrInvalidInput, "invalid_expiry", "expires_at must be in the future")
+	ErrInvalidMaxVisits  = newError(ErrInvalidInput, "invalid_max_visits", "max_visits must not be negative")
+	ErrInvalidStatsRange = newError(ErrInvalidInput, "invalid_stats_range", "invalid stats range")
+	ErrUnsafeURL         = newError(ErrRejected, "unsafe_url", "unsafe URL")
+	ErrLinkNotFound      = newError(ErrNotFound, "link_not_found", "link not found")
+	ErrLinkExpired       = newError(ErrExpired, "link_expired", "link expired")
+	ErrPermissionDenied  = newError(ErrForbidden, "permission_denied", "permission denied")
+	ErrAliasTaken        = newError(ErrConflict, "alias_taken", "alias already taken")
+	ErrUserServiceDown   = newError(ErrUnavailable, "user_service_unavailable", "user service unavailable")
+	// ErrCodeSpaceBusy 表示多次重试后仍未生成不冲突的短码，稍后重试通常可以成功
+	ErrCodeSpaceBusy = newError(ErrUnavailable, "short_code_unavailable", "failed to create a unique short code after multiple retries")
+)
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 
+      "github.com/bigwhite/shortlink/pkg/analytics"
       "github.com/bigwhite/shortlink/pkg/repository/postgres"
       redis_repo "github.com/bigwhite/shortlink/pkg/repository/redis"
       "github.com/bigwhite/shortlink/pkg/service"
@@ -127,7 +130,7 @@ func TestCreateAndRedirect_HappyPath(t *testing.T) {
 	createdLink, err := txTestService.CreateLink(ctx, originalURL)
 	if err != nil { t.Fatalf("CreateLink 不应返回错误: %v", err) }
 
-	redirectedLink, err := txTestService.Redirect(ctx, createdLink.ShortCode)
+	redirectedLink, err := txTestService.Redirect(ctx, createdLink.ShortCode, analytics.Hit{})
 	if err != nil { t.Fatalf("Redirect 不应返回错误: %v", err) }
 	if redirectedLink == nil || redirectedLink.OriginalURL != originalURL {
 		t.Fatalf("重定向的链接不正确")
@@ -139,7 +142,7 @@ func TestCreateAndRedirect_HappyPath(t *testing.T) {
 		return count == 1
 	}, "访问计数应该在 Redis 中变为 1")
 
-	_, err = txTestService.Redirect(ctx, createdLink.ShortCode)
+	_, err = txTestService.Redirect(ctx, createdLink.ShortCode, analytics.Hit{})
 	if err != nil { t.Fatalf("第二次 Redirect 不应返回错误: %v", err) }
 	
 	assertEventually(t, func() bool {
@@ -151,6 +154,46 @@ func TestCreateAndRedirect_HappyPath(t *testing.T) {
 	redisClient.Del(ctx, fmt.Sprintf("link:visits:%s", createdLink.ShortCode))
 }
 
+// TestRedirect_MaxVisits_Atomic 验证 Redis 中的"检查并加一"脚本在并发下不会超出上限
+func TestRedirect_MaxVisits_Atomic(t *testing.T) {
+	ctx := context.Background()
+
+	tx, err := dbPool.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	txTestService := service.NewShortenerService(postgres.NewPgLinkRepository(tx), redis_repo.NewRedisLinkCache(redisClient))
+	createdLink, err := txTestService.CreateLink(ctx, "https://go.dev", service.WithMaxVisits(3))
+	if err != nil {
+		t.Fatalf("CreateLink 不应返回错误: %v", err)
+	}
+	key := fmt.Sprintf("link:visits:%s", createdLink.ShortCode)
+	defer redisClient.Del(ctx, key)
+
+	const n = 20
+	var ok atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := txTestService.Redirect(ctx, createdLink.ShortCode, analytics.Hit{}); err == nil {
+				ok.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := ok.Load(); got != 3 {
+		t.Fatalf("成功跳转 %d 次，期望恰好 3 次", got)
+	}
+	if count, _ := redisClient.Get(ctx, key).Int64(); count != 3 {
+		t.Fatalf("访问计数为 %d，期望 3", count)
+	}
+}
+
 func assertEventually(t *testing.T, condition func() bool, msgAndArgs ...interface{}) {
 	t.Helper()
 	const (
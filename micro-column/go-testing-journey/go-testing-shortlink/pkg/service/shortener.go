@@ -3,49 +3,179 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
-	"io"
+	"fmt"
 	"log"
+	mrand "math/rand/v2"
 	"time"
 
+	"github.com/bigwhite/shortlink/pkg/analytics"
+	"github.com/bigwhite/shortlink/pkg/codegen"
 	"github.com/bigwhite/shortlink/pkg/domain"
 	"github.com/bigwhite/shortlink/pkg/repository"
 	"github.com/bigwhite/shortlink/pkg/validator"
+	"golang.org/x/sync/singleflight"
 )
 
-// generateShortCode 是生成短码的默认实现
-func generateShortCode(length int) (string, error) {
-	bytes := make([]byte, length)
-	if _, err := io.ReadFull(rand.Reader, bytes); err != nil {
-		return "", err
-	}
-	return base64.RawURLEncoding.EncodeToString(bytes), nil
+// UserPermissionChecker 是 ShortenerService 对用户服务的依赖
+// client.UserServiceClient 实现了该接口
+type UserPermissionChecker interface {
+	CanCreateLink(ctx context.Context, userID string) (bool, error)
+}
+
+// VisitRecorder 异步记录访问事件，analytics.Recorder 实现了该接口
+type VisitRecorder interface {
+	Record(hit analytics.Hit) bool
 }
 
 // ShortenerService 提供了短链接的核心业务逻辑
 type ShortenerService struct {
-	repo                  repository.LinkRepository
-	cache                 repository.LinkCache
-	generateShortCodeFunc func(int) (string, error) // 短码生成函数的依赖
+	repo      repository.LinkRepository
+	cache     repository.LinkCache
+	users     UserPermissionChecker      // 可选，为 nil 时不校验创建权限
+	urlPolicy *validator.Policy          // 可选，为 nil 时只校验 URL 格式
+	recorder  VisitRecorder              // 可选，为 nil 时只同步累加访问计数
+	visits    repository.VisitRepository // 可选，为 nil 时统计中没有时间序列与来源
+	codes     codegen.CodeGenerator      // 短码生成器的依赖
+	now       func() time.Time
+
+	// 链接查询的 read-through 缓存
+	linkTTL     time.Duration      // 存在的链接的缓存时间，实际会加上 ±10% 的抖动
+	negativeTTL time.Duration      // 不存在的短码的缓存时间
+	flights     singleflight.Group // 合并同一短码的并发回源
+}
+
+// 链接缓存的默认时间
+const (
+	defaultLinkTTL     = 10 * time.Minute
+	defaultNegativeTTL = 30 * time.Second
+)
+
+// Option 用于配置 ShortenerService 的可选依赖
+type Option func(*ShortenerService)
+
+// WithUserService 注入用户服务，创建带 owner 的链接时会校验其权限
+func WithUserService(users UserPermissionChecker) Option {
+	return func(s *ShortenerService) {
+		s.users = users
+	}
+}
+
+// WithURLPolicy 设置目标 URL 的安全策略，创建和修改链接时都会检查
+func WithURLPolicy(p *validator.Policy) Option {
+	return func(s *ShortenerService) {
+		s.urlPolicy = p
+	}
+}
+
+// WithVisitRecorder 注入访问记录器，每次跳转都会提交一条访问事件
+func WithVisitRecorder(recorder VisitRecorder) Option {
+	return func(s *ShortenerService) {
+		s.recorder = recorder
+	}
+}
+
+// WithVisitRepository 注入访问明细仓库，用于查询时间序列与来源排行
+func WithVisitRepository(visits repository.VisitRepository) Option {
+	return func(s *ShortenerService) {
+		s.visits = visits
+	}
+}
+
+// WithLinkCacheTTL 设置链接缓存的时间
+// negativeTTL 应明显短于 ttl：它只用于挡住对不存在短码的扫描，过长会让刚创建的别名短暂不可用
+func WithLinkCacheTTL(ttl, negativeTTL time.Duration) Option {
+	return func(s *ShortenerService) {
+		s.linkTTL = ttl
+		s.negativeTTL = negativeTTL
+	}
+}
+
+// WithCodeGenerator 替换默认的随机短码生成器
+func WithCodeGenerator(codes codegen.CodeGenerator) Option {
+	return func(s *ShortenerService) {
+		s.codes = codes
+	}
 }
 
 // NewShortenerService 是 ShortenerService 的构造函数
-// 它为 generateShortCodeFunc 字段设置了默认的实现
-func NewShortenerService(repo repository.LinkRepository, cache repository.LinkCache) *ShortenerService {
-	return &ShortenerService{
-		repo:                  repo,
-		cache:                 cache,
-		generateShortCodeFunc: generateShortCode,
+// 默认使用 6 字节 (8 个字符) 的随机短码生成器
+func NewShortenerService(repo repository.LinkRepository, cache repository.LinkCache, opts ...Option) *ShortenerService {
+	s := &ShortenerService{
+		repo:        repo,
+		cache:       cache,
+		codes:       codegen.NewRandomGenerator(6),
+		now:         time.Now,
+		linkTTL:     defaultLinkTTL,
+		negativeTTL: defaultNegativeTTL,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// linkOptions 是创建链接时的可选参数
+type linkOptions struct {
+	alias     string
+	ownerID   string
+	expiresAt *time.Time
+	maxVisits int64
+}
+
+// LinkOption 配置 CreateLink 的可选参数
+type LinkOption func(*linkOptions)
+
+// WithAlias 使用用户自定义的短码，而不是随机生成
+func WithAlias(alias string) LinkOption {
+	return func(o *linkOptions) { o.alias = alias }
+}
+
+// WithOwner 指定链接的所有者，只有所有者可以修改或删除链接
+func WithOwner(userID string) LinkOption {
+	return func(o *linkOptions) { o.ownerID = userID }
+}
+
+// WithExpiresAt 设置链接的过期时间
+func WithExpiresAt(t time.Time) LinkOption {
+	return func(o *linkOptions) { o.expiresAt = &t }
+}
+
+// WithMaxVisits 设置链接的最大访问次数，0 表示不限制
+func WithMaxVisits(n int64) LinkOption {
+	return func(o *linkOptions) { o.maxVisits = n }
 }
 
 // CreateLink 创建一个新的短链接
-func (s *ShortenerService) CreateLink(ctx context.Context, originalURL string) (*domain.Link, error) {
-	// 1. 验证 URL 的合法性
-	if !validator.IsValidURL(originalURL) {
-		return nil, errors.New("invalid URL")
+func (s *ShortenerService) CreateLink(ctx context.Context, originalURL string, opts ...LinkOption) (*domain.Link, error) {
+	var o linkOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// 1. 验证参数的合法性
+	originalURL, err := s.checkURL(ctx, originalURL)
+	if err != nil {
+		return nil, err
+	}
+	if o.alias != "" {
+		if err := validator.ValidateAlias(o.alias); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidAlias, err)
+		}
+	}
+	if err := s.validateLimits(o.expiresAt, o.maxVisits); err != nil {
+		return nil, err
+	}
+
+	// 2. 校验创建权限 (依赖外部用户服务)
+	if o.ownerID != "" && s.users != nil {
+		ok, err := s.users.CanCreateLink(ctx, o.ownerID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUserServiceDown, err)
+		}
+		if !ok {
+			return nil, ErrPermissionDenied
+		}
 	}
 
 	// 为数据库操作创建一个带超时的 context
@@ -53,63 +183,365 @@ func (s *ShortenerService) CreateLink(ctx context.Context, originalURL string) (
 	dbCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	// 2. 尝试生成一个唯一的短码
+	newLink := func(code string) *domain.Link {
+		return &domain.Link{
+			OriginalURL: originalURL,
+			ShortCode:   code,
+			OwnerID:     o.ownerID,
+			ExpiresAt:   o.expiresAt,
+			MaxVisits:   o.maxVisits,
+		}
+	}
+
+	// 3. 自定义别名：冲突时直接报错，不重试
+	if o.alias != "" {
+		link := newLink(o.alias)
+		if err := s.repo.Save(dbCtx, link); err != nil {
+			if errors.Is(err, repository.ErrCodeConflict) {
+				return nil, ErrAliasTaken
+			}
+			return nil, err
+		}
+		// 别名在创建前可能被访问过，清掉它的负缓存
+		s.invalidateLink(ctx, link.ShortCode)
+		return link, nil
+	}
+
+	// 4. 尝试生成一个唯一的短码
+	// 随机生成器的冲突概率随短码数量上升；序列生成器的短码互不重复，只可能与自定义别名冲突
 	const maxRetries = 5
 	for i := 0; i < maxRetries; i++ {
-		// 调用注入的函数来生成短码，而不是包级别的函数
-		code, err := s.generateShortCodeFunc(6)
+		// 调用注入的生成器来生成短码，而不是包级别的函数
+		code, err := s.codes.NextCode(ctx)
 		if err != nil {
 			return nil, err
 		}
 
-		link := &domain.Link{
-			OriginalURL: originalURL,
-			ShortCode:   code,
-		}
+		link := newLink(code)
 
-		// 3. 尝试保存到仓库，使用带超时的 context
+		// 尝试保存到仓库，使用带超时的 context
 		err = s.repo.Save(dbCtx, link) // <-- 使用 dbCtx
 		if err == nil {
+			s.invalidateLink(ctx, link.ShortCode)
 			return link, nil // 保存成功，直接返回
 		}
 
-		// 4. 如果不是冲突错误，则直接返回错误
+		// 如果不是冲突错误，则直接返回错误
 		if !errors.Is(err, repository.ErrCodeConflict) {
 			return nil, err
 		}
 
-		// 5. 如果是冲突错误，则继续下一次循环
+		// 如果是冲突错误，则继续下一次循环
 		// (可以在这里增加日志，记录发生了一次冲突)
 	}
 
-	// 6. 如果重试次数耗尽，返回失败错误
-	return nil, errors.New("failed to create a unique short code after multiple retries")
+	// 5. 如果重试次数耗尽，返回失败错误
+	return nil, ErrCodeSpaceBusy
 }
 
-// Redirect 处理重定向逻辑
-func (s *ShortenerService) Redirect(ctx context.Context, code string) (*domain.Link, error) {
+// LinkUpdate 描述对链接的局部更新，nil 字段表示不修改
+type LinkUpdate struct {
+	OriginalURL *string
+	ExpiresAt   *time.Time
+	// ClearExpiry 为 true 时移除过期时间，优先于 ExpiresAt
+	ClearExpiry bool
+	MaxVisits   *int64
+}
+
+// UpdateLink 修改链接，只有所有者可以操作
+func (s *ShortenerService) UpdateLink(ctx context.Context, code, userID string, upd LinkUpdate) (*domain.Link, error) {
+	link, err := s.findOwned(ctx, code, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if upd.OriginalURL != nil {
+		if link.OriginalURL, err = s.checkURL(ctx, *upd.OriginalURL); err != nil {
+			return nil, err
+		}
+	}
+	if upd.ClearExpiry {
+		link.ExpiresAt = nil
+	} else if upd.ExpiresAt != nil {
+		link.ExpiresAt = upd.ExpiresAt
+	}
+	if upd.MaxVisits != nil {
+		link.MaxVisits = *upd.MaxVisits
+	}
+	if err := s.validateLimits(upd.ExpiresAt, link.MaxVisits); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, link); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	s.invalidateLink(ctx, code)
+	return link, nil
+}
+
+// DeleteLink 删除链接，只有所有者可以操作
+func (s *ShortenerService) DeleteLink(ctx context.Context, code, userID string) error {
+	if _, err := s.findOwned(ctx, code, userID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, code); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrLinkNotFound
+		}
+		return err
+	}
+	s.invalidateLink(ctx, code)
+	// 自定义别名可以被重新创建，不能让新链接继承旧链接的访问计数 (以及 max_visits 的消耗)
+	if err := s.cache.DeleteVisits(context.WithoutCancel(ctx), code); err != nil {
+		log.Printf("ERROR: Failed to delete visit counters for code %s: %v", code, err)
+	}
+	return nil
+}
+
+// findLink 通过 read-through 缓存查找链接，找不到时返回 ErrLinkNotFound
+// 不存在的短码同样会被缓存 (负缓存)，枚举扫描不会打到数据库
+func (s *ShortenerService) findLink(ctx context.Context, code string) (*domain.Link, error) {
+	link, found, err := s.cache.GetLink(ctx, code)
+	if err != nil {
+		// 缓存故障时直接回源，只是暂时失去保护
+		log.Printf("ERROR: Failed to get cached link for code %s: %v", code, err)
+	} else if found {
+		if link == nil {
+			return nil, ErrLinkNotFound
+		}
+		return link, nil
+	}
+
+	// 同一短码的并发未命中只回源一次
+	v, err, _ := s.flights.Do(code, func() (any, error) {
+		// 加载结果由多个调用方共享，不能因为发起者断开而失败
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 3*time.Second)
+		defer cancel()
+
+		link, err := s.repo.FindByCode(loadCtx, code)
+		if err != nil {
+			return nil, err
+		}
+		ttl := s.negativeTTL
+		if link != nil {
+			ttl = jitter(s.linkTTL)
+		}
+		if err := s.cache.SetLink(loadCtx, code, link, ttl); err != nil {
+			log.Printf("ERROR: Failed to cache link for code %s: %v", code, err)
+		}
+		return link, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 不存在的短码返回的是 (*domain.Link)(nil)
+	link, _ = v.(*domain.Link)
+	if link == nil {
+		return nil, ErrLinkNotFound
+	}
+	// 共享的结果不能被某个调用方修改，返回副本
+	linkCopy := *link
+	return &linkCopy, nil
+}
+
+// invalidateLink 在链接变更后删除其缓存
+// 与变更并发的回源仍可能写回旧值，此时最多在 linkTTL 内读到旧数据
+func (s *ShortenerService) invalidateLink(ctx context.Context, code string) {
+	if err := s.cache.DeleteLink(context.WithoutCancel(ctx), code); err != nil {
+		log.Printf("ERROR: Failed to invalidate cached link for code %s: %v", code, err)
+	}
+}
+
+// jitter 给 TTL 加上 ±10% 的随机抖动，避免同一批写入的缓存同时过期
+func jitter(ttl time.Duration) time.Duration {
+	delta := int64(ttl / 10)
+	if delta <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(mrand.Int64N(2*delta+1)-delta)
+}
+
+// findOwned 查找链接并校验所有权
+// 匿名创建的链接没有所有者，任何人都不能修改
+func (s *ShortenerService) findOwned(ctx context.Context, code, userID string) (*domain.Link, error) {
 	link, err := s.repo.FindByCode(ctx, code)
 	if err != nil {
 		return nil, err
 	}
 	if link == nil {
-		return nil, errors.New("link not found")
+		return nil, ErrLinkNotFound
+	}
+	if userID == "" || link.OwnerID != userID {
+		return nil, ErrPermissionDenied
+	}
+	return link, nil
+}
+
+// checkURL 校验目标 URL 的格式与安全策略，返回规范化后的 URL
+// 违反策略时返回的错误同时包装了 ErrUnsafeURL 与 *validator.Violation
+func (s *ShortenerService) checkURL(ctx context.Context, rawURL string) (string, error) {
+	if !validator.IsValidURL(rawURL) {
+		return "", ErrInvalidURL
+	}
+	if s.urlPolicy == nil {
+		return rawURL, nil
+	}
+	normalized, err := s.urlPolicy.Check(ctx, rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnsafeURL, err)
+	}
+	return normalized, nil
+}
+
+// validateLimits 校验过期时间与访问上限
+// expiresAt 为 nil 表示本次没有设置，不做校验
+func (s *ShortenerService) validateLimits(expiresAt *time.Time, maxVisits int64) error {
+	if expiresAt != nil && !expiresAt.After(s.now()) {
+		return ErrInvalidExpiry
+	}
+	if maxVisits < 0 {
+		return ErrInvalidMaxVisits
+	}
+	return nil
+}
+
+// Redirect 处理重定向逻辑
+// hit 携带请求的访问信息 (IP、User-Agent、Referer)，短码与时间由这里填充
+func (s *ShortenerService) Redirect(ctx context.Context, code string, hit analytics.Hit) (*domain.Link, error) {
+	link, err := s.findLink(ctx, code)
+	if err != nil {
+		return nil, err
 	}
 
-	// 异步地增加访问计数 (使用 goroutine)
-	go func() {
-		err := s.cache.IncrementVisitCount(context.Background(), code)
+	// 过期或访问次数耗尽的链接不再跳转
+	if link.IsExpired(s.now()) {
+		return nil, ErrLinkExpired
+	}
+	// 设置了 max_visits 的链接在跳转时同步地"检查并加一"，由 Redis 保证原子性，
+	// 并发访问不会超出上限；异步的 Recorder 只负责访问明细
+	counted := false
+	if link.MaxVisits > 0 {
+		ok, err := s.cache.IncrementVisitCountBelow(ctx, code, link.MaxVisits)
 		if err != nil {
-			// 在真实应用中，这里应该使用结构化日志记录错误
-			log.Printf("ERROR: Failed to increment visit count for code %s: %v", code, err)
+			return nil, err
 		}
-	}()
+		if !ok {
+			return nil, ErrLinkExpired
+		}
+		counted = true
+	}
+
+	if s.recorder == nil {
+		// 未配置 Recorder 时退化为同步计数，不产生访问明细
+		if !counted {
+			if err := s.cache.IncrementVisitCount(ctx, code); err != nil {
+				// 在真实应用中，这里应该使用结构化日志记录错误
+				log.Printf("ERROR: Failed to increment visit count for code %s: %v", code, err)
+			}
+		}
+		return link, nil
+	}
+
+	hit.ShortCode = code
+	hit.Time = s.now()
+	hit.Counted = counted
+	// 缓冲已满时丢弃本次访问，跳转本身不受影响
+	s.recorder.Record(hit)
 
 	return link, nil
 }
 
+// StatsQuery 是统计查询的参数
+type StatsQuery struct {
+	Bucket string    // domain.BucketHour 或 domain.BucketDay，为空时按天
+	Since  time.Time // 为零值时，按小时取最近 24 小时，按天取最近 7 天
+}
+
+// 单次查询最多返回的时间段数与来源数
+const (
+	maxStatsBuckets = 366
+	topReferrers    = 10
+)
+
+// GetStats 返回链接的访问统计
+// 总访问量与独立访客来自 Redis，时间序列与来源排行来自 Postgres 中的访问明细
+func (s *ShortenerService) GetStats(ctx context.Context, code string, q StatsQuery) (*domain.LinkStats, error) {
+	step, since, err := s.statsRange(q)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *ShortenerService) GetStats(ctx context.Context, code string) (int64, error) {
-	// 直接从缓存中读取
-	return s.cache.GetVisitCount(ctx, code)
+	if _, err := s.findLink(ctx, code); err != nil {
+		return nil, err
+	}
+
+	stats := &domain.LinkStats{Bucket: q.Bucket}
+	if stats.Bucket == "" {
+		stats.Bucket = domain.BucketDay
+	}
+	if stats.Visits, err = s.cache.GetVisitCount(ctx, code); err != nil {
+		return nil, err
+	}
+	if stats.UniqueVisitors, err = s.cache.CountUniqueVisitors(ctx, code); err != nil {
+		return nil, err
+	}
+	if s.visits == nil {
+		return stats, nil
+	}
+
+	series, err := s.visits.VisitSeries(ctx, code, since, stats.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	stats.Series = fillSeries(series, since, s.now(), step)
+	if stats.TopReferrers, err = s.visits.TopReferrers(ctx, code, since, topReferrers); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// statsRange 校验查询参数，返回时间段的长度和对齐后的起始时间
+func (s *ShortenerService) statsRange(q StatsQuery) (time.Duration, time.Time, error) {
+	now := s.now().UTC()
+	var step time.Duration
+	switch q.Bucket {
+	case domain.BucketHour:
+		step = time.Hour
+		if q.Since.IsZero() {
+			q.Since = now.Add(-23 * time.Hour)
+		}
+	case domain.BucketDay, "":
+		step = 24 * time.Hour
+		if q.Since.IsZero() {
+			q.Since = now.AddDate(0, 0, -6)
+		}
+	default:
+		return 0, time.Time{}, fmt.Errorf("%w: unsupported bucket %q", ErrInvalidStatsRange, q.Bucket)
+	}
+
+	// 起始时间对齐到时间段边界 (UTC)，与数据库的 date_trunc 保持一致
+	since := q.Since.UTC().Truncate(step)
+	if since.After(now) {
+		return 0, time.Time{}, fmt.Errorf("%w: since is in the future", ErrInvalidStatsRange)
+	}
+	if now.Sub(since)/step >= maxStatsBuckets {
+		return 0, time.Time{}, fmt.Errorf("%w: more than %d buckets", ErrInvalidStatsRange, maxStatsBuckets)
+	}
+	return step, since, nil
+}
+
+// fillSeries 把稀疏的时间序列补齐为连续的时间段，没有访问的时间段计为 0
+func fillSeries(sparse []domain.VisitBucket, since, now time.Time, step time.Duration) []domain.VisitBucket {
+	counts := make(map[int64]int64, len(sparse))
+	for _, b := range sparse {
+		counts[b.Start.Unix()] = b.Visits
+	}
+	var series []domain.VisitBucket
+	for t := since; !t.After(now); t = t.Add(step) {
+		series = append(series, domain.VisitBucket{Start: t, Visits: counts[t.Unix()]})
+	}
+	return series
 }
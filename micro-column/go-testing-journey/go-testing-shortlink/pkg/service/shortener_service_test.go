@@ -5,11 +5,17 @@ package service
 import (
 	"context"
 	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
 	"testing"
-	"crypto/rand"
+	"time"
 
+	"github.com/bigwhite/shortlink/pkg/analytics"
+	"github.com/bigwhite/shortlink/pkg/codegen"
 	"github.com/bigwhite/shortlink/pkg/domain"
 	"github.com/bigwhite/shortlink/pkg/repository/fakes"
+	"github.com/bigwhite/shortlink/pkg/validator"
 )
 
 // 这个测试文件现在是完全独立的，不依赖任何包级别的变量篡改
@@ -86,7 +92,7 @@ func TestShortenerService_CreateLink(t *testing.T) {
 			codes := make([]string, len(tc.generatedCodes))
 			copy(codes, tc.generatedCodes)
 
-			service.generateShortCodeFunc = func(length int) (string, error) {
+			service.codes = codegen.GeneratorFunc(func(ctx context.Context) (string, error) {
 				if tc.wantErrMsg == "generator failed" {
 					return "", errors.New("generator failed")
 				}
@@ -96,7 +102,7 @@ func TestShortenerService_CreateLink(t *testing.T) {
 				code := codes[0]
 				codes = codes[1:]
 				return code, nil
-			}
+			})
 
 			// 3. 执行被测方法
 			createdLink, err := service.CreateLink(context.Background(), tc.originalURL)
@@ -140,53 +146,523 @@ func assertLinkSaved(t *testing.T, repo *fakes.FakeLinkRepository, code, origina
 	}
 }
 
-// a mock reader that always returns an error
-type errReader struct{}
+// stubUserChecker 是 UserPermissionChecker 的测试替身
+type stubUserChecker struct {
+	allowed map[string]bool
+}
+
+func (s *stubUserChecker) CanCreateLink(ctx context.Context, userID string) (bool, error) {
+	// 约定用户 "offline" 模拟用户服务故障
+	if userID == "offline" {
+		return false, errors.New("connection refused")
+	}
+	return s.allowed[userID], nil
+}
+
+func TestShortenerService_CreateLink_WithOptions(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		name      string
+		opts      []LinkOption
+		setupRepo func(*fakes.FakeLinkRepository)
+		wantCode  string
+		wantErr   error
+	}{
+		{
+			name:     "使用自定义别名",
+			opts:     []LinkOption{WithAlias("golang")},
+			wantCode: "golang",
+		},
+		{
+			name: "别名已被占用时不重试",
+			opts: []LinkOption{WithAlias("golang")},
+			setupRepo: func(repo *fakes.FakeLinkRepository) {
+				_ = repo.Save(context.Background(), &domain.Link{ShortCode: "golang"})
+			},
+			wantErr: ErrAliasTaken,
+		},
+		{
+			name:    "别名是保留字",
+			opts:    []LinkOption{WithAlias("api")},
+			wantErr: ErrInvalidAlias,
+		},
+		{
+			name:    "过期时间在过去",
+			opts:    []LinkOption{WithExpiresAt(past)},
+			wantErr: ErrInvalidExpiry,
+		},
+		{
+			name:    "最大访问次数为负数",
+			opts:    []LinkOption{WithMaxVisits(-1)},
+			wantErr: ErrInvalidMaxVisits,
+		},
+		{
+			name:     "有权限的用户",
+			opts:     []LinkOption{WithOwner("alice"), WithAlias("alice-link"), WithExpiresAt(future)},
+			wantCode: "alice-link",
+		},
+		{
+			name:    "没有权限的用户",
+			opts:    []LinkOption{WithOwner("mallory")},
+			wantErr: ErrPermissionDenied,
+		},
+		{
+			name:    "用户服务不可用",
+			opts:    []LinkOption{WithOwner("offline")},
+			wantErr: ErrUnavailable, // 按错误类别匹配
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fakeRepo := fakes.NewFakeLinkRepository()
+			if tc.setupRepo != nil {
+				tc.setupRepo(fakeRepo)
+			}
+			users := &stubUserChecker{allowed: map[string]bool{"alice": true}}
+			service := NewShortenerService(fakeRepo, &fakes.FakeLinkCache{}, WithUserService(users))
+
+			link, err := service.CreateLink(context.Background(), "https://go.dev", tc.opts...)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("CreateLink() error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateLink() returned an unexpected error: %v", err)
+			}
+			if link.ShortCode != tc.wantCode {
+				t.Errorf("ShortCode got %q, want %q", link.ShortCode, tc.wantCode)
+			}
+			assertLinkSaved(t, fakeRepo, link.ShortCode, "https://go.dev")
+		})
+	}
+}
+
+func TestShortenerService_UpdateAndDeleteLink(t *testing.T) {
+	ctx := context.Background()
+	fakeRepo := fakes.NewFakeLinkRepository()
+	service := NewShortenerService(fakeRepo, &fakes.FakeLinkCache{})
+
+	owned, err := service.CreateLink(ctx, "https://go.dev", WithOwner("alice"))
+	if err != nil {
+		t.Fatalf("CreateLink() returned an unexpected error: %v", err)
+	}
+	anonymous, err := service.CreateLink(ctx, "https://go.dev")
+	if err != nil {
+		t.Fatalf("CreateLink() returned an unexpected error: %v", err)
+	}
+
+	newURL := "https://go.dev/doc"
+	upd := LinkUpdate{OriginalURL: &newURL}
+
+	t.Run("非所有者不能修改", func(t *testing.T) {
+		if _, err := service.UpdateLink(ctx, owned.ShortCode, "bob", upd); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("UpdateLink() error = %v, want %v", err, ErrPermissionDenied)
+		}
+	})
 
-func (r *errReader) Read(p []byte) (n int, err error) {
-	return 0, errors.New("simulated reader error")
+	t.Run("匿名链接不能修改", func(t *testing.T) {
+		if _, err := service.UpdateLink(ctx, anonymous.ShortCode, "alice", upd); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("UpdateLink() error = %v, want %v", err, ErrPermissionDenied)
+		}
+	})
+
+	t.Run("所有者修改成功", func(t *testing.T) {
+		if _, err := service.UpdateLink(ctx, owned.ShortCode, "alice", upd); err != nil {
+			t.Fatalf("UpdateLink() returned an unexpected error: %v", err)
+		}
+		assertLinkSaved(t, fakeRepo, owned.ShortCode, newURL)
+	})
+
+	t.Run("所有者删除成功", func(t *testing.T) {
+		if err := service.DeleteLink(ctx, owned.ShortCode, "alice"); err != nil {
+			t.Fatalf("DeleteLink() returned an unexpected error: %v", err)
+		}
+		if err := service.DeleteLink(ctx, owned.ShortCode, "alice"); !errors.Is(err, ErrLinkNotFound) {
+			t.Fatalf("DeleteLink() error = %v, want %v", err, ErrLinkNotFound)
+		}
+	})
 }
 
-// TestGenerateShortCode_Coverage 用于专门提升 generateShortCode 的测试覆盖率
-func TestGenerateShortCode_Coverage(t *testing.T) {
-	// 这是一个白盒测试，因为它需要篡改包内的未导出函数所依赖的全局变量
+func TestShortenerService_DeleteLinkResetsVisits(t *testing.T) {
+	ctx := context.Background()
+	fakeCache := &fakes.FakeLinkCache{}
+	service := NewShortenerService(fakes.NewFakeLinkRepository(), fakeCache)
 
-	t.Run("当 rand.Reader 返回错误时", func(t *testing.T) {
-		// --- 狸猫换太子 ---
+	create := func() {
+		t.Helper()
+		if _, err := service.CreateLink(ctx, "https://go.dev", WithAlias("promo"), WithOwner("alice"), WithMaxVisits(1)); err != nil {
+			t.Fatalf("CreateLink() returned an unexpected error: %v", err)
+		}
+	}
 
-		// 1. 保存原始的 rand.Reader
-		originalReader := rand.Reader
+	create()
+	if _, err := service.Redirect(ctx, "promo", analytics.Hit{}); err != nil {
+		t.Fatalf("Redirect() returned an unexpected error: %v", err)
+	}
+	if err := fakeCache.AddUniqueVisitors(ctx, "promo", []string{"v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.DeleteLink(ctx, "promo", "alice"); err != nil {
+		t.Fatalf("DeleteLink() returned an unexpected error: %v", err)
+	}
+
+	// 重新创建同名别名，计数从零开始，max_visits 也没有被旧链接耗尽
+	create()
+	if count, _ := fakeCache.GetVisitCount(ctx, "promo"); count != 0 {
+		t.Fatalf("visit count after re-create = %d, want 0", count)
+	}
+	if visitors, _ := fakeCache.CountUniqueVisitors(ctx, "promo"); visitors != 0 {
+		t.Fatalf("unique visitors after re-create = %d, want 0", visitors)
+	}
+	if _, err := service.Redirect(ctx, "promo", analytics.Hit{}); err != nil {
+		t.Fatalf("Redirect() after re-create returned an unexpected error: %v", err)
+	}
+}
+
+func TestShortenerService_Redirect_Limits(t *testing.T) {
+	ctx := context.Background()
+	fakeRepo := fakes.NewFakeLinkRepository()
+	fakeCache := &fakes.FakeLinkCache{}
+	service := NewShortenerService(fakeRepo, fakeCache)
+
+	expiring, _ := service.CreateLink(ctx, "https://go.dev", WithExpiresAt(time.Now().Add(time.Hour)))
+	limited, _ := service.CreateLink(ctx, "https://go.dev", WithMaxVisits(1))
+
+	t.Run("过期后返回 ErrLinkExpired", func(t *testing.T) {
+		// 把时钟拨到一天之后
+		service.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+		defer func() { service.now = time.Now }()
+
+		if _, err := service.Redirect(ctx, expiring.ShortCode, analytics.Hit{}); !errors.Is(err, ErrLinkExpired) {
+			t.Fatalf("Redirect() error = %v, want %v", err, ErrLinkExpired)
+		}
+	})
+
+	t.Run("访问次数耗尽后返回 ErrLinkExpired", func(t *testing.T) {
+		// 直接预置计数
+		_ = fakeCache.IncrementVisitCount(ctx, limited.ShortCode)
 
-		// 2. 将 rand.Reader 替换为我们自己的 errReader
-		rand.Reader = &errReader{}
+		if _, err := service.Redirect(ctx, limited.ShortCode, analytics.Hit{}); !errors.Is(err, ErrLinkExpired) {
+			t.Fatalf("Redirect() error = %v, want %v", err, ErrLinkExpired)
+		}
+	})
+}
 
-		// 3. 使用 defer 确保在测试结束后，无论发生什么，都将原始的 Reader 恢复回去
-		//    这是至关重要的，避免污染其他测试！
-		defer func() {
-			rand.Reader = originalReader
+// recordingRecorder 是 VisitRecorder 的测试替身，同步地保存提交的访问
+type recordingRecorder struct {
+	hits []analytics.Hit
+}
+
+func (r *recordingRecorder) Record(hit analytics.Hit) bool {
+	r.hits = append(r.hits, hit)
+	return true
+}
+
+func TestShortenerService_Redirect_RecordsVisit(t *testing.T) {
+	ctx := context.Background()
+	fakeCache := &fakes.FakeLinkCache{}
+	recorder := &recordingRecorder{}
+	service := NewShortenerService(fakes.NewFakeLinkRepository(), fakeCache, WithVisitRecorder(recorder))
+
+	link, _ := service.CreateLink(ctx, "https://go.dev")
+	if _, err := service.Redirect(ctx, link.ShortCode, analytics.Hit{IP: "192.0.2.1", Referrer: "https://t.co/x"}); err != nil {
+		t.Fatalf("Redirect() returned an unexpected error: %v", err)
+	}
+
+	if len(recorder.hits) != 1 {
+		t.Fatalf("got %d recorded hits, want 1", len(recorder.hits))
+	}
+	hit := recorder.hits[0]
+	if hit.ShortCode != link.ShortCode || hit.IP != "192.0.2.1" || hit.Time.IsZero() {
+		t.Errorf("unexpected hit: %+v", hit)
+	}
+	// 配置了 Recorder 时，计数由 flusher 更新，而不是在请求中同步累加
+	if n, _ := fakeCache.GetVisitCount(ctx, link.ShortCode); n != 0 {
+		t.Errorf("visit count got %d, want 0", n)
+	}
+}
+
+func TestShortenerService_Redirect_MaxVisitsIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	fakeCache := &fakes.FakeLinkCache{}
+	service := NewShortenerService(fakes.NewFakeLinkRepository(), fakeCache)
+
+	const maxVisits, n = 5, 50
+	link, _ := service.CreateLink(ctx, "https://go.dev", WithMaxVisits(maxVisits))
+
+	var ok, expired atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Redirect(ctx, link.ShortCode, analytics.Hit{})
+			switch {
+			case err == nil:
+				ok.Add(1)
+			case errors.Is(err, ErrLinkExpired):
+				expired.Add(1)
+			default:
+				t.Errorf("Redirect() returned an unexpected error: %v", err)
+			}
 		}()
+	}
+	wg.Wait()
 
-		// --- 执行测试 ---
-		_, err := generateShortCode(6)
+	// 并发访问不会同时通过检查，成功的跳转数恰好等于上限
+	if ok.Load() != maxVisits || expired.Load() != n-maxVisits {
+		t.Fatalf("got %d redirects and %d expired, want %d and %d", ok.Load(), expired.Load(), maxVisits, n-maxVisits)
+	}
+	if got, _ := fakeCache.GetVisitCount(ctx, link.ShortCode); got != maxVisits {
+		t.Errorf("visit count got %d, want %d", got, maxVisits)
+	}
+}
+
+func TestShortenerService_Redirect_MaxVisitsCountedOnce(t *testing.T) {
+	ctx := context.Background()
+	fakeCache := &fakes.FakeLinkCache{}
+	recorder := &recordingRecorder{}
+	service := NewShortenerService(fakes.NewFakeLinkRepository(), fakeCache, WithVisitRecorder(recorder))
+
+	link, _ := service.CreateLink(ctx, "https://go.dev", WithMaxVisits(2))
+	if _, err := service.Redirect(ctx, link.ShortCode, analytics.Hit{}); err != nil {
+		t.Fatalf("Redirect() returned an unexpected error: %v", err)
+	}
 
-		// --- 断言 ---
-		if err == nil {
-			t.Fatal("期望一个错误，但得到了 nil")
+	// 限量链接的计数在跳转时同步累加，Recorder 收到的访问标记为已计数
+	if got, _ := fakeCache.GetVisitCount(ctx, link.ShortCode); got != 1 {
+		t.Errorf("visit count got %d, want 1", got)
+	}
+	if len(recorder.hits) != 1 || !recorder.hits[0].Counted {
+		t.Errorf("recorded hits = %+v, want one counted hit", recorder.hits)
+	}
+}
+
+func TestShortenerService_GetStats(t *testing.T) {
+	ctx := context.Background()
+	fakeRepo := fakes.NewFakeLinkRepository()
+	fakeCache := &fakes.FakeLinkCache{}
+	fakeVisits := fakes.NewFakeVisitRepository()
+	service := NewShortenerService(fakeRepo, fakeCache, WithVisitRepository(fakeVisits))
+
+	now := time.Date(2025, 6, 10, 15, 30, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	link, _ := service.CreateLink(ctx, "https://go.dev")
+	code := link.ShortCode
+	_ = fakeVisits.SaveVisits(ctx, []domain.Visit{
+		{ShortCode: code, VisitedAt: now.Add(-10 * time.Minute), Referrer: "t.co"},
+		{ShortCode: code, VisitedAt: now.Add(-20 * time.Minute), Referrer: "t.co"},
+		{ShortCode: code, VisitedAt: now.Add(-2 * time.Hour), Referrer: "news.ycombinator.com"},
+		{ShortCode: code, VisitedAt: now.Add(-48 * time.Hour)}, // 超出默认的 24 小时窗口
+		{ShortCode: "other", VisitedAt: now},
+	})
+	_ = fakeCache.RecordVisits(ctx, code, []string{"v1", "v2", "v1", "v3"})
+
+	t.Run("按小时补齐时间序列", func(t *testing.T) {
+		stats, err := service.GetStats(ctx, code, StatsQuery{Bucket: domain.BucketHour})
+		if err != nil {
+			t.Fatalf("GetStats() returned an unexpected error: %v", err)
+		}
+		if stats.Visits != 4 || stats.UniqueVisitors != 3 {
+			t.Errorf("got visits=%d unique=%d, want 4 and 3", stats.Visits, stats.UniqueVisitors)
+		}
+		if len(stats.Series) != 24 {
+			t.Fatalf("got %d buckets, want 24", len(stats.Series))
+		}
+		last := stats.Series[len(stats.Series)-1]
+		if !last.Start.Equal(now.Truncate(time.Hour)) || last.Visits != 2 {
+			t.Errorf("last bucket got %+v, want 2 visits at %s", last, now.Truncate(time.Hour))
+		}
+		if stats.Series[len(stats.Series)-2].Visits != 0 || stats.Series[len(stats.Series)-3].Visits != 1 {
+			t.Errorf("unexpected series: %+v", stats.Series)
+		}
+		if len(stats.TopReferrers) != 2 || stats.TopReferrers[0].Referrer != "t.co" || stats.TopReferrers[0].Visits != 2 {
+			t.Errorf("unexpected top referrers: %+v", stats.TopReferrers)
+		}
+	})
+
+	t.Run("参数不合法", func(t *testing.T) {
+		for _, q := range []StatsQuery{
+			{Bucket: "week"},
+			{Bucket: domain.BucketHour, Since: now.Add(time.Hour)},
+			{Bucket: domain.BucketHour, Since: now.AddDate(-1, 0, 0)},
+		} {
+			if _, err := service.GetStats(ctx, code, q); !errors.Is(err, ErrInvalidStatsRange) {
+				t.Errorf("GetStats(%+v) error = %v, want %v", q, err, ErrInvalidStatsRange)
+			}
 		}
-		if err.Error() != "simulated reader error" {
-			t.Errorf("期望的错误信息不匹配: got %v", err)
+	})
+
+	t.Run("链接不存在", func(t *testing.T) {
+		if _, err := service.GetStats(ctx, "missing", StatsQuery{}); !errors.Is(err, ErrLinkNotFound) {
+			t.Fatalf("GetStats() error = %v, want %v", err, ErrLinkNotFound)
 		}
 	})
+}
 
-	// 我们可以再加一个成功路径的测试，以确保我们的篡改和恢复逻辑是正确的
-	t.Run("成功生成（验证恢复）", func(t *testing.T) {
-		// 在这个子测试中，rand.Reader 应该已经被恢复为原始版本
-		code, err := generateShortCode(6)
+// countingRepo 统计 FindByCode 的调用次数，gate 不为 nil 时查询会阻塞到 gate 被关闭
+type countingRepo struct {
+	*fakes.FakeLinkRepository
+	finds atomic.Int64
+	gate  chan struct{}
+}
+
+func (r *countingRepo) FindByCode(ctx context.Context, code string) (*domain.Link, error) {
+	r.finds.Add(1)
+	if r.gate != nil {
+		<-r.gate
+	}
+	return r.FakeLinkRepository.FindByCode(ctx, code)
+}
+
+func TestShortenerService_LinkCache(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepo{FakeLinkRepository: fakes.NewFakeLinkRepository()}
+	fakeCache := &fakes.FakeLinkCache{}
+	service := NewShortenerService(repo, fakeCache)
+
+	link, _ := service.CreateLink(ctx, "https://go.dev", WithOwner("alice"))
+
+	t.Run("命中后不再查询数据库", func(t *testing.T) {
+		repo.finds.Store(0)
+		for i := 0; i < 3; i++ {
+			if _, err := service.Redirect(ctx, link.ShortCode, analytics.Hit{}); err != nil {
+				t.Fatalf("Redirect() returned an unexpected error: %v", err)
+			}
+		}
+		if n := repo.finds.Load(); n != 1 {
+			t.Errorf("FindByCode called %d times, want 1", n)
+		}
+	})
+
+	t.Run("不存在的短码被负缓存", func(t *testing.T) {
+		repo.finds.Store(0)
+		for i := 0; i < 3; i++ {
+			if _, err := service.Redirect(ctx, "nosuch", analytics.Hit{}); !errors.Is(err, ErrLinkNotFound) {
+				t.Fatalf("Redirect() error = %v, want %v", err, ErrLinkNotFound)
+			}
+		}
+		if n := repo.finds.Load(); n != 1 {
+			t.Errorf("FindByCode called %d times, want 1", n)
+		}
+	})
+
+	t.Run("创建别名后清除负缓存", func(t *testing.T) {
+		if _, err := service.CreateLink(ctx, "https://go.dev/doc", WithAlias("nosuch")); err != nil {
+			t.Fatalf("CreateLink() returned an unexpected error: %v", err)
+		}
+		got, err := service.Redirect(ctx, "nosuch", analytics.Hit{})
+		if err != nil || got.OriginalURL != "https://go.dev/doc" {
+			t.Fatalf("Redirect() = %v, %v; want the new link", got, err)
+		}
+	})
+
+	t.Run("修改后缓存失效", func(t *testing.T) {
+		newURL := "https://go.dev/blog"
+		if _, err := service.UpdateLink(ctx, link.ShortCode, "alice", LinkUpdate{OriginalURL: &newURL}); err != nil {
+			t.Fatalf("UpdateLink() returned an unexpected error: %v", err)
+		}
+		got, err := service.Redirect(ctx, link.ShortCode, analytics.Hit{})
+		if err != nil || got.OriginalURL != newURL {
+			t.Fatalf("Redirect() = %v, %v; want %s", got, err, newURL)
+		}
+	})
+
+	t.Run("删除后缓存失效", func(t *testing.T) {
+		if err := service.DeleteLink(ctx, link.ShortCode, "alice"); err != nil {
+			t.Fatalf("DeleteLink() returned an unexpected error: %v", err)
+		}
+		if _, err := service.Redirect(ctx, link.ShortCode, analytics.Hit{}); !errors.Is(err, ErrLinkNotFound) {
+			t.Fatalf("Redirect() error = %v, want %v", err, ErrLinkNotFound)
+		}
+	})
+}
+
+func TestShortenerService_LinkCache_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepo{FakeLinkRepository: fakes.NewFakeLinkRepository()}
+	service := NewShortenerService(repo, &fakes.FakeLinkCache{})
+	link, _ := service.CreateLink(ctx, "https://go.dev")
+
+	repo.gate = make(chan struct{})
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Redirect(ctx, link.ShortCode, analytics.Hit{})
+			errs <- err
+		}()
+	}
+
+	// 等第一个请求进入数据库查询后，再放行
+	for repo.finds.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(repo.gate)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Redirect() returned an unexpected error: %v", err)
+		}
+	}
+	// 被 gate 挡住期间到达的请求共享同一次查询；之后到达的请求命中缓存
+	if got := repo.finds.Load(); got != 1 {
+		t.Errorf("FindByCode called %d times, want 1", got)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if got := jitter(10 * time.Minute); got < 9*time.Minute || got > 11*time.Minute {
+			t.Fatalf("jitter() = %s, want within ±10%%", got)
+		}
+	}
+}
+
+func TestShortenerService_URLPolicy(t *testing.T) {
+	ctx := context.Background()
+	fakeRepo := fakes.NewFakeLinkRepository()
+	policy := &validator.Policy{
+		SelfDomains: []string{"sho.rt"},
+		// 单元测试不访问真实 DNS
+		Resolver: &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("dns disabled in unit tests")
+		}},
+	}
+	service := NewShortenerService(fakeRepo, &fakes.FakeLinkCache{}, WithURLPolicy(policy))
+
+	t.Run("违反策略返回 ErrUnsafeURL", func(t *testing.T) {
+		_, err := service.CreateLink(ctx, "https://sho.rt/abc")
+		if !errors.Is(err, ErrUnsafeURL) || !errors.Is(err, validator.ErrSelfReference) {
+			t.Fatalf("CreateLink() error = %v, want %v wrapping %v", err, ErrUnsafeURL, validator.ErrSelfReference)
+		}
+	})
+
+	t.Run("保存规范化后的 URL", func(t *testing.T) {
+		link, err := service.CreateLink(ctx, "https://bücher.example/x", WithOwner("alice"))
 		if err != nil {
-			t.Fatalf("不期望的错误: %v", err)
+			t.Fatalf("CreateLink() returned an unexpected error: %v", err)
 		}
-		if len(code) != 8 { // 6 bytes -> 8 base64 chars
-			t.Errorf("生成的 code 长度不符合预期: got %d", len(code))
+		assertLinkSaved(t, fakeRepo, link.ShortCode, "https://xn--bcher-kva.example/x")
+
+		loopback := "http://127.0.0.1/admin"
+		if _, err := service.UpdateLink(ctx, link.ShortCode, "alice", LinkUpdate{OriginalURL: &loopback}); !errors.Is(err, validator.ErrPrivateAddress) {
+			t.Fatalf("UpdateLink() error = %v, want %v", err, validator.ErrPrivateAddress)
 		}
 	})
 }
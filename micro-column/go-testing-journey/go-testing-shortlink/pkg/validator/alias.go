@@ -0,0 +1,55 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	MinAliasLength = 3
+	MaxAliasLength = 32
+)
+
+var (
+	ErrAliasLength   = errors.New("alias must be between 3 and 32 characters")
+	ErrAliasCharset  = errors.New("alias may only contain letters, digits, '-' and '_'")
+	ErrAliasReserved = errors.New("alias is reserved")
+)
+
+// reservedAliases 是不能被用作别名的保留字
+// 这些路径已经被服务自身占用 (如 /api、/healthz)，或容易被用于钓鱼 (如 admin、login)
+var reservedAliases = map[string]struct{}{
+	"api":     {},
+	"links":   {},
+	"healthz": {},
+	"metrics": {},
+	"static":  {},
+	"admin":   {},
+	"login":   {},
+	"logout":  {},
+	"signup":  {},
+	"stats":   {},
+	"help":    {},
+	"about":   {},
+}
+
+// ValidateAlias 检查用户自定义的短码别名
+// 字符集与随机生成的短码 (base64 URL 编码) 保持一致，保留字不区分大小写
+func ValidateAlias(alias string) error {
+	if len(alias) < MinAliasLength || len(alias) > MaxAliasLength {
+		return ErrAliasLength
+	}
+
+	for _, r := range alias {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return ErrAliasCharset
+		}
+	}
+
+	if _, ok := reservedAliases[strings.ToLower(alias)]; ok {
+		return ErrAliasReserved
+	}
+	return nil
+}
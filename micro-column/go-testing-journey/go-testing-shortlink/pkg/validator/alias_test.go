@@ -0,0 +1,66 @@
+//go:build unit
+
+package validator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateAlias(t *testing.T) {
+	testCases := []struct {
+		name  string
+		alias string
+		want  error
+	}{
+		{
+			name:  "合法的别名",
+			alias: "go-2025_promo",
+			want:  nil,
+		},
+		{
+			name:  "太短",
+			alias: "ab",
+			want:  ErrAliasLength,
+		},
+		{
+			name:  "太长",
+			alias: "abcdefghijklmnopqrstuvwxyz0123456",
+			want:  ErrAliasLength,
+		},
+		{
+			name:  "包含路径分隔符",
+			alias: "a/b/c",
+			want:  ErrAliasCharset,
+		},
+		{
+			name:  "包含非 ASCII 字符",
+			alias: "短链接abc",
+			want:  ErrAliasCharset,
+		},
+		{
+			name:  "保留字",
+			alias: "healthz",
+			want:  ErrAliasReserved,
+		},
+		{
+			name:  "与 API 资源同名",
+			alias: "links",
+			want:  ErrAliasReserved,
+		},
+		{
+			name:  "保留字不区分大小写",
+			alias: "Admin",
+			want:  ErrAliasReserved,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ValidateAlias(tc.alias)
+			if !errors.Is(got, tc.want) {
+				t.Errorf("ValidateAlias(%q) = %v; want %v", tc.alias, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// 浏览器按 WHATWG URL 标准解析主机名：只要最后一段是数字，整个主机名就被当作 IPv4 地址，
+// 并且每一段都可以是八进制 (0177)、十六进制 (0x7f)，段数也可以少于 4 (127.1)。
+// 这些写法都不是 netip 认可的点分十进制，必须按同样的规则解析后再检查，
+// 否则 http://0x7f.1/ 这类地址会绕过内网检查，而浏览器照样会访问 127.0.0.1
+
+// endsInNumber 判断主机名是否会被浏览器当作 IPv4 地址 (WHATWG "ends in a number")
+func endsInNumber(host string) bool {
+	parts := strings.Split(host, ".")
+	if parts[len(parts)-1] == "" {
+		if len(parts) == 1 {
+			return false
+		}
+		parts = parts[:len(parts)-1]
+	}
+	last := parts[len(parts)-1]
+	if last != "" && strings.Trim(last, "0123456789") == "" {
+		return true
+	}
+	_, ok := parseIPv4Number(last)
+	return ok
+}
+
+// parseWHATWGIPv4 按 WHATWG 的 IPv4 解析器把主机名转换为地址
+// 返回 false 表示主机名以数字结尾但不是合法的 IPv4 地址，浏览器会拒绝这样的 URL
+func parseWHATWGIPv4(host string) (netip.Addr, bool) {
+	parts := strings.Split(host, ".")
+	if parts[len(parts)-1] == "" && len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+
+	numbers := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, ok := parseIPv4Number(part)
+		if !ok {
+			return netip.Addr{}, false
+		}
+		numbers[i] = n
+	}
+
+	// 除最后一段外每段都不能超过 255，最后一段填满剩余的字节
+	var ipv4 uint64
+	for i, n := range numbers[:len(numbers)-1] {
+		if n > 255 {
+			return netip.Addr{}, false
+		}
+		ipv4 |= n << (8 * (3 - i))
+	}
+	last := numbers[len(numbers)-1]
+	if last >= 1<<(8*(5-len(numbers))) {
+		return netip.Addr{}, false
+	}
+	ipv4 |= last
+
+	return netip.AddrFrom4([4]byte{byte(ipv4 >> 24), byte(ipv4 >> 16), byte(ipv4 >> 8), byte(ipv4)}), true
+}
+
+// parseIPv4Number 解析一段数字：0x 开头为十六进制 ("0x" 本身为 0)，0 开头为八进制，其余为十进制
+func parseIPv4Number(s string) (uint64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	base := 10
+	switch {
+	case len(s) >= 2 && (s[:2] == "0x" || s[:2] == "0X"):
+		s, base = s[2:], 16
+		if s == "" {
+			return 0, true
+		}
+	case len(s) >= 2 && s[0] == '0':
+		s, base = s[1:], 8
+	}
+	n, err := strconv.ParseUint(s, base, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,202 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultMaxURLLength 是默认的 URL 长度上限，与大多数浏览器和 CDN 的限制一致
+const DefaultMaxURLLength = 2048
+
+// URL 安全策略的违规原因，通过 errors.Is 判断
+var (
+	ErrURLTooLong        = errors.New("URL is too long")
+	ErrURLCredentials    = errors.New("URL must not contain credentials")
+	ErrInvalidHost       = errors.New("URL host is not a valid domain name")
+	ErrInternalHost      = errors.New("URL points to an internal host name")
+	ErrPrivateAddress    = errors.New("URL points to a private, loopback or link-local address")
+	ErrSelfReference     = errors.New("URL points to the shortener itself")
+	ErrDomainBlocked     = errors.New("URL domain is blocked")
+	ErrDomainNotAllowed  = errors.New("URL domain is not in the allow list")
+	ErrUnresolvableHost  = errors.New("URL host cannot be resolved")
+	errURLSchemeOrNoHost = errors.New("URL must be http(s) with a host")
+)
+
+// Violation 是违反 URL 安全策略的错误
+type Violation struct {
+	Host   string
+	Reason error
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("url rejected (host %q): %v", v.Host, v.Reason)
+}
+
+func (v *Violation) Unwrap() error {
+	return v.Reason
+}
+
+// Resolver 解析域名，*net.Resolver 实现了该接口，测试中可以替换为固定的结果
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Policy 是目标 URL 的安全策略，零值即可使用
+//
+// 短链接服务本身不会请求目标 URL，但会把访问者的浏览器带过去：
+// 指向内网地址的短链接可以被用来探测或攻击访问者所在的内网，
+// 指向短链接服务自身的短链接则会造成重定向循环
+// 域名解析只在创建时检查一次，之后 DNS 记录仍可能被修改，因此它只是尽力而为的防线
+type Policy struct {
+	MaxLength int // 为 0 时使用 DefaultMaxURLLength
+
+	// 以下域名列表均包括其子域名，支持 Unicode 域名
+	SelfDomains    []string // 短链接服务自身使用的域名
+	BlockedDomains []string // 禁止的域名，例如其他短链接服务
+	AllowedDomains []string // 非空时只允许这些域名
+
+	Resolver Resolver // 为 nil 时使用 net.DefaultResolver
+	// RejectUnresolvable 为 true 时拒绝无法解析的域名，默认放行
+	RejectUnresolvable bool
+}
+
+// Check 检查 URL 是否符合策略，返回规范化后的 URL (域名转为小写的 punycode)
+// 违反策略时返回 *Violation
+func (p *Policy) Check(ctx context.Context, rawURL string) (string, error) {
+	maxLen := p.MaxLength
+	if maxLen <= 0 {
+		maxLen = DefaultMaxURLLength
+	}
+	if len(rawURL) > maxLen {
+		return "", &Violation{Reason: ErrURLTooLong}
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", &Violation{Reason: errURLSchemeOrNoHost}
+	}
+	// http://trusted.com@evil.com 这类 URL 常用于钓鱼
+	if u.User != nil {
+		return "", &Violation{Host: u.Host, Reason: ErrURLCredentials}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	// IP 字面量不需要解析，也不参与域名列表的匹配
+	// IPv6 只能写在方括号中；IPv4 按浏览器的规则解析，0x7f.1 与 127.0.0.1 是同一个地址
+	if strings.Contains(host, ":") {
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return "", &Violation{Host: host, Reason: ErrInvalidHost}
+		}
+		if isPrivateAddr(addr) {
+			return "", &Violation{Host: host, Reason: ErrPrivateAddress}
+		}
+		return u.String(), nil
+	}
+	if endsInNumber(host) {
+		addr, ok := parseWHATWGIPv4(host)
+		if !ok {
+			return "", &Violation{Host: host, Reason: ErrInvalidHost}
+		}
+		if isPrivateAddr(addr) {
+			return "", &Violation{Host: host, Reason: ErrPrivateAddress}
+		}
+		// 规范化为点分十进制，与浏览器实际访问的地址一致
+		if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(addr.String(), port)
+		} else {
+			u.Host = addr.String()
+		}
+		return u.String(), nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil || ascii == "" {
+		return "", &Violation{Host: host, Reason: ErrInvalidHost}
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(ascii, port)
+	} else {
+		u.Host = ascii
+	}
+	normalized := u.String()
+	if len(normalized) > maxLen {
+		return "", &Violation{Host: ascii, Reason: ErrURLTooLong}
+	}
+
+	// 单标签的主机名 (如 http://intranet/) 会通过搜索域解析到内网
+	if !strings.Contains(ascii, ".") || matchDomain(ascii, []string{"localhost"}) {
+		return "", &Violation{Host: ascii, Reason: ErrInternalHost}
+	}
+	if matchDomain(ascii, p.SelfDomains) {
+		return "", &Violation{Host: ascii, Reason: ErrSelfReference}
+	}
+	if matchDomain(ascii, p.BlockedDomains) {
+		return "", &Violation{Host: ascii, Reason: ErrDomainBlocked}
+	}
+	if len(p.AllowedDomains) > 0 && !matchDomain(ascii, p.AllowedDomains) {
+		return "", &Violation{Host: ascii, Reason: ErrDomainNotAllowed}
+	}
+
+	if err := p.checkResolved(ctx, ascii); err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+// checkResolved 解析域名，任何一个地址是内网地址都拒绝
+func (p *Policy) checkResolved(ctx context.Context, host string) error {
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		if p.RejectUnresolvable {
+			return &Violation{Host: host, Reason: ErrUnresolvableHost}
+		}
+		return nil
+	}
+	for _, a := range addrs {
+		addr, ok := netip.AddrFromSlice(a.IP)
+		if ok && isPrivateAddr(addr) {
+			return &Violation{Host: host, Reason: ErrPrivateAddress}
+		}
+	}
+	return nil
+}
+
+// isPrivateAddr 判断地址是否为私有、回环、链路本地或未指定地址
+func isPrivateAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsUnspecified() || addr.IsInterfaceLocalMulticast() ||
+		cgnatPrefix.Contains(addr)
+}
+
+// cgnatPrefix 是运营商级 NAT 的共享地址段，同样不应出现在公网链接中
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// matchDomain 判断 host 是否为 domains 中的某个域名或其子域名
+func matchDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")
+		if ascii, err := idna.Lookup.ToASCII(d); err == nil {
+			d = ascii
+		}
+		if d == "" {
+			continue
+		}
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+//go:build unit
+
+package validator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// stubResolver 按固定的表解析域名，不在表中的域名解析失败
+type stubResolver map[string][]string
+
+func (r stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+	return addrs, nil
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy := &Policy{
+		SelfDomains:    []string{"sho.rt"},
+		BlockedDomains: []string{"bit.ly", "evil.example"},
+		Resolver: stubResolver{
+			"example.com":        {"93.184.216.34"},
+			"www.example.com":    {"93.184.216.34"},
+			"xn--bcher-kva.de":   {"93.184.216.35"},
+			"internal.corp.com":  {"10.0.0.8"},
+			"mixed.example.org":  {"93.184.216.34", "fe80::1"},
+			"rebind.example.net": {"::ffff:127.0.0.1"},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		inputURL string
+		want     string // 期望的规范化结果，wantErr 不为 nil 时忽略
+		wantErr  error
+	}{
+		{name: "公网域名", inputURL: "https://example.com/a?b=1", want: "https://example.com/a?b=1"},
+		{name: "大写域名被规范化", inputURL: "https://WWW.Example.com/", want: "https://www.example.com/"},
+		{name: "IDN 转为 punycode", inputURL: "https://bücher.de/x", want: "https://xn--bcher-kva.de/x"},
+		{name: "无法解析的域名默认放行", inputURL: "https://unknown.example.io", want: "https://unknown.example.io"},
+		{name: "回环 IP", inputURL: "http://127.0.0.1/admin", wantErr: ErrPrivateAddress},
+		{name: "私有 IP 带端口", inputURL: "http://192.168.1.1:8080/", wantErr: ErrPrivateAddress},
+		{name: "IPv6 链路本地", inputURL: "http://[fe80::1]/", wantErr: ErrPrivateAddress},
+		{name: "云厂商元数据地址", inputURL: "http://169.254.169.254/latest/meta-data", wantErr: ErrPrivateAddress},
+		{name: "未指定地址", inputURL: "http://0.0.0.0/", wantErr: ErrPrivateAddress},
+		{name: "简写的回环 IP", inputURL: "http://127.1/admin", wantErr: ErrPrivateAddress},
+		{name: "八进制的回环 IP", inputURL: "http://0177.0.0.1/", wantErr: ErrPrivateAddress},
+		{name: "十六进制的回环 IP", inputURL: "http://0x7f.0.0.1/", wantErr: ErrPrivateAddress},
+		{name: "十六进制简写", inputURL: "http://0x7f.1/", wantErr: ErrPrivateAddress},
+		{name: "整数形式的回环 IP", inputURL: "http://2130706433/", wantErr: ErrPrivateAddress},
+		{name: "十六进制整数形式的私有 IP", inputURL: "http://0xC0A80001:8080/", wantErr: ErrPrivateAddress},
+		{name: "八进制的私有 IP", inputURL: "http://012.0.0.1/", wantErr: ErrPrivateAddress},
+		{name: "尾部带点的简写 IP", inputURL: "http://127.1./", wantErr: ErrPrivateAddress},
+		{name: "公网 IP 规范化为点分十进制", inputURL: "http://0x5d.0xb8.0xd8.0x22:8080/x", want: "http://93.184.216.34:8080/x"},
+		{name: "公网 IP 不变", inputURL: "http://93.184.216.34/", want: "http://93.184.216.34/"},
+		{name: "以数字结尾但不是合法 IPv4", inputURL: "http://1.2.3.4.5/", wantErr: ErrInvalidHost},
+		{name: "段超出范围", inputURL: "http://256.1/", wantErr: ErrInvalidHost},
+		{name: "数字结尾的域名", inputURL: "http://foo.0x/", wantErr: ErrInvalidHost},
+		{name: "八进制中的非法数字", inputURL: "http://08.0.0.1/", wantErr: ErrInvalidHost},
+		{name: "解析到私有 IP 的域名", inputURL: "https://internal.corp.com/", wantErr: ErrPrivateAddress},
+		{name: "任一解析结果为内网即拒绝", inputURL: "https://mixed.example.org/", wantErr: ErrPrivateAddress},
+		{name: "IPv4-mapped 回环地址", inputURL: "https://rebind.example.net/", wantErr: ErrPrivateAddress},
+		{name: "localhost", inputURL: "http://localhost:8080/", wantErr: ErrInternalHost},
+		{name: "单标签主机名", inputURL: "http://intranet/wiki", wantErr: ErrInternalHost},
+		{name: "指向自身", inputURL: "https://sho.rt/abc", wantErr: ErrSelfReference},
+		{name: "指向自身的子域名", inputURL: "https://www.SHO.RT./abc", wantErr: ErrSelfReference},
+		{name: "其他短链接服务", inputURL: "https://bit.ly/xyz", wantErr: ErrDomainBlocked},
+		{name: "带凭据的 URL", inputURL: "https://example.com@evil.example/", wantErr: ErrURLCredentials},
+		{name: "超长 URL", inputURL: "https://example.com/" + strings.Repeat("a", DefaultMaxURLLength), wantErr: ErrURLTooLong},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := policy.Check(context.Background(), tc.inputURL)
+			if tc.wantErr != nil {
+				var v *Violation
+				if !errors.As(err, &v) || !errors.Is(err, tc.wantErr) {
+					t.Fatalf("Check(%q) error = %v, want Violation of %v", tc.inputURL, err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Check(%q) returned an unexpected error: %v", tc.inputURL, err)
+			}
+			if got != tc.want {
+				t.Errorf("Check(%q) = %q, want %q", tc.inputURL, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_AllowListAndUnresolvable(t *testing.T) {
+	resolver := stubResolver{"docs.go.dev": {"216.239.32.21"}, "example.com": {"93.184.216.34"}}
+
+	allow := &Policy{AllowedDomains: []string{"go.dev"}, Resolver: resolver}
+	if _, err := allow.Check(context.Background(), "https://docs.go.dev/"); err != nil {
+		t.Errorf("allowed subdomain rejected: %v", err)
+	}
+	if _, err := allow.Check(context.Background(), "https://example.com/"); !errors.Is(err, ErrDomainNotAllowed) {
+		t.Errorf("Check() error = %v, want %v", err, ErrDomainNotAllowed)
+	}
+
+	strict := &Policy{RejectUnresolvable: true, Resolver: resolver}
+	if _, err := strict.Check(context.Background(), "https://nx.example.io/"); !errors.Is(err, ErrUnresolvableHost) {
+		t.Errorf("Check() error = %v, want %v", err, ErrUnresolvableHost)
+	}
+}
@@ -14,6 +14,9 @@ import (
 	"time"
 )
 
+// e2eGatewayToken 与 docker-compose.e2e.yml 中的 GATEWAY_TOKEN 一致，测试扮演可信网关
+const e2eGatewayToken = "e2e-gateway-token"
+
 func TestMain(m *testing.M) {
 	fmt.Println("启动 E2E 测试环境...")
 	// 使用 --build 标志确保每次都使用最新的代码构建镜像
@@ -82,6 +85,7 @@ func TestShortlink_E2E_HappyPath(t *testing.T) {
 		req, _ := http.NewRequest("POST", appURL+"/api/links", bytes.NewBuffer(reqBody))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("X-User-ID", "user-with-permission") // 假设 handler 会读取这个头
+		req.Header.Set("X-Gateway-Token", e2eGatewayToken)
 
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
@@ -133,8 +137,8 @@ func TestShortlink_E2E_HappyPath(t *testing.T) {
 	t.Run("Get link statistics", func(t *testing.T) {
 		if shortCode == "" { t.Skip("由于创建失败，跳过统计测试") }
 		
-		// 等待异步的 Redis 计数器生效
-		time.Sleep(1 * time.Second) 
+		// 访问由后台 flusher 批量写入 (默认每秒一次)，多等一个刷新周期
+		time.Sleep(2 * time.Second)
 		
 		statsURL := fmt.Sprintf("%s/api/links/%s/stats", appURL, shortCode)
 		resp, err := http.Get(statsURL)
@@ -155,3 +159,47 @@ func TestShortlink_E2E_HappyPath(t *testing.T) {
 		t.Logf("成功获取统计信息，访问次数为: %.0f", visits)
 	})
 }
+
+// TestShortlink_E2E_UserServiceFaults 验证用户服务故障时的降级行为
+// 故障由 dummy user-service 根据特殊的用户 ID 注入
+func TestShortlink_E2E_UserServiceFaults(t *testing.T) {
+	appURL := "http://localhost:8080"
+
+	testCases := []struct {
+		name       string
+		userID     string
+		wantStatus int
+	}{
+		// 第一次请求 503，客户端重试后成功
+		{"flaky user service is retried", "flaky-user", http.StatusCreated},
+		// 每次尝试都超时，重试耗尽后返回 503
+		{"slow user service times out", "slow-user", http.StatusServiceUnavailable},
+		// 连接被重置，重试耗尽后返回 503
+		{"connection reset", "reset-user", http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reqBody, _ := json.Marshal(map[string]string{"url": "https://www.e2e-fault-injection.com/" + tc.userID})
+			req, _ := http.NewRequest("POST", appURL+"/api/links", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-User-ID", tc.userID)
+			req.Header.Set("X-Gateway-Token", e2eGatewayToken)
+
+			start := time.Now()
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("创建请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.wantStatus {
+				t.Fatalf("期望状态码 %d, 得到了 %d", tc.wantStatus, resp.StatusCode)
+			}
+			// 用户服务每次响应都要 2s，客户端必须在单次超时后放弃，而不是一直等待
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("请求耗时 %v，超时与重试没有生效", elapsed)
+			}
+		})
+	}
+}
@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// flakyCalls 记录 flaky-user 的请求次数，用于制造"失败一次、成功一次"的交替故障
+var flakyCalls atomic.Int64
+
 func main() {
 	http.HandleFunc("/users/", permissionsHandler)
 	fmt.Println("Dummy User Service 启动于 :8090...")
@@ -23,9 +28,32 @@ func permissionsHandler(w http.ResponseWriter, r *http.Request) {
 
 	// 这是一个非常简单的 dummy 逻辑
 	// 在 E2E 测试中，我们只需要它能为特定的测试用户返回正确的结果
+	// 以下特殊用户用于注入故障，验证 Shortlink 客户端的超时、重试与熔断：
+	//   slow-user:  每次响应前等待 2s，超过客户端的单次超时
+	//   flaky-user: 奇数次请求返回 503，偶数次正常
+	//   reset-user: 不返回响应，直接断开 TCP 连接
 	canCreate := false
-	if userID == "user-with-permission" {
+	switch userID {
+	case "user-with-permission":
+		canCreate = true
+	case "slow-user":
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+			return
+		}
 		canCreate = true
+	case "flaky-user":
+		if flakyCalls.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		canCreate = true
+	case "reset-user":
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")